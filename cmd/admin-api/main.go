@@ -16,9 +16,11 @@ import (
 	"github.com/saas-multi-database-api/internal/config"
 	"github.com/saas-multi-database-api/internal/database"
 	adminHandlers "github.com/saas-multi-database-api/internal/handlers/admin"
+	"github.com/saas-multi-database-api/internal/mailer"
 	"github.com/saas-multi-database-api/internal/middleware"
 	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
 	adminService "github.com/saas-multi-database-api/internal/services/admin"
+	"github.com/saas-multi-database-api/internal/utils"
 )
 
 // Admin API - Control Plane
@@ -28,6 +30,12 @@ func main() {
 
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.ValidateDBCredentialsKey(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := cfg.ValidateTwoFactorKey(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
@@ -47,32 +55,92 @@ func main() {
 		log.Fatalf("Failed to initialize admin DB pool: %v", err)
 	}
 
+	// Register the default database cluster (from POSTGRES_HOST / MASTER_DB_HOST)
+	if _, err := dbManager.Clusters().EnsureDefault(ctx); err != nil {
+		log.Fatalf("Failed to register default database cluster: %v", err)
+	}
+
 	// Initialize Redis client
 	redisClient, err := cache.NewClient(&cfg.Redis)
 	if err != nil {
 		log.Fatalf("Failed to initialize Redis client: %v", err)
 	}
 
+	// Chaves Ed25519 de assinatura dos tokens admin (a primeira é gerada se não existir)
+	jwtKeyService := adminService.NewJWTKeyService(dbManager.GetMasterPool(), redisClient.Client, cfg)
+	jwtKeys, err := jwtKeyService.KeySet(ctx, adminService.TokenSubjectAdmin)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	// Recarregar as chaves após uma rotação (pub/sub, com recarga periódica como fallback)
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	defer stopRefresh()
+	go jwtKeyService.ListenRefresh(refreshCtx, adminService.TokenSubjectAdmin, jwtKeys)
+	go jwtKeys.RunRefresh(refreshCtx, time.Minute)
+
+	// Chaves dos tokens da Tenant API: assinam os tokens de impersonação do suporte
+	tenantJWTKeys, err := jwtKeyService.KeySet(ctx, adminService.TokenSubjectTenant)
+	if err != nil {
+		log.Fatalf("Failed to load tenant JWT signing keys: %v", err)
+	}
+	go jwtKeyService.ListenRefresh(refreshCtx, adminService.TokenSubjectTenant, tenantJWTKeys)
+	go tenantJWTKeys.RunRefresh(refreshCtx, time.Minute)
+
 	// Initialize repositories
 	sysUserRepo := adminRepo.NewSysUserRepository(dbManager.GetMasterPool())
 	userRepo := adminRepo.NewUserRepository(dbManager.GetMasterPool())
 	tenantRepo := adminRepo.NewTenantRepository(dbManager.GetMasterPool())
 	planRepo := adminRepo.NewPlanRepository(dbManager.GetMasterPool())
 	featureRepo := adminRepo.NewFeatureRepository(dbManager.GetMasterPool())
+	impersonationRepo := adminRepo.NewImpersonationRepository(dbManager.GetMasterPool())
 
 	// Initialize services
-	tenantService := adminService.NewTenantService(tenantRepo, userRepo, redisClient.Client, dbManager.GetMasterPool())
+	tenantService := adminService.NewTenantService(tenantRepo, userRepo, redisClient.Client, dbManager.GetMasterPool(), cfg)
 	planService := adminService.NewPlanService(planRepo, redisClient.Client)
+	dbCredentialService := adminService.NewDBCredentialService(dbManager.GetMasterPool(), dbManager.Clusters(), redisClient.Client, cfg)
+	clusterService := adminService.NewClusterService(dbManager.GetMasterPool(), dbManager.Clusters(), redisClient.Client, cfg)
+	backupService := adminService.NewBackupService(dbManager.GetMasterPool())
+	sandboxService := adminService.NewSandboxService(dbManager.GetMasterPool(), cfg)
+	rateLimitService := adminService.NewRateLimitService(dbManager.GetMasterPool(), redisClient.Client, cfg)
+	tokenService := adminService.NewAuthTokenService(dbManager.GetMasterPool(), redisClient.Client, jwtKeys, cfg)
+
+	// Initialize mailer (verification and password reset emails)
+	mailSender, err := mailer.NewMailer(&mailer.Config{
+		Driver:       cfg.Mailer.Driver,
+		From:         cfg.Mailer.From,
+		OutputDir:    cfg.Mailer.OutputDir,
+		SMTPHost:     cfg.Mailer.SMTPHost,
+		SMTPPort:     cfg.Mailer.SMTPPort,
+		SMTPUsername: cfg.Mailer.SMTPUsername,
+		SMTPPassword: cfg.Mailer.SMTPPassword,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+	emailService := adminService.NewAuthEmailService(dbManager.GetMasterPool(), dbManager, mailSender, tokenService, cfg)
+	twoFactorService := adminService.NewTwoFactorService(dbManager.GetMasterPool(), redisClient.Client, cfg)
+	loginGuard := adminService.NewLoginGuardService(dbManager.GetMasterPool(), redisClient.Client, cfg)
+	impersonationService := adminService.NewImpersonationService(dbManager.GetMasterPool(), redisClient.Client, impersonationRepo, tenantJWTKeys, cfg)
 
 	// Initialize handlers (Admin API uses SysUserRepository)
-	authHandler := adminHandlers.NewAdminAuthHandler(sysUserRepo, cfg)
-	tenantHandler := adminHandlers.NewTenantHandler(tenantService)
+	authHandler := adminHandlers.NewAdminAuthHandler(sysUserRepo, tokenService, emailService, twoFactorService, loginGuard, cfg)
+	tenantHandler := adminHandlers.NewTenantHandler(tenantService, cfg)
 	planHandler := adminHandlers.NewPlanHandler(planService)
-	featureHandler := adminHandlers.NewFeatureHandler(featureRepo)
+	featureHandler := adminHandlers.NewFeatureHandler(featureRepo, planService)
 	sysUserHandler := adminHandlers.NewSysUserHandler(sysUserRepo)
+	sysRoleHandler := adminHandlers.NewSysRoleHandler(twoFactorService)
+	loginLockoutHandler := adminHandlers.NewLoginLockoutHandler(loginGuard)
+	impersonationHandler := adminHandlers.NewImpersonationHandler(impersonationService, impersonationRepo, sysUserRepo)
+	provisioningHandler := adminHandlers.NewProvisioningHandler(tenantService)
+	dbCredentialHandler := adminHandlers.NewDBCredentialHandler(dbCredentialService)
+	clusterHandler := adminHandlers.NewClusterHandler(clusterService)
+	backupHandler := adminHandlers.NewBackupHandler(backupService)
+	sandboxHandler := adminHandlers.NewSandboxHandler(sandboxService)
+	rateLimitHandler := adminHandlers.NewRateLimitHandler(rateLimitService)
 
 	// Setup router
-	router := setupAdminRouter(cfg, authHandler, tenantHandler, planHandler, featureHandler, sysUserHandler)
+	router := setupAdminRouter(cfg, redisClient, jwtKeys, authHandler, tenantHandler, planHandler, featureHandler, sysUserHandler, sysRoleHandler, loginLockoutHandler, impersonationHandler, provisioningHandler, dbCredentialHandler, clusterHandler, backupHandler, sandboxHandler, rateLimitHandler, sysUserRepo)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -83,7 +151,7 @@ func main() {
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Admin API listening on port %s", cfg.AdminAPI.Port)
-		log.Printf("Security: Admin JWT secret isolated, IP whitelist recommended")
+		log.Printf("Security: Admin JWT signing keys isolated, IP whitelist recommended")
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -113,11 +181,23 @@ func main() {
 
 func setupAdminRouter(
 	cfg *config.Config,
+	redisClient *cache.Client,
+	jwtKeys *utils.JWTKeySet,
 	authHandler *adminHandlers.AdminAuthHandler,
 	tenantHandler *adminHandlers.TenantHandler,
 	planHandler *adminHandlers.PlanHandler,
 	featureHandler *adminHandlers.FeatureHandler,
 	sysUserHandler *adminHandlers.SysUserHandler,
+	sysRoleHandler *adminHandlers.SysRoleHandler,
+	loginLockoutHandler *adminHandlers.LoginLockoutHandler,
+	impersonationHandler *adminHandlers.ImpersonationHandler,
+	provisioningHandler *adminHandlers.ProvisioningHandler,
+	dbCredentialHandler *adminHandlers.DBCredentialHandler,
+	clusterHandler *adminHandlers.ClusterHandler,
+	backupHandler *adminHandlers.BackupHandler,
+	sandboxHandler *adminHandlers.SandboxHandler,
+	rateLimitHandler *adminHandlers.RateLimitHandler,
+	sysUserRepo *adminRepo.SysUserRepository,
 ) *gin.Engine {
 	router := gin.Default()
 
@@ -136,18 +216,37 @@ func setupAdminRouter(
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "admin-api"})
 	})
 
+	// Public keys of the admin tokens (EdDSA), for services that verify them
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, jwtKeys.JWKS())
+	})
+
 	// Public routes (admin registration/login)
 	public := router.Group("/api/v1/admin")
 	{
 		public.POST("/register", authHandler.Register)
 		public.POST("/login", authHandler.Login)
+		public.POST("/login/2fa", authHandler.LoginTwoFactor)
+		public.POST("/login/2fa/setup", authHandler.LoginTwoFactorSetup)
+		public.POST("/refresh", authHandler.Refresh)
+		public.POST("/verify-email", authHandler.VerifyEmail)
+		public.POST("/forgot-password", authHandler.ForgotPassword)
+		public.POST("/reset-password", authHandler.ResetPassword)
 	}
 
 	// Protected admin routes (requires admin JWT with AdminAuthMiddleware)
 	protected := router.Group("/api/v1/admin")
-	protected.Use(middleware.AdminAuthMiddleware(cfg))
+	protected.Use(middleware.AdminAuthMiddleware(jwtKeys, redisClient))
 	{
 		protected.GET("/me", authHandler.GetMe)
+		protected.POST("/logout", authHandler.Logout)
+		protected.POST("/logout-all", authHandler.LogoutAll)
+		protected.POST("/resend-verification", authHandler.ResendVerification)
+		protected.GET("/2fa", authHandler.GetTwoFactor)
+		protected.POST("/2fa/setup", authHandler.SetupTwoFactor)
+		protected.POST("/2fa/enable", authHandler.EnableTwoFactor)
+		protected.POST("/2fa/disable", authHandler.DisableTwoFactor)
+		protected.POST("/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
 
 		// Tenant Management (Control Plane)
 		protected.POST("/tenants", tenantHandler.CreateTenant)
@@ -155,6 +254,47 @@ func setupAdminRouter(
 		protected.GET("/tenants/:tenant_id", tenantHandler.GetTenant)
 		protected.PUT("/tenants/:tenant_id", tenantHandler.UpdateTenant)
 		protected.DELETE("/tenants/:tenant_id", tenantHandler.DeleteTenant)
+		protected.POST("/tenants/:tenant_id/suspend", tenantHandler.SuspendTenant)
+		protected.POST("/tenants/:tenant_id/reactivate", tenantHandler.ReactivateTenant)
+		protected.POST("/tenants/:tenant_id/restore", tenantHandler.RestoreTenant)
+
+		// Custom domain ownership (DNS TXT) for Host-based storefront routing
+		protected.GET("/tenants/:tenant_id/custom-domain", tenantHandler.GetCustomDomain)
+		protected.POST("/tenants/:tenant_id/custom-domain/verify", tenantHandler.VerifyCustomDomain)
+
+		// Provisioning progress (JSON + server-sent events)
+		protected.GET("/tenants/:tenant_id/provisioning", provisioningHandler.GetProvisioning)
+		protected.GET("/tenants/:tenant_id/provisioning/stream", provisioningHandler.StreamProvisioning)
+
+		// Tenant database credentials (dedicated Postgres login per tenant)
+		protected.GET("/tenants/:tenant_id/db-credentials", dbCredentialHandler.GetCredentials)
+		protected.POST("/tenants/:tenant_id/db-credentials/rotate", dbCredentialHandler.RotateCredentials)
+
+		// Database clusters (placement and moving tenants between Postgres servers)
+		protected.GET("/clusters", clusterHandler.ListClusters)
+		protected.GET("/clusters/:id", clusterHandler.GetCluster)
+		protected.POST("/clusters", clusterHandler.CreateCluster)
+		protected.PUT("/clusters/:id", clusterHandler.UpdateCluster)
+		protected.DELETE("/clusters/:id", clusterHandler.DeleteCluster)
+		protected.POST("/tenants/:tenant_id/move", clusterHandler.MoveTenant)
+
+		// Tenant backups (executed by the worker, archives stored under backups/{tenant_id}/)
+		protected.POST("/tenants/:tenant_id/backups", backupHandler.CreateBackup)
+		protected.GET("/tenants/:tenant_id/backups", backupHandler.ListBackups)
+		protected.GET("/tenants/:tenant_id/backups/:backup_id", backupHandler.GetBackup)
+		protected.POST("/tenants/:tenant_id/backups/:backup_id/restore", backupHandler.RestoreBackup)
+		protected.GET("/tenants/:tenant_id/restores", backupHandler.ListRestores)
+		protected.GET("/tenants/:tenant_id/restores/:restore_id", backupHandler.GetRestore)
+
+		// Sandbox tenants (staging copies of a tenant, deleted automatically after their TTL)
+		protected.POST("/tenants/:tenant_id/sandboxes", sandboxHandler.CreateSandbox)
+		protected.GET("/tenants/:tenant_id/sandboxes", sandboxHandler.ListSandboxClones)
+		protected.GET("/tenants/:tenant_id/sandboxes/:clone_id", sandboxHandler.GetSandboxClone)
+
+		// Provisioning Dead-Letter Queue
+		protected.GET("/provisioning/dead-letters", provisioningHandler.ListDeadLetters)
+		protected.GET("/provisioning/dead-letters/:tenant_id", provisioningHandler.GetDeadLetter)
+		protected.POST("/provisioning/dead-letters/:tenant_id/replay", provisioningHandler.ReplayDeadLetter)
 
 		// Plan Management
 		protected.GET("/plans", planHandler.GetAllPlans)
@@ -163,6 +303,13 @@ func setupAdminRouter(
 		protected.PUT("/plans/:id", planHandler.UpdatePlan)
 		protected.DELETE("/plans/:id", planHandler.DeletePlan)
 
+		// Tenant API rate limits (requests per minute per bucket: read, write, upload)
+		protected.GET("/plans/:id/rate-limits", rateLimitHandler.GetPlanRateLimits)
+		protected.PUT("/plans/:id/rate-limits", rateLimitHandler.SetPlanRateLimits)
+		protected.GET("/tenants/:tenant_id/rate-limits", rateLimitHandler.GetTenantRateLimits)
+		protected.PUT("/tenants/:tenant_id/rate-limits", rateLimitHandler.SetTenantRateLimits)
+		protected.DELETE("/tenants/:tenant_id/rate-limits", rateLimitHandler.DeleteTenantRateLimits)
+
 		// Feature Management
 		protected.GET("/features", featureHandler.GetAllFeatures)
 		protected.GET("/features/:id", featureHandler.GetFeatureByID)
@@ -177,6 +324,22 @@ func setupAdminRouter(
 		protected.PUT("/sys-users/:id", sysUserHandler.UpdateSysUser)
 		protected.DELETE("/sys-users/:id", sysUserHandler.DeleteSysUser)
 
+		// System roles (mandatory 2FA for the administrators holding the role)
+		protected.GET("/sys-roles", sysRoleHandler.ListSysRoles)
+		protected.PUT("/sys-roles/:id/two-factor", sysRoleHandler.SetTwoFactorPolicy)
+
+		// Login brute-force lockouts (accounts and IPs of both APIs)
+		protected.GET("/login-lockouts", loginLockoutHandler.ListLockoutEvents)
+		protected.POST("/login-lockouts/unlock", loginLockoutHandler.Unlock)
+
+		// Impersonation of tenant users by support (short-lived tenant token, audited per request)
+		supportTools := middleware.RequireSysPermission(sysUserRepo, "access_support_tools")
+		auditLogs := middleware.RequireSysPermission(sysUserRepo, "view_audit_logs")
+		protected.POST("/impersonations", supportTools, impersonationHandler.StartImpersonation)
+		protected.DELETE("/impersonations/:id", supportTools, impersonationHandler.EndImpersonation)
+		protected.GET("/impersonations", auditLogs, impersonationHandler.ListImpersonations)
+		protected.GET("/impersonations/:id", auditLogs, impersonationHandler.GetImpersonation)
+
 		// Profile Management (TODO: implement when needed)
 		// profiles := protected.Group("/profiles")
 		// {
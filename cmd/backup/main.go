@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/database"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+	"github.com/saas-multi-database-api/internal/storage"
+)
+
+// CLI para backup e restore do database de um tenant (executa na hora, sem passar pelo worker)
+//
+// Exemplos:
+//
+//	backup -tenant minha-loja                        # gera um backup agora
+//	backup -tenant minha-loja -list                  # lista os backups do tenant
+//	backup -tenant minha-loja -restore <backup_id>   # restaura no database atual
+//	backup -tenant minha-loja -restore <backup_id> -mode fresh
+//	backup -prune                                    # aplica a retenção em todos os tenants
+func main() {
+	tenant := flag.String("tenant", "", "url_code, db_code ou ID do tenant")
+	list := flag.Bool("list", false, "lista os backups do tenant")
+	restore := flag.String("restore", "", "ID do backup a restaurar")
+	mode := flag.String("mode", adminService.RestoreModeExisting, "modo do restore: existing ou fresh")
+	prune := flag.Bool("prune", false, "remove os backups fora da retenção (todos os tenants)")
+	flag.Parse()
+
+	if *tenant == "" && !*prune {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	if err := cfg.ValidateDBCredentialsKey(); err != nil {
+		log.Fatalf("Configuração inválida: %v", err)
+	}
+	ctx := context.Background()
+
+	masterPool, err := pgxpool.New(ctx, cfg.MasterDB.ConnectionString())
+	if err != nil {
+		log.Fatalf("Erro ao conectar no Master DB: %v", err)
+	}
+	defer masterPool.Close()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Host + ":" + cfg.Redis.Port,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+
+	clusters := database.NewClusterRegistry(masterPool, cfg)
+	defer clusters.Close()
+
+	migrator, err := database.NewTenantMigrator(cfg.Migrations.TenantPath)
+	if err != nil {
+		log.Fatalf("Erro ao carregar migrations de tenant: %v", err)
+	}
+
+	storageDriver, err := storage.NewStorageDriver(&storage.Config{
+		Driver:             cfg.Storage.Driver,
+		UploadsPath:        cfg.Storage.UploadsPath,
+		AWSAccessKeyID:     cfg.Storage.AWSAccessKeyID,
+		AWSSecretAccessKey: cfg.Storage.AWSSecretAccessKey,
+		AWSRegion:          cfg.Storage.AWSRegion,
+		AWSBucket:          cfg.Storage.AWSBucket,
+		R2AccessKeyID:      cfg.Storage.R2AccessKeyID,
+		R2SecretAccessKey:  cfg.Storage.R2SecretAccessKey,
+		R2AccountID:        cfg.Storage.R2AccountID,
+		R2Bucket:           cfg.Storage.R2Bucket,
+		R2PublicURL:        cfg.Storage.R2PublicURL,
+	})
+	if err != nil {
+		log.Fatalf("Erro ao inicializar storage driver: %v", err)
+	}
+
+	dbCreds := adminService.NewDBCredentialService(masterPool, clusters, redisClient, cfg)
+	backups := adminService.NewBackupService(masterPool)
+	runner := adminService.NewBackupRunner(masterPool, clusters, migrator, dbCreds, storageDriver, redisClient, cfg)
+
+	if *prune {
+		pruned, err := runner.Prune(ctx)
+		if err != nil {
+			log.Fatalf("Erro ao aplicar retenção: %v", err)
+		}
+		log.Printf("%d backup(s) removidos", pruned)
+		return
+	}
+
+	tenantID, urlCode, err := findTenant(ctx, masterPool, *tenant)
+	if err != nil {
+		log.Fatalf("Erro ao buscar tenant: %v", err)
+	}
+
+	switch {
+	case *list:
+		items, err := backups.ListBackups(ctx, tenantID)
+		if err != nil {
+			log.Fatalf("Erro ao listar backups: %v", err)
+		}
+		if len(items) == 0 {
+			log.Printf("Nenhum backup para o tenant %s.", urlCode)
+			return
+		}
+		for _, b := range items {
+			var size int64
+			if b.SizeBytes != nil {
+				size = *b.SizeBytes
+			}
+			log.Printf("%s  %-10s  %s  %d bytes", b.ID, b.Status, b.CreatedAt.Format("2006-01-02 15:04:05"), size)
+		}
+
+	case *restore != "":
+		backupID, err := uuid.Parse(*restore)
+		if err != nil {
+			log.Fatalf("ID do backup inválido: %v", err)
+		}
+		job, err := backups.RequestRestore(ctx, tenantID, backupID, adminService.RestoreRequest{Mode: *mode}, nil)
+		if err != nil {
+			log.Fatalf("Erro ao agendar restore: %v", err)
+		}
+		if err := runner.Restore(ctx, job.ID); err != nil {
+			log.Fatalf("Restore %s falhou: %v", job.ID, err)
+		}
+		log.Printf("Restore %s do tenant %s concluído (modo %s)", job.ID, urlCode, job.Mode)
+
+	default:
+		job, err := backups.RequestBackup(ctx, tenantID, nil)
+		if err != nil {
+			log.Fatalf("Erro ao agendar backup: %v", err)
+		}
+		if err := runner.Backup(ctx, job.ID); err != nil {
+			log.Fatalf("Backup %s falhou: %v", job.ID, err)
+		}
+		log.Printf("Backup %s do tenant %s concluído", job.ID, urlCode)
+	}
+}
+
+// findTenant busca o tenant por url_code, db_code ou ID
+func findTenant(ctx context.Context, masterPool *pgxpool.Pool, filter string) (uuid.UUID, string, error) {
+	var id uuid.UUID
+	var urlCode string
+	err := masterPool.QueryRow(ctx, `
+		SELECT id, url_code FROM tenants
+		WHERE url_code = $1 OR db_code::text = $1 OR id::text = $1
+	`, filter).Scan(&id, &urlCode)
+	if err == pgx.ErrNoRows {
+		return id, "", adminService.ErrTenantNotFound
+	}
+	return id, urlCode, err
+}
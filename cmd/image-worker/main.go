@@ -25,6 +25,9 @@ func main() {
 
 	// Carregar configuração
 	cfg := config.Load()
+	if err := cfg.ValidateDBCredentialsKey(); err != nil {
+		log.Fatalf("Configuração inválida: %v", err)
+	}
 
 	ctx := context.Background()
 
@@ -71,6 +74,12 @@ func main() {
 	ctxWorker, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Recriar pools de tenants cujas credenciais de banco foram rotacionadas
+	go dbManager.ListenPoolRefresh(ctxWorker, redisClient.Client)
+
+	// Fechar pools de tenants ociosos (TENANT_POOL_IDLE_MINUTES)
+	go dbManager.RunTenantPoolEviction(ctxWorker)
+
 	// Subscriber para eventos de processamento de imagens
 	pubsub := redisClient.Client.Subscribe(ctxWorker, "image:process")
 	defer pubsub.Close()
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// CLI para gerar e rotacionar as chaves Ed25519 que assinam os tokens das APIs Admin e Tenant
+//
+// Exemplos:
+//
+//	jwt-keys -api admin -generate   # cria a primeira chave (as APIs também criam ao subir)
+//	jwt-keys -api tenant -rotate    # nova chave assina; a anterior valida até os tokens expirarem
+//	jwt-keys -api tenant -list      # lista as chaves ativas e em retirada
+//	jwt-keys -prune                 # remove as chaves rotacionadas cujos tokens já expiraram
+func main() {
+	api := flag.String("api", "", "API dona das chaves: admin ou tenant")
+	generate := flag.Bool("generate", false, "cria a primeira chave da API")
+	rotate := flag.Bool("rotate", false, "cria uma nova chave e retira a atual")
+	list := flag.Bool("list", false, "lista as chaves da API")
+	prune := flag.Bool("prune", false, "remove as chaves rotacionadas vencidas (todas as APIs)")
+	flag.Parse()
+
+	if *api == "" && !*prune {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+
+	masterPool, err := pgxpool.New(ctx, cfg.MasterDB.ConnectionString())
+	if err != nil {
+		log.Fatalf("Erro ao conectar no Master DB: %v", err)
+	}
+	defer masterPool.Close()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Host + ":" + cfg.Redis.Port,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+
+	keys := adminService.NewJWTKeyService(masterPool, redisClient, cfg)
+
+	switch {
+	case *prune:
+		pruned, err := keys.Prune(ctx)
+		if err != nil {
+			log.Fatalf("Erro ao remover chaves: %v", err)
+		}
+		log.Printf("%d chave(s) removidas", pruned)
+
+	case *generate:
+		key, err := keys.Generate(ctx, *api)
+		if err != nil {
+			log.Fatalf("Erro ao gerar chave: %v", err)
+		}
+		log.Printf("Chave %s criada para a API %s", key.KID, key.API)
+
+	case *rotate:
+		key, err := keys.Rotate(ctx, *api)
+		if err != nil {
+			log.Fatalf("Erro ao rotacionar chave: %v", err)
+		}
+		log.Printf("Chave %s ativa para a API %s; as anteriores validam tokens até expirarem", key.KID, key.API)
+
+	case *list:
+		items, err := keys.List(ctx, *api)
+		if err != nil {
+			log.Fatalf("Erro ao listar chaves: %v", err)
+		}
+		if len(items) == 0 {
+			log.Printf("Nenhuma chave para a API %s.", *api)
+			return
+		}
+		for _, k := range items {
+			verifyUntil := "-"
+			if k.VerifyUntil != nil {
+				verifyUntil = k.VerifyUntil.Format("2006-01-02 15:04:05")
+			}
+			log.Printf("%s  %-8s  %s  criada %s  valida até %s", k.KID, k.Status, k.Algorithm, k.CreatedAt.Format("2006-01-02 15:04:05"), verifyUntil)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
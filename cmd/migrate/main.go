@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/database"
+)
+
+// tenantTarget representa um tenant a ser migrado
+type tenantTarget struct {
+	URLCode   string
+	DBCode    string
+	Status    string
+	ClusterID *uuid.UUID // nil = cluster padrão
+}
+
+// tenantOutcome representa o resultado da migração de um tenant
+type tenantOutcome struct {
+	Tenant tenantTarget
+	Result *database.MigrationResult
+	Err    error
+}
+
+// CLI para aplicar migrations versionadas em todos os databases de tenant (ou em um só)
+//
+// Exemplos:
+//
+//	migrate                       # todos os tenants até a versão mais recente
+//	migrate -target 2             # todos os tenants para a versão 2 (up ou down)
+//	migrate -tenant minha-loja    # apenas um tenant (url_code ou db_code)
+//	migrate -status               # apenas exibe a versão atual de cada tenant
+func main() {
+	target := flag.Int("target", -1, "versão alvo (padrão: mais recente)")
+	tenant := flag.String("tenant", "", "url_code ou db_code de um único tenant")
+	statusOnly := flag.Bool("status", false, "apenas exibe a versão atual de cada tenant")
+	concurrency := flag.Int("concurrency", 4, "quantidade de tenants migrados em paralelo")
+	flag.Parse()
+
+	cfg := config.Load()
+	if err := cfg.ValidateDBCredentialsKey(); err != nil {
+		log.Fatalf("Configuração inválida: %v", err)
+	}
+	ctx := context.Background()
+
+	migrator, err := database.NewTenantMigrator(cfg.Migrations.TenantPath)
+	if err != nil {
+		log.Fatalf("Erro ao carregar migrations de tenant: %v", err)
+	}
+
+	if *target < 0 {
+		*target = migrator.LatestVersion()
+	}
+	if *target > migrator.LatestVersion() {
+		log.Fatalf("Versão alvo %d inexistente (mais recente: %d)", *target, migrator.LatestVersion())
+	}
+	if *concurrency < 1 {
+		*concurrency = 1
+	}
+
+	masterPool, err := pgxpool.New(ctx, cfg.MasterDB.ConnectionString())
+	if err != nil {
+		log.Fatalf("Erro ao conectar no Master DB: %v", err)
+	}
+	defer masterPool.Close()
+
+	clusters := database.NewClusterRegistry(masterPool, cfg)
+	defer clusters.Close()
+
+	tenants, err := listTenants(ctx, masterPool, *tenant)
+	if err != nil {
+		log.Fatalf("Erro ao listar tenants: %v", err)
+	}
+	if len(tenants) == 0 {
+		log.Println("Nenhum tenant encontrado.")
+		return
+	}
+
+	if *statusOnly {
+		printStatus(ctx, clusters, migrator, tenants)
+		return
+	}
+
+	log.Printf("Migrando %d tenant(s) para a versão %d...", len(tenants), *target)
+
+	jobs := make(chan tenantTarget)
+	outcomes := make(chan tenantOutcome)
+
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				result, err := migrateTenant(ctx, clusters, migrator, t, *target)
+				outcomes <- tenantOutcome{Tenant: t, Result: result, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, t := range tenants {
+			jobs <- t
+		}
+		close(jobs)
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	var failed []tenantOutcome
+	done := 0
+	for outcome := range outcomes {
+		done++
+		progress := fmt.Sprintf("[%d/%d]", done, len(tenants))
+
+		if outcome.Err != nil {
+			failed = append(failed, outcome)
+			log.Printf("%s FALHA %s (%s): %v", progress, outcome.Tenant.URLCode, outcome.Tenant.DBCode, outcome.Err)
+			continue
+		}
+
+		if len(outcome.Result.Applied) == 0 {
+			log.Printf("%s OK %s: já está na versão %d", progress, outcome.Tenant.URLCode, outcome.Result.To)
+			continue
+		}
+
+		log.Printf("%s OK %s: versão %d -> %d (migrations %v)",
+			progress, outcome.Tenant.URLCode, outcome.Result.From, outcome.Result.To, outcome.Result.Applied)
+	}
+
+	log.Printf("Concluído: %d sucesso(s), %d falha(s)", len(tenants)-len(failed), len(failed))
+
+	if len(failed) > 0 {
+		for _, outcome := range failed {
+			log.Printf("  - %s (%s): %v", outcome.Tenant.URLCode, outcome.Tenant.DBCode, outcome.Err)
+		}
+		os.Exit(1)
+	}
+}
+
+// listTenants busca os tenants no Master DB (todos, ou apenas o informado por url_code/db_code)
+func listTenants(ctx context.Context, masterPool *pgxpool.Pool, filter string) ([]tenantTarget, error) {
+	// Tenants sendo movidos de cluster ficam de fora: rode novamente após a movimentação
+	query := `
+		SELECT url_code, db_code::text, status::text, cluster_id
+		FROM tenants
+		WHERE status NOT IN ('provisioning', 'migrating')
+		ORDER BY created_at
+	`
+	args := []interface{}{}
+
+	if filter != "" {
+		query = `
+			SELECT url_code, db_code::text, status::text, cluster_id
+			FROM tenants
+			WHERE url_code = $1 OR db_code::text = $1
+		`
+		args = append(args, filter)
+	}
+
+	rows, err := masterPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []tenantTarget
+	for rows.Next() {
+		var t tenantTarget
+		if err := rows.Scan(&t.URLCode, &t.DBCode, &t.Status, &t.ClusterID); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, t)
+	}
+
+	return tenants, rows.Err()
+}
+
+// connectTenant conecta direto no postgres (não via pgbouncer) do cluster no database do tenant
+func connectTenant(ctx context.Context, clusters *database.ClusterRegistry, t tenantTarget) (*pgxpool.Pool, error) {
+	cluster, err := clusters.Get(ctx, t.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	dsn, err := clusters.AdminDSN(cluster, database.TenantDBName(t.DBCode))
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.MaxConns = 2
+
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
+// migrateTenant migra um único tenant para a versão alvo
+func migrateTenant(ctx context.Context, clusters *database.ClusterRegistry, migrator *database.TenantMigrator, t tenantTarget, target int) (*database.MigrationResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	pool, err := connectTenant(ctx, clusters, t)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar: %w", err)
+	}
+	defer pool.Close()
+
+	return migrator.MigrateTo(ctx, pool, target)
+}
+
+// printStatus exibe a versão atual do schema de cada tenant
+func printStatus(ctx context.Context, clusters *database.ClusterRegistry, migrator *database.TenantMigrator, tenants []tenantTarget) {
+	latest := migrator.LatestVersion()
+	pending := 0
+
+	for _, t := range tenants {
+		pool, err := connectTenant(ctx, clusters, t)
+		if err != nil {
+			log.Printf("%-30s %-10s erro ao conectar: %v", t.URLCode, t.Status, err)
+			continue
+		}
+
+		version, err := migrator.CurrentVersion(ctx, pool)
+		pool.Close()
+		if err != nil {
+			log.Printf("%-30s %-10s erro: %v", t.URLCode, t.Status, err)
+			continue
+		}
+
+		mark := "atualizado"
+		if version < latest {
+			mark = "pendente"
+			pending++
+		}
+		log.Printf("%-30s %-10s versão %d/%d %s", t.URLCode, t.Status, version, latest, mark)
+	}
+
+	log.Printf("%d de %d tenant(s) com migrations pendentes", pending, len(tenants))
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Provedor OpenID Connect mínimo para testar o SSO dos tenants localmente (não usar em produção).
+//
+// A tela de login não tem senha: o email, o nome, os grupos e o segundo fator informados vão para o
+// ID token. O sub é derivado do email, então o mesmo email sempre gera a mesma conta no IdP.
+//
+// Variáveis de ambiente:
+//
+//	MOCK_IDP_PORT           porta HTTP (padrão 9000)
+//	MOCK_IDP_ISSUER         issuer usado pelas APIs (padrão http://localhost:9000)
+//	MOCK_IDP_PUBLIC_URL     URL do navegador para a tela de login (padrão = issuer)
+//	MOCK_IDP_CLIENT_ID      client_id aceito (padrão saas-tenant)
+//	MOCK_IDP_CLIENT_SECRET  client_secret aceito (padrão saas-tenant-secret)
+
+const (
+	codeTTL    = time.Minute
+	idTokenTTL = 5 * time.Minute
+	keyID      = "mock-idp-1"
+)
+
+// authCode é um authorization code emitido e ainda não trocado
+type authCode struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	email         string
+	name          string
+	groups        []string
+	emailVerified bool
+	mfa           bool
+	expiresAt     time.Time
+}
+
+type server struct {
+	issuer       string
+	publicURL    string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authCode
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Mock IdP</title></head>
+<body style="font-family: sans-serif; max-width: 420px; margin: 40px auto">
+<h2>Mock IdP</h2>
+<form method="post" action="authorize">
+  {{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+  {{end}}
+  <p><label>Email<br><input type="email" name="email" value="{{.LoginHint}}" required style="width: 100%"></label></p>
+  <p><label>Nome<br><input type="text" name="name" style="width: 100%"></label></p>
+  <p><label>Grupos (separados por vírgula)<br><input type="text" name="groups" style="width: 100%"></label></p>
+  <p><label><input type="checkbox" name="mfa" value="1"> Autenticado com segundo fator</label></p>
+  <p><label><input type="checkbox" name="email_unverified" value="1"> Email não verificado</label></p>
+  <button type="submit">Entrar</button>
+</form>
+</body>
+</html>`))
+
+func main() {
+	issuer := strings.TrimSuffix(getEnv("MOCK_IDP_ISSUER", "http://localhost:9000"), "/")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+
+	s := &server{
+		issuer:       issuer,
+		publicURL:    strings.TrimSuffix(getEnv("MOCK_IDP_PUBLIC_URL", issuer), "/"),
+		clientID:     getEnv("MOCK_IDP_CLIENT_ID", "saas-tenant"),
+		clientSecret: getEnv("MOCK_IDP_CLIENT_SECRET", "saas-tenant-secret"),
+		key:          key,
+		codes:        make(map[string]authCode),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/jwks", s.jwks)
+
+	addr := ":" + getEnv("MOCK_IDP_PORT", "9000")
+	log.Printf("Mock IdP listening on %s (issuer %s, client_id %s)", addr, s.issuer, s.clientID)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
+
+// discovery publica os metadados do provedor. A tela de login usa a URL pública (navegador);
+// token e JWKS usam o issuer (chamados pelas APIs)
+func (s *server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.publicURL + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      []string{"openid", "email", "profile", "groups"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+	})
+}
+
+// authorize mostra a tela de login (GET) e emite o code para o redirect_uri (POST)
+func (s *server) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	params := map[string]string{}
+	for _, name := range []string{"client_id", "redirect_uri", "response_type", "scope", "state", "nonce", "code_challenge", "code_challenge_method"} {
+		params[name] = r.Form.Get(name)
+	}
+	switch {
+	case params["client_id"] != s.clientID:
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	case params["response_type"] != "code":
+		http.Error(w, "response_type must be code", http.StatusBadRequest)
+		return
+	case params["code_challenge"] == "" || params["code_challenge_method"] != "S256":
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+	redirectURI, err := url.Parse(params["redirect_uri"])
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		loginPage.Execute(w, map[string]interface{}{"Params": params, "LoginHint": r.Form.Get("login_hint")})
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email := strings.TrimSpace(r.PostForm.Get("email"))
+	if email == "" {
+		http.Error(w, "email is required", http.StatusBadRequest)
+		return
+	}
+	var groups []string
+	for _, group := range strings.Split(r.PostForm.Get("groups"), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = authCode{
+		clientID:      params["client_id"],
+		redirectURI:   params["redirect_uri"],
+		codeChallenge: params["code_challenge"],
+		nonce:         params["nonce"],
+		email:         email,
+		name:          strings.TrimSpace(r.PostForm.Get("name")),
+		groups:        groups,
+		emailVerified: r.PostForm.Get("email_unverified") == "",
+		mfa:           r.PostForm.Get("mfa") != "",
+		expiresAt:     time.Now().Add(codeTTL),
+	}
+	s.mu.Unlock()
+
+	query := redirectURI.Query()
+	query.Set("code", code)
+	query.Set("state", params["state"])
+	redirectURI.RawQuery = query.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token troca o code pelo ID token, verificando o cliente, o redirect_uri e o PKCE
+func (s *server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.clientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(s.clientSecret)) != 1 {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	issued, found := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !found || time.Now().After(issued.expiresAt) || issued.clientID != clientID:
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	case issued.redirectURI != r.PostForm.Get("redirect_uri"):
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	case base64.RawURLEncoding.EncodeToString(verifier[:]) != issued.codeChallenge:
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	subject := sha256.Sum256([]byte(strings.ToLower(issued.email)))
+	amr := []string{"pwd"}
+	if issued.mfa {
+		amr = append(amr, "mfa")
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            s.issuer,
+		"sub":            hex.EncodeToString(subject[:16]),
+		"aud":            clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(idTokenTTL).Unix(),
+		"email":          issued.email,
+		"email_verified": issued.emailVerified,
+		"amr":            amr,
+	}
+	if issued.nonce != "" {
+		claims["nonce"] = issued.nonce
+	}
+	if issued.name != "" {
+		claims["name"] = issued.name
+	}
+	if len(issued.groups) > 0 {
+		claims["groups"] = issued.groups
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// jwks publica a chave pública que assina os ID tokens
+func (s *server) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.PublicKey.E)).Bytes()),
+		}},
+	})
+}
+
+func tokenError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Failed to read random bytes: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/saas-multi-database-api/internal/config"
 	"github.com/saas-multi-database-api/internal/database"
 	tenantHandlers "github.com/saas-multi-database-api/internal/handlers/tenant"
+	"github.com/saas-multi-database-api/internal/mailer"
 	"github.com/saas-multi-database-api/internal/middleware"
 	adminModels "github.com/saas-multi-database-api/internal/models/admin"
 	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
@@ -25,6 +26,7 @@ import (
 	adminService "github.com/saas-multi-database-api/internal/services/admin"
 	tenantImageService "github.com/saas-multi-database-api/internal/services/tenant"
 	"github.com/saas-multi-database-api/internal/storage"
+	"github.com/saas-multi-database-api/internal/utils"
 )
 
 // Tenant API - Data Plane
@@ -34,6 +36,18 @@ func main() {
 
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.ValidateExportLinks(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := cfg.ValidateDBCredentialsKey(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := cfg.ValidateTwoFactorKey(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := cfg.ValidateOIDCKey(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
@@ -54,14 +68,58 @@ func main() {
 		log.Fatalf("Failed to initialize Redis client: %v", err)
 	}
 
+	// Recriar pools de tenants cujas credenciais de banco foram rotacionadas
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	defer stopRefresh()
+	go dbManager.ListenPoolRefresh(refreshCtx, redisClient.Client)
+
+	// Fechar pools de tenants ociosos (TENANT_POOL_IDLE_MINUTES)
+	go dbManager.RunTenantPoolEviction(refreshCtx)
+
+	// Chaves Ed25519 de assinatura dos tokens de tenant (a primeira é gerada se não existir)
+	jwtKeyService := adminService.NewJWTKeyService(dbManager.GetMasterPool(), redisClient.Client, cfg)
+	jwtKeys, err := jwtKeyService.KeySet(ctx, adminService.TokenSubjectTenant)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	go jwtKeyService.ListenRefresh(refreshCtx, adminService.TokenSubjectTenant, jwtKeys)
+	go jwtKeys.RunRefresh(refreshCtx, time.Minute)
+
 	// Initialize repositories
 	userRepo := adminRepo.NewUserRepository(dbManager.GetMasterPool())
 	tenantRepoMaster := adminRepo.NewTenantRepository(dbManager.GetMasterPool())
 	planRepo := adminRepo.NewPlanRepository(dbManager.GetMasterPool())
+	apiKeyRepo := adminRepo.NewAPIKeyRepository(dbManager.GetMasterPool())
+	impersonationRepo := adminRepo.NewImpersonationRepository(dbManager.GetMasterPool())
+
+	// Tenant e permissões resolvidos (Redis + cache local), invalidados por pub/sub quando mudam
+	tenantContexts := cache.NewTenantContextCache(redisClient, tenantRepoMaster, &cfg.Tenants)
+	go tenantContexts.ListenInvalidation(refreshCtx)
 
 	// Initialize services
-	tenantServiceAdmin := adminService.NewTenantService(tenantRepoMaster, userRepo, redisClient.Client, dbManager.GetMasterPool())
+	tenantServiceAdmin := adminService.NewTenantService(tenantRepoMaster, userRepo, redisClient.Client, dbManager.GetMasterPool(), cfg)
 	planService := adminService.NewPlanService(planRepo, redisClient.Client)
+	tokenService := adminService.NewAuthTokenService(dbManager.GetMasterPool(), redisClient.Client, jwtKeys, cfg)
+
+	// Initialize mailer (verification and password reset emails)
+	mailSender, err := mailer.NewMailer(&mailer.Config{
+		Driver:       cfg.Mailer.Driver,
+		From:         cfg.Mailer.From,
+		OutputDir:    cfg.Mailer.OutputDir,
+		SMTPHost:     cfg.Mailer.SMTPHost,
+		SMTPPort:     cfg.Mailer.SMTPPort,
+		SMTPUsername: cfg.Mailer.SMTPUsername,
+		SMTPPassword: cfg.Mailer.SMTPPassword,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+	emailService := adminService.NewAuthEmailService(dbManager.GetMasterPool(), dbManager, mailSender, tokenService, cfg)
+	twoFactorService := adminService.NewTwoFactorService(dbManager.GetMasterPool(), redisClient.Client, cfg)
+	loginGuard := adminService.NewLoginGuardService(dbManager.GetMasterPool(), redisClient.Client, cfg)
+	oidcService := adminService.NewOIDCService(dbManager.GetMasterPool(), redisClient.Client, cfg)
+	tenantRoleService := adminService.NewTenantRoleService(dbManager.GetMasterPool(), redisClient.Client)
+	tenantMemberService := adminService.NewTenantMemberService(dbManager.GetMasterPool(), redisClient.Client, emailService, cfg)
 
 	// Initialize storage driver
 	storageDriver, err := storage.NewStorageDriver(&storage.Config{
@@ -82,13 +140,21 @@ func main() {
 	}
 
 	// Initialize handlers
-	authHandler := tenantHandlers.NewTenantAuthHandler(userRepo, tenantRepoMaster, tenantServiceAdmin, cfg)
+	authHandler := tenantHandlers.NewTenantAuthHandler(userRepo, tenantRepoMaster, tenantServiceAdmin, tokenService, emailService, twoFactorService, loginGuard, oidcService, tenantMemberService, cfg)
+	securityHandler := tenantHandlers.NewSecurityHandler(tenantRepoMaster, twoFactorService)
+	roleHandler := tenantHandlers.NewRoleHandler(tenantRoleService)
+	memberHandler := tenantHandlers.NewMemberHandler(tenantMemberService, tenantRoleService)
+	apiKeyHandler := tenantHandlers.NewAPIKeyHandler(apiKeyRepo)
+	ssoHandler := tenantHandlers.NewSSOHandler(oidcService)
 	productHandler := tenantHandlers.NewProductHandler()
 	serviceHandler := tenantHandlers.NewServiceHandler()
 	settingHandler := tenantHandlers.NewSettingHandler()
+	provisioningHandler := tenantHandlers.NewProvisioningHandler(tenantRepoMaster, tenantServiceAdmin)
+	exportHandler := tenantHandlers.NewExportHandler(tenantImageService.NewExportService(dbManager.GetMasterPool(), storageDriver, cfg))
+	storefrontHandler := tenantHandlers.NewStorefrontHandler(tenantRepoMaster)
 
 	// Setup router
-	router := setupTenantRouter(cfg, dbManager, redisClient, authHandler, productHandler, serviceHandler, settingHandler, tenantRepoMaster, tenantServiceAdmin, storageDriver, planService)
+	router := setupTenantRouter(cfg, dbManager, redisClient, jwtKeys, authHandler, productHandler, serviceHandler, settingHandler, securityHandler, roleHandler, memberHandler, apiKeyHandler, ssoHandler, provisioningHandler, exportHandler, storefrontHandler, tenantRepoMaster, apiKeyRepo, impersonationRepo, tenantContexts, tenantServiceAdmin, storageDriver, planService)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -99,7 +165,7 @@ func main() {
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Tenant API listening on port %s", cfg.TenantAPI.Port)
-		log.Printf("Security: Tenant JWT secret isolated, rate limiting enabled")
+		log.Printf("Security: Tenant JWT signing keys isolated, rate limiting enabled")
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -131,11 +197,23 @@ func setupTenantRouter(
 	cfg *config.Config,
 	dbManager *database.Manager,
 	redisClient *cache.Client,
+	jwtKeys *utils.JWTKeySet,
 	authHandler *tenantHandlers.TenantAuthHandler,
 	productHandler *tenantHandlers.ProductHandler,
 	serviceHandler *tenantHandlers.ServiceHandler,
 	settingHandler *tenantHandlers.SettingHandler,
+	securityHandler *tenantHandlers.SecurityHandler,
+	roleHandler *tenantHandlers.RoleHandler,
+	memberHandler *tenantHandlers.MemberHandler,
+	apiKeyHandler *tenantHandlers.APIKeyHandler,
+	ssoHandler *tenantHandlers.SSOHandler,
+	provisioningHandler *tenantHandlers.ProvisioningHandler,
+	exportHandler *tenantHandlers.ExportHandler,
+	storefrontHandler *tenantHandlers.StorefrontHandler,
 	tenantRepo *adminRepo.TenantRepository,
+	apiKeyRepo *adminRepo.APIKeyRepository,
+	impersonationRepo *adminRepo.ImpersonationRepository,
+	tenantContexts *cache.TenantContextCache,
 	tenantService *adminService.TenantService,
 	storageDriver storage.StorageDriver,
 	planService *adminService.PlanService,
@@ -147,25 +225,64 @@ func setupTenantRouter(
 		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:5173", "http://localhost:5174", "http://localhost:8080"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// Serve static files from uploads directory
-	router.Static("/uploads", cfg.Storage.UploadsPath)
+	// Serve static files from uploads directory (private prefixes such as backups/ are never served)
+	uploads := router.Group("/uploads")
+	uploads.Use(func(c *gin.Context) {
+		if storage.IsPrivatePath(c.Param("filepath")) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.Next()
+	})
+	uploads.Static("/", cfg.Storage.UploadsPath)
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "tenant-api"})
 	})
 
+	// Métricas agregadas dos pools de tenant abertos por esta instância (rota sem autenticação:
+	// o detalhe por pool, com o db_code de cada tenant, não é exposto)
+	router.GET("/health/pools", func(c *gin.Context) {
+		stats := dbManager.TenantPoolStats()
+		stats.Pools = nil
+		c.JSON(http.StatusOK, stats)
+	})
+
+	// Login/registration limited per client IP; tenant routes per tenant + user (limits of the plan)
+	authRateLimit := middleware.AuthRateLimit(cfg, redisClient)
+	tenantRateLimit := middleware.TenantRateLimit(cfg, redisClient)
+
+	// Public keys of the tenant tokens (EdDSA), for services that verify them
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, jwtKeys.JWKS())
+	})
+
 	// Public routes (tenant user authentication)
 	public := router.Group("/api/v1")
 	{
-		public.POST("/auth/register", authHandler.Register)
-		public.POST("/auth/login", authHandler.Login)
-		public.POST("/subscription", authHandler.Subscribe) // Nova rota de assinatura
+		public.POST("/auth/register", authRateLimit, authHandler.Register)
+		public.POST("/auth/login", authRateLimit, authHandler.Login)
+		public.POST("/auth/login/2fa", authRateLimit, authHandler.LoginTwoFactor)
+		public.POST("/auth/login/2fa/setup", authRateLimit, authHandler.LoginTwoFactorSetup)
+		public.POST("/auth/refresh", authRateLimit, authHandler.Refresh)
+		public.POST("/auth/verify-email", authRateLimit, authHandler.VerifyEmail)
+		public.POST("/auth/forgot-password", authRateLimit, authHandler.ForgotPassword)
+		public.POST("/auth/reset-password", authRateLimit, authHandler.ResetPassword)
+		public.POST("/auth/invitations/lookup", authRateLimit, authHandler.InvitationLookup)
+		public.POST("/auth/invitations/register", authRateLimit, authHandler.InvitationRegister)
+		public.GET("/auth/oidc/:tenant", authRateLimit, authHandler.OIDCStatus)
+		public.GET("/auth/oidc/:tenant/authorize", authRateLimit, authHandler.OIDCAuthorize)
+		public.POST("/auth/oidc/callback", authRateLimit, authHandler.OIDCCallback)
+		public.POST("/subscription", authRateLimit, authHandler.Subscribe) // Nova rota de assinatura
+
+		// Export download via signed, expiring link (no JWT: the link is the credential)
+		public.GET("/exports/:export_id/download", exportHandler.Download)
 
 		// Public endpoint to list plans (for registration) - with Redis cache
 		public.GET("/plans", func(c *gin.Context) {
@@ -182,26 +299,58 @@ func setupTenantRouter(
 		})
 	}
 
-	// Protected tenant user routes (requires tenant JWT)
+	// Public storefront routes: tenant resolved by Host ({subdomain}.TENANT_BASE_DOMAIN or verified custom domain), no JWT
+	storefront := router.Group("/api/v1/storefront")
+	storefront.Use(middleware.TenantHostMiddleware(cfg, dbManager, redisClient, tenantRepo, tenantContexts))
+	storefront.Use(tenantRateLimit)
+	{
+		storefront.GET("/config", storefrontHandler.GetConfig)
+		storefront.GET("/products", middleware.RequireFeature("products"), storefrontHandler.ListProducts)
+		storefront.GET("/products/:id", middleware.RequireFeature("products"), storefrontHandler.GetProduct)
+		storefront.GET("/services", middleware.RequireFeature("services"), storefrontHandler.ListServices)
+		storefront.GET("/services/:id", middleware.RequireFeature("services"), storefrontHandler.GetService)
+	}
+
+	// Protected tenant user routes (requires tenant JWT; API keys are not accepted)
 	protected := router.Group("/api/v1")
-	protected.Use(middleware.TenantAuthMiddleware(cfg))
+	protected.Use(middleware.TenantAuthMiddleware(jwtKeys, redisClient, nil))
+	protected.Use(middleware.ImpersonationAudit(impersonationRepo))
 	{
+		// Account routes are not available to administrators impersonating the user
+		noImpersonation := middleware.RejectImpersonation()
+
 		protected.GET("/auth/me", authHandler.GetMe)
-		protected.POST("/auth/switch-tenant", authHandler.SwitchTenant) // Nova rota de troca de tenant
-		protected.GET("/tenants", func(c *gin.Context) {
+		protected.POST("/auth/logout", noImpersonation, authHandler.Logout)
+		protected.POST("/auth/logout-all", noImpersonation, authHandler.LogoutAll)
+		protected.POST("/auth/resend-verification", noImpersonation, authRateLimit, authHandler.ResendVerification)
+		protected.GET("/auth/2fa", authHandler.GetTwoFactor)
+		protected.POST("/auth/2fa/setup", noImpersonation, authHandler.SetupTwoFactor)
+		protected.POST("/auth/2fa/enable", noImpersonation, authRateLimit, authHandler.EnableTwoFactor)
+		protected.POST("/auth/2fa/disable", noImpersonation, authRateLimit, authHandler.DisableTwoFactor)
+		protected.POST("/auth/2fa/recovery-codes", noImpersonation, authRateLimit, authHandler.RegenerateRecoveryCodes)
+		protected.POST("/auth/switch-tenant", noImpersonation, authRateLimit, authHandler.SwitchTenant) // Nova rota de troca de tenant
+		protected.POST("/auth/invitations/accept", noImpersonation, authRateLimit, authHandler.AcceptInvitation)
+		protected.POST("/auth/oidc/link", noImpersonation, authRateLimit, authHandler.OIDCLink)
+		protected.GET("/tenants", noImpersonation, func(c *gin.Context) {
 			userID := c.MustGet("user_id").(uuid.UUID)
 			tenants, _ := tenantService.ListUserTenants(c.Request.Context(), userID)
 			c.JSON(http.StatusOK, tenants)
 		})
+
+		// Progresso do provisionamento (tenant ainda não está ativo, por isso fora de /:url_code)
+		protected.GET("/tenants/:url_code/provisioning", noImpersonation, provisioningHandler.GetProvisioning)
+		protected.GET("/tenants/:url_code/provisioning/stream", noImpersonation, provisioningHandler.StreamProvisioning)
 	}
 
-	// Tenant-scoped routes (authentication + tenant resolution required)
+	// Tenant-scoped routes (authentication + tenant resolution required; user JWT or tenant API key)
 	tenant := router.Group("/api/v1/:url_code")
-	tenant.Use(middleware.TenantAuthMiddleware(cfg))
-	tenant.Use(middleware.TenantMiddleware(dbManager, redisClient, tenantRepo))
+	tenant.Use(middleware.TenantAuthMiddleware(jwtKeys, redisClient, apiKeyRepo))
+	tenant.Use(middleware.ImpersonationAudit(impersonationRepo))
+	tenant.Use(middleware.TenantMiddleware(dbManager, redisClient, tenantContexts))
+	tenant.Use(tenantRateLimit)
 	{
-		// Tenant configuration endpoint for frontend
-		tenant.GET("/config", func(c *gin.Context) {
+		// Tenant configuration endpoint for frontend (API keys need setg_m)
+		tenant.GET("/config", middleware.RequireAPIKeyPermission("setg_m"), func(c *gin.Context) {
 			features := c.MustGet("features").([]string)
 			permissions := c.MustGet("permissions").([]string)
 			tenantIDStr := c.MustGet("tenant_id").(string)
@@ -231,37 +380,101 @@ func setupTenantRouter(
 		products := tenant.Group("/products")
 		products.Use(middleware.RequireFeature("products"))
 		{
-			products.GET("", productHandler.List)
-			products.POST("", middleware.RequirePermission("create_product"), productHandler.Create)
-			products.GET("/:id", productHandler.GetByID)
-			products.PUT("/:id", middleware.RequirePermission("update_product"), productHandler.Update)
-			products.DELETE("/:id", middleware.RequirePermission("delete_product"), productHandler.Delete)
+			products.GET("", middleware.RequireAPIKeyPermission("prod_r"), productHandler.List)
+			products.POST("", middleware.RequirePermission("prod_c"), productHandler.Create)
+			products.GET("/:id", middleware.RequireAPIKeyPermission("prod_r"), productHandler.GetByID)
+			products.PUT("/:id", middleware.RequirePermission("prod_u"), productHandler.Update)
+			products.DELETE("/:id", middleware.RequirePermission("prod_d"), productHandler.Delete)
 		}
 
 		// Services routes (requires 'services' feature)
 		services := tenant.Group("/services")
 		services.Use(middleware.RequireFeature("services"))
 		{
-			services.GET("", serviceHandler.List)
-			services.POST("", middleware.RequirePermission("create_service"), serviceHandler.Create)
-			services.GET("/:id", serviceHandler.GetByID)
-			services.PUT("/:id", middleware.RequirePermission("update_service"), serviceHandler.Update)
-			services.DELETE("/:id", middleware.RequirePermission("delete_service"), serviceHandler.Delete)
+			services.GET("", middleware.RequireAPIKeyPermission("serv_r"), serviceHandler.List)
+			services.POST("", middleware.RequirePermission("serv_c"), serviceHandler.Create)
+			services.GET("/:id", middleware.RequireAPIKeyPermission("serv_r"), serviceHandler.GetByID)
+			services.PUT("/:id", middleware.RequirePermission("serv_u"), serviceHandler.Update)
+			services.DELETE("/:id", middleware.RequirePermission("serv_d"), serviceHandler.Delete)
 		}
 
-		// Settings routes (always available for reading, manage_settings for editing)
+		// Settings routes (always available for reading by members, setg_m for editing and for API keys)
 		settings := tenant.Group("/settings")
 		{
-			settings.GET("", settingHandler.List)
-			settings.GET("/:key", settingHandler.GetByKey)
+			settings.GET("", middleware.RequireAPIKeyPermission("setg_m"), settingHandler.List)
+			settings.GET("/:key", middleware.RequireAPIKeyPermission("setg_m"), settingHandler.GetByKey)
 			settings.PUT("/:key", middleware.RequirePermission("setg_m"), settingHandler.Update)
 		}
 
+		// Security policies of the tenant (mandatory 2FA for the members)
+		security := tenant.Group("/security")
+		security.Use(middleware.RequirePermission("user_m"))
+		{
+			security.GET("/two-factor", securityHandler.GetTwoFactorPolicy)
+			security.PUT("/two-factor", securityHandler.UpdateTwoFactorPolicy)
+		}
+
+		// Custom roles of the tenant and their permissions (limited to the features of the plan)
+		roles := tenant.Group("/roles")
+		roles.Use(middleware.RequirePermission("user_m"))
+		{
+			roles.GET("", roleHandler.List)
+			roles.GET("/permissions", roleHandler.ListPermissions)
+			roles.POST("", roleHandler.Create)
+			roles.PUT("/:id", roleHandler.Update)
+			roles.DELETE("/:id", roleHandler.Delete)
+		}
+
+		// Members of the tenant (the owner is changed only by an ownership transfer)
+		members := tenant.Group("/members")
+		{
+			members.GET("", middleware.RequirePermission("user_m"), memberHandler.List)
+			members.PUT("/:user_id/role", middleware.RequirePermission("user_m"), memberHandler.UpdateRole)
+			members.DELETE("/:user_id", middleware.RequirePermission("user_m"), memberHandler.Remove)
+			members.POST("/transfer-ownership", middleware.RequireOwner(), middleware.RejectImpersonation(), memberHandler.TransferOwnership)
+		}
+
+		// Email invitations to join the tenant with a role (limited by plans.max_members)
+		invitations := tenant.Group("/invitations")
+		invitations.Use(middleware.RequirePermission("user_m"))
+		{
+			invitations.GET("", memberHandler.ListInvitations)
+			invitations.POST("", memberHandler.Invite)
+			invitations.DELETE("/:id", memberHandler.RevokeInvitation)
+		}
+
+		// API keys for integrations (owner only; keys and impersonation sessions cannot manage keys)
+		apiKeys := tenant.Group("/api-keys")
+		apiKeys.Use(middleware.RequireOwner(), middleware.RejectImpersonation())
+		{
+			apiKeys.GET("", apiKeyHandler.List)
+			apiKeys.POST("", apiKeyHandler.Create)
+			apiKeys.DELETE("/:id", apiKeyHandler.Revoke)
+		}
+
+		// Single sign-on (OpenID Connect) of the tenant (owner only)
+		sso := tenant.Group("/sso")
+		sso.Use(middleware.RequireOwner(), middleware.RejectImpersonation())
+		{
+			sso.GET("/oidc", ssoHandler.GetOIDCProvider)
+			sso.PUT("/oidc", ssoHandler.SaveOIDCProvider)
+			sso.DELETE("/oidc", ssoHandler.DeleteOIDCProvider)
+		}
+
+		// Data export routes (full export of the tenant data, built by the worker)
+		exports := tenant.Group("/exports")
+		exports.Use(middleware.RequirePermission("data_x"))
+		{
+			exports.POST("", exportHandler.Create)
+			exports.GET("", exportHandler.List)
+			exports.GET("/:id", exportHandler.GetByID)
+		}
+
 		// Profile routes (avatar and logo uploads)
 		profiles := tenant.Group("/profiles")
 		{
 			// User avatar upload
-			profiles.POST("/users/:user_id/avatar", func(c *gin.Context) {
+			profiles.POST("/users/:user_id/avatar", middleware.RequireAPIKeyPermission("user_m"), func(c *gin.Context) {
 				tenantPool := c.MustGet("tenant_pool").(*pgxpool.Pool)
 				imageRepo := tenantImageRepo.NewImageRepository(tenantPool)
 				profileService := tenantImageService.NewProfileService(imageRepo, storageDriver)
@@ -270,7 +483,7 @@ func setupTenantRouter(
 			})
 
 			// Tenant logo upload
-			profiles.POST("/tenants/:tenant_id/logo", func(c *gin.Context) {
+			profiles.POST("/tenants/:tenant_id/logo", middleware.RequireAPIKeyPermission("setg_m"), func(c *gin.Context) {
 				tenantPool := c.MustGet("tenant_pool").(*pgxpool.Pool)
 				imageRepo := tenantImageRepo.NewImageRepository(tenantPool)
 				profileService := tenantImageService.NewProfileService(imageRepo, storageDriver)
@@ -280,7 +493,7 @@ func setupTenantRouter(
 		}
 
 		// Images routes (polymorphic - works with products, services, etc.)
-		// Permissions: Uses product/service permissions (update_product, update_service, delete_product, delete_service)
+		// Permissions: Uses product/service permissions (prod_u/serv_u to change, prod_d/serv_d to delete; API keys need prod_r/serv_r to read)
 		images := tenant.Group("/images")
 		{
 			// Handler will be initialized per request with tenant pool
@@ -294,15 +507,16 @@ func setupTenantRouter(
 				imageHandler.UploadImages(c)
 			})
 
-			images.GET("", func(c *gin.Context) {
-				tenantPool := c.MustGet("tenant_pool").(*pgxpool.Pool)
+			images.GET("", middleware.RequireAnyAPIKeyPermission("prod_r", "serv_r"), func(c *gin.Context) {
+				// Listing reads from the replica when available
+				tenantPool := c.MustGet("tenant_read_pool").(*pgxpool.Pool)
 				imageRepo := tenantImageRepo.NewImageRepository(tenantPool)
 				uploadService := tenantImageService.NewUploadService(imageRepo, storageDriver, redisClient)
 				imageHandler := tenantHandlers.NewImageHandler(imageRepo, uploadService)
 				imageHandler.ListImages(c)
 			})
 
-			images.GET("/:id", func(c *gin.Context) {
+			images.GET("/:id", middleware.RequireAnyAPIKeyPermission("prod_r", "serv_r"), func(c *gin.Context) {
 				tenantPool := c.MustGet("tenant_pool").(*pgxpool.Pool)
 				imageRepo := tenantImageRepo.NewImageRepository(tenantPool)
 				uploadService := tenantImageService.NewUploadService(imageRepo, storageDriver, redisClient)
@@ -53,6 +53,17 @@ func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
 	return count > 0, err
 }
 
+// IncrWindow increments a counter that expires with its window and returns the new count
+func (c *Client) IncrWindow(ctx context.Context, key string, window time.Duration) (int64, error) {
+	pipe := c.Client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
 // GetDBCode retrieves the db_code for a given url_code from cache
 func (c *Client) GetDBCode(ctx context.Context, urlCode string) (string, error) {
 	key := fmt.Sprintf("tenant:urlcode:%s", urlCode)
@@ -71,6 +82,36 @@ func (c *Client) InvalidateTenantCache(ctx context.Context, urlCode string) erro
 	return c.Delete(ctx, key)
 }
 
+// GetHostURLCode retrieves the url_code a request host ({subdomain}.{base} or custom domain) resolves to
+func (c *Client) GetHostURLCode(ctx context.Context, host string) (string, error) {
+	key := fmt.Sprintf("tenant:host:%s", host)
+	return c.Get(ctx, key)
+}
+
+// SetHostURLCode caches the url_code for a request host
+func (c *Client) SetHostURLCode(ctx context.Context, host, urlCode string, expiration time.Duration) error {
+	key := fmt.Sprintf("tenant:host:%s", host)
+	return c.Set(ctx, key, urlCode, expiration)
+}
+
+// InvalidateHostCache removes the cached host -> url_code mapping
+func (c *Client) InvalidateHostCache(ctx context.Context, host string) error {
+	key := fmt.Sprintf("tenant:host:%s", host)
+	return c.Delete(ctx, key)
+}
+
+// MarkRecentWrite records that a user just wrote to a tenant database (read-your-writes window)
+func (c *Client) MarkRecentWrite(ctx context.Context, dbCode, userID string, window time.Duration) error {
+	key := fmt.Sprintf("tenant:recent_write:%s:%s", dbCode, userID)
+	return c.Set(ctx, key, 1, window)
+}
+
+// HasRecentWrite reports whether the user wrote to the tenant database within the read-your-writes window
+func (c *Client) HasRecentWrite(ctx context.Context, dbCode, userID string) (bool, error) {
+	key := fmt.Sprintf("tenant:recent_write:%s:%s", dbCode, userID)
+	return c.Exists(ctx, key)
+}
+
 // Publish publishes a message to a Redis channel
 func (c *Client) Publish(ctx context.Context, channel string, message interface{}) error {
 	return c.Client.Publish(ctx, channel, message).Err()
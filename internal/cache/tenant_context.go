@@ -0,0 +1,341 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+)
+
+// TenantContextInvalidateChannel receives TenantContextEvent messages (JSON) whenever a cached tenant
+// or member context changes: tenant status, plan, plan features, roles or memberships
+const TenantContextInvalidateChannel = "tenant:context:invalidate"
+
+// Entries kept in the L1 before expired ones are pruned
+const tenantContextL1PruneSize = 10000
+
+// TenantContext is the resolved tenant shared by every request of that tenant
+type TenantContext struct {
+	ID       uuid.UUID `json:"id"`
+	DBCode   string    `json:"db_code"`
+	URLCode  string    `json:"url_code"`
+	Status   string    `json:"status"`
+	Features []string  `json:"features"`
+	// Requests per minute of the rate limit buckets (override or plan; nil = API default)
+	RateLimits admin.RateLimits `json:"rate_limits"`
+	// Members must sign in with a second factor (TOTP) to access the tenant
+	RequireTwoFactor bool `json:"require_two_factor"`
+	// Members (except the owner) must sign in with the tenant SSO (OIDC) to access the tenant
+	SSOOnly bool `json:"sso_only"`
+}
+
+// MemberContext is the access of a user to a tenant
+type MemberContext struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// TenantContextEvent describes what must be dropped from the tenant context caches.
+// URLCode drops the tenant context, TenantID drops the member contexts of the tenant (only the
+// one of UserID when set), All drops everything (plan and feature changes).
+type TenantContextEvent struct {
+	URLCode  string     `json:"url_code,omitempty"`
+	TenantID *uuid.UUID `json:"tenant_id,omitempty"`
+	UserID   *uuid.UUID `json:"user_id,omitempty"`
+	All      bool       `json:"all,omitempty"`
+}
+
+type l1Entry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// TenantContextCache caches resolved tenants and member permission sets in Redis (shared by all
+// tenant-api replicas) with a short in-process L1 in front of it. Only active tenants and actual
+// members are cached, so a new member or a reactivated tenant is seen on the next request.
+type TenantContextCache struct {
+	redis      *Client
+	tenantRepo *adminRepo.TenantRepository
+	ttl        time.Duration
+	l1TTL      time.Duration
+
+	mu      sync.RWMutex
+	tenants map[string]l1Entry[*TenantContext]
+	members map[string]l1Entry[*MemberContext]
+}
+
+// NewTenantContextCache creates the cache using TENANT_CONTEXT_CACHE_SECONDS and TENANT_CONTEXT_L1_SECONDS
+func NewTenantContextCache(redisClient *Client, tenantRepo *adminRepo.TenantRepository, cfg *config.TenantsConfig) *TenantContextCache {
+	return &TenantContextCache{
+		redis:      redisClient,
+		tenantRepo: tenantRepo,
+		ttl:        time.Duration(cfg.ContextCacheSeconds) * time.Second,
+		l1TTL:      time.Duration(cfg.ContextL1Seconds) * time.Second,
+		tenants:    make(map[string]l1Entry[*TenantContext]),
+		members:    make(map[string]l1Entry[*MemberContext]),
+	}
+}
+
+func tenantContextKey(urlCode string) string {
+	return fmt.Sprintf("tenant:ctx:%s", urlCode)
+}
+
+func memberContextKey(tenantID, userID uuid.UUID) string {
+	return fmt.Sprintf("tenant:member:%s:%s", tenantID, userID)
+}
+
+// GetTenant returns the tenant of a url_code (L1, then Redis, then the master DB)
+func (c *TenantContextCache) GetTenant(ctx context.Context, urlCode string) (*TenantContext, error) {
+	key := tenantContextKey(urlCode)
+
+	if tenant, ok := getL1(c, c.tenants, key); ok {
+		return tenant, nil
+	}
+
+	var tenant *TenantContext
+	if getL2(ctx, c.redis, key, &tenant) {
+		setL1(c, c.tenants, key, tenant)
+		return tenant, nil
+	}
+
+	model, err := c.tenantRepo.GetTenantByURLCode(ctx, urlCode)
+	if err != nil {
+		return nil, err
+	}
+	features, err := c.tenantRepo.GetTenantFeatures(ctx, model.ID)
+	if err != nil {
+		return nil, err
+	}
+	rateLimits, err := c.tenantRepo.GetTenantRateLimits(ctx, model.ID)
+	if err != nil {
+		return nil, err
+	}
+	requireTwoFactor, err := c.tenantRepo.GetTenantRequireTwoFactor(ctx, model.ID)
+	if err != nil {
+		return nil, err
+	}
+	ssoOnly, err := c.tenantRepo.GetTenantSSOOnly(ctx, model.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant = &TenantContext{
+		ID:               model.ID,
+		DBCode:           model.DBCode.String(),
+		URLCode:          model.URLCode,
+		Status:           string(model.Status),
+		Features:         features,
+		RateLimits:       rateLimits,
+		RequireTwoFactor: requireTwoFactor,
+		SSOOnly:          ssoOnly,
+	}
+	if tenant.Features == nil {
+		tenant.Features = []string{}
+	}
+
+	// Suspended, migrating or provisioning tenants are read from the master DB on every request
+	if tenant.Status == "active" {
+		setL2(ctx, c.redis, key, tenant, c.ttl)
+		setL1(c, c.tenants, key, tenant)
+	}
+	return tenant, nil
+}
+
+// GetMember returns the role and permissions of a user in a tenant, or nil when the user is not a member
+func (c *TenantContextCache) GetMember(ctx context.Context, tenantID, userID uuid.UUID) (*MemberContext, error) {
+	key := memberContextKey(tenantID, userID)
+
+	if member, ok := getL1(c, c.members, key); ok {
+		return member, nil
+	}
+
+	var member *MemberContext
+	if getL2(ctx, c.redis, key, &member) {
+		setL1(c, c.members, key, member)
+		return member, nil
+	}
+
+	hasAccess, err := c.tenantRepo.CheckUserAccess(ctx, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, nil
+	}
+
+	permissions, err := c.tenantRepo.GetUserPermissions(ctx, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := c.tenantRepo.GetUserRole(ctx, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	member = &MemberContext{Role: role, Permissions: permissions}
+	if member.Permissions == nil {
+		member.Permissions = []string{}
+	}
+
+	setL2(ctx, c.redis, key, member, c.ttl)
+	setL1(c, c.members, key, member)
+	return member, nil
+}
+
+// ListenInvalidation drops L1 entries published on TenantContextInvalidateChannel until ctx is cancelled
+func (c *TenantContextCache) ListenInvalidation(ctx context.Context) {
+	pubsub := c.redis.Client.Subscribe(ctx, TenantContextInvalidateChannel)
+	defer pubsub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-pubsub.Channel():
+			if !ok {
+				return
+			}
+			var event TenantContextEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Printf("Invalid tenant context event %q: %v", msg.Payload, err)
+				continue
+			}
+			c.dropL1(event)
+		}
+	}
+}
+
+// dropL1 removes the in-process entries matched by the event
+func (c *TenantContextCache) dropL1(event TenantContextEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if event.All {
+		clear(c.tenants)
+		clear(c.members)
+		return
+	}
+	if event.URLCode != "" {
+		delete(c.tenants, tenantContextKey(event.URLCode))
+	}
+	if event.TenantID != nil {
+		if event.UserID != nil {
+			delete(c.members, memberContextKey(*event.TenantID, *event.UserID))
+			return
+		}
+		prefix := fmt.Sprintf("tenant:member:%s:", *event.TenantID)
+		for key := range c.members {
+			if strings.HasPrefix(key, prefix) {
+				delete(c.members, key)
+			}
+		}
+	}
+}
+
+// InvalidateTenantContext removes the Redis entries matched by the event and publishes it so every
+// tenant-api replica drops its L1. Call it after the change is committed.
+func InvalidateTenantContext(ctx context.Context, redisClient *redis.Client, event TenantContextEvent) error {
+	var patterns []string
+	var keys []string
+	switch {
+	case event.All:
+		patterns = append(patterns, "tenant:ctx:*", "tenant:member:*")
+	default:
+		if event.URLCode != "" {
+			keys = append(keys, tenantContextKey(event.URLCode))
+		}
+		if event.TenantID != nil && event.UserID != nil {
+			keys = append(keys, memberContextKey(*event.TenantID, *event.UserID))
+		} else if event.TenantID != nil {
+			patterns = append(patterns, fmt.Sprintf("tenant:member:%s:*", *event.TenantID))
+		}
+	}
+
+	for _, pattern := range patterns {
+		iter := redisClient.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return fmt.Errorf("failed to scan tenant context cache: %w", err)
+		}
+	}
+	if len(keys) > 0 {
+		if err := redisClient.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to invalidate tenant context cache: %w", err)
+		}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := redisClient.Publish(ctx, TenantContextInvalidateChannel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish tenant context invalidation: %w", err)
+	}
+	return nil
+}
+
+func getL1[T any](c *TenantContextCache, entries map[string]l1Entry[T], key string) (T, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		var zero T
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func setL1[T any](c *TenantContextCache, entries map[string]l1Entry[T], key string, value T) {
+	if c.l1TTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Expired entries are only replaced on access; prune them when the map grows
+	if len(entries) >= tenantContextL1PruneSize {
+		now := time.Now()
+		for k, entry := range entries {
+			if now.After(entry.expiresAt) {
+				delete(entries, k)
+			}
+		}
+	}
+	entries[key] = l1Entry[T]{value: value, expiresAt: time.Now().Add(c.l1TTL)}
+}
+
+func getL2(ctx context.Context, redisClient *Client, key string, dest any) bool {
+	cached, err := redisClient.Get(ctx, key)
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Redis error: %v", err)
+		}
+		return false
+	}
+	return json.Unmarshal([]byte(cached), dest) == nil
+}
+
+func setL2(ctx context.Context, redisClient *Client, key string, value any, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if err := redisClient.Set(ctx, key, data, ttl); err != nil {
+		log.Printf("Failed to cache tenant context: %v", err)
+	}
+}
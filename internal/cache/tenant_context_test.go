@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+)
+
+var (
+	testTenantID = uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	testOtherID  = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	testUserA    = uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+	testUserB    = uuid.MustParse("bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb")
+)
+
+// allTestKeys are the cached contexts seeded by seedTenantContexts
+var allTestKeys = []string{
+	tenantContextKey("acme"),
+	tenantContextKey("other"),
+	memberContextKey(testTenantID, testUserA),
+	memberContextKey(testTenantID, testUserB),
+	memberContextKey(testOtherID, testUserA),
+}
+
+func newTestTenantContextCache(client *Client) *TenantContextCache {
+	return NewTenantContextCache(client, nil, &config.TenantsConfig{ContextCacheSeconds: 60, ContextL1Seconds: 60})
+}
+
+// seedTenantContexts fills the L1 (and the Redis L2 when c has a client) with two tenants and three members
+func seedTenantContexts(t *testing.T, c *TenantContextCache) {
+	t.Helper()
+	ctx := context.Background()
+	for _, tenant := range []*TenantContext{
+		{ID: testTenantID, URLCode: "acme", Status: "active", Features: []string{}},
+		{ID: testOtherID, URLCode: "other", Status: "active", Features: []string{}},
+	} {
+		key := tenantContextKey(tenant.URLCode)
+		setL1(c, c.tenants, key, tenant)
+		if c.redis != nil {
+			setL2(ctx, c.redis, key, tenant, c.ttl)
+		}
+	}
+	for _, key := range allTestKeys[2:] {
+		member := &MemberContext{Role: "member", Permissions: []string{"prod_r"}}
+		setL1(c, c.members, key, member)
+		if c.redis != nil {
+			setL2(ctx, c.redis, key, member, c.ttl)
+		}
+	}
+}
+
+// cachedL1Keys lists the keys still in the L1 of c
+func cachedL1Keys(c *TenantContextCache) []string {
+	keys := []string{}
+	for _, key := range allTestKeys {
+		_, tenant := getL1(c, c.tenants, key)
+		_, member := getL1(c, c.members, key)
+		if tenant || member {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func without(keys []string, dropped ...string) []string {
+	remaining := []string{}
+	for _, key := range keys {
+		keep := true
+		for _, d := range dropped {
+			keep = keep && key != d
+		}
+		if keep {
+			remaining = append(remaining, key)
+		}
+	}
+	sort.Strings(remaining)
+	return remaining
+}
+
+var tenantContextEventTests = []struct {
+	name    string
+	event   TenantContextEvent
+	dropped []string
+}{
+	{
+		name:    "tenant",
+		event:   TenantContextEvent{URLCode: "acme"},
+		dropped: []string{tenantContextKey("acme")},
+	},
+	{
+		name:    "one member",
+		event:   TenantContextEvent{TenantID: &testTenantID, UserID: &testUserA},
+		dropped: []string{memberContextKey(testTenantID, testUserA)},
+	},
+	{
+		name:    "every member of a tenant",
+		event:   TenantContextEvent{TenantID: &testTenantID},
+		dropped: []string{memberContextKey(testTenantID, testUserA), memberContextKey(testTenantID, testUserB)},
+	},
+	{
+		name:    "tenant and its members",
+		event:   TenantContextEvent{URLCode: "acme", TenantID: &testTenantID},
+		dropped: []string{tenantContextKey("acme"), memberContextKey(testTenantID, testUserA), memberContextKey(testTenantID, testUserB)},
+	},
+	{
+		name:    "all",
+		event:   TenantContextEvent{All: true},
+		dropped: allTestKeys,
+	},
+}
+
+func TestTenantContextDropL1(t *testing.T) {
+	for _, tt := range tenantContextEventTests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestTenantContextCache(nil)
+			seedTenantContexts(t, c)
+
+			c.dropL1(tt.event)
+			if got, want := cachedL1Keys(c), without(allTestKeys, tt.dropped...); !reflect.DeepEqual(got, want) {
+				t.Errorf("L1 keys = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTenantContextInvalidationPubSub(t *testing.T) {
+	rdb := testRedis(t)
+
+	for _, tt := range tenantContextEventTests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := rdb.FlushDB(ctx).Err(); err != nil {
+				t.Fatalf("flush: %v", err)
+			}
+
+			// Two tenant-api replicas sharing the Redis L2, each with its own L1
+			replicas := []*TenantContextCache{newTestTenantContextCache(&Client{Client: rdb}), newTestTenantContextCache(&Client{Client: rdb})}
+			for _, c := range replicas {
+				seedTenantContexts(t, c)
+				go c.ListenInvalidation(ctx)
+			}
+			waitSubscribers(t, rdb, len(replicas))
+
+			if err := InvalidateTenantContext(ctx, rdb, tt.event); err != nil {
+				t.Fatalf("InvalidateTenantContext: %v", err)
+			}
+
+			want := without(allTestKeys, tt.dropped...)
+			for i, c := range replicas {
+				deadline := time.Now().Add(2 * time.Second)
+				for !reflect.DeepEqual(cachedL1Keys(c), want) && time.Now().Before(deadline) {
+					time.Sleep(5 * time.Millisecond)
+				}
+				if got := cachedL1Keys(c); !reflect.DeepEqual(got, want) {
+					t.Errorf("replica %d L1 keys = %v, want %v", i, got, want)
+				}
+			}
+
+			redisKeys := []string{}
+			for _, key := range allTestKeys {
+				if n, err := rdb.Exists(ctx, key).Result(); err != nil {
+					t.Fatalf("exists %s: %v", key, err)
+				} else if n == 1 {
+					redisKeys = append(redisKeys, key)
+				}
+			}
+			sort.Strings(redisKeys)
+			if !reflect.DeepEqual(redisKeys, want) {
+				t.Errorf("Redis keys = %v, want %v", redisKeys, want)
+			}
+		})
+	}
+}
+
+// waitSubscribers waits until n clients listen on TenantContextInvalidateChannel (a message
+// published before the subscription is not delivered)
+func waitSubscribers(t *testing.T, rdb *redis.Client, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for rdb.PubSubNumSub(context.Background(), TenantContextInvalidateChannel).Val()[TenantContextInvalidateChannel] < int64(n) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d listeners not subscribed to %s", n, TenantContextInvalidateChannel)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
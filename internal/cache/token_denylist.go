@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func deniedTokenKey(jti string) string {
+	return fmt.Sprintf("auth:denied:%s", jti)
+}
+
+func revokedBeforeKey(subject string) string {
+	return fmt.Sprintf("auth:revoked_before:%s", subject)
+}
+
+// TokenSubject identifies the owner of access tokens in the denylist ("admin:{sys_user_id}" or "tenant:{user_id}")
+func TokenSubject(apiType, userID string) string {
+	return apiType + ":" + userID
+}
+
+// DenyAccessToken rejects the access token with this jti until it expires (logout)
+func DenyAccessToken(ctx context.Context, redisClient *redis.Client, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	if err := redisClient.Set(ctx, deniedTokenKey(jti), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to deny access token: %w", err)
+	}
+	return nil
+}
+
+// RevokeSubjectAccessTokens rejects every access token of the subject issued up to now (logout-all).
+// ttl must be at least the access token lifetime.
+func RevokeSubjectAccessTokens(ctx context.Context, redisClient *redis.Client, subject string, ttl time.Duration) error {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := redisClient.Set(ctx, revokedBeforeKey(subject), now, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	return nil
+}
+
+// IsAccessTokenRevoked reports whether the token was denied by jti or issued before a logout-all of its subject
+func (c *Client) IsAccessTokenRevoked(ctx context.Context, jti, subject string, issuedAt time.Time) (bool, error) {
+	values, err := c.Client.MGet(ctx, deniedTokenKey(jti), revokedBeforeKey(subject)).Result()
+	if err != nil {
+		return false, err
+	}
+	if values[0] != nil {
+		return true, nil
+	}
+	if cutoff, ok := values[1].(string); ok {
+		revokedBefore, err := strconv.ParseInt(cutoff, 10, 64)
+		if err == nil && issuedAt.Unix() <= revokedBefore {
+			return true, nil
+		}
+	}
+	return false, nil
+}
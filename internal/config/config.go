@@ -7,15 +7,19 @@ import (
 )
 
 type Config struct {
-	Server    ServerConfig
-	AdminAPI  APIConfig
-	TenantAPI APIConfig
-	MasterDB  DatabaseConfig
-	AdminDB   DatabaseConfig
-	Redis     RedisConfig
-	JWT       JWTConfig
-	App       AppConfig
-	Storage   StorageConfig
+	Server     ServerConfig
+	AdminAPI   APIConfig
+	TenantAPI  APIConfig
+	MasterDB   DatabaseConfig
+	AdminDB    DatabaseConfig
+	Redis      RedisConfig
+	JWT        JWTConfig
+	Auth       AuthConfig
+	App        AppConfig
+	Storage    StorageConfig
+	Mailer     MailerConfig
+	Migrations MigrationsConfig
+	Tenants    TenantsConfig
 }
 
 type ServerConfig struct {
@@ -24,8 +28,7 @@ type ServerConfig struct {
 }
 
 type APIConfig struct {
-	Port      string
-	JWTSecret string
+	Port string
 }
 
 type DatabaseConfig struct {
@@ -45,12 +48,112 @@ type RedisConfig struct {
 }
 
 type JWTConfig struct {
-	Secret          string
-	ExpirationHours int
+	// Encrypts the private signing keys stored in jwt_signing_keys (no default: required)
+	KeysEncryptionKey string
+	// Lifetime of the access tokens of the Admin and Tenant APIs
+	AccessTokenMinutes int
+	// Lifetime of the refresh tokens (each refresh issues a new one)
+	RefreshTokenDays int
+}
+
+type AuthConfig struct {
+	// Hours an email verification link stays valid
+	EmailVerificationHours int
+	// Minutes a password reset link stays valid
+	PasswordResetMinutes int
+	// Hours an invitation to join a tenant stays valid
+	InvitationHours int
+	// Refuse logins until the email is verified
+	RequireVerifiedEmail bool
+	// Encrypts the TOTP secrets stored in two_factor_credentials
+	TwoFactorEncryptionKey string
+	// Failed logins of an account (per API) before it is locked (0 = never lock)
+	LoginMaxAttempts int
+	// Failed logins from a client IP (any account) before the IP is locked (0 = never lock)
+	LoginIPMaxAttempts int
+	// Minutes a lock lasts; failed attempts are also forgotten after this long without a new one
+	LoginLockoutMinutes int
+	// Encrypts the client secrets of the tenant OIDC providers
+	OIDCEncryptionKey string
+	// Frontend page registered at the IdPs as redirect_uri; it posts code and state to /auth/oidc/callback
+	OIDCRedirectURL string
+	// Maximum lifetime of the tenant tokens issued to administrators impersonating a user
+	ImpersonationMinutes int
 }
 
 type AppConfig struct {
 	Env string
+	// Name shown in the emails of the platform (admins and users without a tenant)
+	Name string
+	// Frontends that receive the links sent by email (verification, password reset, invitations)
+	AdminURL  string
+	TenantURL string
+}
+
+type MigrationsConfig struct {
+	TenantPath string // Directory with the tenant NNN_name.up/down.sql files
+}
+
+type TenantsConfig struct {
+	DeletionGraceDays int // Default grace period before a deleted tenant is purged
+	// Key used to encrypt the per-tenant DB passwords stored in the master DB
+	DBCredentialsKey string
+	// Minutes the previous login role stays valid after a password rotation
+	DBCredentialsRetireMinutes int
+	// Cluster placement for new tenants: least_loaded, plan or region
+	PlacementPolicy string
+	// Create tenant databases from a migrated template database (CREATE DATABASE ... TEMPLATE)
+	TemplateDB bool
+	// Pre-created, unassigned tenant databases kept ready by the worker (0 = disabled)
+	WarmPoolSize int
+	// Completed backups kept per tenant; older ones are pruned (the newest is always kept)
+	BackupRetentionCount int
+	// Days a completed backup is kept (0 = no age limit)
+	BackupRetentionDays int
+	// Key used to sign the expiring download links of data exports
+	ExportLinkSecret string
+	// Minutes a signed export download link stays valid
+	ExportLinkTTLMinutes int
+	// Hours an export file is kept before the worker removes it
+	ExportRetentionHours int
+	// Default lifetime of a sandbox tenant before it is scheduled for deletion
+	SandboxTTLHours int
+	// Default connection budget of a tenant pool (plans.max_db_connections overrides it)
+	PoolMaxConns int
+	// Connections each tenant pool keeps open while in cache
+	PoolMinConns int
+	// Cap on the connections reserved by all tenant pools of a process (0 = no cap)
+	PoolMaxTotalConns int
+	// Minutes without use before a tenant pool is closed (0 = never)
+	PoolIdleMinutes int
+	// Replication lag above which reads go to the primary instead of the cluster replica
+	ReplicaMaxLagSeconds int
+	// Seconds after a write during which the same user reads from the primary
+	ReadYourWritesSeconds int
+	// Domain whose subdomains resolve to tenants on the storefront routes ({subdomain}.{BaseDomain})
+	BaseDomain string
+	// Seconds a resolved tenant / member permission set stays in Redis (invalidated on change)
+	ContextCacheSeconds int
+	// Seconds the same data stays in the in-process cache of each tenant-api replica
+	ContextL1Seconds int
+	// Default requests per minute of the rate limit buckets (plans and tenant overrides replace them; 0 = no limit)
+	RateLimitReadPerMinute   int
+	RateLimitWritePerMinute  int
+	RateLimitUploadPerMinute int
+	// Requests per minute per client IP on the login/registration endpoints (no tenant known yet)
+	RateLimitAuthPerMinute int
+}
+
+type MailerConfig struct {
+	Driver string // log, smtp
+	From   string
+	// Directory where the log driver also writes each message as .eml (empty = log only)
+	OutputDir string
+	// SMTP
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
 }
 
 type StorageConfig struct {
@@ -69,6 +172,18 @@ type StorageConfig struct {
 	R2PublicURL       string
 }
 
+// defaultExportLinkSecret only signs export links in development (see ValidateExportLinks)
+const defaultExportLinkSecret = "tenant-export-link-secret-change-in-production"
+
+// defaultDBCredentialsKey only encrypts the tenant database passwords in development (see ValidateDBCredentialsKey)
+const defaultDBCredentialsKey = "tenant-db-credentials-key-change-in-production"
+
+// defaultTwoFactorEncryptionKey only encrypts the TOTP secrets in development (see ValidateTwoFactorKey)
+const defaultTwoFactorEncryptionKey = "two-factor-encryption-key-change-in-production"
+
+// defaultOIDCEncryptionKey only encrypts the IdP client secrets in development (see ValidateOIDCKey)
+const defaultOIDCEncryptionKey = "oidc-encryption-key-change-in-production"
+
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
@@ -76,12 +191,10 @@ func Load() *Config {
 			GinMode: getEnv("GIN_MODE", "debug"),
 		},
 		AdminAPI: APIConfig{
-			Port:      getEnv("ADMIN_API_PORT", "8080"),
-			JWTSecret: getEnv("ADMIN_JWT_SECRET", "admin-super-secret-jwt-key-change-in-production"),
+			Port: getEnv("ADMIN_API_PORT", "8080"),
 		},
 		TenantAPI: APIConfig{
-			Port:      getEnv("TENANT_API_PORT", "8081"),
-			JWTSecret: getEnv("TENANT_JWT_SECRET", "tenant-super-secret-jwt-key-change-in-production"),
+			Port: getEnv("TENANT_API_PORT", "8081"),
 		},
 		MasterDB: DatabaseConfig{
 			Host:     getEnv("MASTER_DB_HOST", "localhost"),
@@ -106,11 +219,28 @@ func Load() *Config {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		JWT: JWTConfig{
-			Secret:          getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
-			ExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
+			KeysEncryptionKey:  getEnv("JWT_KEYS_ENCRYPTION_KEY", ""),
+			AccessTokenMinutes: getEnvAsInt("JWT_ACCESS_TOKEN_MINUTES", 15),
+			RefreshTokenDays:   getEnvAsInt("JWT_REFRESH_TOKEN_DAYS", 30),
+		},
+		Auth: AuthConfig{
+			EmailVerificationHours: getEnvAsInt("AUTH_EMAIL_VERIFICATION_HOURS", 48),
+			PasswordResetMinutes:   getEnvAsInt("AUTH_PASSWORD_RESET_MINUTES", 60),
+			InvitationHours:        getEnvAsInt("AUTH_INVITATION_HOURS", 168),
+			RequireVerifiedEmail:   getEnvAsBool("AUTH_REQUIRE_VERIFIED_EMAIL", false),
+			TwoFactorEncryptionKey: getEnv("AUTH_2FA_ENCRYPTION_KEY", defaultTwoFactorEncryptionKey),
+			LoginMaxAttempts:       getEnvAsInt("AUTH_LOGIN_MAX_ATTEMPTS", 5),
+			LoginIPMaxAttempts:     getEnvAsInt("AUTH_LOGIN_IP_MAX_ATTEMPTS", 20),
+			LoginLockoutMinutes:    getEnvAsInt("AUTH_LOGIN_LOCKOUT_MINUTES", 15),
+			OIDCEncryptionKey:      getEnv("AUTH_OIDC_ENCRYPTION_KEY", defaultOIDCEncryptionKey),
+			OIDCRedirectURL:        getEnv("AUTH_OIDC_REDIRECT_URL", "http://localhost:5173/auth/oidc/callback"),
+			ImpersonationMinutes:   getEnvAsInt("AUTH_IMPERSONATION_MINUTES", 30),
 		},
 		App: AppConfig{
-			Env: getEnv("APP_ENV", "development"),
+			Env:       getEnv("APP_ENV", "development"),
+			Name:      getEnv("APP_NAME", "SaaS"),
+			AdminURL:  getEnv("APP_ADMIN_URL", "http://localhost:5174"),
+			TenantURL: getEnv("APP_TENANT_URL", "http://localhost:5173"),
 		},
 		Storage: StorageConfig{
 			Driver:             getEnv("STORAGE_DRIVER", "local"),
@@ -125,6 +255,45 @@ func Load() *Config {
 			R2Bucket:           getEnv("R2_BUCKET", ""),
 			R2PublicURL:        getEnv("R2_PUBLIC_URL", ""),
 		},
+		Mailer: MailerConfig{
+			Driver:       getEnv("MAILER_DRIVER", "log"),
+			From:         getEnv("MAILER_FROM", "no-reply@localhost"),
+			OutputDir:    getEnv("MAILER_OUTPUT_DIR", ""),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		},
+		Migrations: MigrationsConfig{
+			TenantPath: getEnv("TENANT_MIGRATIONS_PATH", "./migrations/tenant"),
+		},
+		Tenants: TenantsConfig{
+			DeletionGraceDays:          getEnvAsInt("TENANT_DELETION_GRACE_DAYS", 7),
+			DBCredentialsKey:           getEnv("TENANT_DB_CREDENTIALS_KEY", defaultDBCredentialsKey),
+			DBCredentialsRetireMinutes: getEnvAsInt("TENANT_DB_CREDENTIALS_RETIRE_MINUTES", 10),
+			PlacementPolicy:            getEnv("TENANT_PLACEMENT_POLICY", "least_loaded"),
+			TemplateDB:                 getEnvAsBool("TENANT_TEMPLATE_DB", true),
+			WarmPoolSize:               getEnvAsInt("TENANT_WARM_POOL_SIZE", 0),
+			BackupRetentionCount:       getEnvAsInt("TENANT_BACKUP_RETENTION_COUNT", 7),
+			BackupRetentionDays:        getEnvAsInt("TENANT_BACKUP_RETENTION_DAYS", 30),
+			ExportLinkSecret:           getEnv("TENANT_EXPORT_LINK_SECRET", defaultExportLinkSecret),
+			ExportLinkTTLMinutes:       getEnvAsInt("TENANT_EXPORT_LINK_TTL_MINUTES", 60),
+			ExportRetentionHours:       getEnvAsInt("TENANT_EXPORT_RETENTION_HOURS", 24),
+			SandboxTTLHours:            getEnvAsInt("TENANT_SANDBOX_TTL_HOURS", 72),
+			PoolMaxConns:               getEnvAsInt("TENANT_POOL_MAX_CONNS", 20),
+			PoolMinConns:               getEnvAsInt("TENANT_POOL_MIN_CONNS", 0),
+			PoolMaxTotalConns:          getEnvAsInt("TENANT_POOL_MAX_TOTAL_CONNS", 400),
+			PoolIdleMinutes:            getEnvAsInt("TENANT_POOL_IDLE_MINUTES", 15),
+			ReplicaMaxLagSeconds:       getEnvAsInt("TENANT_REPLICA_MAX_LAG_SECONDS", 5),
+			ReadYourWritesSeconds:      getEnvAsInt("TENANT_READ_YOUR_WRITES_SECONDS", 10),
+			BaseDomain:                 getEnv("TENANT_BASE_DOMAIN", "localhost"),
+			ContextCacheSeconds:        getEnvAsInt("TENANT_CONTEXT_CACHE_SECONDS", 300),
+			ContextL1Seconds:           getEnvAsInt("TENANT_CONTEXT_L1_SECONDS", 5),
+			RateLimitReadPerMinute:     getEnvAsInt("TENANT_RATE_LIMIT_READ_PER_MINUTE", 600),
+			RateLimitWritePerMinute:    getEnvAsInt("TENANT_RATE_LIMIT_WRITE_PER_MINUTE", 120),
+			RateLimitUploadPerMinute:   getEnvAsInt("TENANT_RATE_LIMIT_UPLOAD_PER_MINUTE", 30),
+			RateLimitAuthPerMinute:     getEnvAsInt("TENANT_RATE_LIMIT_AUTH_PER_MINUTE", 10),
+		},
 	}
 }
 
@@ -140,6 +309,85 @@ func (c *DatabaseConfig) ConnectionString() string {
 	)
 }
 
+// IsDevelopment reports whether APP_ENV is development
+func (c *AppConfig) IsDevelopment() bool {
+	return c.Env == "development"
+}
+
+// ValidateExportLinks rejects an unset or default TENANT_EXPORT_LINK_SECRET outside development:
+// anyone who knows the default could forge export download links
+func (c *Config) ValidateExportLinks() error {
+	if c.App.IsDevelopment() {
+		return nil
+	}
+	if c.Tenants.ExportLinkSecret == "" || c.Tenants.ExportLinkSecret == defaultExportLinkSecret {
+		return fmt.Errorf("TENANT_EXPORT_LINK_SECRET must be set to a unique secret when APP_ENV is %q", c.App.Env)
+	}
+	return nil
+}
+
+// ValidateDBCredentialsKey rejects an unset or default TENANT_DB_CREDENTIALS_KEY outside development:
+// anyone who knows the default could decrypt the tenant database passwords stored in tenant_db_credentials
+func (c *Config) ValidateDBCredentialsKey() error {
+	if c.App.IsDevelopment() {
+		return nil
+	}
+	if c.Tenants.DBCredentialsKey == "" || c.Tenants.DBCredentialsKey == defaultDBCredentialsKey {
+		return fmt.Errorf("TENANT_DB_CREDENTIALS_KEY must be set to a unique key when APP_ENV is %q", c.App.Env)
+	}
+	return nil
+}
+
+// ValidateTwoFactorKey rejects an unset or default AUTH_2FA_ENCRYPTION_KEY outside development:
+// anyone who can read the master database and knows the default could decrypt every TOTP secret
+func (c *Config) ValidateTwoFactorKey() error {
+	if c.App.IsDevelopment() {
+		return nil
+	}
+	if c.Auth.TwoFactorEncryptionKey == "" || c.Auth.TwoFactorEncryptionKey == defaultTwoFactorEncryptionKey {
+		return fmt.Errorf("AUTH_2FA_ENCRYPTION_KEY must be set to a unique key when APP_ENV is %q", c.App.Env)
+	}
+	return nil
+}
+
+// ValidateOIDCKey rejects an unset or default AUTH_OIDC_ENCRYPTION_KEY outside development:
+// anyone who can read the master database and knows the default could decrypt the tenant IdP client secrets
+func (c *Config) ValidateOIDCKey() error {
+	if c.App.IsDevelopment() {
+		return nil
+	}
+	if c.Auth.OIDCEncryptionKey == "" || c.Auth.OIDCEncryptionKey == defaultOIDCEncryptionKey {
+		return fmt.Errorf("AUTH_OIDC_ENCRYPTION_KEY must be set to a unique key when APP_ENV is %q", c.App.Env)
+	}
+	return nil
+}
+
+// ConnectionStringForDB returns a connection string with the same credentials pointing to another database
+func (c *DatabaseConfig) ConnectionStringForDB(dbName string) string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
+		c.User,
+		c.Password,
+		c.Host,
+		c.Port,
+		dbName,
+		c.SSLMode,
+	)
+}
+
+// ConnectionStringWithCredentials returns a connection string to dbName on the same host using another login
+func (c *DatabaseConfig) ConnectionStringWithCredentials(user, password, dbName string) string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
+		user,
+		password,
+		c.Host,
+		c.Port,
+		dbName,
+		c.SSLMode,
+	)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -155,3 +403,12 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,73 @@
+package config
+
+import "testing"
+
+func TestValidateDBCredentialsKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		key     string
+		wantErr bool
+	}{
+		{name: "default key in development", env: "development", key: defaultDBCredentialsKey},
+		{name: "default key in production", env: "production", key: defaultDBCredentialsKey, wantErr: true},
+		{name: "empty key in production", env: "production", key: "", wantErr: true},
+		{name: "default key in staging", env: "staging", key: defaultDBCredentialsKey, wantErr: true},
+		{name: "own key in production", env: "production", key: "b1f4c0e2a9d8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{App: AppConfig{Env: tt.env}, Tenants: TenantsConfig{DBCredentialsKey: tt.key}}
+			if err := cfg.ValidateDBCredentialsKey(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDBCredentialsKey() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTwoFactorKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		key     string
+		wantErr bool
+	}{
+		{name: "default key in development", env: "development", key: defaultTwoFactorEncryptionKey},
+		{name: "default key in production", env: "production", key: defaultTwoFactorEncryptionKey, wantErr: true},
+		{name: "empty key in production", env: "production", key: "", wantErr: true},
+		{name: "own key in production", env: "production", key: "9c2e71d05f3a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{App: AppConfig{Env: tt.env}, Auth: AuthConfig{TwoFactorEncryptionKey: tt.key}}
+			if err := cfg.ValidateTwoFactorKey(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTwoFactorKey() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOIDCKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		key     string
+		wantErr bool
+	}{
+		{name: "default key in development", env: "development", key: defaultOIDCEncryptionKey},
+		{name: "default key in production", env: "production", key: defaultOIDCEncryptionKey, wantErr: true},
+		{name: "empty key in production", env: "production", key: "", wantErr: true},
+		{name: "own key in production", env: "production", key: "4e8d2a6b1c07"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{App: AppConfig{Env: tt.env}, Auth: AuthConfig{OIDCEncryptionKey: tt.key}}
+			if err := cfg.ValidateOIDCKey(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOIDCKey() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
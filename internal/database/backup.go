@@ -0,0 +1,87 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DumpTable grava a tabela em CSV (com cabeçalho) no writer via COPY, sem carregar tudo em memória.
+// O cabeçalho traz os nomes das colunas, então a carga não depende da ordem física no destino.
+func DumpTable(ctx context.Context, conn *pgx.Conn, table string, w io.Writer) (int64, error) {
+	columns, err := tableColumns(ctx, conn, table)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf("COPY %s (%s) TO STDOUT WITH (FORMAT csv, HEADER true)",
+		pgx.Identifier{"public", table}.Sanitize(), strings.Join(columns, ", "))
+	tag, err := conn.PgConn().CopyTo(ctx, w, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to dump table %s: %w", table, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// LoadTable carrega na tabela um CSV gerado por DumpTable. A tabela deve estar vazia e com o
+// mesmo schema da origem; constraints e triggers ficam a cargo da sessão (ver TruncateTenantTables).
+func LoadTable(ctx context.Context, conn *pgx.Conn, table string, r io.Reader) (int64, error) {
+	buffered := bufio.NewReader(r)
+
+	line, err := buffered.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, fmt.Errorf("failed to read header of %s: %w", table, err)
+	}
+	header, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return 0, fmt.Errorf("invalid header on %s: %w", table, err)
+	}
+
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = pgx.Identifier{column}.Sanitize()
+	}
+
+	query := fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv)",
+		pgx.Identifier{"public", table}.Sanitize(), strings.Join(columns, ", "))
+	tag, err := conn.PgConn().CopyFrom(ctx, buffered, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load table %s: %w", table, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// TruncateTenantTables esvazia todas as tabelas do tenant e desativa constraints e triggers na sessão
+// (session_replication_role = replica, exige superuser) para a carga em qualquer ordem.
+// O retorno restaura a sessão e deve ser chamado ao final da carga.
+func TruncateTenantTables(ctx context.Context, conn *pgx.Conn) (func(), error) {
+	tables, err := ListTenantTables(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Exec(ctx, "SET session_replication_role = replica"); err != nil {
+		return nil, fmt.Errorf("failed to disable constraints: %w", err)
+	}
+	reset := func() { conn.Exec(context.Background(), "SET session_replication_role = DEFAULT") }
+
+	if len(tables) > 0 {
+		idents := make([]string, len(tables))
+		for i, table := range tables {
+			idents[i] = pgx.Identifier{"public", table}.Sanitize()
+		}
+		if _, err := conn.Exec(ctx, "TRUNCATE "+strings.Join(idents, ", ")); err != nil {
+			reset()
+			return nil, fmt.Errorf("failed to truncate tables: %w", err)
+		}
+	}
+
+	return reset, nil
+}
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// Nome do cluster padrão (o definido por POSTGRES_HOST / MASTER_DB_HOST)
+const DefaultClusterName = "default"
+
+// clusterColumns são as colunas de db_clusters lidas por scanCluster
+const clusterColumns = `id, name, host, port, pool_host, pool_port, replica_host, replica_port, admin_user,
+	admin_password_encrypted, region, plan_id, dedicated, max_tenants, status, is_default, created_at, updated_at`
+
+// ClusterRegistry resolve em qual cluster Postgres cada tenant está e mantém
+// pools administrativos (conexão direta como superuser) por cluster
+type ClusterRegistry struct {
+	masterPool *pgxpool.Pool
+	cfg        *config.Config
+	adminPools sync.Map // map[string]*pgxpool.Pool (cluster ID)
+}
+
+func NewClusterRegistry(masterPool *pgxpool.Pool, cfg *config.Config) *ClusterRegistry {
+	return &ClusterRegistry{
+		masterPool: masterPool,
+		cfg:        cfg,
+	}
+}
+
+// EnsureDefault registra o cluster padrão (a partir da configuração) se ainda não existir
+func (r *ClusterRegistry) EnsureDefault(ctx context.Context) (*admin.DBCluster, error) {
+	port, _ := strconv.Atoi(r.cfg.AdminDB.Port)
+	poolPort, _ := strconv.Atoi(r.cfg.MasterDB.Port)
+
+	_, err := r.masterPool.Exec(ctx, `
+		INSERT INTO db_clusters (name, host, port, pool_host, pool_port, is_default)
+		SELECT $1, $2, $3, $4, $5, true
+		WHERE NOT EXISTS (SELECT 1 FROM db_clusters WHERE is_default)
+		ON CONFLICT (name) DO NOTHING
+	`, DefaultClusterName, r.cfg.AdminDB.Host, port, r.cfg.MasterDB.Host, poolPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register default cluster: %w", err)
+	}
+
+	return r.Get(ctx, nil)
+}
+
+// Get retorna o cluster pelo ID (nil = cluster padrão)
+func (r *ClusterRegistry) Get(ctx context.Context, clusterID *uuid.UUID) (*admin.DBCluster, error) {
+	var row pgx.Row
+	if clusterID == nil {
+		row = r.masterPool.QueryRow(ctx, `SELECT `+clusterColumns+` FROM db_clusters WHERE is_default`)
+	} else {
+		row = r.masterPool.QueryRow(ctx, `SELECT `+clusterColumns+` FROM db_clusters WHERE id = $1`, *clusterID)
+	}
+
+	cluster, err := scanCluster(row)
+	if err == pgx.ErrNoRows && clusterID == nil {
+		// Cluster padrão ainda não registrado (worker não iniciou): usar a configuração
+		return r.configDefault(), nil
+	}
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("cluster %s not found", clusterID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster: %w", err)
+	}
+	return cluster, nil
+}
+
+// TenantCluster retorna o cluster onde está o database do tenant
+func (r *ClusterRegistry) TenantCluster(ctx context.Context, tenantID uuid.UUID) (*admin.DBCluster, error) {
+	var clusterID *uuid.UUID
+	if err := r.masterPool.QueryRow(ctx, `SELECT cluster_id FROM tenants WHERE id = $1`, tenantID).Scan(&clusterID); err != nil {
+		return nil, fmt.Errorf("failed to load tenant cluster: %w", err)
+	}
+	return r.Get(ctx, clusterID)
+}
+
+// List retorna todos os clusters com a quantidade de tenants de cada um
+func (r *ClusterRegistry) List(ctx context.Context) ([]admin.DBCluster, error) {
+	rows, err := r.masterPool.Query(ctx, `
+		SELECT `+clusterColumns+`,
+			(SELECT COUNT(*) FROM tenants t
+			 WHERE t.cluster_id = c.id OR (c.is_default AND t.cluster_id IS NULL)) AS tenant_count
+		FROM db_clusters c
+		ORDER BY is_default DESC, name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := []admin.DBCluster{}
+	for rows.Next() {
+		var c admin.DBCluster
+		if err := rows.Scan(append(clusterFields(&c), &c.TenantCount)...); err != nil {
+			return nil, fmt.Errorf("failed to read cluster: %w", err)
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, rows.Err()
+}
+
+// AdminDSN retorna a conexão direta (superuser) com um database do cluster
+func (r *ClusterRegistry) AdminDSN(cluster *admin.DBCluster, dbName string) (string, error) {
+	if cluster.IsDefault {
+		return r.cfg.AdminDB.ConnectionStringForDB(dbName), nil
+	}
+
+	user := r.cfg.AdminDB.User
+	if cluster.AdminUser != nil && *cluster.AdminUser != "" {
+		user = *cluster.AdminUser
+	}
+
+	password := r.cfg.AdminDB.Password
+	if cluster.AdminPasswordEncrypted != nil && *cluster.AdminPasswordEncrypted != "" {
+		decrypted, err := utils.DecryptSecret(r.cfg.Tenants.DBCredentialsKey, *cluster.AdminPasswordEncrypted)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt cluster %s admin password: %w", cluster.Name, err)
+		}
+		password = decrypted
+	}
+
+	return clusterDSN(cluster.Host, cluster.Port, user, password, dbName, r.cfg.AdminDB.SSLMode), nil
+}
+
+// PoolDSN retorna a conexão usada pelas APIs (PgBouncer do cluster) com o login informado
+func (r *ClusterRegistry) PoolDSN(cluster *admin.DBCluster, user, password, dbName string) string {
+	if cluster.IsDefault {
+		return r.cfg.MasterDB.ConnectionStringWithCredentials(user, password, dbName)
+	}
+
+	host, port := cluster.Host, cluster.Port
+	if cluster.PoolHost != nil && *cluster.PoolHost != "" {
+		host = *cluster.PoolHost
+	}
+	if cluster.PoolPort != nil && *cluster.PoolPort > 0 {
+		port = *cluster.PoolPort
+	}
+
+	return clusterDSN(host, port, user, password, dbName, r.cfg.MasterDB.SSLMode)
+}
+
+// ReplicaDSN retorna a conexão com a réplica de leitura do cluster ("" se o cluster não tem réplica).
+// Os logins dos tenants são replicados junto com o cluster, então valem as mesmas credenciais.
+func (r *ClusterRegistry) ReplicaDSN(cluster *admin.DBCluster, user, password, dbName string) string {
+	if cluster.ReplicaHost == nil || *cluster.ReplicaHost == "" {
+		return ""
+	}
+
+	port := 5432
+	if cluster.ReplicaPort != nil && *cluster.ReplicaPort > 0 {
+		port = *cluster.ReplicaPort
+	}
+
+	return clusterDSN(*cluster.ReplicaHost, port, user, password, dbName, r.cfg.MasterDB.SSLMode)
+}
+
+// AdminPool retorna (e mantém em cache) um pool administrativo para o database postgres do cluster
+func (r *ClusterRegistry) AdminPool(ctx context.Context, cluster *admin.DBCluster) (*pgxpool.Pool, error) {
+	key := cluster.ID.String()
+	if pool, ok := r.adminPools.Load(key); ok {
+		return pool.(*pgxpool.Pool), nil
+	}
+
+	dbName := "postgres"
+	if cluster.IsDefault {
+		dbName = r.cfg.AdminDB.DBName
+	}
+
+	dsn, err := r.AdminDSN(cluster, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cluster %s admin config: %w", cluster.Name, err)
+	}
+	poolConfig.MaxConns = 5
+	poolConfig.MinConns = 0
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admin pool for cluster %s: %w", cluster.Name, err)
+	}
+
+	if existing, loaded := r.adminPools.LoadOrStore(key, pool); loaded {
+		pool.Close()
+		return existing.(*pgxpool.Pool), nil
+	}
+
+	log.Printf("Admin pool created for cluster: %s", cluster.Name)
+	return pool, nil
+}
+
+// Close fecha os pools administrativos dos clusters
+func (r *ClusterRegistry) Close() {
+	r.adminPools.Range(func(key, value interface{}) bool {
+		value.(*pgxpool.Pool).Close()
+		r.adminPools.Delete(key)
+		return true
+	})
+}
+
+// configDefault descreve o cluster padrão a partir da configuração
+func (r *ClusterRegistry) configDefault() *admin.DBCluster {
+	port, _ := strconv.Atoi(r.cfg.AdminDB.Port)
+	return &admin.DBCluster{
+		ID:        uuid.Nil,
+		Name:      DefaultClusterName,
+		Host:      r.cfg.AdminDB.Host,
+		Port:      port,
+		Status:    shared.ClusterStatusActive,
+		IsDefault: true,
+	}
+}
+
+func clusterDSN(host string, port int, user, password, dbName, sslMode string) string {
+	// Senhas de clusters externos são informadas pelo admin e podem conter caracteres especiais
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     fmt.Sprintf("%s:%d", host, port),
+		Path:     "/" + dbName,
+		RawQuery: "sslmode=" + sslMode,
+	}
+	return dsn.String()
+}
+
+func clusterFields(c *admin.DBCluster) []any {
+	return []any{
+		&c.ID, &c.Name, &c.Host, &c.Port, &c.PoolHost, &c.PoolPort, &c.ReplicaHost, &c.ReplicaPort, &c.AdminUser,
+		&c.AdminPasswordEncrypted, &c.Region, &c.PlanID, &c.Dedicated, &c.MaxTenants, &c.Status, &c.IsDefault, &c.CreatedAt, &c.UpdatedAt,
+	}
+}
+
+func scanCluster(row pgx.Row) (*admin.DBCluster, error) {
+	c := &admin.DBCluster{}
+	if err := row.Scan(clusterFields(c)...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
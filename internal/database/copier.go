@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TableCopyResult é o resultado da cópia de uma tabela
+type TableCopyResult struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+// CopyTenantData copia os dados de todas as tabelas de um tenant DB para outro com o mesmo schema
+// (mesma versão de migrations). O destino é esvaziado antes da cópia; chaves estrangeiras e
+// triggers ficam desativadas durante a carga (session_replication_role = replica, exige superuser).
+// Ao final as sequences são ajustadas e a quantidade de linhas de cada tabela é conferida.
+func CopyTenantData(ctx context.Context, src, dst *pgx.Conn) ([]TableCopyResult, error) {
+	tables, err := ListTenantTables(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	// Migrations podem ter inserido dados iniciais (ex.: settings) no destino
+	reset, err := TruncateTenantTables(ctx, dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare target: %w", err)
+	}
+	defer reset()
+
+	results := make([]TableCopyResult, 0, len(tables))
+	for _, table := range tables {
+		rows, err := copyTable(ctx, src, dst, table)
+		if err != nil {
+			return results, fmt.Errorf("failed to copy table %s: %w", table, err)
+		}
+		results = append(results, TableCopyResult{Table: table, Rows: rows})
+	}
+
+	sequences, err := ListSequences(ctx, src)
+	if err != nil {
+		return results, err
+	}
+	if err := SetSequences(ctx, dst, sequences); err != nil {
+		return results, err
+	}
+
+	for _, r := range results {
+		var count int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s", pgx.Identifier{"public", r.Table}.Sanitize())
+		if err := dst.QueryRow(ctx, query).Scan(&count); err != nil {
+			return results, fmt.Errorf("failed to verify table %s: %w", r.Table, err)
+		}
+		if count != r.Rows {
+			return results, fmt.Errorf("row count mismatch on %s: copied %d, found %d", r.Table, r.Rows, count)
+		}
+	}
+
+	return results, nil
+}
+
+// ListTenantTables lista as tabelas do schema public (exceto o controle de migrations)
+func ListTenantTables(ctx context.Context, conn *pgx.Conn) ([]string, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT tablename FROM pg_tables
+		WHERE schemaname = 'public' AND tablename <> 'schema_migrations'
+		ORDER BY tablename
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// tableColumns lista as colunas graváveis da tabela (sem colunas geradas), na ordem da origem
+func tableColumns(ctx context.Context, conn *pgx.Conn, table string) ([]string, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = 'public' AND table_name = $1 AND is_generated = 'NEVER'
+		ORDER BY ordinal_position
+	`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, pgx.Identifier{column}.Sanitize())
+	}
+	return columns, rows.Err()
+}
+
+// copyTable transmite a tabela da origem para o destino via COPY, sem carregar tudo em memória.
+// As colunas são listadas pelo nome para não depender da ordem física no destino.
+func copyTable(ctx context.Context, src, dst *pgx.Conn, table string) (int64, error) {
+	columns, err := tableColumns(ctx, src, table)
+	if err != nil {
+		return 0, err
+	}
+
+	target := fmt.Sprintf("%s (%s)", pgx.Identifier{"public", table}.Sanitize(), strings.Join(columns, ", "))
+
+	reader, writer := io.Pipe()
+	copyErr := make(chan error, 1)
+
+	go func() {
+		_, err := src.PgConn().CopyTo(ctx, writer, fmt.Sprintf("COPY %s TO STDOUT", target))
+		writer.CloseWithError(err)
+		copyErr <- err
+	}()
+
+	tag, err := dst.PgConn().CopyFrom(ctx, reader, fmt.Sprintf("COPY %s FROM STDIN", target))
+	reader.CloseWithError(err)
+	if srcErr := <-copyErr; srcErr != nil && err == nil {
+		err = srcErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// ListSequences retorna o valor atual de cada sequence do schema public
+func ListSequences(ctx context.Context, conn *pgx.Conn) (map[string]int64, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT sequencename, last_value FROM pg_sequences
+		WHERE schemaname = 'public' AND last_value IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sequences: %w", err)
+	}
+	defer rows.Close()
+
+	values := map[string]int64{}
+	for rows.Next() {
+		var name string
+		var value int64
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, rows.Err()
+}
+
+// SetSequences ajusta as sequences do destino para os valores informados
+func SetSequences(ctx context.Context, conn *pgx.Conn, values map[string]int64) error {
+	for name, value := range values {
+		if _, err := conn.Exec(ctx, "SELECT setval($1::regclass, $2, true)", pgx.Identifier{"public", name}.Sanitize(), value); err != nil {
+			return fmt.Errorf("failed to set sequence %s: %w", name, err)
+		}
+	}
+	return nil
+}
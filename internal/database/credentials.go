@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// TenantPoolRefreshChannel recebe o db_code de tenants cujas credenciais ou cluster mudaram
+const TenantPoolRefreshChannel = "tenant:pool:refresh"
+
+// TenantGroupRole returns the NOLOGIN role that holds the grants on the tenant database
+func TenantGroupRole(dbCode string) string {
+	return fmt.Sprintf("tenant_%s", strings.ReplaceAll(dbCode, "-", "_"))
+}
+
+// TenantLoginRoles returns the two login roles used alternately on password rotation
+func TenantLoginRoles(dbCode string) (string, string) {
+	group := TenantGroupRole(dbCode)
+	return group + "_a", group + "_b"
+}
+
+// tenantDBTarget é o destino do pool de um tenant: a conexão, a da réplica e o orçamento de conexões do plano
+type tenantDBTarget struct {
+	connStr        string
+	replicaConnStr string // "" = cluster sem réplica
+	planMaxConns   *int   // plans.max_db_connections (nil = TENANT_POOL_MAX_CONNS)
+}
+
+// tenantConnectionString monta a conexão com o login do próprio tenant no cluster onde ele está.
+// Tenants provisionados antes das credenciais dedicadas continuam usando o usuário compartilhado.
+func (m *Manager) tenantConnectionString(ctx context.Context, dbCode string) (*tenantDBTarget, error) {
+	dbName := TenantDBName(dbCode)
+
+	masterPool := m.GetMasterPool()
+	if masterPool == nil {
+		return nil, fmt.Errorf("master pool not initialized")
+	}
+
+	var clusterID *uuid.UUID
+	var username, encrypted *string
+	target := &tenantDBTarget{}
+	err := masterPool.QueryRow(ctx, `
+		SELECT t.cluster_id, c.username, c.password_encrypted, p.max_db_connections
+		FROM tenants t
+		LEFT JOIN tenant_db_credentials c ON c.tenant_id = t.id
+		LEFT JOIN plans p ON p.id = t.plan_id
+		WHERE t.db_code = $1
+	`, dbCode).Scan(&clusterID, &username, &encrypted, &target.planMaxConns)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("tenant %s not found", dbCode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenant db credentials: %w", err)
+	}
+
+	cluster, err := m.Clusters().Get(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if username == nil || encrypted == nil {
+		log.Printf("Warning: tenant %s has no dedicated DB credentials, using shared user", dbCode)
+		target.connStr = m.Clusters().PoolDSN(cluster, m.cfg.MasterDB.User, m.cfg.MasterDB.Password, dbName)
+		target.replicaConnStr = m.Clusters().ReplicaDSN(cluster, m.cfg.MasterDB.User, m.cfg.MasterDB.Password, dbName)
+		return target, nil
+	}
+
+	password, err := utils.DecryptSecret(m.cfg.Tenants.DBCredentialsKey, *encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt tenant db credentials: %w", err)
+	}
+
+	target.connStr = m.Clusters().PoolDSN(cluster, *username, password, dbName)
+	target.replicaConnStr = m.Clusters().ReplicaDSN(cluster, *username, password, dbName)
+	return target, nil
+}
+
+// RefreshTenantPool replaces an open tenant pool with one using the current credentials, cluster and plan budget.
+// The old pool (and the replica pool, reopened on the next read) is closed in background,
+// after in-flight queries release their connections.
+func (m *Manager) RefreshTenantPool(ctx context.Context, dbCode string) error {
+	m.poolsMu.Lock()
+	entry, ok := m.tenantPools[dbCode]
+	m.poolsMu.Unlock()
+	if !ok || !entry.open() {
+		return nil // Pool ainda não foi aberto; será criado com as credenciais novas
+	}
+
+	target, err := m.tenantTarget(ctx, dbCode)
+	if err != nil {
+		return err
+	}
+
+	maxConns := m.tenantMaxConns(target)
+	pool, err := m.openTenantPool(ctx, dbCode, target.connStr, maxConns)
+	if err != nil {
+		return err
+	}
+
+	refreshed := &tenantPoolEntry{
+		dbCode:         dbCode,
+		pool:           pool,
+		maxConns:       maxConns,
+		replicaConnStr: target.replicaConnStr,
+		ready:          make(chan struct{}),
+	}
+	refreshed.lastUsed.Store(entry.lastUsed.Load())
+	close(refreshed.ready)
+
+	m.poolsMu.Lock()
+	if m.tenantPools[dbCode] != entry {
+		// Pool despejado ou fechado enquanto o novo era aberto
+		m.poolsMu.Unlock()
+		pool.Close()
+		return nil
+	}
+	m.tenantPools[dbCode] = refreshed
+	replica, hasReplica := m.tenantPools[tenantPoolKey(dbCode, true)]
+	if hasReplica {
+		delete(m.tenantPools, tenantPoolKey(dbCode, true))
+	}
+	m.poolsMu.Unlock()
+
+	go entry.pool.Close()
+	if hasReplica && replica.open() {
+		go replica.pool.Close()
+	}
+
+	log.Printf("Tenant DB pool refreshed for: %s", dbCode)
+	return nil
+}
+
+// ListenPoolRefresh recria os pools dos tenants publicados em TenantPoolRefreshChannel até ctx ser cancelado
+func (m *Manager) ListenPoolRefresh(ctx context.Context, redisClient *redis.Client) {
+	pubsub := redisClient.Subscribe(ctx, TenantPoolRefreshChannel)
+	defer pubsub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-pubsub.Channel():
+			if !ok {
+				return
+			}
+			if err := m.RefreshTenantPool(ctx, msg.Payload); err != nil {
+				log.Printf("Failed to refresh tenant DB pool %s: %v", msg.Payload, err)
+			}
+		}
+	}
+}
@@ -6,19 +6,41 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/saas-multi-database-api/internal/config"
 )
 
 type Manager struct {
-	masterPool  *pgxpool.Pool
-	adminPool   *pgxpool.Pool
-	tenantPools sync.Map // map[string]*pgxpool.Pool
-	cfg         *config.Config
-	mu          sync.RWMutex
+	masterPool *pgxpool.Pool
+	adminPool  *pgxpool.Pool
+	clusters   *ClusterRegistry
+	cfg        *config.Config
+	mu         sync.RWMutex
+
+	// Pools de tenant abertos por db_code, limitados por TENANT_POOL_MAX_TOTAL_CONNS
+	poolsMu       sync.Mutex
+	tenantPools   map[string]*tenantPoolEntry
+	poolsCreated  atomic.Uint64
+	poolsEvicted  atomic.Uint64
+	poolsRejected atomic.Uint64
+
+	// Destino e abertura dos pools de tenant (substituídos nos testes, sem Master DB nem Postgres)
+	tenantTarget   func(ctx context.Context, dbCode string) (*tenantDBTarget, error)
+	openTenantPool func(ctx context.Context, key, connStr string, maxConns int32) (*pgxpool.Pool, error)
 }
 
+// TenantDBName returns the physical database name for a tenant db_code
+func TenantDBName(dbCode string) string {
+	// Substituir hífens por underscores no db_code para nome válido de database (PostgreSQL identifier)
+	return fmt.Sprintf("db_tenant_%s", strings.ReplaceAll(dbCode, "-", "_"))
+}
+
+// TenantTemplateDBName is the migrated template tenant databases are cloned from (one per cluster)
+const TenantTemplateDBName = "db_tenant_template"
+
 var (
 	instance *Manager
 	once     sync.Once
@@ -27,13 +49,21 @@ var (
 // GetManager returns the singleton database manager instance
 func GetManager(cfg *config.Config) *Manager {
 	once.Do(func() {
-		instance = &Manager{
-			cfg: cfg,
-		}
+		instance = newManager(cfg)
 	})
 	return instance
 }
 
+func newManager(cfg *config.Config) *Manager {
+	m := &Manager{
+		cfg:         cfg,
+		tenantPools: make(map[string]*tenantPoolEntry),
+	}
+	m.tenantTarget = m.tenantConnectionString
+	m.openTenantPool = m.newTenantPool
+	return m
+}
+
 // InitMasterPool initializes the connection pool to the Master DB (Control Plane)
 func (m *Manager) InitMasterPool(ctx context.Context) error {
 	m.mu.Lock()
@@ -64,6 +94,7 @@ func (m *Manager) InitMasterPool(ctx context.Context) error {
 	}
 
 	m.masterPool = pool
+	m.clusters = NewClusterRegistry(pool, m.cfg)
 	log.Println("Master DB pool initialized successfully")
 	return nil
 }
@@ -109,6 +140,13 @@ func (m *Manager) GetMasterPool() *pgxpool.Pool {
 	return m.masterPool
 }
 
+// Clusters returns the registry used to route tenants to their database cluster
+func (m *Manager) Clusters() *ClusterRegistry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.clusters
+}
+
 // GetAdminPool returns the admin database pool
 func (m *Manager) GetAdminPool() *pgxpool.Pool {
 	m.mu.RLock()
@@ -116,35 +154,17 @@ func (m *Manager) GetAdminPool() *pgxpool.Pool {
 	return m.adminPool
 }
 
-// GetTenantPool retrieves or creates a connection pool for a tenant database
-func (m *Manager) GetTenantPool(ctx context.Context, dbCode string) (*pgxpool.Pool, error) {
-	// Check if pool already exists
-	if pool, ok := m.tenantPools.Load(dbCode); ok {
-		return pool.(*pgxpool.Pool), nil
-	}
-
-	// Create new pool
-	// Substituir hífens por underscores no db_code para nome válido de database (PostgreSQL identifier)
-	dbCodeClean := strings.ReplaceAll(dbCode, "-", "_")
-	dbName := fmt.Sprintf("db_tenant_%s", dbCodeClean)
-	connStr := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		m.cfg.MasterDB.User,
-		m.cfg.MasterDB.Password,
-		m.cfg.MasterDB.Host,
-		m.cfg.MasterDB.Port,
-		dbName,
-		m.cfg.MasterDB.SSLMode,
-	)
-
+// newTenantPool opens a pool to the tenant database using the tenant's own login role
+func (m *Manager) newTenantPool(ctx context.Context, dbCode, connStr string, maxConns int32) (*pgxpool.Pool, error) {
 	poolConfig, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse tenant db config: %w", err)
 	}
 
-	// Configure pool settings
-	poolConfig.MaxConns = 20
-	poolConfig.MinConns = 2
+	// Configure pool settings (conexões ociosas são fechadas para não segurar o orçamento do Postgres)
+	poolConfig.MaxConns = maxConns
+	poolConfig.MinConns = min(int32(m.cfg.Tenants.PoolMinConns), maxConns)
+	poolConfig.MaxConnIdleTime = 5 * time.Minute
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
@@ -157,21 +177,9 @@ func (m *Manager) GetTenantPool(ctx context.Context, dbCode string) (*pgxpool.Po
 		return nil, fmt.Errorf("failed to ping tenant db %s: %w", dbCode, err)
 	}
 
-	// Store the pool
-	m.tenantPools.Store(dbCode, pool)
-	log.Printf("Tenant DB pool created for: %s", dbCode)
-
 	return pool, nil
 }
 
-// CloseTenantPool closes and removes a tenant pool
-func (m *Manager) CloseTenantPool(dbCode string) {
-	if pool, ok := m.tenantPools.LoadAndDelete(dbCode); ok {
-		pool.(*pgxpool.Pool).Close()
-		log.Printf("Tenant DB pool closed for: %s", dbCode)
-	}
-}
-
 // Close closes all database connections
 func (m *Manager) Close() {
 	m.mu.Lock()
@@ -187,10 +195,10 @@ func (m *Manager) Close() {
 		log.Println("Admin DB pool closed")
 	}
 
+	if m.clusters != nil {
+		m.clusters.Close()
+	}
+
 	// Close all tenant pools
-	m.tenantPools.Range(func(key, value interface{}) bool {
-		value.(*pgxpool.Pool).Close()
-		log.Printf("Tenant DB pool closed for: %s", key)
-		return true
-	})
+	m.closeTenantPools()
 }
@@ -0,0 +1,294 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockID is the advisory lock key used to serialize migrations on a tenant database
+const migrationLockID = 72707369
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// migrationQuerier is implemented by pools, acquired connections and transactions
+type migrationQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Migration represents a numbered tenant schema migration (NNN_name.up.sql / NNN_name.down.sql)
+type Migration struct {
+	Version int
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+// MigrationResult describes what happened when a tenant database was migrated
+type MigrationResult struct {
+	From    int
+	To      int
+	Applied []int // Versions applied (up) or reverted (down), in execution order
+}
+
+// TenantMigrator applies versioned migrations to tenant databases and tracks them
+// in a schema_migrations table inside each tenant database
+type TenantMigrator struct {
+	migrations []Migration
+}
+
+// NewTenantMigrator loads the migration files from dir (usually migrations/tenant)
+func NewTenantMigrator(dir string) (*TenantMigrator, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations dir %s: %w", dir, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d (%s, %s)", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.UpSQL = string(content)
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpSQL == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	return &TenantMigrator{migrations: migrations}, nil
+}
+
+// Migrations returns the loaded migrations ordered by version
+func (m *TenantMigrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// LatestVersion returns the highest migration version available
+func (m *TenantMigrator) LatestVersion() int {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// CurrentVersion returns the schema version of a tenant database (0 if never migrated)
+func (m *TenantMigrator) CurrentVersion(ctx context.Context, db migrationQuerier) (int, error) {
+	var exists bool
+	if err := db.QueryRow(ctx, "SELECT to_regclass('public.schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+
+	if !exists {
+		// Databases provisioned before the migration runner have the initial schema but no tracking table
+		var legacy bool
+		if err := db.QueryRow(ctx, "SELECT to_regclass('public.products') IS NOT NULL").Scan(&legacy); err != nil {
+			return 0, fmt.Errorf("failed to check legacy schema: %w", err)
+		}
+		if legacy {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	var version int
+	if err := db.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+// Up migrates a tenant database to the latest version
+func (m *TenantMigrator) Up(ctx context.Context, pool *pgxpool.Pool) (*MigrationResult, error) {
+	return m.MigrateTo(ctx, pool, m.LatestVersion())
+}
+
+// MigrateTo moves a tenant database up or down to the target version.
+// Each migration runs in its own transaction, so a failure leaves the database at the last good version.
+func (m *TenantMigrator) MigrateTo(ctx context.Context, pool *pgxpool.Pool, target int) (*MigrationResult, error) {
+	if target < 0 || target > m.LatestVersion() {
+		return nil, fmt.Errorf("invalid target version %d (latest is %d)", target, m.LatestVersion())
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// Serialize concurrent migrators (worker + CLI) on the same database. The lock is held by this
+	// session, so the version check, the migrations and the bookkeeping all run on conn.
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if err := m.ensureSchemaTable(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	current, err := m.CurrentVersion(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{From: current, To: current, Applied: []int{}}
+
+	if target >= current {
+		for _, migration := range m.migrations {
+			if migration.Version <= current || migration.Version > target {
+				continue
+			}
+
+			tx, err := conn.Begin(ctx)
+			if err != nil {
+				return result, fmt.Errorf("failed to begin transaction: %w", err)
+			}
+
+			if _, err := tx.Exec(ctx, migration.UpSQL); err != nil {
+				tx.Rollback(ctx)
+				return result, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+			}
+
+			if _, err := tx.Exec(ctx,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+				migration.Version, migration.Name,
+			); err != nil {
+				tx.Rollback(ctx)
+				return result, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+			}
+
+			if err := tx.Commit(ctx); err != nil {
+				return result, fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+			}
+
+			result.To = migration.Version
+			result.Applied = append(result.Applied, migration.Version)
+		}
+
+		return result, nil
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current || migration.Version <= target {
+			continue
+		}
+
+		if migration.DownSQL == "" {
+			return result, fmt.Errorf("migration %d (%s) has no down file", migration.Version, migration.Name)
+		}
+
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, migration.DownSQL); err != nil {
+			tx.Rollback(ctx)
+			return result, fmt.Errorf("rollback of migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+
+		if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+			tx.Rollback(ctx)
+			return result, fmt.Errorf("failed to unrecord migration %d: %w", migration.Version, err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return result, fmt.Errorf("failed to commit rollback of migration %d: %w", migration.Version, err)
+		}
+
+		result.To = migration.Version - 1
+		result.Applied = append(result.Applied, migration.Version)
+	}
+
+	result.To = target
+	return result, nil
+}
+
+// ensureSchemaTable creates the schema_migrations table, baselining legacy databases at version 1
+func (m *TenantMigrator) ensureSchemaTable(ctx context.Context, db migrationQuerier) error {
+	var exists bool
+	if err := db.QueryRow(ctx, "SELECT to_regclass('public.schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	current, err := m.CurrentVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	if current > 0 {
+		// Legacy database: the initial schema is already there, record it without running it again
+		_, err = db.Exec(ctx,
+			"INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING",
+			m.migrations[0].Version, m.migrations[0].Name,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to baseline schema_migrations: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeMigrationFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestNewTenantMigratorOrdersByVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		versions []int
+		names    []string
+		latest   int
+	}{
+		{
+			name: "numeric order, not lexicographic",
+			files: map[string]string{
+				"10_tenth.up.sql":           "SELECT 10",
+				"2_second.up.sql":           "SELECT 2",
+				"001_initial_schema.up.sql": "SELECT 1",
+			},
+			versions: []int{1, 2, 10},
+			names:    []string{"initial_schema", "second", "tenth"},
+			latest:   10,
+		},
+		{
+			name: "up and down files of a version are merged",
+			files: map[string]string{
+				"002_add_index.down.sql": "DROP INDEX x",
+				"002_add_index.up.sql":   "CREATE INDEX x",
+				"001_init.up.sql":        "SELECT 1",
+			},
+			versions: []int{1, 2},
+			names:    []string{"init", "add_index"},
+			latest:   2,
+		},
+		{
+			name: "gaps are allowed and other files are ignored",
+			files: map[string]string{
+				"001_init.up.sql": "SELECT 1",
+				"005_late.up.sql": "SELECT 5",
+				"README.md":       "docs",
+				"003_notes.txt":   "not a migration",
+			},
+			versions: []int{1, 5},
+			names:    []string{"init", "late"},
+			latest:   5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewTenantMigrator(writeMigrationFiles(t, tt.files))
+			if err != nil {
+				t.Fatalf("NewTenantMigrator: %v", err)
+			}
+
+			var versions []int
+			var names []string
+			for _, migration := range m.Migrations() {
+				versions = append(versions, migration.Version)
+				names = append(names, migration.Name)
+			}
+			if !reflect.DeepEqual(versions, tt.versions) {
+				t.Errorf("versions = %v, want %v", versions, tt.versions)
+			}
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("names = %v, want %v", names, tt.names)
+			}
+			if got := m.LatestVersion(); got != tt.latest {
+				t.Errorf("LatestVersion() = %d, want %d", got, tt.latest)
+			}
+		})
+	}
+}
+
+func TestNewTenantMigratorKeepsUpAndDownSQL(t *testing.T) {
+	m, err := NewTenantMigrator(writeMigrationFiles(t, map[string]string{
+		"001_init.up.sql":   "CREATE TABLE a ()",
+		"001_init.down.sql": "DROP TABLE a",
+	}))
+	if err != nil {
+		t.Fatalf("NewTenantMigrator: %v", err)
+	}
+
+	migration := m.Migrations()[0]
+	if migration.UpSQL != "CREATE TABLE a ()" || migration.DownSQL != "DROP TABLE a" {
+		t.Errorf("migration = %+v", migration)
+	}
+}
+
+func TestNewTenantMigratorRejectsInvalidSets(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "no migrations",
+			files:   map[string]string{"README.md": "docs"},
+			wantErr: "no migrations found",
+		},
+		{
+			name: "same version with two names",
+			files: map[string]string{
+				"002_one.up.sql": "SELECT 1",
+				"002_two.up.sql": "SELECT 2",
+			},
+			wantErr: "duplicate migration version 2",
+		},
+		{
+			name:    "down without up",
+			files:   map[string]string{"003_orphan.down.sql": "SELECT 1"},
+			wantErr: "has no up file",
+		},
+		{
+			name:    "version zero",
+			files:   map[string]string{"000_zero.up.sql": "SELECT 1"},
+			wantErr: "invalid migration version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTenantMigrator(writeMigrationFiles(t, tt.files))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTenantMigratorMissingDir(t *testing.T) {
+	if _, err := NewTenantMigrator(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
+
+func TestRepositoryTenantMigrationsLoad(t *testing.T) {
+	m, err := NewTenantMigrator(filepath.Join("..", "..", "migrations", "tenant"))
+	if err != nil {
+		t.Fatalf("NewTenantMigrator(migrations/tenant): %v", err)
+	}
+	previous := 0
+	for _, migration := range m.Migrations() {
+		if migration.Version <= previous {
+			t.Fatalf("version %d after %d", migration.Version, previous)
+		}
+		previous = migration.Version
+	}
+}
@@ -0,0 +1,487 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrTenantPoolBudgetExhausted indica que abrir o pool do tenant ultrapassaria TENANT_POOL_MAX_TOTAL_CONNS
+// e nenhum pool ocioso pôde ser despejado para liberar espaço
+var ErrTenantPoolBudgetExhausted = errors.New("tenant connection budget exhausted")
+
+const (
+	// Pools usados há menos que isso não são despejados para abrir espaço a outro tenant:
+	// a requisição que acabou de recebê-lo ainda pode estar entre uma query e outra
+	tenantPoolEvictionGrace = time.Minute
+	// Intervalo entre medições do atraso de replicação de um pool de réplica
+	replicaLagCheckInterval = 2 * time.Second
+	// Tempo sem tentar abrir de novo o pool de réplica de um tenant após uma falha
+	replicaRetryInterval = 30 * time.Second
+)
+
+// replicaLagQuery mede o atraso da réplica em segundos. Sem WAL pendente o atraso é zero,
+// mesmo que a última transação replicada seja antiga (primário sem escritas).
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())::float8, 'Infinity'::float8)
+	END
+`
+
+// tenantPoolEntry é um pool de tenant em cache. ready é fechado quando a criação termina
+// (com pool ou err), para que requisições simultâneas do mesmo tenant esperem a mesma criação.
+type tenantPoolEntry struct {
+	dbCode   string
+	pool     *pgxpool.Pool
+	maxConns int32
+	err      error
+	ready    chan struct{}
+	lastUsed atomic.Int64 // UnixNano
+
+	// Pool do primário: conexão da réplica do cluster ("" = sem réplica) e próxima tentativa após falha
+	replicaConnStr string
+	replicaRetryAt atomic.Int64 // UnixNano
+
+	// Pool de réplica: primário de onde vêm a conexão e o orçamento, e o último atraso medido
+	source       *tenantPoolEntry
+	lagMu        sync.Mutex    // Uma medição por vez
+	lagCheckedAt atomic.Int64  // UnixNano (0 = nunca medido)
+	lagBits      atomic.Uint64 // math.Float64bits do atraso em segundos
+}
+
+// tenantPoolKey é a chave do pool no cache: o db_code, com sufixo para o pool de réplica
+func tenantPoolKey(dbCode string, replica bool) string {
+	if replica {
+		return dbCode + "@replica"
+	}
+	return dbCode
+}
+
+func (e *tenantPoolEntry) key() string {
+	return tenantPoolKey(e.dbCode, e.source != nil)
+}
+
+func (e *tenantPoolEntry) touch() {
+	e.lastUsed.Store(time.Now().UnixNano())
+}
+
+func (e *tenantPoolEntry) idleSince() time.Time {
+	return time.Unix(0, e.lastUsed.Load())
+}
+
+// open indica se a criação terminou com sucesso
+func (e *tenantPoolEntry) open() bool {
+	select {
+	case <-e.ready:
+		return e.pool != nil
+	default:
+		return false
+	}
+}
+
+// TenantPoolStat descreve um pool de tenant aberto
+type TenantPoolStat struct {
+	DBCode        string    `json:"db_code"`
+	Replica       bool      `json:"replica"`
+	LagSeconds    *float64  `json:"lag_seconds,omitempty"` // Último atraso medido (pools de réplica)
+	MaxConns      int32     `json:"max_conns"`
+	TotalConns    int32     `json:"total_conns"`
+	AcquiredConns int32     `json:"acquired_conns"`
+	IdleConns     int32     `json:"idle_conns"`
+	LastUsedAt    time.Time `json:"last_used_at"`
+}
+
+// TenantPoolStats resume o cache de pools de tenant do processo
+type TenantPoolStats struct {
+	OpenPools     int              `json:"open_pools"`
+	PendingPools  int              `json:"pending_pools"`
+	ReservedConns int32            `json:"reserved_conns"` // Soma de MaxConns dos pools abertos e em criação
+	MaxTotalConns int              `json:"max_total_conns"`
+	TotalConns    int32            `json:"total_conns"`
+	AcquiredConns int32            `json:"acquired_conns"`
+	IdleConns     int32            `json:"idle_conns"`
+	Created       uint64           `json:"created"`
+	Evicted       uint64           `json:"evicted"`
+	Rejected      uint64           `json:"rejected"`
+	Pools         []TenantPoolStat `json:"pools,omitempty"` // Detalhe por pool (não exposto em /health/pools)
+}
+
+// GetTenantPool retrieves or creates a connection pool for a tenant database.
+// Concurrent first requests for the same tenant share a single pool creation.
+func (m *Manager) GetTenantPool(ctx context.Context, dbCode string) (*pgxpool.Pool, error) {
+	entry, err := m.loadTenantPool(ctx, dbCode, nil)
+	if err != nil {
+		return nil, err
+	}
+	return entry.pool, nil
+}
+
+// GetTenantReadPool retorna o pool para leituras do tenant: a réplica do cluster quando existe e está
+// com atraso até TENANT_REPLICA_MAX_LAG_SECONDS, senão o primário (o mesmo de GetTenantPool)
+func (m *Manager) GetTenantReadPool(ctx context.Context, dbCode string) (*pgxpool.Pool, error) {
+	primary, err := m.loadTenantPool(ctx, dbCode, nil)
+	if err != nil {
+		return nil, err
+	}
+	if primary.replicaConnStr == "" || time.Now().UnixNano() < primary.replicaRetryAt.Load() {
+		return primary.pool, nil
+	}
+
+	replica, err := m.loadTenantPool(ctx, dbCode, primary)
+	if err != nil {
+		if ctx.Err() == nil {
+			primary.replicaRetryAt.Store(time.Now().Add(replicaRetryInterval).UnixNano())
+		}
+		log.Printf("Tenant DB replica unavailable for %s, reading from primary: %v", dbCode, err)
+		return primary.pool, nil
+	}
+
+	if !m.replicaWithinLag(ctx, replica) {
+		return primary.pool, nil
+	}
+	return replica.pool, nil
+}
+
+// TenantHasReplica indica se o pool aberto do tenant tem réplica para leituras
+func (m *Manager) TenantHasReplica(dbCode string) bool {
+	m.poolsMu.Lock()
+	entry, ok := m.tenantPools[tenantPoolKey(dbCode, false)]
+	m.poolsMu.Unlock()
+	return ok && entry.open() && entry.replicaConnStr != ""
+}
+
+// ReadYourWritesWindow é por quanto tempo após uma escrita as leituras do mesmo usuário vão ao primário
+func (m *Manager) ReadYourWritesWindow() time.Duration {
+	return time.Duration(m.cfg.Tenants.ReadYourWritesSeconds) * time.Second
+}
+
+// loadTenantPool retorna o pool do primário (source nil) ou da réplica de source, criando-o se preciso
+func (m *Manager) loadTenantPool(ctx context.Context, dbCode string, source *tenantPoolEntry) (*tenantPoolEntry, error) {
+	key := tenantPoolKey(dbCode, source != nil)
+
+	m.poolsMu.Lock()
+	if entry, ok := m.tenantPools[key]; ok {
+		m.poolsMu.Unlock()
+		return m.waitTenantPool(ctx, entry)
+	}
+
+	entry := &tenantPoolEntry{dbCode: dbCode, source: source, ready: make(chan struct{})}
+	entry.touch()
+	m.tenantPools[key] = entry
+	m.poolsMu.Unlock()
+
+	pool, maxConns, err := m.createTenantPool(ctx, entry)
+	m.poolsMu.Lock()
+	if err != nil {
+		// Remover a entrada para que a próxima requisição tente de novo
+		if m.tenantPools[key] == entry {
+			delete(m.tenantPools, key)
+		}
+		entry.err = err
+	} else {
+		entry.pool = pool
+		entry.maxConns = maxConns
+	}
+	close(entry.ready)
+	m.poolsMu.Unlock()
+
+	if err != nil {
+		if errors.Is(err, ErrTenantPoolBudgetExhausted) {
+			m.poolsRejected.Add(1)
+		}
+		return nil, err
+	}
+
+	m.poolsCreated.Add(1)
+	log.Printf("Tenant DB pool created for: %s (max conns %d)", key, maxConns)
+	return entry, nil
+}
+
+// waitTenantPool espera a criação em andamento de um pool (ou retorna o pool já aberto)
+func (m *Manager) waitTenantPool(ctx context.Context, entry *tenantPoolEntry) (*tenantPoolEntry, error) {
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	entry.touch()
+	return entry, nil
+}
+
+// createTenantPool carrega o destino do tenant, reserva seu orçamento de conexões e abre o pool.
+// O pool de réplica usa a conexão e o orçamento do primário.
+func (m *Manager) createTenantPool(ctx context.Context, entry *tenantPoolEntry) (*pgxpool.Pool, int32, error) {
+	var connStr string
+	var maxConns int32
+	if entry.source != nil {
+		connStr, maxConns = entry.source.replicaConnStr, entry.source.maxConns
+	} else {
+		target, err := m.tenantTarget(ctx, entry.dbCode)
+		if err != nil {
+			return nil, 0, err
+		}
+		connStr, maxConns = target.connStr, m.tenantMaxConns(target)
+		entry.replicaConnStr = target.replicaConnStr
+	}
+
+	if err := m.reserveTenantConns(entry, maxConns); err != nil {
+		return nil, 0, err
+	}
+
+	pool, err := m.openTenantPool(ctx, entry.key(), connStr, maxConns)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pool, maxConns, nil
+}
+
+// replicaWithinLag indica se o atraso da réplica está dentro de TENANT_REPLICA_MAX_LAG_SECONDS.
+// A medição é refeita a cada replicaLagCheckInterval; durante uma medição vale a anterior.
+func (m *Manager) replicaWithinLag(ctx context.Context, entry *tenantPoolEntry) bool {
+	maxLag := float64(m.cfg.Tenants.ReplicaMaxLagSeconds)
+
+	checkedAt := entry.lagCheckedAt.Load()
+	if time.Since(time.Unix(0, checkedAt)) >= replicaLagCheckInterval && entry.lagMu.TryLock() {
+		lag := entry.measureLag(ctx)
+		entry.lagMu.Unlock()
+		if lag > maxLag {
+			log.Printf("Tenant DB replica for %s is %.1fs behind, reading from primary", entry.dbCode, lag)
+		}
+		return lag <= maxLag
+	}
+
+	if checkedAt == 0 {
+		return false // Primeira medição em andamento em outra requisição
+	}
+	return math.Float64frombits(entry.lagBits.Load()) <= maxLag
+}
+
+// measureLag consulta o atraso da réplica (infinito se a consulta falhar)
+func (e *tenantPoolEntry) measureLag(ctx context.Context) float64 {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	var lag float64
+	if err := e.pool.QueryRow(ctx, replicaLagQuery).Scan(&lag); err != nil {
+		log.Printf("Failed to check replica lag for %s: %v", e.dbCode, err)
+		lag = math.Inf(1)
+	}
+	e.lagBits.Store(math.Float64bits(lag))
+	e.lagCheckedAt.Store(time.Now().UnixNano())
+	return lag
+}
+
+// tenantMaxConns retorna o orçamento de conexões do tenant: o do plano ou TENANT_POOL_MAX_CONNS
+func (m *Manager) tenantMaxConns(target *tenantDBTarget) int32 {
+	maxConns := m.cfg.Tenants.PoolMaxConns
+	if target.planMaxConns != nil && *target.planMaxConns > 0 {
+		maxConns = *target.planMaxConns
+	}
+	if maxConns < 1 {
+		maxConns = 1
+	}
+	return int32(maxConns)
+}
+
+// reserveTenantConns conta maxConns da entrada no total de conexões do processo, despejando os pools
+// ociosos menos usados recentemente quando o total ultrapassaria TENANT_POOL_MAX_TOTAL_CONNS
+func (m *Manager) reserveTenantConns(entry *tenantPoolEntry, maxConns int32) error {
+	limit := int32(m.cfg.Tenants.PoolMaxTotalConns)
+
+	m.poolsMu.Lock()
+	defer m.poolsMu.Unlock()
+
+	if limit > 0 {
+		reserved := m.reservedTenantConnsLocked()
+		if reserved+maxConns > limit {
+			for _, candidate := range m.evictionCandidatesLocked(entry) {
+				m.evictTenantPoolLocked(candidate, "connection budget")
+				reserved -= candidate.maxConns
+				if reserved+maxConns <= limit {
+					break
+				}
+			}
+		}
+		if reserved+maxConns > limit {
+			return fmt.Errorf("%w: %d of %d connections reserved, tenant %s needs %d",
+				ErrTenantPoolBudgetExhausted, reserved, limit, entry.dbCode, maxConns)
+		}
+	}
+
+	// Conta no total enquanto o pool é aberto, para que criações simultâneas não estourem o limite
+	entry.maxConns = maxConns
+	return nil
+}
+
+// reservedTenantConnsLocked soma MaxConns dos pools abertos e em criação (poolsMu travado)
+func (m *Manager) reservedTenantConnsLocked() int32 {
+	var reserved int32
+	for _, entry := range m.tenantPools {
+		reserved += entry.maxConns
+	}
+	return reserved
+}
+
+// evictionCandidatesLocked lista os pools abertos sem conexões em uso e fora do período de graça,
+// do menos usado recentemente para o mais recente (poolsMu travado)
+func (m *Manager) evictionCandidatesLocked(except *tenantPoolEntry) []*tenantPoolEntry {
+	cutoff := time.Now().Add(-tenantPoolEvictionGrace)
+
+	var candidates []*tenantPoolEntry
+	for _, entry := range m.tenantPools {
+		if entry == except || !entry.open() {
+			continue
+		}
+		if entry.idleSince().After(cutoff) || entry.pool.Stat().AcquiredConns() > 0 {
+			continue
+		}
+		candidates = append(candidates, entry)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Load() < candidates[j].lastUsed.Load()
+	})
+	return candidates
+}
+
+// evictTenantPoolLocked remove o pool do cache e o fecha em background (poolsMu travado)
+func (m *Manager) evictTenantPoolLocked(entry *tenantPoolEntry, reason string) {
+	delete(m.tenantPools, entry.key())
+	m.poolsEvicted.Add(1)
+	go entry.pool.Close()
+	log.Printf("Tenant DB pool evicted for: %s (%s, idle since %s)", entry.key(), reason, entry.idleSince().Format(time.RFC3339))
+}
+
+// EvictIdleTenantPools fecha os pools sem uso há mais de TENANT_POOL_IDLE_MINUTES e sem conexões em uso
+func (m *Manager) EvictIdleTenantPools() int {
+	idle := time.Duration(m.cfg.Tenants.PoolIdleMinutes) * time.Minute
+	if idle <= 0 {
+		return 0
+	}
+	cutoff := time.Now().Add(-idle)
+
+	m.poolsMu.Lock()
+	defer m.poolsMu.Unlock()
+
+	evicted := 0
+	for _, entry := range m.tenantPools {
+		if !entry.open() || entry.idleSince().After(cutoff) || entry.pool.Stat().AcquiredConns() > 0 {
+			continue
+		}
+		m.evictTenantPoolLocked(entry, "idle")
+		evicted++
+	}
+	return evicted
+}
+
+// RunTenantPoolEviction despeja periodicamente os pools ociosos até ctx ser cancelado
+func (m *Manager) RunTenantPoolEviction(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n := m.EvictIdleTenantPools(); n > 0 {
+				log.Printf("Evicted %d idle tenant DB pools", n)
+			}
+		}
+	}
+}
+
+// TenantPoolStats retorna as métricas do cache de pools de tenant
+func (m *Manager) TenantPoolStats() TenantPoolStats {
+	m.poolsMu.Lock()
+	defer m.poolsMu.Unlock()
+
+	stats := TenantPoolStats{
+		MaxTotalConns: m.cfg.Tenants.PoolMaxTotalConns,
+		Created:       m.poolsCreated.Load(),
+		Evicted:       m.poolsEvicted.Load(),
+		Rejected:      m.poolsRejected.Load(),
+		Pools:         []TenantPoolStat{},
+	}
+
+	for _, entry := range m.tenantPools {
+		stats.ReservedConns += entry.maxConns
+		if !entry.open() {
+			stats.PendingPools++
+			continue
+		}
+
+		s := entry.pool.Stat()
+		stats.OpenPools++
+		stats.TotalConns += s.TotalConns()
+		stats.AcquiredConns += s.AcquiredConns()
+		stats.IdleConns += s.IdleConns()
+		stat := TenantPoolStat{
+			DBCode:        entry.dbCode,
+			Replica:       entry.source != nil,
+			MaxConns:      entry.maxConns,
+			TotalConns:    s.TotalConns(),
+			AcquiredConns: s.AcquiredConns(),
+			IdleConns:     s.IdleConns(),
+			LastUsedAt:    entry.idleSince(),
+		}
+		if entry.source != nil && entry.lagCheckedAt.Load() != 0 {
+			if lag := math.Float64frombits(entry.lagBits.Load()); !math.IsInf(lag, 1) {
+				stat.LagSeconds = &lag
+			}
+		}
+		stats.Pools = append(stats.Pools, stat)
+	}
+
+	sort.Slice(stats.Pools, func(i, j int) bool {
+		return stats.Pools[i].LastUsedAt.After(stats.Pools[j].LastUsedAt)
+	})
+	return stats
+}
+
+// CloseTenantPool closes and removes a tenant pool (and its replica pool)
+func (m *Manager) CloseTenantPool(dbCode string) {
+	var closing []*tenantPoolEntry
+	m.poolsMu.Lock()
+	for _, key := range []string{tenantPoolKey(dbCode, false), tenantPoolKey(dbCode, true)} {
+		if entry, ok := m.tenantPools[key]; ok && entry.open() {
+			delete(m.tenantPools, key)
+			closing = append(closing, entry)
+		}
+	}
+	m.poolsMu.Unlock()
+
+	for _, entry := range closing {
+		entry.pool.Close()
+		log.Printf("Tenant DB pool closed for: %s", entry.key())
+	}
+}
+
+// closeTenantPools fecha todos os pools de tenant abertos
+func (m *Manager) closeTenantPools() {
+	m.poolsMu.Lock()
+	entries := m.tenantPools
+	m.tenantPools = make(map[string]*tenantPoolEntry)
+	m.poolsMu.Unlock()
+
+	for key, entry := range entries {
+		if entry.open() {
+			entry.pool.Close()
+			log.Printf("Tenant DB pool closed for: %s", key)
+		}
+	}
+}
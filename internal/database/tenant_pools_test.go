@@ -0,0 +1,250 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/config"
+)
+
+// newTestPoolManager cria um Manager sem Master DB: o orçamento de cada tenant vem de planConns
+// (TENANT_POOL_MAX_CONNS=1 nos demais) e os pools apontam para uma porta fechada. O pgxpool só
+// conecta na primeira query, então os pools abrem sem Postgres.
+func newTestPoolManager(t *testing.T, tenants config.TenantsConfig, planConns map[string]int) *Manager {
+	t.Helper()
+	if tenants.PoolMaxConns == 0 {
+		tenants.PoolMaxConns = 1
+	}
+	m := newManager(&config.Config{Tenants: tenants})
+	m.tenantTarget = func(ctx context.Context, dbCode string) (*tenantDBTarget, error) {
+		target := &tenantDBTarget{connStr: "postgres://tenant@127.0.0.1:1/" + TenantDBName(dbCode)}
+		if conns, ok := planConns[dbCode]; ok {
+			target.planMaxConns = &conns
+		}
+		return target, nil
+	}
+	m.openTenantPool = func(ctx context.Context, key, connStr string, maxConns int32) (*pgxpool.Pool, error) {
+		poolConfig, err := pgxpool.ParseConfig(connStr)
+		if err != nil {
+			return nil, err
+		}
+		poolConfig.MaxConns = maxConns
+		return pgxpool.NewWithConfig(ctx, poolConfig)
+	}
+	t.Cleanup(m.closeTenantPools)
+	return m
+}
+
+// openTestPools abre o pool de cada tenant e marca o último uso como há age
+func openTestPools(t *testing.T, m *Manager, idle map[string]time.Duration) {
+	t.Helper()
+	now := time.Now()
+	for dbCode, age := range idle {
+		if _, err := m.GetTenantPool(context.Background(), dbCode); err != nil {
+			t.Fatalf("GetTenantPool(%s): %v", dbCode, err)
+		}
+		m.tenantPools[dbCode].lastUsed.Store(now.Add(-age).UnixNano())
+	}
+}
+
+func openPoolCodes(m *Manager) []string {
+	m.poolsMu.Lock()
+	defer m.poolsMu.Unlock()
+	codes := []string{}
+	for key := range m.tenantPools {
+		codes = append(codes, key)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func TestTenantPoolBudgetEviction(t *testing.T) {
+	tests := []struct {
+		name      string
+		idle      map[string]time.Duration
+		planConns map[string]int
+		wantOpen  []string
+		wantErr   error
+	}{
+		{
+			name:     "least recently used pool is evicted",
+			idle:     map[string]time.Duration{"a": 3 * time.Minute, "b": 5 * time.Minute, "c": 2 * time.Minute},
+			wantOpen: []string{"a", "c", "new"},
+		},
+		{
+			name:     "pools within the grace period are kept",
+			idle:     map[string]time.Duration{"a": 30 * time.Second, "b": 5 * time.Minute, "c": 10 * time.Second},
+			wantOpen: []string{"a", "c", "new"},
+		},
+		{
+			name:      "several pools are evicted for a larger budget, oldest first",
+			idle:      map[string]time.Duration{"a": 3 * time.Minute, "b": 5 * time.Minute, "c": 4 * time.Minute},
+			planConns: map[string]int{"new": 2},
+			wantOpen:  []string{"a", "new"},
+		},
+		{
+			name:     "budget exhausted when every pool was used recently",
+			idle:     map[string]time.Duration{"a": 10 * time.Second, "b": 20 * time.Second, "c": 30 * time.Second},
+			wantOpen: []string{"a", "b", "c"},
+			wantErr:  ErrTenantPoolBudgetExhausted,
+		},
+		{
+			name:      "budget exhausted when the plan alone exceeds the limit",
+			idle:      map[string]time.Duration{"a": 5 * time.Minute},
+			planConns: map[string]int{"new": 4},
+			wantOpen:  []string{},
+			wantErr:   ErrTenantPoolBudgetExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestPoolManager(t, config.TenantsConfig{PoolMaxTotalConns: 3}, tt.planConns)
+			openTestPools(t, m, tt.idle)
+
+			_, err := m.GetTenantPool(context.Background(), "new")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetTenantPool err = %v, want %v", err, tt.wantErr)
+			}
+			if got := openPoolCodes(m); !reflect.DeepEqual(got, tt.wantOpen) {
+				t.Errorf("open pools = %v, want %v", got, tt.wantOpen)
+			}
+
+			stats := m.TenantPoolStats()
+			if stats.ReservedConns > 3 {
+				t.Errorf("reserved conns = %d, over the limit of 3", stats.ReservedConns)
+			}
+			wantRejected := uint64(0)
+			if tt.wantErr != nil {
+				wantRejected = 1
+			}
+			if stats.Rejected != wantRejected {
+				t.Errorf("rejected = %d, want %d", stats.Rejected, wantRejected)
+			}
+		})
+	}
+}
+
+func TestEvictIdleTenantPools(t *testing.T) {
+	tests := []struct {
+		name        string
+		idleMinutes int
+		idle        map[string]time.Duration
+		wantEvicted int
+		wantOpen    []string
+	}{
+		{
+			name:        "pools idle longer than the limit are closed",
+			idleMinutes: 15,
+			idle:        map[string]time.Duration{"a": 20 * time.Minute, "b": 10 * time.Minute, "c": 16 * time.Minute},
+			wantEvicted: 2,
+			wantOpen:    []string{"b"},
+		},
+		{
+			name:        "recent pools are kept",
+			idleMinutes: 15,
+			idle:        map[string]time.Duration{"a": time.Minute, "b": 14 * time.Minute},
+			wantOpen:    []string{"a", "b"},
+		},
+		{
+			name:        "idle eviction disabled",
+			idleMinutes: 0,
+			idle:        map[string]time.Duration{"a": 24 * time.Hour},
+			wantOpen:    []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestPoolManager(t, config.TenantsConfig{PoolIdleMinutes: tt.idleMinutes}, nil)
+			openTestPools(t, m, tt.idle)
+
+			if got := m.EvictIdleTenantPools(); got != tt.wantEvicted {
+				t.Errorf("EvictIdleTenantPools = %d, want %d", got, tt.wantEvicted)
+			}
+			if got := openPoolCodes(m); !reflect.DeepEqual(got, tt.wantOpen) {
+				t.Errorf("open pools = %v, want %v", got, tt.wantOpen)
+			}
+			if got := m.TenantPoolStats().Evicted; got != uint64(tt.wantEvicted) {
+				t.Errorf("evicted = %d, want %d", got, tt.wantEvicted)
+			}
+		})
+	}
+}
+
+func TestGetTenantPoolSharesConcurrentOpen(t *testing.T) {
+	m := newTestPoolManager(t, config.TenantsConfig{}, nil)
+
+	var opens atomic.Int32
+	release := make(chan struct{})
+	open := m.openTenantPool
+	m.openTenantPool = func(ctx context.Context, key, connStr string, maxConns int32) (*pgxpool.Pool, error) {
+		opens.Add(1)
+		<-release
+		return open(ctx, key, connStr, maxConns)
+	}
+
+	const requests = 20
+	pools := make([]*pgxpool.Pool, requests)
+	var wg sync.WaitGroup
+	for i := range pools {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool, err := m.GetTenantPool(context.Background(), "a")
+			if err != nil {
+				t.Errorf("GetTenantPool: %v", err)
+			}
+			pools[i] = pool
+		}()
+	}
+
+	// Todas as requisições chegam enquanto o primeiro pool ainda está sendo aberto
+	for m.TenantPoolStats().PendingPools == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := opens.Load(); got != 1 {
+		t.Errorf("pool opened %d times, want 1", got)
+	}
+	for i, pool := range pools {
+		if pool == nil || pool != pools[0] {
+			t.Fatalf("request %d got pool %p, want the shared %p", i, pool, pools[0])
+		}
+	}
+	if stats := m.TenantPoolStats(); stats.Created != 1 || stats.OpenPools != 1 {
+		t.Errorf("created = %d, open = %d, want 1 and 1", stats.Created, stats.OpenPools)
+	}
+}
+
+func TestGetTenantPoolRetriesFailedOpen(t *testing.T) {
+	m := newTestPoolManager(t, config.TenantsConfig{}, nil)
+
+	errUnavailable := errors.New("cluster unavailable")
+	open := m.openTenantPool
+	m.openTenantPool = func(ctx context.Context, key, connStr string, maxConns int32) (*pgxpool.Pool, error) {
+		return nil, errUnavailable
+	}
+	if _, err := m.GetTenantPool(context.Background(), "a"); !errors.Is(err, errUnavailable) {
+		t.Fatalf("GetTenantPool err = %v, want %v", err, errUnavailable)
+	}
+	if stats := m.TenantPoolStats(); stats.ReservedConns != 0 {
+		t.Errorf("reserved conns after a failed open = %d, want 0", stats.ReservedConns)
+	}
+
+	// A falha não fica em cache: a próxima requisição abre o pool
+	m.openTenantPool = open
+	if _, err := m.GetTenantPool(context.Background(), "a"); err != nil {
+		t.Fatalf("GetTenantPool after the failure: %v", err)
+	}
+}
@@ -1,25 +1,40 @@
 package admin
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/saas-multi-database-api/internal/config"
 	adminModels "github.com/saas-multi-database-api/internal/models/admin"
 	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
 	"github.com/saas-multi-database-api/internal/utils"
 )
 
 // AdminAuthHandler handles authentication for SaaS administrators (Control Plane)
 type AdminAuthHandler struct {
-	sysUserRepo *adminRepo.SysUserRepository
-	cfg         *config.Config
+	sysUserRepo  *adminRepo.SysUserRepository
+	tokenService *adminService.AuthTokenService
+	emailService *adminService.AuthEmailService
+	twoFactor    *adminService.TwoFactorService
+	loginGuard   *adminService.LoginGuardService
+	cfg          *config.Config
 }
 
-func NewAdminAuthHandler(sysUserRepo *adminRepo.SysUserRepository, cfg *config.Config) *AdminAuthHandler {
+func NewAdminAuthHandler(sysUserRepo *adminRepo.SysUserRepository, tokenService *adminService.AuthTokenService, emailService *adminService.AuthEmailService, twoFactor *adminService.TwoFactorService, loginGuard *adminService.LoginGuardService, cfg *config.Config) *AdminAuthHandler {
 	return &AdminAuthHandler{
-		sysUserRepo: sysUserRepo,
-		cfg:         cfg,
+		sysUserRepo:  sysUserRepo,
+		tokenService: tokenService,
+		emailService: emailService,
+		twoFactor:    twoFactor,
+		loginGuard:   loginGuard,
+		cfg:          cfg,
 	}
 }
 
@@ -54,8 +69,13 @@ func (h *AdminAuthHandler) Register(c *gin.Context) {
 	// For now, skip role assignment in register - admin can assign roles later
 	// TODO: Implement dynamic role lookup and assignment
 
-	// Generate Admin JWT
-	token, err := utils.GenerateAdminJWT(sysUser.ID, h.cfg)
+	// Send email verification link (the account works meanwhile unless AUTH_REQUIRE_VERIFIED_EMAIL)
+	if err := h.emailService.SendVerification(c.Request.Context(), adminService.TokenSubjectAdmin, sysUser.ID, ""); err != nil {
+		log.Printf("Warning: Failed to send verification email to admin %s: %v", sysUser.ID, err)
+	}
+
+	// Generate access and refresh tokens
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), adminService.TokenSubjectAdmin, sysUser.ID, utils.TokenOptions{}, tokenClient(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
@@ -74,7 +94,9 @@ func (h *AdminAuthHandler) Register(c *gin.Context) {
 
 	// Build response
 	response := adminModels.AdminLoginResponse{
-		Token: token,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 	}
 	response.SysUser.ID = sysUser.ID
 	response.SysUser.Email = sysUser.Email
@@ -107,21 +129,52 @@ func (h *AdminAuthHandler) Login(c *gin.Context) {
 	// Normalize email
 	req.Email = utils.NormalizeEmail(req.Email)
 
-	// Get sys_user by email
-	sysUser, err := h.sysUserRepo.GetSysUserByEmail(c.Request.Context(), req.Email)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+	// Brute-force protection: locked account/IP or progressive delay after failures
+	if retryAfter, err := h.loginGuard.Check(c.Request.Context(), adminService.TokenSubjectAdmin, req.Email, c.ClientIP()); err != nil {
+		respondLoginBlocked(c, retryAfter, err)
 		return
 	}
 
+	// Get sys_user by email (unknown emails still go through bcrypt: same response time)
+	sysUser, err := h.sysUserRepo.GetSysUserByEmail(c.Request.Context(), req.Email)
+	passwordHash := ""
+	if err == nil {
+		passwordHash = sysUser.PasswordHash
+	}
+
 	// Verify password
-	if !utils.CheckPasswordHash(req.Password, sysUser.PasswordHash) {
+	if !utils.CheckPasswordHashConstantTime(req.Password, passwordHash) {
+		h.loginGuard.RecordFailure(c.Request.Context(), adminService.TokenSubjectAdmin, req.Email, c.ClientIP())
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
-	// Generate Admin JWT
-	token, err := utils.GenerateAdminJWT(sysUser.ID, h.cfg)
+	// Unverified email blocks login only when AUTH_REQUIRE_VERIFIED_EMAIL is enabled
+	if err := h.emailService.CheckLoginAllowed(c.Request.Context(), adminService.TokenSubjectAdmin, sysUser.ID); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// With 2FA enabled (or required by a role) the login continues on /login/2fa with the mfa_token
+	challenge, err := h.twoFactor.BeginLogin(c.Request.Context(), adminService.TokenSubjectAdmin, sysUser.ID, adminService.TwoFactorLogin{Email: req.Email})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check two-factor authentication"})
+		return
+	}
+	if challenge != nil {
+		// The failure counter is only reset once the second factor passes (LoginTwoFactor)
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+	h.loginGuard.RecordSuccess(c.Request.Context(), adminService.TokenSubjectAdmin, req.Email)
+
+	h.respondLogin(c, sysUser, utils.TokenOptions{}, nil)
+}
+
+// respondLogin emite os tokens da sessão e retorna a resposta de login (usuário, papéis e permissões)
+func (h *AdminAuthHandler) respondLogin(c *gin.Context, sysUser *adminModels.SysUser, opts utils.TokenOptions, recoveryCodes []string) {
+	// Generate access and refresh tokens
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), adminService.TokenSubjectAdmin, sysUser.ID, opts, tokenClient(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
@@ -140,7 +193,10 @@ func (h *AdminAuthHandler) Login(c *gin.Context) {
 
 	// Build response
 	response := adminModels.AdminLoginResponse{
-		Token: token,
+		Token:         tokens.AccessToken,
+		RefreshToken:  tokens.RefreshToken,
+		ExpiresIn:     tokens.ExpiresIn,
+		RecoveryCodes: recoveryCodes,
 	}
 	response.SysUser.ID = sysUser.ID
 	response.SysUser.Email = sysUser.Email
@@ -163,9 +219,9 @@ func (h *AdminAuthHandler) Login(c *gin.Context) {
 
 // GetMe returns the authenticated SaaS administrator's information
 func (h *AdminAuthHandler) GetMe(c *gin.Context) {
-	userID := c.MustGet("user_id").(string)
+	userID := c.MustGet("user_id").(uuid.UUID)
 
-	sysUser, err := h.sysUserRepo.GetSysUserByID(c.Request.Context(), mustParseUUID(userID))
+	sysUser, err := h.sysUserRepo.GetSysUserByID(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "admin user not found"})
 		return
@@ -193,13 +249,350 @@ func (h *AdminAuthHandler) GetMe(c *gin.Context) {
 		permissionsSlugs[i] = perm.Slug
 	}
 
+	emailVerified, err := h.emailService.EmailVerified(c.Request.Context(), adminService.TokenSubjectAdmin, sysUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get admin email status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":             sysUser.ID,
+		"email":          sysUser.Email,
+		"email_verified": emailVerified,
+		"full_name":      sysUser.FullName,
+		"avatar_url":     sysUser.AvatarURL,
+		"status":         sysUser.Status,
+		"roles":          rolesSlugs,
+		"permissions":    permissionsSlugs,
+	})
+}
+
+// Refresh troca o refresh token por um novo par de tokens (rotação)
+func (h *AdminAuthHandler) Refresh(c *gin.Context) {
+	var req adminModels.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.tokenService.Refresh(c.Request.Context(), adminService.TokenSubjectAdmin, req.RefreshToken, tokenClient(c))
+	if err != nil {
+		c.JSON(authTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revoga o access token atual e a sessão do refresh token informado
+func (h *AdminAuthHandler) Logout(c *gin.Context) {
+	var req adminModels.LogoutRequest
+
+	// Body opcional: sem refresh_token, apenas o access token é revogado
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	tokenID := c.GetString("token_id")
+	expiresAt := c.GetTime("token_expires_at")
+
+	if err := h.tokenService.Logout(c.Request.Context(), adminService.TokenSubjectAdmin, userID, tokenID, expiresAt, req.RefreshToken); err != nil {
+		c.JSON(authTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// LogoutAll revoga todas as sessões e access tokens do administrador
+func (h *AdminAuthHandler) LogoutAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	if err := h.tokenService.LogoutAll(c.Request.Context(), adminService.TokenSubjectAdmin, userID); err != nil {
+		c.JSON(authTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
+}
+
+// VerifyEmail confirma o email com o token do link enviado por email
+func (h *AdminAuthHandler) VerifyEmail(c *gin.Context) {
+	var req adminModels.VerifyEmailRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailService.VerifyEmail(c.Request.Context(), adminService.TokenSubjectAdmin, req.Token); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// ResendVerification reenvia o link de verificação para o administrador autenticado
+func (h *AdminAuthHandler) ResendVerification(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	if err := h.emailService.SendVerification(c.Request.Context(), adminService.TokenSubjectAdmin, userID, ""); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+// ForgotPassword envia o link de redefinição de senha (a resposta não revela se o email existe)
+func (h *AdminAuthHandler) ForgotPassword(c *gin.Context) {
+	var req adminModels.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailService.RequestPasswordReset(c.Request.Context(), adminService.TokenSubjectAdmin, req.Email, ""); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered, a reset link was sent"})
+}
+
+// ResetPassword troca a senha com o token do link e encerra as sessões abertas
+func (h *AdminAuthHandler) ResetPassword(c *gin.Context) {
+	var req adminModels.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailService.ResetPassword(c.Request.Context(), adminService.TokenSubjectAdmin, req.Token, req.Password); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset"})
+}
+
+// LoginTwoFactor conclui o login com o código do app autenticador ou um código de recuperação
+func (h *AdminAuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req adminModels.LoginTwoFactorRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Wrong codes count as login failures of the account: a new password login does not reset them
+	login := h.twoFactor.ChallengeLogin(c.Request.Context(), adminService.TokenSubjectAdmin, req.MFAToken)
+	if login.Email != "" {
+		if retryAfter, err := h.loginGuard.Check(c.Request.Context(), adminService.TokenSubjectAdmin, login.Email, c.ClientIP()); err != nil {
+			respondLoginBlocked(c, retryAfter, err)
+			return
+		}
+	}
+
+	userID, recoveryCodes, err := h.twoFactor.CompleteLogin(c.Request.Context(), adminService.TokenSubjectAdmin, req.MFAToken, req.Code)
+	if err != nil {
+		if login.Email != "" && errors.Is(err, adminService.ErrInvalidTwoFactorCode) {
+			h.loginGuard.RecordFailure(c.Request.Context(), adminService.TokenSubjectAdmin, login.Email, c.ClientIP())
+		}
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if login.Email != "" {
+		h.loginGuard.RecordSuccess(c.Request.Context(), adminService.TokenSubjectAdmin, login.Email)
+	}
+
+	sysUser, err := h.sysUserRepo.GetSysUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	h.respondLogin(c, sysUser, utils.TokenOptions{MFA: true}, recoveryCodes)
+}
+
+// LoginTwoFactorSetup retorna o segredo TOTP a cadastrar quando o login responde setup_required
+// (um papel do administrador exige o segundo fator e ele ainda não o cadastrou)
+func (h *AdminAuthHandler) LoginTwoFactorSetup(c *gin.Context) {
+	var req adminModels.LoginTwoFactorSetupRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setup, err := h.twoFactor.LoginSetup(c.Request.Context(), adminService.TokenSubjectAdmin, req.MFAToken)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// GetTwoFactor retorna a situação do segundo fator do administrador autenticado
+func (h *AdminAuthHandler) GetTwoFactor(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	status, err := h.twoFactor.Status(c.Request.Context(), adminService.TokenSubjectAdmin, userID)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SetupTwoFactor gera o segredo TOTP (QR code em provisioning_uri); fica pendente até EnableTwoFactor
+func (h *AdminAuthHandler) SetupTwoFactor(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	setup, err := h.twoFactor.Setup(c.Request.Context(), adminService.TokenSubjectAdmin, userID)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// EnableTwoFactor ativa o segundo fator com o primeiro código do app. Retorna os códigos de
+// recuperação e uma nova sessão autenticada com o segundo fator.
+func (h *AdminAuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req adminModels.TwoFactorCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	recoveryCodes, err := h.twoFactor.Enable(c.Request.Context(), adminService.TokenSubjectAdmin, userID, req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), adminService.TokenSubjectAdmin, userID, utils.TokenOptions{MFA: true}, tokenClient(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"id":          sysUser.ID,
-		"email":       sysUser.Email,
-		"full_name":   sysUser.FullName,
-		"avatar_url":  sysUser.AvatarURL,
-		"status":      sysUser.Status,
-		"roles":       rolesSlugs,
-		"permissions": permissionsSlugs,
+		"recovery_codes": recoveryCodes,
+		"token":          tokens.AccessToken,
+		"refresh_token":  tokens.RefreshToken,
+		"expires_in":     tokens.ExpiresIn,
 	})
 }
+
+// DisableTwoFactor remove o segundo fator (senha + código do app ou de recuperação)
+func (h *AdminAuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req adminModels.DisableTwoFactorRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	if err := h.twoFactor.Disable(c.Request.Context(), adminService.TokenSubjectAdmin, userID, req.Password, req.Code); err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes troca os códigos de recuperação (os anteriores deixam de valer)
+func (h *AdminAuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req adminModels.TwoFactorCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	recoveryCodes, err := h.twoFactor.RegenerateRecoveryCodes(c.Request.Context(), adminService.TokenSubjectAdmin, userID, req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+}
+
+// authTokenErrorStatus mapeia erros do AuthTokenService para status HTTP
+func authTokenErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrInvalidRefreshToken), errors.Is(err, adminService.ErrRefreshTokenReused),
+		errors.Is(err, adminService.ErrTwoFactorRequired):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// authEmailErrorStatus mapeia erros do AuthEmailService para status HTTP
+func authEmailErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrInvalidEmailToken):
+		return http.StatusBadRequest
+	case errors.Is(err, adminService.ErrEmailAlreadyVerified):
+		return http.StatusConflict
+	case errors.Is(err, adminService.ErrEmailNotVerified):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// twoFactorErrorStatus mapeia erros do TwoFactorService para status HTTP
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrInvalidTwoFactorCode), errors.Is(err, adminService.ErrInvalidTwoFactorChallenge),
+		errors.Is(err, adminService.ErrInvalidPassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, adminService.ErrTwoFactorAlreadyEnabled), errors.Is(err, adminService.ErrTwoFactorNotEnabled),
+		errors.Is(err, adminService.ErrTwoFactorNotSetUp):
+		return http.StatusConflict
+	case errors.Is(err, adminService.ErrTwoFactorRequired):
+		return http.StatusForbidden
+	case errors.Is(err, adminService.ErrSysRoleNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondLoginBlocked responde 429 com Retry-After quando o login está bloqueado ou atrasado
+func respondLoginBlocked(c *gin.Context, retryAfter time.Duration, err error) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retry_after": seconds})
+}
+
+// tokenClient identifica o dispositivo da sessão (guardado com o refresh token)
+func tokenClient(c *gin.Context) adminService.TokenClient {
+	return adminService.TokenClient{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
@@ -0,0 +1,163 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+type BackupHandler struct {
+	backupService *adminService.BackupService
+}
+
+func NewBackupHandler(backupService *adminService.BackupService) *BackupHandler {
+	return &BackupHandler{
+		backupService: backupService,
+	}
+}
+
+// CreateBackup agenda um backup do database do tenant (executado pelo worker)
+func (h *BackupHandler) CreateBackup(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	sysUserID := c.MustGet("user_id").(uuid.UUID)
+	backup, err := h.backupService.RequestBackup(c.Request.Context(), tenantID, &sysUserID)
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, backup)
+}
+
+// ListBackups lista os backups disponíveis do tenant
+func (h *BackupHandler) ListBackups(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	backups, err := h.backupService.ListBackups(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"backups": backups})
+}
+
+// GetBackup retorna um backup do tenant (status do job e conteúdo)
+func (h *BackupHandler) GetBackup(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+	backupID, err := uuid.Parse(c.Param("backup_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do backup inválido"})
+		return
+	}
+
+	backup, err := h.backupService.GetBackup(c.Request.Context(), tenantID, backupID)
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, backup)
+}
+
+// RestoreBackup agenda a restauração de um backup no database do tenant
+func (h *BackupHandler) RestoreBackup(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+	backupID, err := uuid.Parse(c.Param("backup_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do backup inválido"})
+		return
+	}
+
+	// Corpo opcional: sem corpo o modo padrão é 'existing'
+	var req adminService.RestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sysUserID := c.MustGet("user_id").(uuid.UUID)
+	restore, err := h.backupService.RequestRestore(c.Request.Context(), tenantID, backupID, req, &sysUserID)
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, restore)
+}
+
+// ListRestores lista os restores do tenant
+func (h *BackupHandler) ListRestores(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	restores, err := h.backupService.ListRestores(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"restores": restores})
+}
+
+// GetRestore retorna o status de um restore
+func (h *BackupHandler) GetRestore(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+	restoreID, err := uuid.Parse(c.Param("restore_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do restore inválido"})
+		return
+	}
+
+	restore, err := h.backupService.GetRestore(c.Request.Context(), tenantID, restoreID)
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, restore)
+}
+
+// backupErrorStatus mapeia os erros do BackupService para status HTTP
+func backupErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrInvalidRestoreMode):
+		return http.StatusBadRequest
+	case errors.Is(err, adminService.ErrTenantNotFound), errors.Is(err, adminService.ErrBackupNotFound),
+		errors.Is(err, adminService.ErrRestoreNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, adminService.ErrBackupInProgress), errors.Is(err, adminService.ErrBackupNotRestorable),
+		errors.Is(err, adminService.ErrTenantNotBackupable), errors.Is(err, adminService.ErrTenantPendingDeletion):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+type ClusterHandler struct {
+	clusterService *adminService.ClusterService
+}
+
+func NewClusterHandler(clusterService *adminService.ClusterService) *ClusterHandler {
+	return &ClusterHandler{
+		clusterService: clusterService,
+	}
+}
+
+// ListClusters lista os clusters Postgres com a quantidade de tenants de cada um
+func (h *ClusterHandler) ListClusters(c *gin.Context) {
+	clusters, err := h.clusterService.ListClusters(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"clusters": clusters})
+}
+
+// GetCluster retorna um cluster
+func (h *ClusterHandler) GetCluster(c *gin.Context) {
+	clusterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do cluster inválido"})
+		return
+	}
+
+	cluster, err := h.clusterService.GetCluster(c.Request.Context(), clusterID)
+	if err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cluster)
+}
+
+// CreateCluster registra um novo cluster (a conexão administrativa é validada antes)
+func (h *ClusterHandler) CreateCluster(c *gin.Context) {
+	var req adminService.ClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cluster, err := h.clusterService.CreateCluster(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, cluster)
+}
+
+// UpdateCluster altera um cluster (ex.: status 'draining' para parar de receber tenants)
+func (h *ClusterHandler) UpdateCluster(c *gin.Context) {
+	clusterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do cluster inválido"})
+		return
+	}
+
+	var req adminService.ClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cluster, err := h.clusterService.UpdateCluster(c.Request.Context(), clusterID, req)
+	if err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cluster)
+}
+
+// DeleteCluster remove um cluster sem tenants
+func (h *ClusterHandler) DeleteCluster(c *gin.Context) {
+	clusterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do cluster inválido"})
+		return
+	}
+
+	if err := h.clusterService.DeleteCluster(c.Request.Context(), clusterID); err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cluster removido"})
+}
+
+// MoveTenant agenda a movimentação do database do tenant para outro cluster
+func (h *ClusterHandler) MoveTenant(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	var req adminService.MoveTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.clusterService.MoveTenant(c.Request.Context(), tenantID, req)
+	if err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":           "movimentação agendada; o tenant ficará 'migrating' durante a cópia",
+		"tenant_id":         event.TenantID,
+		"source_cluster_id": event.SourceClusterID,
+		"target_cluster_id": event.TargetClusterID,
+	})
+}
+
+// clusterErrorStatus mapeia os erros do ClusterService para status HTTP
+func clusterErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrInvalidCluster):
+		return http.StatusBadRequest
+	case errors.Is(err, adminService.ErrClusterNotFound), errors.Is(err, adminService.ErrTenantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, adminService.ErrClusterInUse), errors.Is(err, adminService.ErrInvalidMove),
+		errors.Is(err, adminService.ErrTenantPendingDeletion):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+type DBCredentialHandler struct {
+	credentialService *adminService.DBCredentialService
+}
+
+func NewDBCredentialHandler(credentialService *adminService.DBCredentialService) *DBCredentialHandler {
+	return &DBCredentialHandler{
+		credentialService: credentialService,
+	}
+}
+
+// GetCredentials retorna o login de banco usado pelo tenant (nunca a senha)
+func (h *DBCredentialHandler) GetCredentials(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	info, err := h.credentialService.GetInfo(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(credentialErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// RotateCredentials troca a senha do banco do tenant sem derrubar conexões em uso
+func (h *DBCredentialHandler) RotateCredentials(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	info, err := h.credentialService.Rotate(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(credentialErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "senha do banco do tenant rotacionada",
+		"credentials": info,
+	})
+}
+
+// credentialErrorStatus mapeia os erros do DBCredentialService para status HTTP
+func credentialErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrTenantNotFound), errors.Is(err, adminService.ErrDBCredentialsNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, adminService.ErrTenantNotProvisioned), errors.Is(err, adminService.ErrCredentialRotationPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
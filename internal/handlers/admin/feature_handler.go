@@ -8,15 +8,18 @@ import (
 	"github.com/google/uuid"
 	adminModels "github.com/saas-multi-database-api/internal/models/admin"
 	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
 )
 
 type FeatureHandler struct {
 	featureRepo *adminRepo.FeatureRepository
+	planService *adminService.PlanService
 }
 
-func NewFeatureHandler(featureRepo *adminRepo.FeatureRepository) *FeatureHandler {
+func NewFeatureHandler(featureRepo *adminRepo.FeatureRepository, planService *adminService.PlanService) *FeatureHandler {
 	return &FeatureHandler{
 		featureRepo: featureRepo,
+		planService: planService,
 	}
 }
 
@@ -196,6 +199,9 @@ func (h *FeatureHandler) UpdateFeature(c *gin.Context) {
 		return
 	}
 
+	// Slug e status da feature aparecem nos planos e no contexto dos tenants
+	h.planService.FeaturesChanged(c.Request.Context())
+
 	planCount, err := h.featureRepo.GetFeaturePlanCount(c.Request.Context(), featureID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get plan count"})
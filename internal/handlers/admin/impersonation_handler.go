@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// Permissão de sistema exigida, além de access_support_tools, para impersonar com escrita
+const impersonationWritePermission = "update_tenant"
+
+// ImpersonationHandler abre e encerra sessões de impersonação de usuários dos tenants (suporte) e
+// consulta a trilha de auditoria delas
+type ImpersonationHandler struct {
+	impersonation *adminService.ImpersonationService
+	repo          *adminRepo.ImpersonationRepository
+	sysUserRepo   *adminRepo.SysUserRepository
+}
+
+func NewImpersonationHandler(impersonation *adminService.ImpersonationService, repo *adminRepo.ImpersonationRepository, sysUserRepo *adminRepo.SysUserRepository) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonation: impersonation,
+		repo:          repo,
+		sysUserRepo:   sysUserRepo,
+	}
+}
+
+// StartImpersonation emite um token da Tenant API em nome do usuário, limitado ao tenant.
+// Somente leitura por padrão; read_only=false exige também a permissão update_tenant.
+// POST /api/v1/admin/impersonations
+func (h *ImpersonationHandler) StartImpersonation(c *gin.Context) {
+	var req adminModels.StartImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sysUserID := c.MustGet("user_id").(uuid.UUID)
+
+	if req.ReadOnly != nil && !*req.ReadOnly {
+		allowed, err := h.sysUserRepo.HasSysPermission(c.Request.Context(), sysUserID, impersonationWritePermission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "system permission 'update_tenant' required for a read-write impersonation"})
+			return
+		}
+	}
+
+	token, err := h.impersonation.Start(c.Request.Context(), sysUserID, req, c.GetBool("token_mfa"), c.ClientIP())
+	if err != nil {
+		c.JSON(impersonationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// ListImpersonations lista as sessões mais recentes
+// GET /api/v1/admin/impersonations?tenant_id=&sys_user_id=&limit=50
+func (h *ImpersonationHandler) ListImpersonations(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	var tenantID, sysUserID *uuid.UUID
+	for param, target := range map[string]**uuid.UUID{"tenant_id": &tenantID, "sys_user_id": &sysUserID} {
+		if value := c.Query(param); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			*target = &id
+		}
+	}
+
+	sessions, err := h.repo.ListSessions(c.Request.Context(), tenantID, sysUserID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "total": len(sessions)})
+}
+
+// GetImpersonation retorna a sessão e as requisições feitas com ela
+// GET /api/v1/admin/impersonations/:id
+func (h *ImpersonationHandler) GetImpersonation(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	session, err := h.repo.GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "impersonation session not found"})
+		return
+	}
+
+	requests, err := h.repo.ListRequests(c.Request.Context(), sessionID, 1000)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session, "requests": requests})
+}
+
+// EndImpersonation encerra a sessão: o token deixa de ser aceito pela Tenant API
+// DELETE /api/v1/admin/impersonations/:id
+func (h *ImpersonationHandler) EndImpersonation(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	sysUserID := c.MustGet("user_id").(uuid.UUID)
+
+	if err := h.impersonation.End(c.Request.Context(), sessionID, sysUserID); err != nil {
+		c.JSON(impersonationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "impersonation session ended"})
+}
+
+// impersonationErrorStatus mapeia erros do ImpersonationService para status HTTP
+func impersonationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrImpersonationTargetNotFound), errors.Is(err, adminService.ErrImpersonationNotActive):
+		return http.StatusNotFound
+	case errors.Is(err, adminService.ErrImpersonationTenantInactive), errors.Is(err, adminService.ErrImpersonationNotMember):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
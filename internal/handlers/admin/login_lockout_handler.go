@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// LoginLockoutHandler consulta e remove os bloqueios de login por força bruta (Admin e Tenant API)
+type LoginLockoutHandler struct {
+	loginGuard *adminService.LoginGuardService
+}
+
+func NewLoginLockoutHandler(loginGuard *adminService.LoginGuardService) *LoginLockoutHandler {
+	return &LoginLockoutHandler{
+		loginGuard: loginGuard,
+	}
+}
+
+// ListLockoutEvents lista os bloqueios e desbloqueios mais recentes
+// GET /api/v1/admin/login-lockouts?subject_type=tenant&email=...&limit=50
+func (h *LoginLockoutHandler) ListLockoutEvents(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	events, err := h.loginGuard.ListEvents(c.Request.Context(), c.Query("subject_type"), c.Query("email"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events, "total": len(events)})
+}
+
+// Unlock remove o bloqueio de uma conta (email) e/ou de um IP antes de expirar
+// POST /api/v1/admin/login-lockouts/unlock
+func (h *LoginLockoutHandler) Unlock(c *gin.Context) {
+	var req adminModels.UnlockLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID := c.MustGet("user_id").(uuid.UUID)
+
+	wasLocked, err := h.loginGuard.Unlock(c.Request.Context(), req.SubjectType, req.Email, req.IPAddress, actorID)
+	if err != nil {
+		c.JSON(loginLockoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "login unlocked", "was_locked": wasLocked})
+}
+
+// loginLockoutErrorStatus mapeia erros do LoginGuardService para status HTTP
+func loginLockoutErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrUnlockTargetRequired):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
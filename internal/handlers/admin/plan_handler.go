@@ -51,13 +51,15 @@ func (h *PlanHandler) GetPlanByID(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, adminModels.PlanResponse{
-		ID:          planResponse.ID,
-		Name:        planResponse.Name,
-		Description: planResponse.Description,
-		Price:       planResponse.Price,
-		Features:    planResponse.Features,
-		CreatedAt:   planResponse.CreatedAt,
-		UpdatedAt:   planResponse.UpdatedAt,
+		ID:               planResponse.ID,
+		Name:             planResponse.Name,
+		Description:      planResponse.Description,
+		Price:            planResponse.Price,
+		Features:         planResponse.Features,
+		MaxDBConnections: planResponse.MaxDBConnections,
+		MaxMembers:       planResponse.MaxMembers,
+		CreatedAt:        planResponse.CreatedAt,
+		UpdatedAt:        planResponse.UpdatedAt,
 	})
 }
 
@@ -71,7 +73,7 @@ func (h *PlanHandler) CreatePlan(c *gin.Context) {
 	}
 
 	// Criar plano (com invalidação de cache)
-	plan, err := h.planService.CreatePlan(c.Request.Context(), req.Name, req.Description, req.Price)
+	plan, err := h.planService.CreatePlan(c.Request.Context(), req.Name, req.Description, req.Price, req.MaxDBConnections, req.MaxMembers)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create plan", "details": err.Error()})
 		return
@@ -102,13 +104,15 @@ func (h *PlanHandler) CreatePlan(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, adminModels.PlanResponse{
-		ID:          planResponse.ID,
-		Name:        planResponse.Name,
-		Description: planResponse.Description,
-		Price:       planResponse.Price,
-		Features:    planResponse.Features,
-		CreatedAt:   planResponse.CreatedAt,
-		UpdatedAt:   planResponse.UpdatedAt,
+		ID:               planResponse.ID,
+		Name:             planResponse.Name,
+		Description:      planResponse.Description,
+		Price:            planResponse.Price,
+		Features:         planResponse.Features,
+		MaxDBConnections: planResponse.MaxDBConnections,
+		MaxMembers:       planResponse.MaxMembers,
+		CreatedAt:        planResponse.CreatedAt,
+		UpdatedAt:        planResponse.UpdatedAt,
 	})
 }
 
@@ -129,7 +133,7 @@ func (h *PlanHandler) UpdatePlan(c *gin.Context) {
 	}
 
 	// Atualizar plano (com invalidação de cache)
-	_, err = h.planService.UpdatePlan(c.Request.Context(), planID, req.Name, req.Description, req.Price)
+	_, err = h.planService.UpdatePlan(c.Request.Context(), planID, req.Name, req.Description, req.Price, req.MaxDBConnections, req.MaxMembers)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update plan", "details": err.Error()})
 		return
@@ -158,13 +162,15 @@ func (h *PlanHandler) UpdatePlan(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, adminModels.PlanResponse{
-		ID:          planResponse.ID,
-		Name:        planResponse.Name,
-		Description: planResponse.Description,
-		Price:       planResponse.Price,
-		Features:    planResponse.Features,
-		CreatedAt:   planResponse.CreatedAt,
-		UpdatedAt:   planResponse.UpdatedAt,
+		ID:               planResponse.ID,
+		Name:             planResponse.Name,
+		Description:      planResponse.Description,
+		Price:            planResponse.Price,
+		Features:         planResponse.Features,
+		MaxDBConnections: planResponse.MaxDBConnections,
+		MaxMembers:       planResponse.MaxMembers,
+		CreatedAt:        planResponse.CreatedAt,
+		UpdatedAt:        planResponse.UpdatedAt,
 	})
 }
 
@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+type ProvisioningHandler struct {
+	tenantService *adminService.TenantService
+}
+
+func NewProvisioningHandler(tenantService *adminService.TenantService) *ProvisioningHandler {
+	return &ProvisioningHandler{
+		tenantService: tenantService,
+	}
+}
+
+// ListDeadLetters lista os provisionamentos que esgotaram as tentativas (DLQ)
+func (h *ProvisioningHandler) ListDeadLetters(c *gin.Context) {
+	deadLetters, err := h.tenantService.ListFailedProvisionings(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "erro ao listar dead-letter queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": deadLetters,
+		"total":        len(deadLetters),
+		"max_attempts": adminService.ProvisionMaxAttempts,
+	})
+}
+
+// GetDeadLetter retorna o provisionamento na DLQ de um tenant
+func (h *ProvisioningHandler) GetDeadLetter(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	deadLetter, err := h.tenantService.GetFailedProvisioning(c.Request.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, adminService.ErrDeadLetterNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "erro ao ler dead-letter queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetter)
+}
+
+// ReplayDeadLetter republica o provisionamento de um tenant que está na DLQ
+func (h *ProvisioningHandler) ReplayDeadLetter(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	event, err := h.tenantService.ReplayProvisioning(c.Request.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, adminService.ErrDeadLetterNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "provisionamento republicado na fila",
+		"event":   event,
+	})
+}
+
+// GetProvisioning retorna o progresso etapa a etapa do provisionamento de um tenant
+func (h *ProvisioningHandler) GetProvisioning(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	progress, err := h.tenantService.GetProvisioningProgress(c.Request.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, adminService.ErrTenantNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "erro ao buscar progresso do provisionamento"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// StreamProvisioning envia os eventos de provisionamento via SSE até o tenant ficar ativo ou falhar
+func (h *ProvisioningHandler) StreamProvisioning(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	events, err := h.tenantService.StreamProvisioning(c.Request.Context(), tenantID, LastEventID(c))
+	if err != nil {
+		if errors.Is(err, adminService.ErrTenantNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "erro ao abrir stream de provisionamento"})
+		return
+	}
+
+	StreamProvisioningEvents(c, events)
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// Intervalo de comentários keep-alive no stream SSE (evita timeout de proxies)
+const sseHeartbeatInterval = 15 * time.Second
+
+// LastEventID lê o último evento recebido pelo cliente (header Last-Event-ID ou ?last_event_id=)
+func LastEventID(c *gin.Context) int64 {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// StreamProvisioningEvents escreve os eventos de provisionamento como server-sent events.
+// Cada evento usa o id persistido, para que o cliente retome com Last-Event-ID após reconectar.
+func StreamProvisioningEvents(c *gin.Context, events <-chan adminService.ProvisioningEvent) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			return true
+		case event, ok := <-events:
+			if !ok {
+				fmt.Fprint(w, "event: done\ndata: {}\n\n")
+				return false
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return true
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Step, payload)
+			return true
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+type RateLimitHandler struct {
+	rateLimitService *adminService.RateLimitService
+}
+
+func NewRateLimitHandler(rateLimitService *adminService.RateLimitService) *RateLimitHandler {
+	return &RateLimitHandler{
+		rateLimitService: rateLimitService,
+	}
+}
+
+// GetPlanRateLimits retorna os limites de requisições por minuto do plano
+// GET /api/v1/admin/plans/:id/rate-limits
+func (h *RateLimitHandler) GetPlanRateLimits(c *gin.Context) {
+	planID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do plano inválido"})
+		return
+	}
+
+	limits, err := h.rateLimitService.GetPlanRateLimits(c.Request.Context(), planID)
+	if err != nil {
+		c.JSON(rateLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// SetPlanRateLimits substitui os limites do plano (campos omitidos = padrão da Tenant API)
+// PUT /api/v1/admin/plans/:id/rate-limits
+func (h *RateLimitHandler) SetPlanRateLimits(c *gin.Context) {
+	planID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do plano inválido"})
+		return
+	}
+
+	var req adminModels.RateLimits
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dados inválidos", "details": err.Error()})
+		return
+	}
+
+	limits, err := h.rateLimitService.SetPlanRateLimits(c.Request.Context(), planID, req)
+	if err != nil {
+		c.JSON(rateLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// GetTenantRateLimits retorna os limites do tenant (plano, override e efetivos)
+// GET /api/v1/admin/tenants/:tenant_id/rate-limits
+func (h *RateLimitHandler) GetTenantRateLimits(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	limits, err := h.rateLimitService.GetTenantRateLimits(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(rateLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// SetTenantRateLimits grava o override dos limites do tenant (campos omitidos seguem o plano)
+// PUT /api/v1/admin/tenants/:tenant_id/rate-limits
+func (h *RateLimitHandler) SetTenantRateLimits(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	var req adminModels.RateLimits
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dados inválidos", "details": err.Error()})
+		return
+	}
+
+	sysUserID := c.MustGet("user_id").(uuid.UUID)
+	limits, err := h.rateLimitService.SetTenantRateLimits(c.Request.Context(), tenantID, req, sysUserID)
+	if err != nil {
+		c.JSON(rateLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// DeleteTenantRateLimits remove o override; o tenant volta aos limites do plano
+// DELETE /api/v1/admin/tenants/:tenant_id/rate-limits
+func (h *RateLimitHandler) DeleteTenantRateLimits(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	limits, err := h.rateLimitService.DeleteTenantRateLimits(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(rateLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// rateLimitErrorStatus mapeia os erros do RateLimitService para status HTTP
+func rateLimitErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrTenantNotFound), errors.Is(err, adminService.ErrPlanNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
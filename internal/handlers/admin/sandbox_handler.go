@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+type SandboxHandler struct {
+	sandboxService *adminService.SandboxService
+}
+
+func NewSandboxHandler(sandboxService *adminService.SandboxService) *SandboxHandler {
+	return &SandboxHandler{
+		sandboxService: sandboxService,
+	}
+}
+
+// CreateSandbox cria um tenant sandbox a partir do tenant (a cópia dos dados é feita pelo worker)
+func (h *SandboxHandler) CreateSandbox(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	// Corpo opcional: sem corpo são usados o subdomain gerado e a validade padrão
+	var req adminService.CreateSandboxRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sysUserID := c.MustGet("user_id").(uuid.UUID)
+	clone, err := h.sandboxService.CreateSandbox(c.Request.Context(), tenantID, req, &sysUserID)
+	if err != nil {
+		c.JSON(sandboxErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, clone)
+}
+
+// ListSandboxClones lista as cópias para sandbox feitas a partir do tenant
+func (h *SandboxHandler) ListSandboxClones(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	clones, err := h.sandboxService.ListSandboxClones(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(sandboxErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sandboxes": clones})
+}
+
+// GetSandboxClone retorna uma cópia para sandbox (status do job e tenant criado)
+func (h *SandboxHandler) GetSandboxClone(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+	cloneID, err := uuid.Parse(c.Param("clone_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da cópia inválido"})
+		return
+	}
+
+	clone, err := h.sandboxService.GetSandboxClone(c.Request.Context(), tenantID, cloneID)
+	if err != nil {
+		c.JSON(sandboxErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clone)
+}
+
+// sandboxErrorStatus mapeia erros do SandboxService para status HTTP
+func sandboxErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrInvalidSandboxTTL), errors.Is(err, adminService.ErrInvalidSandboxSubdomain):
+		return http.StatusBadRequest
+	case errors.Is(err, adminService.ErrTenantNotFound), errors.Is(err, adminService.ErrSandboxCloneNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, adminService.ErrSandboxOfSandbox), errors.Is(err, adminService.ErrSandboxCloneInProgress),
+		errors.Is(err, adminService.ErrSandboxSourceNotReady), errors.Is(err, adminService.ErrTenantPendingDeletion),
+		errors.Is(err, adminService.ErrSubdomainTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
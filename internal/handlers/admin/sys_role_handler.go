@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// SysRoleHandler gerencia as políticas dos papéis de sistema (segundo fator obrigatório)
+type SysRoleHandler struct {
+	twoFactor *adminService.TwoFactorService
+}
+
+func NewSysRoleHandler(twoFactor *adminService.TwoFactorService) *SysRoleHandler {
+	return &SysRoleHandler{
+		twoFactor: twoFactor,
+	}
+}
+
+// ListSysRoles lista os papéis de sistema com a exigência de segundo fator
+// GET /api/v1/admin/sys-roles
+func (h *SysRoleHandler) ListSysRoles(c *gin.Context) {
+	roles, err := h.twoFactor.ListRolePolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles, "total": len(roles)})
+}
+
+// SetTwoFactorPolicy torna o segundo fator obrigatório (ou opcional) para os administradores do papel
+// PUT /api/v1/admin/sys-roles/:id/two-factor
+func (h *SysRoleHandler) SetTwoFactorPolicy(c *gin.Context) {
+	roleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role ID"})
+		return
+	}
+
+	var req adminModels.TwoFactorPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.twoFactor.SetRoleRequirement(c.Request.Context(), roleID, *req.RequireTwoFactor)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
@@ -1,21 +1,26 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/saas-multi-database-api/internal/config"
 	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/models/shared"
 	adminService "github.com/saas-multi-database-api/internal/services/admin"
 )
 
 type TenantHandler struct {
 	tenantService *adminService.TenantService
+	cfg           *config.Config
 }
 
-func NewTenantHandler(tenantService *adminService.TenantService) *TenantHandler {
+func NewTenantHandler(tenantService *adminService.TenantService, cfg *config.Config) *TenantHandler {
 	return &TenantHandler{
 		tenantService: tenantService,
+		cfg:           cfg,
 	}
 }
 
@@ -93,7 +98,7 @@ func (h *TenantHandler) ListMyTenants(c *gin.Context) {
 	})
 }
 
-// UpdateTenant atualiza informações do tenant (Admin API)
+// UpdateTenant atualiza perfil, plano e/ou status do tenant (Admin API)
 func (h *TenantHandler) UpdateTenant(c *gin.Context) {
 	if h.tenantService == nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "operação disponível apenas na Admin API"})
@@ -107,26 +112,55 @@ func (h *TenantHandler) UpdateTenant(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Name        *string `json:"name"`
-		Status      *string `json:"status"`
-		PlanID      *string `json:"plan_id"`
-		CompanyName *string `json:"company_name"`
+	var req adminService.UpdateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dados inválidos", "details": err.Error()})
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "dados inválidos"})
+	tenant, err := h.tenantService.UpdateTenant(c.Request.Context(), tenantID, req)
+	if err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "tenant atualizado com sucesso",
+		"tenant":  tenant,
+	})
+}
+
+// SuspendTenant suspende o acesso ao tenant (Admin API)
+func (h *TenantHandler) SuspendTenant(c *gin.Context) {
+	h.setStatus(c, shared.TenantStatusSuspended, "tenant suspenso com sucesso")
+}
+
+// ReactivateTenant reativa um tenant suspenso (Admin API)
+func (h *TenantHandler) ReactivateTenant(c *gin.Context) {
+	h.setStatus(c, shared.TenantStatusActive, "tenant reativado com sucesso")
+}
+
+func (h *TenantHandler) setStatus(c *gin.Context, status shared.TenantStatus, message string) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	tenant, err := h.tenantService.SetTenantStatus(c.Request.Context(), tenantID, status)
+	if err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	// TODO: Implement UpdateTenant in service layer
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "tenant atualizado com sucesso",
-		"tenant_id": tenantID,
+		"message": message,
+		"tenant":  tenant,
 	})
 }
 
-// DeleteTenant suspende/exclui um tenant (Admin API)
+// DeleteTenant agenda a exclusão definitiva do tenant (Admin API)
+// Exige confirmação (url_code do tenant) e aplica período de carência antes do purge pelo worker
 func (h *TenantHandler) DeleteTenant(c *gin.Context) {
 	if h.tenantService == nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "operação disponível apenas na Admin API"})
@@ -140,13 +174,117 @@ func (h *TenantHandler) DeleteTenant(c *gin.Context) {
 		return
 	}
 
-	// TODO: Implement soft delete (status = suspended) in service layer
+	var req adminService.DeleteTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dados inválidos", "details": err.Error()})
+		return
+	}
+
+	sysUserID := c.MustGet("user_id").(uuid.UUID)
+
+	tenant, err := h.tenantService.ScheduleTenantDeletion(c.Request.Context(), tenantID, req, h.cfg.Tenants.DeletionGraceDays, sysUserID)
+	if err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":               "exclusão agendada; o tenant foi suspenso e será removido definitivamente após o período de carência",
+		"deletion_scheduled_at": tenant.DeletionScheduledAt,
+		"tenant":                tenant,
+	})
+}
+
+// RestoreTenant cancela a exclusão agendada de um tenant (Admin API)
+// O tenant continua suspenso até ser reativado
+func (h *TenantHandler) RestoreTenant(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	tenant, err := h.tenantService.CancelTenantDeletion(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "exclusão cancelada; reative o tenant para liberar o acesso",
+		"tenant":  tenant,
+	})
+}
+
+// GetCustomDomain retorna o registro DNS (TXT) que comprova a posse do domínio customizado (Admin API)
+func (h *TenantHandler) GetCustomDomain(c *gin.Context) {
+	if h.tenantService == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "operação disponível apenas na Admin API"})
+		return
+	}
+
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	verification, err := h.tenantService.GetCustomDomainVerification(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
+
+// VerifyCustomDomain consulta o DNS e libera o domínio customizado para o storefront (Admin API)
+func (h *TenantHandler) VerifyCustomDomain(c *gin.Context) {
+	if h.tenantService == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "operação disponível apenas na Admin API"})
+		return
+	}
+
+	tenantID, err := uuid.Parse(c.Param("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do tenant inválido"})
+		return
+	}
+
+	verification, err := h.tenantService.VerifyCustomDomain(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "tenant suspenso com sucesso",
-		"tenant_id": tenantID,
+		"message":      "domínio verificado com sucesso",
+		"verification": verification,
 	})
 }
 
+// tenantErrorStatus mapeia erros do TenantService para status HTTP
+func tenantErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrTenantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, adminService.ErrPlanNotFound),
+		errors.Is(err, adminService.ErrDeletionNotConfirmed),
+		errors.Is(err, adminService.ErrInvalidStatusTransition),
+		errors.Is(err, adminService.ErrNoCustomDomain):
+		return http.StatusBadRequest
+	case errors.Is(err, adminService.ErrCustomDomainNotVerified):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, adminService.ErrTenantPendingDeletion),
+		errors.Is(err, adminService.ErrDeletionNotScheduled),
+		errors.Is(err, adminService.ErrTenantPurging),
+		errors.Is(err, adminService.ErrCustomDomainInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // Helper function to parse UUID
 func mustParseUUID(s string) uuid.UUID {
 	id, _ := uuid.Parse(s)
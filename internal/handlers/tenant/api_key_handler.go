@@ -0,0 +1,155 @@
+package tenant
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// APIKeyHandler gerencia as chaves de API do tenant (acesso de integrações sem um usuário)
+type APIKeyHandler struct {
+	apiKeyRepo *adminRepo.APIKeyRepository
+}
+
+func NewAPIKeyHandler(apiKeyRepo *adminRepo.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// List lista as chaves do tenant (sem a chave em si, só o prefixo)
+// GET /api/v1/:url_code/api-keys
+func (h *APIKeyHandler) List(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	keys, err := h.apiKeyRepo.ListAPIKeys(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys, "total": len(keys)})
+}
+
+// Create cria uma chave com um subconjunto das permissões. A chave completa só é retornada aqui.
+// POST /api/v1/:url_code/api-keys
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req adminModels.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	permissions := uniqueStrings(req.Permissions)
+	unknown, err := h.apiKeyRepo.UnknownPermissions(c.Request.Context(), permissions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(unknown) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown permissions: %s", strings.Join(unknown, ", "))})
+		return
+	}
+
+	allowedIPs := make([]string, 0, len(req.AllowedIPs))
+	for _, value := range req.AllowedIPs {
+		cidr, err := utils.NormalizeCIDR(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		allowedIPs = append(allowedIPs, cidr)
+	}
+
+	rawKey, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.apiKeyRepo.CreateAPIKey(c.Request.Context(), &adminModels.TenantAPIKey{
+		TenantID:    tenantID,
+		Name:        strings.TrimSpace(req.Name),
+		KeyPrefix:   prefix,
+		Permissions: permissions,
+		AllowedIPs:  uniqueStrings(allowedIPs),
+		ExpiresAt:   req.ExpiresAt,
+		CreatedBy:   &userID,
+	}, utils.HashToken(rawKey))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"api_key": key,
+		"key":     rawKey, // Exibida uma única vez
+	})
+}
+
+// Revoke revoga uma chave; as próximas requisições com ela recebem 401
+// DELETE /api/v1/:url_code/api-keys/:id
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	revoked, err := h.apiKeyRepo.RevokeAPIKey(c.Request.Context(), tenantID, keyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found or already revoked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "api key revoked"})
+}
+
+// requestActor retorna quem fez a requisição: o usuário (JWT) ou a chave de API
+func requestActor(c *gin.Context) (userID, apiKeyID *uuid.UUID) {
+	if value, exists := c.Get("user_id"); exists {
+		if id, ok := value.(uuid.UUID); ok {
+			userID = &id
+		}
+	}
+	if value, exists := c.Get("api_key_id"); exists {
+		if id, ok := value.(uuid.UUID); ok {
+			apiKeyID = &id
+		}
+	}
+	return userID, apiKeyID
+}
+
+// uniqueStrings remove valores repetidos mantendo a ordem
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
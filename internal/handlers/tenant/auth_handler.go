@@ -1,8 +1,12 @@
 package tenant
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -19,14 +23,26 @@ type TenantAuthHandler struct {
 	userRepo      *adminRepo.UserRepository
 	tenantRepo    *adminRepo.TenantRepository
 	tenantService *adminService.TenantService
+	tokenService  *adminService.AuthTokenService
+	emailService  *adminService.AuthEmailService
+	twoFactor     *adminService.TwoFactorService
+	loginGuard    *adminService.LoginGuardService
+	oidc          *adminService.OIDCService
+	members       *adminService.TenantMemberService
 	cfg           *config.Config
 }
 
-func NewTenantAuthHandler(userRepo *adminRepo.UserRepository, tenantRepo *adminRepo.TenantRepository, tenantService *adminService.TenantService, cfg *config.Config) *TenantAuthHandler {
+func NewTenantAuthHandler(userRepo *adminRepo.UserRepository, tenantRepo *adminRepo.TenantRepository, tenantService *adminService.TenantService, tokenService *adminService.AuthTokenService, emailService *adminService.AuthEmailService, twoFactor *adminService.TwoFactorService, loginGuard *adminService.LoginGuardService, oidc *adminService.OIDCService, members *adminService.TenantMemberService, cfg *config.Config) *TenantAuthHandler {
 	return &TenantAuthHandler{
 		userRepo:      userRepo,
 		tenantRepo:    tenantRepo,
 		tenantService: tenantService,
+		tokenService:  tokenService,
+		emailService:  emailService,
+		twoFactor:     twoFactor,
+		loginGuard:    loginGuard,
+		oidc:          oidc,
+		members:       members,
 		cfg:           cfg,
 	}
 }
@@ -63,8 +79,13 @@ func (h *TenantAuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate Tenant JWT
-	token, err := utils.GenerateTenantJWT(user.ID, h.cfg)
+	// Send email verification link (the account works meanwhile unless AUTH_REQUIRE_VERIFIED_EMAIL)
+	if err := h.emailService.SendVerification(c.Request.Context(), adminService.TokenSubjectTenant, user.ID, ""); err != nil {
+		log.Printf("Warning: Failed to send verification email to user %s: %v", user.ID, err)
+	}
+
+	// Generate access and refresh tokens
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), adminService.TokenSubjectTenant, user.ID, utils.TokenOptions{}, tokenClient(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
@@ -78,7 +99,9 @@ func (h *TenantAuthHandler) Register(c *gin.Context) {
 	}
 
 	response := tenantModels.TenantLoginResponse{
-		Token: token,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 	}
 	response.User.ID = user.ID
 	response.User.Email = user.Email
@@ -101,21 +124,53 @@ func (h *TenantAuthHandler) Login(c *gin.Context) {
 	// Normalize email
 	req.Email = utils.NormalizeEmail(req.Email)
 
-	// Get user by email
-	user, err := h.userRepo.GetUserByEmail(c.Request.Context(), req.Email)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+	// Brute-force protection: locked account/IP or progressive delay after failures
+	if retryAfter, err := h.loginGuard.Check(c.Request.Context(), adminService.TokenSubjectTenant, req.Email, c.ClientIP()); err != nil {
+		respondLoginBlocked(c, retryAfter, err)
 		return
 	}
 
+	// Get user by email (unknown emails still go through bcrypt: same response time)
+	user, err := h.userRepo.GetUserByEmail(c.Request.Context(), req.Email)
+	passwordHash := ""
+	if err == nil {
+		passwordHash = user.PasswordHash
+	}
+
 	// Verify password
-	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+	if !utils.CheckPasswordHashConstantTime(req.Password, passwordHash) {
+		h.loginGuard.RecordFailure(c.Request.Context(), adminService.TokenSubjectTenant, req.Email, c.ClientIP())
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
-	// Generate Tenant JWT
-	token, err := utils.GenerateTenantJWT(user.ID, h.cfg)
+	// Unverified email blocks login only when AUTH_REQUIRE_VERIFIED_EMAIL is enabled
+	if err := h.emailService.CheckLoginAllowed(c.Request.Context(), adminService.TokenSubjectTenant, user.ID); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// With 2FA enabled the login continues on /auth/login/2fa with the mfa_token
+	challenge, err := h.twoFactor.BeginLogin(c.Request.Context(), adminService.TokenSubjectTenant, user.ID, adminService.TwoFactorLogin{Email: req.Email})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check two-factor authentication"})
+		return
+	}
+	if challenge != nil {
+		// The failure counter is only reset once the second factor passes (LoginTwoFactor)
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+	h.loginGuard.RecordSuccess(c.Request.Context(), adminService.TokenSubjectTenant, req.Email)
+
+	h.respondLogin(c, user, utils.TokenOptions{}, nil)
+}
+
+// respondLogin emite os tokens da sessão e retorna a resposta de login (usuário, tenants e a
+// configuração do último tenant acessado)
+func (h *TenantAuthHandler) respondLogin(c *gin.Context, user *adminModels.User, opts utils.TokenOptions, recoveryCodes []string) {
+	// Generate access and refresh tokens
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), adminService.TokenSubjectTenant, user.ID, opts, tokenClient(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
@@ -141,7 +196,10 @@ func (h *TenantAuthHandler) Login(c *gin.Context) {
 
 	// Build base response
 	response := tenantModels.LoginResponse{
-		Token: token,
+		Token:         tokens.AccessToken,
+		RefreshToken:  tokens.RefreshToken,
+		ExpiresIn:     tokens.ExpiresIn,
+		RecoveryCodes: recoveryCodes,
 	}
 	response.User.ID = user.ID
 	response.User.Email = user.Email
@@ -326,15 +384,42 @@ func (h *TenantAuthHandler) GetMe(c *gin.Context) {
 	if err != nil {
 		adminTenants = []adminModels.UserTenant{}
 	}
+	// Sessão de impersonation enxerga só o tenant que ela acessa
+	if actor, ok := c.Get("token_actor"); ok {
+		adminTenants = impersonatedTenants(adminTenants, actor.(*utils.Actor).TenantID)
+	}
 	tenants := convertUserTenants(adminTenants)
 
-	c.JSON(http.StatusOK, gin.H{
+	emailVerified, err := h.emailService.EmailVerified(c.Request.Context(), adminService.TokenSubjectTenant, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user email status"})
+		return
+	}
+
+	response := gin.H{
 		"id":                 user.ID,
 		"email":              user.Email,
+		"email_verified":     emailVerified,
 		"full_name":          profile.FullName,
 		"last_tenant_logged": user.LastTenantLogged,
 		"tenants":            tenants,
-	})
+		"impersonated":       false,
+	}
+
+	// Sessão aberta por um administrador em nome do usuário: o frontend deve deixar isso visível
+	if actor, ok := c.Get("token_actor"); ok {
+		act := actor.(*utils.Actor)
+		response["impersonated"] = true
+		response["impersonation"] = gin.H{
+			"session_id":  c.GetString("token_id"),
+			"sys_user_id": act.SysUserID,
+			"tenant_id":   act.TenantID,
+			"read_only":   act.ReadOnly,
+			"expires_at":  c.GetTime("token_expires_at"),
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // Subscribe cria um novo assinante com usuário e tenant simultaneamente
@@ -407,8 +492,13 @@ func (h *TenantAuthHandler) Subscribe(c *gin.Context) {
 		log.Printf("Warning: Failed to update last_tenant_logged for user %s: %v", user.ID, err)
 	}
 
-	// Gerar JWT para o usuário
-	token, err := utils.GenerateTenantJWT(user.ID, h.cfg)
+	// Enviar o link de verificação de email com a identidade visual do novo tenant
+	if err := h.emailService.SendVerification(c.Request.Context(), adminService.TokenSubjectTenant, user.ID, tenant.URLCode); err != nil {
+		log.Printf("Warning: Failed to send verification email to user %s: %v", user.ID, err)
+	}
+
+	// Gerar access e refresh tokens para o usuário
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), adminService.TokenSubjectTenant, user.ID, utils.TokenOptions{}, tokenClient(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
@@ -443,7 +533,9 @@ func (h *TenantAuthHandler) Subscribe(c *gin.Context) {
 
 	// Retornar resposta completa com configuração do tenant
 	response := tenantModels.SubscriptionResponse{
-		Token: token,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 		CurrentTenant: tenantModels.CurrentTenant{
 			ID:        tenant.ID,
 			URLCode:   tenant.URLCode,
@@ -461,6 +553,541 @@ func (h *TenantAuthHandler) Subscribe(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
+// Refresh troca o refresh token por um novo par de tokens (rotação)
+func (h *TenantAuthHandler) Refresh(c *gin.Context) {
+	var req adminModels.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.tokenService.Refresh(c.Request.Context(), adminService.TokenSubjectTenant, req.RefreshToken, tokenClient(c))
+	if err != nil {
+		c.JSON(authTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revoga o access token atual e a sessão do refresh token informado
+func (h *TenantAuthHandler) Logout(c *gin.Context) {
+	var req adminModels.LogoutRequest
+
+	// Body opcional: sem refresh_token, apenas o access token é revogado
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+	tokenID := c.GetString("token_id")
+	expiresAt := c.GetTime("token_expires_at")
+
+	if err := h.tokenService.Logout(c.Request.Context(), adminService.TokenSubjectTenant, userID, tokenID, expiresAt, req.RefreshToken); err != nil {
+		c.JSON(authTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// LogoutAll revoga todas as sessões e access tokens do usuário
+func (h *TenantAuthHandler) LogoutAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	if err := h.tokenService.LogoutAll(c.Request.Context(), adminService.TokenSubjectTenant, userID); err != nil {
+		c.JSON(authTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
+}
+
+// VerifyEmail confirma o email com o token do link enviado por email
+func (h *TenantAuthHandler) VerifyEmail(c *gin.Context) {
+	var req adminModels.VerifyEmailRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailService.VerifyEmail(c.Request.Context(), adminService.TokenSubjectTenant, req.Token); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// ResendVerification reenvia o link de verificação para o usuário autenticado
+func (h *TenantAuthHandler) ResendVerification(c *gin.Context) {
+	var req adminModels.ResendVerificationRequest
+
+	// Body opcional: sem url_code, o email usa a identidade visual da plataforma
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	if err := h.emailService.SendVerification(c.Request.Context(), adminService.TokenSubjectTenant, userID, req.URLCode); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+// ForgotPassword envia o link de redefinição de senha (a resposta não revela se o email existe)
+func (h *TenantAuthHandler) ForgotPassword(c *gin.Context) {
+	var req adminModels.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailService.RequestPasswordReset(c.Request.Context(), adminService.TokenSubjectTenant, req.Email, req.URLCode); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered, a reset link was sent"})
+}
+
+// ResetPassword troca a senha com o token do link e encerra as sessões abertas
+func (h *TenantAuthHandler) ResetPassword(c *gin.Context) {
+	var req adminModels.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailService.ResetPassword(c.Request.Context(), adminService.TokenSubjectTenant, req.Token, req.Password); err != nil {
+		c.JSON(authEmailErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset"})
+}
+
+// LoginTwoFactor conclui o login com o código do app autenticador ou um código de recuperação
+func (h *TenantAuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req adminModels.LoginTwoFactorRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Wrong codes count as login failures of the account: a new password login does not reset them
+	login := h.twoFactor.ChallengeLogin(c.Request.Context(), adminService.TokenSubjectTenant, req.MFAToken)
+	if login.Email != "" {
+		if retryAfter, err := h.loginGuard.Check(c.Request.Context(), adminService.TokenSubjectTenant, login.Email, c.ClientIP()); err != nil {
+			respondLoginBlocked(c, retryAfter, err)
+			return
+		}
+	}
+
+	userID, recoveryCodes, err := h.twoFactor.CompleteLogin(c.Request.Context(), adminService.TokenSubjectTenant, req.MFAToken, req.Code)
+	if err != nil {
+		if login.Email != "" && errors.Is(err, adminService.ErrInvalidTwoFactorCode) {
+			h.loginGuard.RecordFailure(c.Request.Context(), adminService.TokenSubjectTenant, login.Email, c.ClientIP())
+		}
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if login.Email != "" {
+		h.loginGuard.RecordSuccess(c.Request.Context(), adminService.TokenSubjectTenant, login.Email)
+	}
+
+	user, err := h.userRepo.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	// A login started by the SSO keeps its tenant in the session
+	h.respondLogin(c, user, utils.TokenOptions{MFA: true, SSOTenantID: login.SSOTenantID}, recoveryCodes)
+}
+
+// LoginTwoFactorSetup retorna o segredo TOTP a cadastrar quando o login responde setup_required
+func (h *TenantAuthHandler) LoginTwoFactorSetup(c *gin.Context) {
+	var req adminModels.LoginTwoFactorSetupRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setup, err := h.twoFactor.LoginSetup(c.Request.Context(), adminService.TokenSubjectTenant, req.MFAToken)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// InvitationLookup retorna o convite do token para a tela de aceite (tenant, papel e se o email já tem conta)
+// POST /api/v1/auth/invitations/lookup
+func (h *TenantAuthHandler) InvitationLookup(c *gin.Context) {
+	var req adminModels.InvitationTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.members.Preview(c.Request.Context(), req.Token)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// InvitationRegister cria a conta do convidado, aceita o convite e já abre a sessão no tenant
+// POST /api/v1/auth/invitations/register
+func (h *TenantAuthHandler) InvitationRegister(c *gin.Context) {
+	var req adminModels.InvitationSignupRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accepted, err := h.members.Signup(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Set the invited tenant as the last one so the login response brings its config
+	if err := h.userRepo.UpdateLastTenantLogged(c.Request.Context(), accepted.UserID, accepted.URLCode); err != nil {
+		log.Printf("Warning: Failed to update last_tenant_logged for user %s: %v", accepted.UserID, err)
+	}
+
+	user, err := h.userRepo.GetUserByID(c.Request.Context(), accepted.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return
+	}
+
+	h.respondLogin(c, user, utils.TokenOptions{}, nil)
+}
+
+// AcceptInvitation aceita o convite com a conta logada (o email precisa ser o do convite)
+// POST /api/v1/auth/invitations/accept
+func (h *TenantAuthHandler) AcceptInvitation(c *gin.Context) {
+	var req adminModels.InvitationTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	accepted, err := h.members.Accept(c.Request.Context(), req.Token, userID)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invitation accepted", "invitation": accepted})
+}
+
+// OIDCStatus informa à tela de login se o tenant (url_code ou subdomínio) tem SSO e se ele é obrigatório
+// GET /api/v1/auth/oidc/:tenant
+func (h *TenantAuthHandler) OIDCStatus(c *gin.Context) {
+	status, err := h.oidc.Status(c.Request.Context(), c.Param("tenant"))
+	if err != nil {
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// OIDCAuthorize inicia o login SSO e retorna a URL do IdP para onde o frontend redireciona
+// GET /api/v1/auth/oidc/:tenant/authorize?login_hint=
+func (h *TenantAuthHandler) OIDCAuthorize(c *gin.Context) {
+	start, err := h.oidc.BeginLogin(c.Request.Context(), c.Param("tenant"), c.Query("login_hint"))
+	if err != nil {
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, start)
+}
+
+// OIDCCallback conclui o login SSO com o code e o state devolvidos pelo IdP. Usuários com segundo
+// fator continuam em /auth/login/2fa. A sessão guarda o tenant do SSO (exigido pelos tenants com
+// sso_only) e o segundo fator feito no IdP, que vale só nesse tenant.
+// POST /api/v1/auth/oidc/callback
+func (h *TenantAuthHandler) OIDCCallback(c *gin.Context) {
+	var req adminModels.OIDCCallbackRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.oidc.CompleteLogin(c.Request.Context(), req.Code, req.State)
+	var linkErr *adminService.OIDCLinkRequiredError
+	if errors.As(err, &linkErr) {
+		// Existing account outside the tenant: the user signs in with the password and confirms the link
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      err.Error(),
+			"code":       "account_link_required",
+			"link_token": linkErr.LinkToken,
+			"expires_in": linkErr.ExpiresIn,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userRepo.GetUserByID(c.Request.Context(), result.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	// The IdP does not replace the platform second factor: with 2FA enabled the login continues on /auth/login/2fa
+	challenge, err := h.twoFactor.BeginLogin(c.Request.Context(), adminService.TokenSubjectTenant, user.ID, adminService.TwoFactorLogin{
+		Email:       user.Email,
+		SSOTenantID: result.TenantID.String(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check two-factor authentication"})
+		return
+	}
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	h.respondLogin(c, user, utils.TokenOptions{SSOTenantID: result.TenantID.String(), SSOMFA: result.MFA}, nil)
+}
+
+// OIDCLink vincula a conta do IdP de um callback account_link_required à conta autenticada com a
+// senha. O próximo login SSO entra direto.
+// POST /api/v1/auth/oidc/link
+func (h *TenantAuthHandler) OIDCLink(c *gin.Context) {
+	var req adminModels.OIDCLinkRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	result, err := h.oidc.LinkAccount(c.Request.Context(), userID, req.LinkToken)
+	if err != nil {
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "SSO account linked", "tenant_id": result.TenantID, "url_code": result.URLCode})
+}
+
+// GetTwoFactor retorna a situação do segundo fator do usuário autenticado
+func (h *TenantAuthHandler) GetTwoFactor(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	status, err := h.twoFactor.Status(c.Request.Context(), adminService.TokenSubjectTenant, userID)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SetupTwoFactor gera o segredo TOTP (QR code em provisioning_uri); fica pendente até EnableTwoFactor
+func (h *TenantAuthHandler) SetupTwoFactor(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	setup, err := h.twoFactor.Setup(c.Request.Context(), adminService.TokenSubjectTenant, userID)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// EnableTwoFactor ativa o segundo fator com o primeiro código do app. Retorna os códigos de
+// recuperação e uma nova sessão autenticada com o segundo fator (aceita pelos tenants que o exigem).
+func (h *TenantAuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req adminModels.TwoFactorCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	recoveryCodes, err := h.twoFactor.Enable(c.Request.Context(), adminService.TokenSubjectTenant, userID, req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.tokenService.IssueTokens(c.Request.Context(), adminService.TokenSubjectTenant, userID, utils.TokenOptions{MFA: true}, tokenClient(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+		"token":          tokens.AccessToken,
+		"refresh_token":  tokens.RefreshToken,
+		"expires_in":     tokens.ExpiresIn,
+	})
+}
+
+// DisableTwoFactor remove o segundo fator (senha + código do app ou de recuperação)
+func (h *TenantAuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req adminModels.DisableTwoFactorRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	if err := h.twoFactor.Disable(c.Request.Context(), adminService.TokenSubjectTenant, userID, req.Password, req.Code); err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes troca os códigos de recuperação (os anteriores deixam de valer)
+func (h *TenantAuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req adminModels.TwoFactorCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	recoveryCodes, err := h.twoFactor.RegenerateRecoveryCodes(c.Request.Context(), adminService.TokenSubjectTenant, userID, req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+}
+
+// authTokenErrorStatus mapeia erros do AuthTokenService para status HTTP
+func authTokenErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrInvalidRefreshToken), errors.Is(err, adminService.ErrRefreshTokenReused),
+		errors.Is(err, adminService.ErrTwoFactorRequired):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// authEmailErrorStatus mapeia erros do AuthEmailService para status HTTP
+func authEmailErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrInvalidEmailToken):
+		return http.StatusBadRequest
+	case errors.Is(err, adminService.ErrEmailAlreadyVerified):
+		return http.StatusConflict
+	case errors.Is(err, adminService.ErrEmailNotVerified):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// twoFactorErrorStatus mapeia erros do TwoFactorService para status HTTP
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrInvalidTwoFactorCode), errors.Is(err, adminService.ErrInvalidTwoFactorChallenge),
+		errors.Is(err, adminService.ErrInvalidPassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, adminService.ErrTwoFactorAlreadyEnabled), errors.Is(err, adminService.ErrTwoFactorNotEnabled),
+		errors.Is(err, adminService.ErrTwoFactorNotSetUp):
+		return http.StatusConflict
+	case errors.Is(err, adminService.ErrTwoFactorRequired):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// oidcErrorStatus mapeia erros do OIDCService para status HTTP
+func oidcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrOIDCNotConfigured):
+		return http.StatusNotFound
+	case errors.Is(err, adminService.ErrOIDCInvalidConfig), errors.Is(err, adminService.ErrOIDCInvalidState),
+		errors.Is(err, adminService.ErrOIDCInvalidLink):
+		return http.StatusBadRequest
+	case errors.Is(err, adminService.ErrOIDCLoginFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, adminService.ErrOIDCDomainNotAllowed), errors.Is(err, adminService.ErrOIDCEmailNotVerified),
+		errors.Is(err, adminService.ErrOIDCInvalidEmail), errors.Is(err, adminService.ErrOIDCUserNotProvisioned):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// impersonatedTenants filtra os tenants do usuário para o tenant da sessão de impersonation
+func impersonatedTenants(tenants []adminModels.UserTenant, tenantID string) []adminModels.UserTenant {
+	filtered := make([]adminModels.UserTenant, 0, 1)
+	for _, t := range tenants {
+		if t.ID.String() == tenantID {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// respondLoginBlocked responde 429 com Retry-After quando o login está bloqueado ou atrasado
+func respondLoginBlocked(c *gin.Context, retryAfter time.Duration, err error) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retry_after": seconds})
+}
+
+// tokenClient identifica o dispositivo da sessão (guardado com o refresh token)
+func tokenClient(c *gin.Context) adminService.TokenClient {
+	return adminService.TokenClient{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
 // Helper function to parse UUID
 func mustParseUUID(s string) uuid.UUID {
 	id, _ := uuid.Parse(s)
@@ -0,0 +1,110 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	tenantService "github.com/saas-multi-database-api/internal/services/tenant"
+)
+
+// ExportHandler handles the tenant data export (data portability) endpoints
+type ExportHandler struct {
+	exportService *tenantService.ExportService
+}
+
+func NewExportHandler(exportService *tenantService.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// Create schedules a full export of the tenant data (built by the worker)
+func (h *ExportHandler) Create(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.MustGet("tenant_id").(string))
+	userID, apiKeyID := requestActor(c)
+
+	export, err := h.exportService.RequestExport(c.Request.Context(), tenantID, userID, apiKeyID)
+	if err != nil {
+		c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, export)
+}
+
+// List returns the tenant exports with their status
+func (h *ExportHandler) List(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.MustGet("tenant_id").(string))
+
+	exports, err := h.exportService.ListExports(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exports": exports})
+}
+
+// GetByID returns an export; completed exports carry a signed, expiring download_url
+func (h *ExportHandler) GetByID(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.MustGet("tenant_id").(string))
+	exportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export id"})
+		return
+	}
+
+	export, err := h.exportService.GetExport(c.Request.Context(), tenantID, exportID)
+	if err != nil {
+		c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// Download streams the export file. Public route: the signed link is the credential.
+func (h *ExportHandler) Download(c *gin.Context) {
+	exportID, err := uuid.Parse(c.Param("export_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "export not found"})
+		return
+	}
+
+	reader, export, err := h.exportService.OpenDownload(c.Request.Context(), exportID, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	var size int64 = -1
+	if export.SizeBytes != nil {
+		size = *export.SizeBytes
+	}
+
+	filename := fmt.Sprintf("export-%s.zip", export.CreatedAt.Format("20060102-150405"))
+	c.DataFromReader(http.StatusOK, size, "application/zip", reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, filename),
+		"Cache-Control":       "no-store",
+	})
+}
+
+// exportErrorStatus maps ExportService errors to HTTP status codes
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, tenantService.ErrExportNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tenantService.ErrExportInProgress):
+		return http.StatusConflict
+	case errors.Is(err, tenantService.ErrInvalidDownloadLink):
+		return http.StatusForbidden
+	case errors.Is(err, tenantService.ErrExportUnavailable):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,223 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// MemberHandler gerencia os membros do tenant e os convites por email
+type MemberHandler struct {
+	members *adminService.TenantMemberService
+	roles   *adminService.TenantRoleService
+}
+
+func NewMemberHandler(members *adminService.TenantMemberService, roles *adminService.TenantRoleService) *MemberHandler {
+	return &MemberHandler{
+		members: members,
+		roles:   roles,
+	}
+}
+
+// List lista os membros do tenant com o perfil e o papel
+// GET /api/v1/:url_code/members
+func (h *MemberHandler) List(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	members, err := h.members.ListMembers(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members, "total": len(members)})
+}
+
+// UpdateRole troca o papel de um membro
+// PUT /api/v1/:url_code/members/:user_id/role
+func (h *MemberHandler) UpdateRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req adminModels.UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	if !h.canManageMember(c, tenantID, userID) {
+		return
+	}
+	role, ok := h.grantableRole(c, tenantID, req.Role)
+	if !ok {
+		return
+	}
+
+	if err := h.members.UpdateMemberRole(c.Request.Context(), tenantID, userID, role.ID); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member role updated", "user_id": userID, "role": role.Slug})
+}
+
+// Remove remove um membro do tenant
+// DELETE /api/v1/:url_code/members/:user_id
+func (h *MemberHandler) Remove(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	if !h.canManageMember(c, tenantID, userID) {
+		return
+	}
+
+	if err := h.members.RemoveMember(c.Request.Context(), tenantID, userID); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member removed"})
+}
+
+// TransferOwnership passa o tenant para outro membro (somente o owner)
+// POST /api/v1/:url_code/members/transfer-ownership
+func (h *MemberHandler) TransferOwnership(c *gin.Context) {
+	var req adminModels.TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+	ownerID := c.MustGet("user_id").(uuid.UUID)
+
+	if err := h.members.TransferOwnership(c.Request.Context(), tenantID, ownerID, req.UserID); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ownership transferred", "owner_id": req.UserID})
+}
+
+// ListInvitations lista os convites do tenant (pendentes, aceitos, revogados e expirados)
+// GET /api/v1/:url_code/invitations
+func (h *MemberHandler) ListInvitations(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	invitations, err := h.members.ListInvitations(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations, "total": len(invitations)})
+}
+
+// Invite convida um email para o tenant com um papel. O token só vai no email.
+// POST /api/v1/:url_code/invitations
+func (h *MemberHandler) Invite(c *gin.Context) {
+	var req adminModels.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	role, ok := h.grantableRole(c, tenantID, req.Role)
+	if !ok {
+		return
+	}
+
+	inviterID, _ := requestActor(c)
+	invitation, err := h.members.Invite(c.Request.Context(), tenantID, inviterID, req.Email, role)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// RevokeInvitation revoga um convite pendente
+// DELETE /api/v1/:url_code/invitations/:id
+func (h *MemberHandler) RevokeInvitation(c *gin.Context) {
+	invitationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation id"})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	if err := h.members.RevokeInvitation(c.Request.Context(), tenantID, invitationID); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invitation revoked"})
+}
+
+// grantableRole resolve o papel pelo slug e recusa papéis com permissões que quem pede não tem.
+// Responde o erro quando recusa.
+func (h *MemberHandler) grantableRole(c *gin.Context, tenantID uuid.UUID, slug string) (*adminModels.Role, bool) {
+	role, err := h.roles.GetAssignableRole(c.Request.Context(), tenantID, strings.TrimSpace(slug))
+	if err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if !canGrantPermissions(c, role.Permissions) {
+		return nil, false
+	}
+	return role, true
+}
+
+// canManageMember impede que quem não é owner altere ou remova um membro com permissões que não tem.
+// Responde o erro quando recusa.
+func (h *MemberHandler) canManageMember(c *gin.Context, tenantID, userID uuid.UUID) bool {
+	current, err := h.members.MemberPermissions(c.Request.Context(), tenantID, userID)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return false
+	}
+	if missing := missingPermissions(c, current); len(missing) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("cannot manage a member with permissions you do not have: %s", strings.Join(missing, ", "))})
+		return false
+	}
+	return true
+}
+
+// memberErrorStatus mapeia erros do TenantMemberService para status HTTP
+func memberErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrMemberNotFound), errors.Is(err, adminService.ErrInvitationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, adminService.ErrAlreadyMember), errors.Is(err, adminService.ErrInvitationAccountExists):
+		return http.StatusConflict
+	case errors.Is(err, adminService.ErrMemberIsOwner), errors.Is(err, adminService.ErrTransferToSelf):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, adminService.ErrNotTenantOwner), errors.Is(err, adminService.ErrInvitationEmailMismatch),
+		errors.Is(err, adminService.ErrMemberLimitReached):
+		return http.StatusForbidden
+	case errors.Is(err, adminService.ErrInvalidInvitation):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
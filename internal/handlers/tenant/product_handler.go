@@ -101,8 +101,8 @@ func (h *ProductHandler) List(c *gin.Context) {
 		}
 	}
 
-	// Get tenant pool from context
-	pool, exists := c.Get("tenant_pool")
+	// Get read pool from context (replica when available, see TenantMiddleware)
+	pool, exists := c.Get("tenant_read_pool")
 	if !exists {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "tenant pool not found"})
 		return
@@ -0,0 +1,81 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminHandlers "github.com/saas-multi-database-api/internal/handlers/admin"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// ProvisioningHandler expõe o progresso do provisionamento para membros do tenant.
+// Fica fora do grupo /:url_code porque o TenantMiddleware só aceita tenants ativos.
+type ProvisioningHandler struct {
+	tenantRepo    *adminRepo.TenantRepository
+	tenantService *adminService.TenantService
+}
+
+func NewProvisioningHandler(tenantRepo *adminRepo.TenantRepository, tenantService *adminService.TenantService) *ProvisioningHandler {
+	return &ProvisioningHandler{
+		tenantRepo:    tenantRepo,
+		tenantService: tenantService,
+	}
+}
+
+// resolveTenant busca o tenant pelo url_code e valida se o usuário é membro
+func (h *ProvisioningHandler) resolveTenant(c *gin.Context) (uuid.UUID, bool) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	tenant, err := h.tenantRepo.GetTenantByURLCode(c.Request.Context(), c.Param("url_code"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return uuid.Nil, false
+	}
+
+	hasAccess, err := h.tenantRepo.CheckUserAccess(c.Request.Context(), userID, tenant.ID)
+	if err != nil || !hasAccess {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied to this tenant"})
+		return uuid.Nil, false
+	}
+
+	return tenant.ID, true
+}
+
+// GetProvisioning retorna o progresso etapa a etapa do provisionamento
+func (h *ProvisioningHandler) GetProvisioning(c *gin.Context) {
+	tenantID, ok := h.resolveTenant(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.tenantService.GetProvisioningProgress(c.Request.Context(), tenantID)
+	if err != nil {
+		if errors.Is(err, adminService.ErrTenantNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get provisioning progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// StreamProvisioning envia os eventos de provisionamento via SSE
+func (h *ProvisioningHandler) StreamProvisioning(c *gin.Context) {
+	tenantID, ok := h.resolveTenant(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.tenantService.StreamProvisioning(c.Request.Context(), tenantID, adminHandlers.LastEventID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open provisioning stream"})
+		return
+	}
+
+	adminHandlers.StreamProvisioningEvents(c, events)
+}
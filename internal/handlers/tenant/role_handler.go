@@ -0,0 +1,174 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// RoleHandler gerencia os papéis próprios do tenant e as permissões deles
+type RoleHandler struct {
+	roles *adminService.TenantRoleService
+}
+
+func NewRoleHandler(roles *adminService.TenantRoleService) *RoleHandler {
+	return &RoleHandler{
+		roles: roles,
+	}
+}
+
+// List lista os papéis globais e os do tenant, com as permissões e a quantidade de membros
+// GET /api/v1/:url_code/roles
+func (h *RoleHandler) List(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	roles, err := h.roles.ListRoles(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles, "total": len(roles)})
+}
+
+// ListPermissions lista as permissões que os papéis podem receber no plano do tenant
+// GET /api/v1/:url_code/roles/permissions
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	permissions, err := h.roles.ListAssignablePermissions(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"permissions": permissions, "total": len(permissions)})
+}
+
+// Create cria um papel do tenant
+// POST /api/v1/:url_code/roles
+func (h *RoleHandler) Create(c *gin.Context) {
+	var req adminModels.CreateTenantRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !canGrantPermissions(c, req.Permissions) {
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	role, err := h.roles.CreateRole(c.Request.Context(), tenantID, req)
+	if err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// Update renomeia um papel do tenant e substitui as permissões dele
+// PUT /api/v1/:url_code/roles/:id
+func (h *RoleHandler) Update(c *gin.Context) {
+	roleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+
+	var req adminModels.UpdateTenantRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !canGrantPermissions(c, req.Permissions) {
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	role, err := h.roles.UpdateRole(c.Request.Context(), tenantID, roleID, req)
+	if err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Delete remove um papel do tenant que não está com nenhum membro nem no SSO
+// DELETE /api/v1/:url_code/roles/:id
+func (h *RoleHandler) Delete(c *gin.Context) {
+	roleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	if err := h.roles.DeleteRole(c.Request.Context(), tenantID, roleID); err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted"})
+}
+
+// canGrantPermissions impede que quem não é owner dê a um papel permissões que não tem
+// (com user_m, criaria um papel mais forte que o próprio). Responde 403 quando recusa.
+func canGrantPermissions(c *gin.Context, requested []string) bool {
+	if missing := missingPermissions(c, requested); len(missing) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("cannot grant permissions you do not have: %s", strings.Join(missing, ", "))})
+		return false
+	}
+	return true
+}
+
+// missingPermissions retorna as permissões pedidas que o usuário não tem (nenhuma para o owner)
+func missingPermissions(c *gin.Context, requested []string) []string {
+	if c.GetString("user_role") == "owner" {
+		return nil
+	}
+
+	held, _ := c.Get("permissions")
+	heldList, _ := held.([]string)
+
+	var missing []string
+	for _, slug := range uniqueStrings(requested) {
+		found := false
+		for _, p := range heldList {
+			if p == slug {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, slug)
+		}
+	}
+	return missing
+}
+
+// roleErrorStatus mapeia erros do TenantRoleService para status HTTP
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, adminService.ErrTenantRoleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, adminService.ErrTenantRoleSlugTaken), errors.Is(err, adminService.ErrTenantRoleInUse):
+		return http.StatusConflict
+	case errors.Is(err, adminService.ErrTenantRoleInvalidSlug), errors.Is(err, adminService.ErrPermissionNotAssignable):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,63 @@
+package tenant
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// SecurityHandler gerencia as políticas de segurança do tenant (segundo fator obrigatório)
+type SecurityHandler struct {
+	tenantRepo *adminRepo.TenantRepository
+	twoFactor  *adminService.TwoFactorService
+}
+
+func NewSecurityHandler(tenantRepo *adminRepo.TenantRepository, twoFactor *adminService.TwoFactorService) *SecurityHandler {
+	return &SecurityHandler{
+		tenantRepo: tenantRepo,
+		twoFactor:  twoFactor,
+	}
+}
+
+// GetTwoFactorPolicy informa se o tenant exige o segundo fator dos membros
+func (h *SecurityHandler) GetTwoFactorPolicy(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	required, err := h.tenantRepo.GetTenantRequireTwoFactor(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"require_two_factor": required})
+}
+
+// UpdateTwoFactorPolicy torna o segundo fator obrigatório (ou opcional) para os membros do tenant.
+// Membros sem o segundo fator passam a receber 403 (code two_factor_required) até ativá-lo.
+func (h *SecurityHandler) UpdateTwoFactorPolicy(c *gin.Context) {
+	var req adminModels.TwoFactorPolicyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Quem ativa a exigência precisa estar com uma sessão de segundo fator, senão perde o acesso
+	if *req.RequireTwoFactor && !c.GetBool("token_mfa") {
+		c.JSON(http.StatusConflict, gin.H{"error": "enable two-factor authentication and sign in with it before requiring it"})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	if err := h.twoFactor.SetTenantRequirement(c.Request.Context(), tenantID, c.Param("url_code"), *req.RequireTwoFactor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"require_two_factor": *req.RequireTwoFactor})
+}
@@ -0,0 +1,70 @@
+package tenant
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminService "github.com/saas-multi-database-api/internal/services/admin"
+)
+
+// SSOHandler gerencia o SSO (OpenID Connect) do tenant
+type SSOHandler struct {
+	oidc *adminService.OIDCService
+}
+
+func NewSSOHandler(oidc *adminService.OIDCService) *SSOHandler {
+	return &SSOHandler{
+		oidc: oidc,
+	}
+}
+
+// GetOIDCProvider retorna a configuração de SSO do tenant (sem o client_secret)
+// GET /api/v1/:url_code/sso/oidc
+func (h *SSOHandler) GetOIDCProvider(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	provider, err := h.oidc.GetProvider(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, provider)
+}
+
+// SaveOIDCProvider cria ou altera o SSO do tenant. Com sso_only os membros (exceto o owner) só
+// acessam o tenant com uma sessão iniciada pelo SSO.
+// PUT /api/v1/:url_code/sso/oidc
+func (h *SSOHandler) SaveOIDCProvider(c *gin.Context) {
+	var req adminModels.SaveOIDCProviderRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	provider, err := h.oidc.SaveProvider(c.Request.Context(), tenantID, c.Param("url_code"), req)
+	if err != nil {
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, provider)
+}
+
+// DeleteOIDCProvider remove o SSO do tenant (os membros voltam a entrar com senha)
+// DELETE /api/v1/:url_code/sso/oidc
+func (h *SSOHandler) DeleteOIDCProvider(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.GetString("tenant_id"))
+
+	if err := h.oidc.DeleteProvider(c.Request.Context(), tenantID, c.Param("url_code")); err != nil {
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sso provider deleted"})
+}
@@ -0,0 +1,128 @@
+package tenant
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	tenantRepo "github.com/saas-multi-database-api/internal/repository/tenant"
+)
+
+// StorefrontHandler serves the public, unauthenticated storefront of a tenant resolved by Host
+// (see TenantHostMiddleware). Only active products and services are exposed.
+type StorefrontHandler struct {
+	tenantRepo  *adminRepo.TenantRepository
+	productRepo *tenantRepo.ProductRepository
+	serviceRepo *tenantRepo.ServiceRepository
+}
+
+func NewStorefrontHandler(tenantRepository *adminRepo.TenantRepository) *StorefrontHandler {
+	return &StorefrontHandler{
+		tenantRepo:  tenantRepository,
+		productRepo: tenantRepo.NewProductRepository(),
+		serviceRepo: tenantRepo.NewServiceRepository(),
+	}
+}
+
+// GetConfig returns the public layout configuration of the tenant
+func (h *StorefrontHandler) GetConfig(c *gin.Context) {
+	tenantID, _ := uuid.Parse(c.MustGet("tenant_id").(string))
+
+	profile, err := h.tenantRepo.GetTenantProfile(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant profile not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"features": c.MustGet("features").([]string),
+		"config": gin.H{
+			"logo_url":     profile.LogoURL,
+			"company_name": profile.CompanyName,
+			"about":        profile.About,
+		},
+	})
+}
+
+// ListProducts lists the active products of the tenant
+func (h *StorefrontHandler) ListProducts(c *gin.Context) {
+	page, pageSize := storefrontPagination(c)
+	active := true
+
+	pool := c.MustGet("tenant_read_pool").(*pgxpool.Pool)
+	result, err := h.productRepo.List(c.Request.Context(), pool, page, pageSize, &active)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetProduct retrieves an active product by ID
+func (h *StorefrontHandler) GetProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product ID"})
+		return
+	}
+
+	pool := c.MustGet("tenant_read_pool").(*pgxpool.Pool)
+	product, err := h.productRepo.GetByID(c.Request.Context(), pool, id)
+	if err != nil || !product.Active {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// ListServices lists the active services of the tenant
+func (h *StorefrontHandler) ListServices(c *gin.Context) {
+	page, pageSize := storefrontPagination(c)
+	active := true
+
+	pool := c.MustGet("tenant_read_pool").(*pgxpool.Pool)
+	result, err := h.serviceRepo.List(c.Request.Context(), pool, page, pageSize, &active)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list services"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetService retrieves an active service by ID
+func (h *StorefrontHandler) GetService(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service ID"})
+		return
+	}
+
+	pool := c.MustGet("tenant_read_pool").(*pgxpool.Pool)
+	service, err := h.serviceRepo.GetByID(c.Request.Context(), pool, id)
+	if err != nil || !service.Active {
+		c.JSON(http.StatusNotFound, gin.H{"error": "service not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, service)
+}
+
+// storefrontPagination parses page and page_size with the same limits as the tenant list endpoints
+func storefrontPagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
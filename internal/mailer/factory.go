@@ -0,0 +1,20 @@
+package mailer
+
+import (
+	"fmt"
+)
+
+// NewMailer creates a mailer based on configuration
+func NewMailer(cfg *Config) (Mailer, error) {
+	switch cfg.Driver {
+	case "log", "":
+		// Default to logging the messages (local development)
+		return NewLogMailer(cfg.From, cfg.OutputDir), nil
+
+	case "smtp":
+		return NewSMTPMailer(cfg)
+
+	default:
+		return nil, fmt.Errorf("unsupported mailer driver: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"context"
+)
+
+// Mailer defines the interface for the transports of transactional emails
+// (email verification, password reset)
+type Mailer interface {
+	// Send delivers a single message; From comes from the mailer configuration
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is an email with a plain text and an HTML alternative
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Config holds the mailer configuration
+type Config struct {
+	Driver string // log, smtp
+	From   string
+
+	// Log: also write every message as an .eml file in this directory (empty = log only)
+	OutputDir string
+
+	// SMTP
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
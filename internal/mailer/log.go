@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogMailer implements Mailer for local development: messages are logged instead of sent
+// and, when an output directory is configured, written as .eml files that mail clients can open
+type LogMailer struct {
+	from      string
+	outputDir string
+}
+
+// NewLogMailer creates a new log mailer
+func NewLogMailer(from, outputDir string) *LogMailer {
+	return &LogMailer{
+		from:      from,
+		outputDir: outputDir,
+	}
+}
+
+// Send logs the message (the text body carries the links) and writes the .eml file
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+
+	if m.outputDir == "" {
+		return nil
+	}
+
+	raw, err := buildMIME(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000"), sanitizeFileName(msg.To))
+	if err := os.WriteFile(filepath.Join(m.outputDir, name), raw, 0644); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
+
+// sanitizeFileName keeps only characters that are safe in a file name
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPMailer implements Mailer over SMTP (STARTTLS is used when the server offers it)
+type SMTPMailer struct {
+	from string
+	addr string
+	host string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a new SMTP mailer
+func NewSMTPMailer(cfg *Config) (*SMTPMailer, error) {
+	if cfg.SMTPHost == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if cfg.From == "" {
+		return nil, fmt.Errorf("mailer from address is required")
+	}
+
+	port := cfg.SMTPPort
+	if port == "" {
+		port = "587"
+	}
+
+	var auth smtp.Auth
+	if cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+
+	return &SMTPMailer{
+		from: cfg.From,
+		addr: net.JoinHostPort(cfg.SMTPHost, port),
+		host: cfg.SMTPHost,
+		auth: auth,
+	}, nil
+}
+
+// Send delivers the message through the SMTP server
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("invalid recipient address")
+	}
+
+	raw, err := buildMIME(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	// net/smtp has no context support: run the delivery and give up waiting when ctx ends
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, envelopeAddress(m.from), []string{msg.To}, raw)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send mail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to send mail: %w", ctx.Err())
+	}
+}
+
+// envelopeAddress extracts the bare address from "Name <address>"
+func envelopeAddress(from string) string {
+	if start := strings.LastIndex(from, "<"); start >= 0 {
+		if end := strings.LastIndex(from, ">"); end > start {
+			return from[start+1 : end]
+		}
+	}
+	return from
+}
@@ -0,0 +1,117 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmlTemplate "html/template"
+	"regexp"
+	textTemplate "text/template"
+)
+
+// Default colors of the tenant "interface" setting (migrations/tenant/001_initial_schema.up.sql)
+const (
+	DefaultPrimaryColor   = "#003388"
+	DefaultSecondaryColor = "#DDDDDD"
+)
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Branding is the visual identity applied to an email: the platform's for admins and users
+// without a tenant, or the tenant's (profile + "interface" setting)
+type Branding struct {
+	CompanyName    string
+	LogoURL        string
+	PrimaryColor   string
+	SecondaryColor string
+}
+
+// DefaultBranding returns the platform branding with the default interface colors
+func DefaultBranding(companyName string) Branding {
+	return Branding{
+		CompanyName:    companyName,
+		PrimaryColor:   DefaultPrimaryColor,
+		SecondaryColor: DefaultSecondaryColor,
+	}
+}
+
+// normalized replaces values that cannot be used safely in the templates
+func (b Branding) normalized() Branding {
+	if !hexColor.MatchString(b.PrimaryColor) {
+		b.PrimaryColor = DefaultPrimaryColor
+	}
+	if !hexColor.MatchString(b.SecondaryColor) {
+		b.SecondaryColor = DefaultSecondaryColor
+	}
+	return b
+}
+
+// ActionEmail is a message with a single call to action (a link with a one-time token)
+type ActionEmail struct {
+	Branding    Branding
+	Subject     string
+	Greeting    string
+	Intro       string
+	ButtonLabel string
+	Link        string
+	// Shown below the button (expiration, what to do if the email was not expected)
+	Footer string
+}
+
+var actionHTML = htmlTemplate.Must(htmlTemplate.New("action").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Subject}}</title></head>
+<body style="margin:0;padding:0;background-color:{{.Branding.SecondaryColor}};font-family:Arial,Helvetica,sans-serif;">
+  <table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="padding:24px 0;">
+    <tr><td align="center">
+      <table role="presentation" width="560" cellpadding="0" cellspacing="0" style="background-color:#ffffff;border-radius:8px;overflow:hidden;">
+        <tr><td style="background-color:{{.Branding.PrimaryColor}};padding:20px 32px;color:#ffffff;font-size:20px;font-weight:bold;">
+          {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="{{.Branding.CompanyName}}" height="40" style="display:block;border:0;">{{else}}{{.Branding.CompanyName}}{{end}}
+        </td></tr>
+        <tr><td style="padding:32px;color:#333333;font-size:15px;line-height:22px;">
+          <p style="margin:0 0 16px;">{{.Greeting}}</p>
+          <p style="margin:0 0 24px;">{{.Intro}}</p>
+          <p style="margin:0 0 24px;text-align:center;">
+            <a href="{{.Link}}" style="display:inline-block;background-color:{{.Branding.PrimaryColor}};color:#ffffff;text-decoration:none;padding:12px 24px;border-radius:6px;font-weight:bold;">{{.ButtonLabel}}</a>
+          </p>
+          <p style="margin:0 0 8px;font-size:13px;color:#666666;">Se o botão não funcionar, copie e cole este endereço no navegador:</p>
+          <p style="margin:0 0 24px;font-size:13px;word-break:break-all;"><a href="{{.Link}}" style="color:{{.Branding.PrimaryColor}};">{{.Link}}</a></p>
+          <p style="margin:0;font-size:13px;color:#666666;">{{.Footer}}</p>
+        </td></tr>
+      </table>
+      <p style="font-size:12px;color:#666666;">{{.Branding.CompanyName}}</p>
+    </td></tr>
+  </table>
+</body>
+</html>
+`))
+
+var actionText = textTemplate.Must(textTemplate.New("action").Parse(`{{.Greeting}}
+
+{{.Intro}}
+
+{{.ButtonLabel}}: {{.Link}}
+
+{{.Footer}}
+
+--
+{{.Branding.CompanyName}}
+`))
+
+// Render builds the message (without recipient) from the HTML and plain text templates
+func (e ActionEmail) Render() (Message, error) {
+	e.Branding = e.Branding.normalized()
+
+	var html, text bytes.Buffer
+	if err := actionHTML.Execute(&html, e); err != nil {
+		return Message{}, fmt.Errorf("failed to render email: %w", err)
+	}
+	if err := actionText.Execute(&text, e); err != nil {
+		return Message{}, fmt.Errorf("failed to render email: %w", err)
+	}
+
+	return Message{
+		Subject: e.Subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
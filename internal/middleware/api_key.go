@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// APIKeyRole is the user_role of requests made with a tenant API key: it never bypasses
+// permission checks, the key only has the permissions it was created with
+const APIKeyRole = "api_key"
+
+// authenticateAPIKey validates a tenant API key (hash, revocation, expiry and allowed IPs), records
+// its use and injects it into the context. TenantMiddleware binds it to its tenant.
+func authenticateAPIKey(c *gin.Context, apiKeys *adminRepo.APIKeyRepository, rawKey string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	key, err := apiKeys.GetAPIKeyByHash(ctx, utils.HashToken(rawKey))
+	if err != nil {
+		log.Printf("Error checking API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify api key"})
+		c.Abort()
+		return
+	}
+
+	now := time.Now()
+	if key == nil || !key.Active(now) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid, revoked or expired api key"})
+		c.Abort()
+		return
+	}
+
+	if !key.AllowsIP(c.ClientIP()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "api key not allowed from this IP address"})
+		c.Abort()
+		return
+	}
+
+	// Every request made with the key updates its last use
+	if err := apiKeys.TouchAPIKey(ctx, key.ID, c.ClientIP(), now); err != nil {
+		log.Printf("Failed to record API key use: %v", err)
+	}
+
+	c.Set("api_key", key)
+	c.Set("api_key_id", key.ID)
+	c.Set("api_type", "tenant")
+
+	c.Next()
+}
+
+// apiKeyFromContext returns the API key of the request, when it was authenticated with one
+func apiKeyFromContext(c *gin.Context) (*admin.TenantAPIKey, bool) {
+	value, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
+	}
+	key, ok := value.(*admin.TenantAPIKey)
+	return key, ok
+}
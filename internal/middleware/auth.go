@@ -1,17 +1,21 @@
 package middleware
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/cache"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
 	"github.com/saas-multi-database-api/internal/utils"
 )
 
-// AuthMiddleware validates JWT token and injects user information into context
-// Deprecated: Use AdminAuthMiddleware or TenantAuthMiddleware instead
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// AdminAuthMiddleware validates JWT token for Admin API (Control Plane)
+// Uses separate signing keys for security isolation and rejects tokens in the Redis denylist
+func AdminAuthMiddleware(keys *utils.JWTKeySet, redisClient *cache.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -30,46 +34,17 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 		tokenString := parts[1]
 
-		// Validate token
-		claims, err := utils.ValidateJWT(tokenString, cfg)
+		// Validate token with the Admin API key of its kid
+		claims, err := utils.ValidateAdminJWT(c.Request.Context(), tokenString, keys)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		// Inject user information into context (como UUID)
-		c.Set("user_id", claims.UserID)
-
-		c.Next()
-	}
-}
-
-// AdminAuthMiddleware validates JWT token for Admin API (Control Plane)
-// Uses separate JWT secret for security isolation
-func AdminAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
-			c.Abort()
-			return
-		}
-
-		// Extract token from "Bearer <token>" format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization format"})
-			c.Abort()
-			return
-		}
-
-		tokenString := parts[1]
-
-		// Validate token with Admin JWT secret
-		claims, err := utils.ValidateAdminJWT(tokenString, cfg)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		// Reject tokens revoked by logout or logout-all
+		if tokenRevoked(c, redisClient, "admin", claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
 			c.Abort()
 			return
 		}
@@ -77,14 +52,17 @@ func AdminAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		// Inject user information into context
 		c.Set("user_id", claims.UserID)
 		c.Set("api_type", "admin")
+		setTokenClaims(c, claims)
 
 		c.Next()
 	}
 }
 
 // TenantAuthMiddleware validates JWT token for Tenant API (Data Plane)
-// Uses separate JWT secret for security isolation
-func TenantAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// Uses separate signing keys for security isolation and rejects tokens in the Redis denylist.
+// When apiKeys is set, tenant API keys ("Bearer tk_...") are accepted as an alternative to a user
+// JWT; routes that act on the user (nil apiKeys) only accept JWTs.
+func TenantAuthMiddleware(keys *utils.JWTKeySet, redisClient *cache.Client, apiKeys *adminRepo.APIKeyRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -103,18 +81,71 @@ func TenantAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 		tokenString := parts[1]
 
-		// Validate token with Tenant JWT secret
-		claims, err := utils.ValidateTenantJWT(tokenString, cfg)
+		// Machine-to-machine access with a tenant API key
+		if utils.IsAPIKey(tokenString) {
+			if apiKeys == nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "api keys are only accepted on tenant routes"})
+				c.Abort()
+				return
+			}
+			authenticateAPIKey(c, apiKeys, tokenString)
+			return
+		}
+
+		// Validate token with the Tenant API key of its kid
+		claims, err := utils.ValidateTenantJWT(c.Request.Context(), tokenString, keys)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			c.Abort()
 			return
 		}
 
+		// Reject tokens revoked by logout or logout-all
+		if tokenRevoked(c, redisClient, "tenant", claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+			c.Abort()
+			return
+		}
+
 		// Inject user information into context
 		c.Set("user_id", claims.UserID)
 		c.Set("api_type", "tenant")
+		setTokenClaims(c, claims)
 
 		c.Next()
 	}
 }
+
+// tokenRevoked checks the jti denylist and the logout-all cutoff of the user.
+// Redis errors let the token through: access tokens are short-lived.
+func tokenRevoked(c *gin.Context, redisClient *cache.Client, apiType string, claims *utils.Claims) bool {
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	revoked, err := redisClient.IsAccessTokenRevoked(ctx, claims.ID, cache.TokenSubject(apiType, claims.UserID.String()), issuedAt)
+	if err != nil {
+		log.Printf("Redis error checking token denylist: %v", err)
+		return false
+	}
+	return revoked
+}
+
+// setTokenClaims exposes the jti and expiration of the access token to the logout handlers, and the
+// optional claims (second factor, SSO, impersonation actor) to the middlewares
+func setTokenClaims(c *gin.Context, claims *utils.Claims) {
+	c.Set("token_id", claims.ID)
+	c.Set("token_mfa", claims.MFA)
+	c.Set("token_sso", claims.SSO)
+	c.Set("token_sso_mfa", claims.SSOMFA)
+	if claims.Act != nil {
+		c.Set("token_actor", claims.Act)
+	}
+	if claims.ExpiresAt != nil {
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+	}
+}
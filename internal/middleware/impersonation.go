@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// impersonationActor returns the administrator acting on behalf of the user (nil for regular sessions)
+func impersonationActor(c *gin.Context) *utils.Actor {
+	actor, _ := c.Get("token_actor")
+	act, _ := actor.(*utils.Actor)
+	return act
+}
+
+// ImpersonationAudit records every request made with an impersonation token (including rejected ones)
+// and rejects the writes of read-only impersonations. Must run right after TenantAuthMiddleware.
+func ImpersonationAudit(repo *adminRepo.ImpersonationRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := impersonationActor(c)
+		if actor == nil {
+			c.Next()
+			return
+		}
+
+		sessionID, _ := uuid.Parse(c.GetString("token_id"))
+		defer func() {
+			// The audit entry must be written even when the client goes away
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if err := repo.RecordRequest(ctx, sessionID, c.Request.Method, c.Request.URL.RequestURI(),
+				c.Writer.Status(), c.ClientIP(), c.Request.UserAgent()); err != nil {
+				log.Printf("Error recording impersonation request of session %s: %v", sessionID, err)
+			}
+		}()
+
+		if actor.ReadOnly && !isReadRequest(c) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "this impersonation session is read-only",
+				"code":  "impersonation_read_only",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RejectImpersonation blocks routes that act on the account itself (credentials, sessions, tenant
+// switch) for impersonation tokens, even read-write ones
+func RejectImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if impersonationActor(c) != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "not available in an impersonation session",
+				"code":  "impersonation_forbidden",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
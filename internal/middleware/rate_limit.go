@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/models/admin"
+)
+
+// Rate limit buckets (plans and tenant overrides define read, write and upload)
+const (
+	RateLimitRead   = "read"
+	RateLimitWrite  = "write"
+	RateLimitUpload = "upload"
+	RateLimitAuth   = "auth"
+)
+
+// Fixed window of the rate limit counters
+const rateLimitWindow = time.Minute
+
+// TenantRateLimit limits tenant-scoped requests per tenant and user or API key (client IP on public routes).
+// Must run after TenantMiddleware or TenantHostMiddleware, which inject the plan limits (or the
+// admin override of the tenant) as "rate_limits".
+func TenantRateLimit(cfg *config.Config, redisClient *cache.Client) gin.HandlerFunc {
+	defaults := admin.RateLimits{
+		Read:   &cfg.Tenants.RateLimitReadPerMinute,
+		Write:  &cfg.Tenants.RateLimitWritePerMinute,
+		Upload: &cfg.Tenants.RateLimitUploadPerMinute,
+	}
+
+	return func(c *gin.Context) {
+		limits := defaults
+		if value, exists := c.Get("rate_limits"); exists {
+			limits = value.(admin.RateLimits).Merge(defaults)
+		}
+
+		bucket := requestBucket(c)
+		limit := *limits.Read
+		switch bucket {
+		case RateLimitWrite:
+			limit = *limits.Write
+		case RateLimitUpload:
+			limit = *limits.Upload
+		}
+
+		subject := "ip:" + c.ClientIP()
+		if userID, exists := c.Get("user_id"); exists {
+			subject = fmt.Sprintf("user:%v", userID)
+		} else if keyID, exists := c.Get("api_key_id"); exists {
+			subject = fmt.Sprintf("api_key:%v", keyID)
+		}
+
+		key := fmt.Sprintf("ratelimit:%s:%s:%s", bucket, c.GetString("tenant_id"), subject)
+		if !allowRequest(c, redisClient, key, limit) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthRateLimit limits login and registration requests per client IP (TENANT_RATE_LIMIT_AUTH_PER_MINUTE)
+func AuthRateLimit(cfg *config.Config, redisClient *cache.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := fmt.Sprintf("ratelimit:%s:ip:%s", RateLimitAuth, c.ClientIP())
+		if !allowRequest(c, redisClient, key, cfg.Tenants.RateLimitAuthPerMinute) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestBucket classifies the request: reads, multipart uploads and other writes
+func requestBucket(c *gin.Context) string {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return RateLimitRead
+	}
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		return RateLimitUpload
+	}
+	return RateLimitWrite
+}
+
+// allowRequest counts the request in the current window, sets the RateLimit-* headers and aborts
+// with 429 when the limit is exceeded. Redis errors let the request through.
+func allowRequest(c *gin.Context, redisClient *cache.Client, key string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	windowStart := now.Truncate(rateLimitWindow)
+	reset := int(math.Ceil(windowStart.Add(rateLimitWindow).Sub(now).Seconds()))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Second)
+	defer cancel()
+
+	count, err := redisClient.IncrWindow(ctx, fmt.Sprintf("%s:%d", key, windowStart.Unix()), rateLimitWindow)
+	if err != nil {
+		log.Printf("Rate limit unavailable: %v", err)
+		return true
+	}
+
+	remaining := int64(limit) - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Header("RateLimit-Limit", strconv.Itoa(limit))
+	c.Header("RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+	c.Header("RateLimit-Reset", strconv.Itoa(reset))
+
+	if count > int64(limit) {
+		c.Header("Retry-After", strconv.Itoa(reset))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded", "retry_after": reset})
+		c.Abort()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+)
+
+// RequireSysPermission only lets through administrators whose system roles grant the permission (slug).
+// Must run after AdminAuthMiddleware.
+func RequireSysPermission(sysUserRepo *adminRepo.SysUserRepository, slug string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sysUserID, _ := c.Get("user_id")
+		id, ok := sysUserID.(uuid.UUID)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Abort()
+			return
+		}
+
+		allowed, err := sysUserRepo.HasSysPermission(c.Request.Context(), id, slug)
+		if err != nil {
+			log.Printf("Error checking system permission %s of %s: %v", slug, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("system permission '%s' required", slug)})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,14 +10,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/saas-multi-database-api/internal/cache"
 	"github.com/saas-multi-database-api/internal/database"
-	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
 )
 
 // TenantMiddleware resolves tenant from URL code and injects context
-func TenantMiddleware(dbManager *database.Manager, redisClient *cache.Client, tenantRepo *adminRepo.TenantRepository) gin.HandlerFunc {
+func TenantMiddleware(dbManager *database.Manager, redisClient *cache.Client, tenantContexts *cache.TenantContextCache) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract url_code from route parameter
 		urlCode := c.Param("url_code")
@@ -26,141 +26,178 @@ func TenantMiddleware(dbManager *database.Manager, redisClient *cache.Client, te
 			return
 		}
 
-		// Get user ID from context (set by AuthMiddleware)
-		userIDValue, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
-			c.Abort()
-			return
-		}
+		// Requests made with a tenant API key have no user (set by TenantAuthMiddleware)
+		apiKey, isAPIKey := apiKeyFromContext(c)
 
-		// userID is already uuid.UUID from JWT claims
-		userID, ok := userIDValue.(uuid.UUID)
-		if !ok {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id format"})
-			return
+		// Subject of the request: the user, or the API key
+		var userID uuid.UUID
+		var subject string
+		if isAPIKey {
+			subject = "api_key:" + apiKey.ID.String()
+		} else {
+			// Get user ID from context (set by AuthMiddleware)
+			userIDValue, exists := c.Get("user_id")
+			if !exists {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+				c.Abort()
+				return
+			}
+
+			// userID is already uuid.UUID from JWT claims
+			var ok bool
+			userID, ok = userIDValue.(uuid.UUID)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id format"})
+				return
+			}
+			subject = userID.String()
 		}
 
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 		defer cancel()
 
-		// Step 1: Try to get db_code from Redis cache
-		dbCode, err := redisClient.GetDBCode(ctx, urlCode)
-		if err != nil && err != redis.Nil {
-			log.Printf("Redis error: %v", err)
+		// Step 1: Resolve tenant (in-process cache, then Redis, then Master DB)
+		tenant, err := tenantContexts.GetTenant(ctx, urlCode)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			c.Abort()
+			return
+		}
+		dbCode := tenant.DBCode
+
+		// Step 2: Verify tenant is active
+		if tenant.Status != "active" {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("tenant is %s", tenant.Status)})
+			c.Abort()
+			return
 		}
 
-		// Step 2: If not in cache, query from database
-		var tenant *adminRepo.Tenant
-		if dbCode == "" {
-			// Query tenant from Master DB using the repository
-			tenantModel, err := tenantRepo.GetTenantByURLCode(ctx, urlCode)
-			if err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		// Step 3: Verify user has access to this tenant and get role and permissions
+		// (an API key only accesses its own tenant, with the permissions it was created with)
+		var member *cache.MemberContext
+		if isAPIKey {
+			if apiKey.TenantID != tenant.ID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "api key does not belong to this tenant"})
 				c.Abort()
 				return
 			}
-
-			tenant = &adminRepo.Tenant{
-				ID:     tenantModel.ID,
-				DBCode: tenantModel.DBCode,
-				Status: string(tenantModel.Status),
+			member = &cache.MemberContext{Role: APIKeyRole, Permissions: apiKey.Permissions}
+		} else {
+			// Impersonation tokens only access the tenant they were issued for
+			actor := impersonationActor(c)
+			if actor != nil && actor.TenantID != tenant.ID.String() {
+				c.JSON(http.StatusForbidden, gin.H{"error": "impersonation session does not belong to this tenant"})
+				c.Abort()
+				return
 			}
 
-			dbCode = tenantModel.DBCode.String()
-
-			// Cache the db_code for future requests (24 hour expiration)
-			if err := redisClient.SetDBCode(ctx, urlCode, dbCode, 24*time.Hour); err != nil {
-				log.Printf("Failed to cache db_code: %v", err)
-			}
-		} else {
-			// If we got db_code from cache, we still need tenant details
-			tenantModel, err := tenantRepo.GetTenantByURLCode(ctx, urlCode)
+			member, err = tenantContexts.GetMember(ctx, tenant.ID, userID)
 			if err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+				log.Printf("Error checking user access: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify access"})
 				c.Abort()
 				return
 			}
 
-			tenant = &adminRepo.Tenant{
-				ID:     tenantModel.ID,
-				DBCode: tenantModel.DBCode,
-				Status: string(tenantModel.Status),
+			if member == nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "access denied to this tenant"})
+				c.Abort()
+				return
 			}
-		}
-
-		// Step 3: Verify tenant is active
-		if tenant.Status != "active" {
-			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("tenant is %s", tenant.Status)})
-			c.Abort()
-			return
-		}
-
-		// Step 4: Verify user has access to this tenant
-		hasAccess, err := tenantRepo.CheckUserAccess(ctx, userID, tenant.ID)
-		if err != nil {
-			log.Printf("Error checking user access: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify access"})
-			c.Abort()
-			return
-		}
 
-		if !hasAccess {
-			c.JSON(http.StatusForbidden, gin.H{"error": "access denied to this tenant"})
-			c.Abort()
-			return
-		}
+			// The second factor done at the IdP only counts on the tenant whose SSO opened the session
+			if c.GetBool("token_sso_mfa") && c.GetString("token_sso") == tenant.ID.String() {
+				c.Set("token_mfa", true)
+			}
 
-		// Step 5: Get tenant features from plan
-		features, err := tenantRepo.GetTenantFeatures(ctx, tenant.ID)
-		if err != nil {
-			log.Printf("Error getting tenant features: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get tenant features"})
-			c.Abort()
-			return
-		}
+			// Step 3.5: Tenants that require 2FA only accept sessions opened with the second factor
+			if tenant.RequireTwoFactor && !c.GetBool("token_mfa") {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "two-factor authentication required by this tenant",
+					"code":  "two_factor_required",
+				})
+				c.Abort()
+				return
+			}
 
-		// Step 6: Get user permissions for this tenant
-		permissions, err := tenantRepo.GetUserPermissions(ctx, userID, tenant.ID)
-		if err != nil {
-			log.Printf("Error getting user permissions: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user permissions"})
-			c.Abort()
-			return
+			// Step 3.6: SSO-only tenants only accept sessions opened by their OIDC provider
+			// (the owner keeps password access to fix a broken IdP configuration; impersonation
+			// sessions were authenticated by the Admin API)
+			if tenant.SSOOnly && member.Role != "owner" && actor == nil && c.GetString("token_sso") != tenant.ID.String() {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "this tenant requires single sign-on",
+					"code":  "sso_required",
+				})
+				c.Abort()
+				return
+			}
 		}
 
-		// Step 6.5: Get user role for this tenant
-		userRole, err := tenantRepo.GetUserRole(ctx, userID, tenant.ID)
+		// Step 4: Get or create tenant database pool
+		tenantPool, err := dbManager.GetTenantPool(ctx, dbCode)
 		if err != nil {
-			log.Printf("Error getting user role: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user role"})
-			c.Abort()
+			abortTenantPoolError(c, err)
 			return
 		}
 
-		// Step 7: Get or create tenant database pool
-		tenantPool, err := dbManager.GetTenantPool(ctx, dbCode)
-		if err != nil {
-			log.Printf("Error getting tenant pool: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to tenant database"})
-			c.Abort()
-			return
+		// Step 4.5: Read pool (replica when available, primary after a recent write of the same user)
+		readPool := tenantPool
+		if isReadRequest(c) {
+			readPool = resolveReadPool(ctx, dbManager, redisClient, dbCode, subject, tenantPool)
 		}
 
-		// Step 8: Inject data into context
+		// Step 5: Inject data into context
 		c.Set("tenant_id", tenant.ID.String())
 		c.Set("tenant_uuid", tenant.ID.String())
 		c.Set("tenant_db_code", dbCode)
 		c.Set("tenant_pool", tenantPool)
-		c.Set("features", features)
-		c.Set("permissions", permissions)
-		c.Set("user_role", userRole)
+		c.Set("tenant_read_pool", readPool)
+		c.Set("features", tenant.Features)
+		c.Set("rate_limits", tenant.RateLimits)
+		c.Set("permissions", member.Permissions)
+		c.Set("user_role", member.Role)
 
 		log.Printf("Tenant resolved: %s (DB: %s) | User: %s | Role: %s | Features: %v | Permissions: %v",
-			urlCode, dbCode, userID, userRole, features, permissions)
+			urlCode, dbCode, subject, member.Role, tenant.Features, member.Permissions)
 
 		c.Next()
+
+		// Successful writes send the user's next reads to the primary until the replica catches up
+		if !isReadRequest(c) && c.Writer.Status() < http.StatusBadRequest && dbManager.TenantHasReplica(dbCode) {
+			markCtx, markCancel := context.WithTimeout(context.Background(), time.Second)
+			defer markCancel()
+			if err := redisClient.MarkRecentWrite(markCtx, dbCode, subject, dbManager.ReadYourWritesWindow()); err != nil {
+				log.Printf("Failed to mark recent write: %v", err)
+			}
+		}
+	}
+}
+
+// isReadRequest reports whether the request only reads tenant data
+func isReadRequest(c *gin.Context) bool {
+	return c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+}
+
+// resolveReadPool returns the replica-aware read pool, falling back to the primary pool
+func resolveReadPool(ctx context.Context, dbManager *database.Manager, redisClient *cache.Client, dbCode, userID string, primary *pgxpool.Pool) *pgxpool.Pool {
+	readPool, err := dbManager.GetTenantReadPool(ctx, dbCode)
+	if err != nil {
+		log.Printf("Error getting tenant read pool: %v", err)
+		return primary
+	}
+	if readPool == primary {
+		return primary
+	}
+
+	recentWrite, err := redisClient.HasRecentWrite(ctx, dbCode, userID)
+	if err != nil {
+		log.Printf("Redis error: %v", err)
+		return primary
+	}
+	if recentWrite {
+		return primary
 	}
+	return readPool
 }
 
 // RequireFeature middleware checks if a specific feature is enabled for the tenant
@@ -192,6 +229,19 @@ func RequireFeature(featureSlug string) gin.HandlerFunc {
 	}
 }
 
+// RequireOwner middleware restricts a route to the tenant owner (API keys are never owners)
+func RequireOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("user_role") != "owner" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the tenant owner can perform this action"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // RequirePermission middleware checks if user has a specific permission
 // Owners bypass permission checks automatically
 func RequirePermission(permissionSlug string) gin.HandlerFunc {
@@ -272,3 +322,43 @@ func RequireAnyPermission(permissionSlugs ...string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireAPIKeyPermission checks a permission only on requests made with a tenant API key.
+// Members keep the access they have to the route (e.g. every member reads the settings).
+func RequireAPIKeyPermission(permissionSlug string) gin.HandlerFunc {
+	check := RequirePermission(permissionSlug)
+	return func(c *gin.Context) {
+		if c.GetString("user_role") != APIKeyRole {
+			c.Next()
+			return
+		}
+		check(c)
+	}
+}
+
+// RequireAnyAPIKeyPermission checks that an API key has at least one of the permissions.
+// Members keep the access they have to the route.
+func RequireAnyAPIKeyPermission(permissionSlugs ...string) gin.HandlerFunc {
+	check := RequireAnyPermission(permissionSlugs...)
+	return func(c *gin.Context) {
+		if c.GetString("user_role") != APIKeyRole {
+			c.Next()
+			return
+		}
+		check(c)
+	}
+}
+
+// abortTenantPoolError responds to a failure to get the tenant pool. An exhausted connection budget
+// is temporary (idle pools are evicted), so the client is told to retry.
+func abortTenantPoolError(c *gin.Context, err error) {
+	log.Printf("Error getting tenant pool: %v", err)
+	if errors.Is(err, database.ErrTenantPoolBudgetExhausted) {
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "tenant database temporarily unavailable, try again"})
+		c.Abort()
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to connect to tenant database"})
+	c.Abort()
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// TenantHostMiddleware resolves the tenant from the request Host ({subdomain}.{base domain} or a
+// verified custom domain) for public storefront routes. No user is authenticated, so there are
+// no membership, role or permission checks.
+func TenantHostMiddleware(cfg *config.Config, dbManager *database.Manager, redisClient *cache.Client, tenantRepo *adminRepo.TenantRepository, tenantContexts *cache.TenantContextCache) gin.HandlerFunc {
+	baseSuffix := "." + utils.NormalizeHost(cfg.Tenants.BaseDomain)
+
+	return func(c *gin.Context) {
+		host := utils.NormalizeHost(c.Request.Host)
+		if host == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "host header required"})
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		// Step 1: Try to get url_code for this host from Redis cache
+		urlCode, err := redisClient.GetHostURLCode(ctx, host)
+		if err != nil && err != redis.Nil {
+			log.Printf("Redis error: %v", err)
+		}
+
+		// Step 2: Resolve tenant with the same cache used by TenantMiddleware
+		var tenant *cache.TenantContext
+		if urlCode != "" {
+			tenant, err = tenantContexts.GetTenant(ctx, urlCode)
+			if err != nil {
+				// Stale mapping (tenant purged, subdomain or domain taken by another tenant): resolve again
+				tenant = nil
+				if err := redisClient.InvalidateHostCache(ctx, host); err != nil {
+					log.Printf("Failed to invalidate host cache: %v", err)
+				}
+			}
+		}
+
+		// Step 3: Not cached: find the url_code by subdomain or custom domain
+		if tenant == nil {
+			model, err := lookupHostTenant(ctx, tenantRepo, host, baseSuffix)
+			if err == nil {
+				tenant, err = tenantContexts.GetTenant(ctx, model.URLCode)
+			}
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+				c.Abort()
+				return
+			}
+
+			// Cache the url_code for future requests (24 hour expiration)
+			if err := redisClient.SetHostURLCode(ctx, host, tenant.URLCode, 24*time.Hour); err != nil {
+				log.Printf("Failed to cache host url_code: %v", err)
+			}
+		}
+		dbCode := tenant.DBCode
+
+		// Step 4: Verify tenant is active
+		if tenant.Status != "active" {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("tenant is %s", tenant.Status)})
+			c.Abort()
+			return
+		}
+
+		// Step 5: Get or create tenant database pool
+		tenantPool, err := dbManager.GetTenantPool(ctx, dbCode)
+		if err != nil {
+			abortTenantPoolError(c, err)
+			return
+		}
+
+		// Step 5.5: Storefront reads have no user, so no read-your-writes window applies
+		readPool, err := dbManager.GetTenantReadPool(ctx, dbCode)
+		if err != nil {
+			log.Printf("Error getting tenant read pool: %v", err)
+			readPool = tenantPool
+		}
+
+		// Step 6: Inject data into context
+		c.Set("tenant_id", tenant.ID.String())
+		c.Set("tenant_uuid", tenant.ID.String())
+		c.Set("tenant_db_code", dbCode)
+		c.Set("tenant_pool", tenantPool)
+		c.Set("tenant_read_pool", readPool)
+		c.Set("features", tenant.Features)
+		c.Set("rate_limits", tenant.RateLimits)
+
+		c.Next()
+	}
+}
+
+// lookupHostTenant finds the tenant of a host: {subdomain}.{base domain} or a verified custom domain
+func lookupHostTenant(ctx context.Context, tenantRepo *adminRepo.TenantRepository, host, baseSuffix string) (*admin.Tenant, error) {
+	if subdomain, ok := strings.CutSuffix(host, baseSuffix); ok && subdomain != "" && !strings.Contains(subdomain, ".") {
+		return tenantRepo.GetTenantBySubdomain(ctx, subdomain)
+	}
+	return tenantRepo.GetTenantByCustomDomain(ctx, host)
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saas-multi-database-api/internal/database"
+)
+
+func TestRequireAPIKeyPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		role        string
+		permissions []string
+		handler     gin.HandlerFunc
+		want        int
+	}{
+		{name: "member without the permission", role: "member", handler: RequireAPIKeyPermission("setg_m"), want: http.StatusOK},
+		{name: "owner", role: "owner", handler: RequireAPIKeyPermission("setg_m"), want: http.StatusOK},
+		{name: "api key with the permission", role: APIKeyRole, permissions: []string{"setg_m"}, handler: RequireAPIKeyPermission("setg_m"), want: http.StatusOK},
+		{name: "api key without the permission", role: APIKeyRole, permissions: []string{"prod_r"}, handler: RequireAPIKeyPermission("setg_m"), want: http.StatusForbidden},
+		{name: "any: member without the permissions", role: "member", handler: RequireAnyAPIKeyPermission("prod_r", "serv_r"), want: http.StatusOK},
+		{name: "any: api key with one of the permissions", role: APIKeyRole, permissions: []string{"serv_r"}, handler: RequireAnyAPIKeyPermission("prod_r", "serv_r"), want: http.StatusOK},
+		{name: "any: api key without the permissions", role: APIKeyRole, permissions: []string{"setg_m"}, handler: RequireAnyAPIKeyPermission("prod_r", "serv_r"), want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				c.Set("user_role", tt.role)
+				c.Set("permissions", append([]string{}, tt.permissions...))
+				c.Next()
+			}, tt.handler, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbortTenantPoolError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{
+			name:           "connection budget exhausted",
+			err:            fmt.Errorf("%w: 400 of 400 connections reserved", database.ErrTenantPoolBudgetExhausted),
+			wantStatus:     http.StatusServiceUnavailable,
+			wantRetryAfter: "5",
+		},
+		{name: "database unreachable", err: errors.New("failed to ping tenant db"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				abortTenantPoolError(c, tt.err)
+			}, func(c *gin.Context) {
+				t.Error("handler ran after the pool error")
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantAPIKey é uma chave de acesso de integrações (máquina a máquina) às rotas de um tenant.
+// Só o hash da chave é armazenado; a chave completa aparece uma única vez, na criação.
+type TenantAPIKey struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    uuid.UUID  `json:"tenant_id"`
+	Name        string     `json:"name"`
+	KeyPrefix   string     `json:"key_prefix"`  // Início da chave, para identificá-la nas listagens
+	Permissions []string   `json:"permissions"` // Slugs de permissão que a chave pode usar
+	AllowedIPs  []string   `json:"allowed_ips"` // CIDRs de origem aceitos (vazio = qualquer IP)
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP  *string    `json:"last_used_ip,omitempty"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Active informa se a chave não foi revogada nem expirou
+func (k *TenantAPIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// AllowsIP informa se a chave pode ser usada a partir do IP (sem restrição quando AllowedIPs está vazio)
+func (k *TenantAPIKey) AllowsIP(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, cidr := range k.AllowedIPs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			continue
+		}
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTenantAPIKeyAllowsIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowedIPs []string
+		ip         string
+		want       bool
+	}{
+		{name: "no allowlist", ip: "198.51.100.1", want: true},
+		{name: "single IP", allowedIPs: []string{"203.0.113.10/32"}, ip: "203.0.113.10", want: true},
+		{name: "other IP", allowedIPs: []string{"203.0.113.10/32"}, ip: "203.0.113.11", want: false},
+		{name: "inside the range", allowedIPs: []string{"203.0.113.0/24"}, ip: "203.0.113.200", want: true},
+		{name: "outside the range", allowedIPs: []string{"203.0.113.0/24"}, ip: "203.0.114.1", want: false},
+		{name: "any of the ranges", allowedIPs: []string{"10.0.0.0/8", "203.0.113.0/24"}, ip: "203.0.113.5", want: true},
+		{name: "IPv4-mapped IPv6", allowedIPs: []string{"203.0.113.0/24"}, ip: "::ffff:203.0.113.5", want: true},
+		{name: "IPv6 range", allowedIPs: []string{"2001:db8::/32"}, ip: "2001:db8:1::5", want: true},
+		{name: "IPv6 outside the range", allowedIPs: []string{"2001:db8::/32"}, ip: "2001:db9::5", want: false},
+		{name: "IPv4 against an IPv6 range", allowedIPs: []string{"::/0"}, ip: "203.0.113.5", want: false},
+		{name: "invalid entries are ignored", allowedIPs: []string{"invalid", "203.0.113.0/24"}, ip: "203.0.113.5", want: true},
+		{name: "only invalid entries", allowedIPs: []string{"invalid"}, ip: "203.0.113.5", want: false},
+		{name: "invalid client IP", allowedIPs: []string{"0.0.0.0/0"}, ip: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &TenantAPIKey{AllowedIPs: tt.allowedIPs}
+			if got := key.AllowsIP(tt.ip); got != tt.want {
+				t.Errorf("AllowsIP(%q) with %v = %v, want %v", tt.ip, tt.allowedIPs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantAPIKeyActive(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		revokedAt *time.Time
+		want      bool
+	}{
+		{name: "no expiration", want: true},
+		{name: "expires in the future", expiresAt: &future, want: true},
+		{name: "expired", expiresAt: &past, want: false},
+		{name: "expires now", expiresAt: &now, want: false},
+		{name: "revoked", revokedAt: &past, want: false},
+		{name: "revoked before expiring", expiresAt: &future, revokedAt: &past, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &TenantAPIKey{ExpiresAt: tt.expiresAt, RevokedAt: tt.revokedAt}
+			if got := key.Active(now); got != tt.want {
+				t.Errorf("Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saas-multi-database-api/internal/models/shared"
+)
+
+// TenantBackup representa um backup do database de um tenant (arquivo no storage em backups/{tenant_id}/)
+type TenantBackup struct {
+	ID            uuid.UUID        `json:"id"`
+	TenantID      uuid.UUID        `json:"tenant_id"`
+	Status        shared.JobStatus `json:"status"`
+	StoragePath   *string          `json:"storage_path,omitempty"`
+	SizeBytes     *int64           `json:"size_bytes,omitempty"`
+	SchemaVersion *int             `json:"schema_version,omitempty"`
+	Tables        json.RawMessage  `json:"tables,omitempty"`
+	MediaCount    *int             `json:"media_count,omitempty"`
+	Error         *string          `json:"error,omitempty"`
+	RequestedBy   *uuid.UUID       `json:"requested_by,omitempty"`
+	StartedAt     *time.Time       `json:"started_at,omitempty"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// TenantRestore representa a restauração de um backup no database do tenant
+type TenantRestore struct {
+	ID             uuid.UUID        `json:"id"`
+	TenantID       uuid.UUID        `json:"tenant_id"`
+	BackupID       uuid.UUID        `json:"backup_id"`
+	Mode           string           `json:"mode"` // existing, fresh
+	Status         shared.JobStatus `json:"status"`
+	PreviousStatus *string          `json:"previous_status,omitempty"`
+	Error          *string          `json:"error,omitempty"`
+	RequestedBy    *uuid.UUID       `json:"requested_by,omitempty"`
+	StartedAt      *time.Time       `json:"started_at,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saas-multi-database-api/internal/models/shared"
+)
+
+// DBCluster representa um cluster Postgres que hospeda databases de tenants
+type DBCluster struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Host     string    `json:"host"`
+	Port     int       `json:"port"`
+	PoolHost *string   `json:"pool_host,omitempty"`
+	PoolPort *int      `json:"pool_port,omitempty"`
+	// Réplica de leitura (ou o PgBouncer dela) usada pelas listagens das APIs
+	ReplicaHost *string              `json:"replica_host,omitempty"`
+	ReplicaPort *int                 `json:"replica_port,omitempty"`
+	AdminUser   *string              `json:"admin_user,omitempty"`
+	Region      *string              `json:"region,omitempty"`
+	PlanID      *uuid.UUID           `json:"plan_id,omitempty"` // Reservado para tenants deste plano
+	Dedicated   bool                 `json:"dedicated"`         // Só recebe tenants atribuídos explicitamente
+	MaxTenants  *int                 `json:"max_tenants,omitempty"`
+	Status      shared.ClusterStatus `json:"status"`
+	IsDefault   bool                 `json:"is_default"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+
+	// Senha do admin criptografada (nunca serializada)
+	AdminPasswordEncrypted *string `json:"-"`
+	// Preenchido nas listagens
+	TenantCount int `json:"tenant_count"`
+}
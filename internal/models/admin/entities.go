@@ -12,8 +12,42 @@ type Plan struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description,omitempty"`
 	Price       float64   `json:"price"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// Conexões de cada pool do database do tenant (nil = TENANT_POOL_MAX_CONNS)
+	MaxDBConnections *int `json:"max_db_connections,omitempty"`
+	// Membros do tenant, contando o owner e os convites pendentes (nil = sem limite)
+	MaxMembers *int      `json:"max_members,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// RateLimits são os limites de requisições por minuto de cada bucket da Tenant API
+// (nil = limite do plano ou, no plano, TENANT_RATE_LIMIT_*_PER_MINUTE)
+type RateLimits struct {
+	Read   *int `json:"read" binding:"omitempty,min=1"`
+	Write  *int `json:"write" binding:"omitempty,min=1"`
+	Upload *int `json:"upload" binding:"omitempty,min=1"`
+}
+
+// Merge retorna os limites definidos em l, completando os ausentes com fallback
+func (l RateLimits) Merge(fallback RateLimits) RateLimits {
+	if l.Read == nil {
+		l.Read = fallback.Read
+	}
+	if l.Write == nil {
+		l.Write = fallback.Write
+	}
+	if l.Upload == nil {
+		l.Upload = fallback.Upload
+	}
+	return l
+}
+
+// TenantRateLimits mostra os limites de um tenant: do plano, override do admin e o efetivo
+type TenantRateLimits struct {
+	TenantID  uuid.UUID      `json:"tenant_id"`
+	Plan      RateLimits     `json:"plan"`
+	Override  *RateLimits    `json:"override,omitempty"`
+	Effective map[string]int `json:"effective"` // 0 = sem limite
 }
 
 // Feature representa uma funcionalidade do sistema
@@ -30,12 +64,14 @@ type Feature struct {
 
 // Role representa uma role (pode ser de sistema ou de tenant)
 type Role struct {
-	ID        uuid.UUID  `json:"id"`
-	TenantID  *uuid.UUID `json:"tenant_id,omitempty"` // Null = role de sistema
-	Name      string     `json:"name"`
-	Slug      string     `json:"slug"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID          uuid.UUID  `json:"id"`
+	TenantID    *uuid.UUID `json:"tenant_id,omitempty"` // Null = role de sistema
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	Permissions []string   `json:"permissions"`
+	MemberCount int        `json:"member_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 // Permission representa uma permissão
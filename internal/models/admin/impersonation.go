@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonationSession é um acesso de um administrador (suporte) à Tenant API em nome de um usuário.
+// A sessão é um único access token de curta duração (ID = jti do token) limitado a um tenant.
+type ImpersonationSession struct {
+	ID           uuid.UUID  `json:"id"`
+	SysUserID    *uuid.UUID `json:"sys_user_id,omitempty"` // nil quando o administrador foi removido
+	SysUserEmail *string    `json:"sys_user_email,omitempty"`
+	UserID       uuid.UUID  `json:"user_id"`
+	UserEmail    string     `json:"user_email"`
+	TenantID     uuid.UUID  `json:"tenant_id"`
+	URLCode      string     `json:"url_code"`
+	Reason       string     `json:"reason"`
+	ReadOnly     bool       `json:"read_only"`
+	IPAddress    *string    `json:"ip_address,omitempty"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+	EndedBy      *uuid.UUID `json:"ended_by,omitempty"`
+	RequestCount int        `json:"request_count"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ImpersonationRequest é uma requisição feita na Tenant API com o token de uma sessão de impersonação
+type ImpersonationRequest struct {
+	ID        int64     `json:"id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	IPAddress *string   `json:"ip_address,omitempty"`
+	UserAgent *string   `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantInvitation é um convite para entrar no tenant com um papel. O token só vai no email.
+type TenantInvitation struct {
+	ID             uuid.UUID  `json:"id"`
+	TenantID       uuid.UUID  `json:"tenant_id"`
+	Email          string     `json:"email"`
+	RoleID         uuid.UUID  `json:"role_id"`
+	Role           string     `json:"role"`
+	RoleName       string     `json:"role_name"`
+	InvitedBy      *uuid.UUID `json:"invited_by,omitempty"`
+	InvitedByEmail *string    `json:"invited_by_email,omitempty"`
+	Status         string     `json:"status"` // pending, accepted, revoked ou expired
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// InvitationPreview é o que o convidado vê antes de aceitar. account_exists indica se ele entra com
+// a conta que já tem (login) ou cria uma (cadastro pelo convite).
+type InvitationPreview struct {
+	Email         string    `json:"email"`
+	TenantName    string    `json:"tenant_name"`
+	URLCode       string    `json:"url_code"`
+	RoleName      string    `json:"role_name"`
+	InviterName   string    `json:"inviter_name,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	AccountExists bool      `json:"account_exists"`
+}
@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OIDCGroupRole associa um grupo do IdP a um papel do tenant (slug)
+type OIDCGroupRole struct {
+	Group string `json:"group" binding:"required"`
+	Role  string `json:"role" binding:"required"`
+}
+
+// OIDCProvider é a configuração de SSO (OpenID Connect) de um tenant
+type OIDCProvider struct {
+	TenantID        uuid.UUID       `json:"tenant_id"`
+	Issuer          string          `json:"issuer"`
+	ClientID        string          `json:"client_id"`
+	ClientSecret    string          `json:"-"` // Cifrado no Master DB; nunca retornado
+	Scopes          []string        `json:"scopes"`
+	AllowedDomains  []string        `json:"allowed_domains"` // Domínios de email aceitos (vazio = qualquer)
+	GroupsClaim     string          `json:"groups_claim"`
+	GroupRoles      []OIDCGroupRole `json:"group_roles"` // Em ordem de prioridade
+	DefaultRole     string          `json:"default_role"`
+	AutoCreateUsers bool            `json:"auto_create_users"`
+	SSOOnly         bool            `json:"sso_only"`
+	Enabled         bool            `json:"enabled"`
+	RedirectURI     string          `json:"redirect_uri"` // A cadastrar no IdP
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
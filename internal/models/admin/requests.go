@@ -16,14 +16,18 @@ type AdminRegisterRequest struct {
 }
 
 type AdminLoginResponse struct {
-	Token   string `json:"token"`
-	SysUser struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Validade do token em segundos
+	SysUser      struct {
 		ID       uuid.UUID `json:"id"`
 		Email    string    `json:"email"`
 		FullName string    `json:"full_name"`
 	} `json:"sys_user"`
 	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions"`
+	// Entregues uma única vez quando o segundo fator é cadastrado durante o login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 type CreateSysUserRequest struct {
@@ -64,13 +68,19 @@ type CreatePlanRequest struct {
 	Description string   `json:"description"`
 	Price       float64  `json:"price" binding:"required,min=0"`
 	FeatureIDs  []string `json:"feature_ids"` // UUIDs das features
+	// Conexões de cada pool do database do tenant (omitido = TENANT_POOL_MAX_CONNS)
+	MaxDBConnections *int `json:"max_db_connections" binding:"omitempty,min=1,max=200"`
+	// Membros do tenant, contando o owner e os convites pendentes (omitido = sem limite)
+	MaxMembers *int `json:"max_members" binding:"omitempty,min=1"`
 }
 
 type UpdatePlanRequest struct {
-	Name        string   `json:"name" binding:"required"`
-	Description string   `json:"description"`
-	Price       float64  `json:"price" binding:"required,min=0"`
-	FeatureIDs  []string `json:"feature_ids"`
+	Name             string   `json:"name" binding:"required"`
+	Description      string   `json:"description"`
+	Price            float64  `json:"price" binding:"required,min=0"`
+	FeatureIDs       []string `json:"feature_ids"`
+	MaxDBConnections *int     `json:"max_db_connections" binding:"omitempty,min=1,max=200"`
+	MaxMembers       *int     `json:"max_members" binding:"omitempty,min=1"`
 }
 
 type PlanResponse struct {
@@ -79,8 +89,12 @@ type PlanResponse struct {
 	Description string    `json:"description,omitempty"`
 	Price       float64   `json:"price"`
 	Features    []Feature `json:"features"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// Conexões de cada pool do database do tenant (nil = padrão da API)
+	MaxDBConnections *int `json:"max_db_connections,omitempty"`
+	// Membros do tenant, contando o owner e os convites pendentes (nil = sem limite)
+	MaxMembers *int      `json:"max_members,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type PlanListResponse struct {
@@ -150,9 +164,164 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest revoga também a sessão do refresh token, quando informado
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// VerifyEmailRequest confirma o email com o token recebido no link
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest reenvia o link de verificação; url_code escolhe a identidade visual do tenant
+type ResendVerificationRequest struct {
+	URLCode string `json:"url_code"`
+}
+
+// ForgotPasswordRequest pede o link de redefinição de senha; url_code escolhe a identidade visual do tenant
+type ForgotPasswordRequest struct {
+	Email   string `json:"email" binding:"required,email"`
+	URLCode string `json:"url_code"`
+}
+
+// ResetPasswordRequest troca a senha com o token recebido no link
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// LoginTwoFactorRequest conclui o login com o segundo fator: code aceita o código do app ou um
+// código de recuperação
+type LoginTwoFactorRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// LoginTwoFactorSetupRequest inicia o cadastro do segundo fator exigido durante o login
+type LoginTwoFactorSetupRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// TwoFactorCodeRequest confirma uma operação do segundo fator com um código do app
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest desativa o segundo fator (senha + código do app ou de recuperação)
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorPolicyRequest torna o segundo fator obrigatório (ou opcional) para um papel ou tenant
+type TwoFactorPolicyRequest struct {
+	RequireTwoFactor *bool `json:"require_two_factor" binding:"required"`
+}
+
+// UnlockLoginRequest remove o bloqueio de login de uma conta (email) e/ou de um IP
+type UnlockLoginRequest struct {
+	SubjectType string `json:"subject_type" binding:"required,oneof=admin tenant"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	IPAddress   string `json:"ip_address" binding:"omitempty,ip"`
+}
+
+// CreateAPIKeyRequest cria uma chave de API do tenant. allowed_ips aceita IPs ou CIDRs (vazio = qualquer IP).
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name" binding:"required,max=100"`
+	Permissions []string   `json:"permissions" binding:"required,min=1,dive,required"`
+	AllowedIPs  []string   `json:"allowed_ips"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// SaveOIDCProviderRequest cria ou altera o SSO do tenant. client_secret é obrigatório na criação;
+// omitido na alteração, mantém o atual.
+type SaveOIDCProviderRequest struct {
+	Issuer          string          `json:"issuer" binding:"required,url"`
+	ClientID        string          `json:"client_id" binding:"required,max=255"`
+	ClientSecret    string          `json:"client_secret"`
+	Scopes          []string        `json:"scopes"`
+	AllowedDomains  []string        `json:"allowed_domains"`
+	GroupsClaim     string          `json:"groups_claim" binding:"max=100"`
+	GroupRoles      []OIDCGroupRole `json:"group_roles" binding:"dive"`
+	DefaultRole     string          `json:"default_role" binding:"max=100"`
+	AutoCreateUsers *bool           `json:"auto_create_users"`
+	SSOOnly         bool            `json:"sso_only"`
+	Enabled         *bool           `json:"enabled"`
+}
+
+// OIDCCallbackRequest conclui o login SSO com o code e o state que o IdP devolveu ao frontend
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// OIDCLinkRequest vincula a conta do IdP à conta autenticada com senha, com o link_token que o
+// callback do SSO devolveu (account_link_required)
+type OIDCLinkRequest struct {
+	LinkToken string `json:"link_token" binding:"required"`
+}
+
+// StartImpersonationRequest abre uma sessão de impersonação de um usuário em um tenant. Somente
+// leitura por padrão; duration_minutes é limitado por AUTH_IMPERSONATION_MINUTES.
+type StartImpersonationRequest struct {
+	UserID          uuid.UUID `json:"user_id" binding:"required"`
+	TenantID        uuid.UUID `json:"tenant_id" binding:"required"`
+	Reason          string    `json:"reason" binding:"required,max=500"`
+	ReadOnly        *bool     `json:"read_only"`
+	DurationMinutes int       `json:"duration_minutes" binding:"omitempty,min=1"`
+}
+
 // ===== Config Responses =====
 
 type TenantConfigResponse struct {
 	Features    []string `json:"features"`
 	Permissions []string `json:"permissions"`
 }
+
+// CreateTenantRoleRequest cria um papel do tenant. slug: letras minúsculas, números e "_"; não pode
+// repetir o slug de um papel global (owner, admin, member...).
+type CreateTenantRoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=255"`
+	Slug        string   `json:"slug" binding:"required,min=2,max=100"`
+	Permissions []string `json:"permissions" binding:"dive,required"`
+}
+
+// UpdateTenantRoleRequest altera o nome e substitui as permissões de um papel do tenant
+type UpdateTenantRoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=255"`
+	Permissions []string `json:"permissions" binding:"required,dive,required"`
+}
+
+// CreateInvitationRequest convida um email para o tenant com um papel (slug, global ou do tenant).
+// Um novo convite para o mesmo email substitui o pendente.
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+	Role  string `json:"role" binding:"required,max=100"`
+}
+
+// InvitationTokenRequest identifica o convite pelo token recebido no email
+type InvitationTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// InvitationSignupRequest cria a conta do convidado e aceita o convite (o email é o do convite)
+type InvitationSignupRequest struct {
+	Token    string `json:"token" binding:"required"`
+	FullName string `json:"full_name" binding:"required,max=255"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// UpdateMemberRoleRequest troca o papel de um membro (slug, global ou do tenant)
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,max=100"`
+}
+
+// TransferOwnershipRequest passa o tenant para outro membro; o owner atual fica com o papel admin
+type TransferOwnershipRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saas-multi-database-api/internal/models/shared"
+)
+
+// TenantSandboxClone representa a cópia de um tenant para um tenant sandbox (executada pelo worker)
+type TenantSandboxClone struct {
+	ID              uuid.UUID        `json:"id"`
+	SourceTenantID  uuid.UUID        `json:"source_tenant_id"`
+	SandboxTenantID *uuid.UUID       `json:"sandbox_tenant_id,omitempty"` // nil após o purge do sandbox
+	Status          shared.JobStatus `json:"status"`
+	SchemaVersion   *int             `json:"schema_version,omitempty"`
+	Tables          json.RawMessage  `json:"tables,omitempty"`
+	MediaCount      *int             `json:"media_count,omitempty"`
+	MissingMedia    *int             `json:"missing_media,omitempty"` // Referenciadas, mas ausentes no storage
+	Error           *string          `json:"error,omitempty"`
+	RequestedBy     *uuid.UUID       `json:"requested_by,omitempty"`
+	StartedAt       *time.Time       `json:"started_at,omitempty"`
+	CompletedAt     *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+
+	// Tenant sandbox criado (preenchido na criação)
+	Sandbox *Tenant `json:"sandbox,omitempty"`
+}
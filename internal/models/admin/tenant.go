@@ -17,8 +17,19 @@ type Tenant struct {
 	PlanID       uuid.UUID           `json:"plan_id"`
 	BillingCycle shared.BillingCycle `json:"billing_cycle"`
 	Status       shared.TenantStatus `json:"status"`
+	ClusterID    *uuid.UUID          `json:"cluster_id,omitempty"` // nil = cluster padrão
+	Region       *string             `json:"region,omitempty"`
 	CreatedAt    time.Time           `json:"created_at"`
 	UpdatedAt    time.Time           `json:"updated_at"`
+
+	// Exclusão agendada (tenant suspenso aguardando purge após o período de carência)
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+
+	// Sandbox: cópia de outro tenant para testes (fora da cobrança, removida após sandbox_expires_at)
+	IsSandbox        bool       `json:"is_sandbox"`
+	SandboxSourceID  *uuid.UUID `json:"sandbox_source_id,omitempty"`
+	SandboxExpiresAt *time.Time `json:"sandbox_expires_at,omitempty"`
 }
 
 // TenantProfile contém dados adicionais do tenant
@@ -27,17 +38,25 @@ type TenantProfile struct {
 	CompanyName    string                 `json:"company_name,omitempty"`
 	IsCompany      bool                   `json:"is_company"`
 	CustomDomain   string                 `json:"custom_domain,omitempty"`
+	About          string                 `json:"about,omitempty"`
 	LogoURL        string                 `json:"logo_url,omitempty"`
 	CustomSettings map[string]interface{} `json:"custom_settings,omitempty"`
 	CreatedAt      time.Time              `json:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at"`
 }
 
-// TenantMember representa a associação entre usuário e tenant
+// TenantMember representa a associação entre usuário e tenant, com o perfil e o papel do usuário
 type TenantMember struct {
-	UserID    uuid.UUID  `json:"user_id"`
-	TenantID  uuid.UUID  `json:"tenant_id"`
-	RoleID    *uuid.UUID `json:"role_id,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	UserID        uuid.UUID  `json:"user_id"`
+	TenantID      uuid.UUID  `json:"tenant_id"`
+	RoleID        *uuid.UUID `json:"role_id,omitempty"`
+	Role          string     `json:"role"` // Slug do papel ("owner" para o dono)
+	RoleName      string     `json:"role_name"`
+	IsOwner       bool       `json:"is_owner"`
+	Email         string     `json:"email"`
+	FullName      string     `json:"full_name"`
+	AvatarURL     *string    `json:"avatar_url,omitempty"`
+	EmailVerified bool       `json:"email_verified"`
+	CreatedAt     time.Time  `json:"created_at"` // Entrada no tenant
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
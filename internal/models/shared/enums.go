@@ -17,4 +17,26 @@ const (
 	TenantStatusProvisioning TenantStatus = "provisioning"
 	TenantStatusActive       TenantStatus = "active"
 	TenantStatusSuspended    TenantStatus = "suspended"
+	TenantStatusFailed       TenantStatus = "failed"    // Provisionamento esgotou as tentativas
+	TenantStatusMigrating    TenantStatus = "migrating" // Database sendo movido para outro cluster
+)
+
+// ClusterStatus representa os status possíveis de um cluster de banco de dados
+type ClusterStatus string
+
+const (
+	ClusterStatusActive   ClusterStatus = "active"   // Recebe novos tenants
+	ClusterStatusDraining ClusterStatus = "draining" // Mantém os tenants atuais, não recebe novos
+	ClusterStatusDisabled ClusterStatus = "disabled"
+)
+
+// JobStatus representa os status dos jobs assíncronos executados pelo worker (backups, exports...)
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusExpired   JobStatus = "expired" // Arquivo gerado já removido (ex.: export vencido)
 )
@@ -0,0 +1,34 @@
+package tenant
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saas-multi-database-api/internal/models/shared"
+)
+
+// DataExport represents a full export of the tenant data requested by a member
+type DataExport struct {
+	ID          uuid.UUID        `json:"id"`
+	TenantID    uuid.UUID        `json:"tenant_id"`
+	RequestedBy *uuid.UUID       `json:"requested_by,omitempty"`
+	Status      shared.JobStatus `json:"status"`
+	SizeBytes   *int64           `json:"size_bytes,omitempty"`
+	Tables      json.RawMessage  `json:"tables,omitempty"`
+	MediaCount  *int             `json:"media_count,omitempty"`
+	Error       *string          `json:"error,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+
+	// API key that requested the export (integrations), instead of a member
+	RequestedByAPIKey *uuid.UUID `json:"requested_by_api_key,omitempty"`
+
+	// Signed download link, filled while the export file is available
+	DownloadURL       *string    `json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+
+	StoragePath *string `json:"-"`
+}
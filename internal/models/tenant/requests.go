@@ -19,8 +19,10 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Validade do token em segundos
+	User         struct {
 		ID       uuid.UUID `json:"id"`
 		Email    string    `json:"email"`
 		FullName string    `json:"full_name"`
@@ -30,6 +32,8 @@ type LoginResponse struct {
 	Interface     *TenantConfig  `json:"interface,omitempty"`      // Configuração de layout
 	Features      []string       `json:"features,omitempty"`       // Features disponíveis
 	Permissions   []string       `json:"permissions,omitempty"`    // Permissões do usuário
+	// Entregues uma única vez quando o segundo fator é cadastrado durante o login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 // CurrentTenant representa o tenant atualmente ativo
@@ -49,8 +53,10 @@ type UserTenant struct {
 }
 
 type TenantLoginResponse struct {
-	Token string `json:"token"`
-	User  struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Validade do token em segundos
+	User         struct {
 		ID       uuid.UUID `json:"id"`
 		Email    string    `json:"email"`
 		FullName string    `json:"full_name"`
@@ -104,8 +110,10 @@ type SubscriptionRequest struct {
 }
 
 type SubscriptionResponse struct {
-	Token string `json:"token"`
-	User  struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Validade do token em segundos
+	User         struct {
 		ID       uuid.UUID `json:"id"`
 		Email    string    `json:"email"`
 		FullName string    `json:"full_name"`
@@ -0,0 +1,92 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Maximum number of redirects followed on a request to the IdP
+const maxRedirects = 3
+
+// ErrForbiddenAddress is returned when an IdP URL points to a private, loopback or link-local address
+var ErrForbiddenAddress = errors.New("oidc: IdP address is not public")
+
+// Carrier-grade NAT range, also used by some cloud metadata services
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// PublicIP reports whether ip can be reached by the IdP requests: private (RFC 1918 / ULA),
+// loopback, link-local, multicast, unspecified and shared (100.64.0.0/10) addresses are refused
+func PublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) ||
+		ip.Equal(net.IPv4bcast))
+}
+
+// CheckHost resolves host and fails when any of its addresses is not public
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("oidc: resolve %s: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("oidc: resolve %s: no addresses", host)
+	}
+	for _, addr := range addrs {
+		if !PublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// newHTTPClient builds the client used for discovery, token exchange and JWKS. Unless allowPrivate
+// (development, e.g. the mock IdP of docker-compose), connections are only opened to public
+// addresses: the check runs on the resolved address of every dial, so it also covers redirects and
+// DNS answers that change after the provider was saved.
+func newHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); !PublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return nil
+		}
+	}
+
+	transport := &http.Transport{
+		// No proxy: the address check must see the IdP address, not the proxy's
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConnsPerHost:   2,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("oidc: stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "https" && !(allowPrivate && req.URL.Scheme == "http") {
+				return fmt.Errorf("%w: redirect to %s", ErrForbiddenAddress, req.URL.Redacted())
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "8.8.8.8", want: true},
+		{ip: "203.0.113.10", want: true},
+		{ip: "2606:4700::1111", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "::1", want: false},
+		{ip: "10.1.2.3", want: false},
+		{ip: "172.16.0.1", want: false},
+		{ip: "192.168.1.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "100.64.0.1", want: false},
+		{ip: "100.127.255.254", want: false},
+		{ip: "100.128.0.1", want: true},
+		{ip: "0.0.0.0", want: false},
+		{ip: "255.255.255.255", want: false},
+		{ip: "224.0.0.1", want: false},
+		{ip: "fc00::1", want: false},
+		{ip: "fe80::1", want: false},
+		{ip: "::ffff:127.0.0.1", want: false},
+		{ip: "::ffff:169.254.169.254", want: false},
+		{ip: "::", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := PublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("PublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+	if PublicIP(nil) {
+		t.Error("PublicIP(nil) = true")
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host      string
+		forbidden bool
+	}{
+		{host: "127.0.0.1", forbidden: true},
+		{host: "169.254.169.254", forbidden: true},
+		{host: "10.0.0.5", forbidden: true},
+		{host: "::1", forbidden: true},
+		{host: "203.0.113.10"},
+	}
+
+	for _, tt := range tests {
+		err := CheckHost(context.Background(), tt.host)
+		if got := errors.Is(err, ErrForbiddenAddress); got != tt.forbidden {
+			t.Errorf("CheckHost(%s) = %v, want forbidden %v", tt.host, err, tt.forbidden)
+		}
+		if !tt.forbidden && err != nil {
+			t.Errorf("CheckHost(%s) = %v", tt.host, err)
+		}
+	}
+}
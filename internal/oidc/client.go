@@ -0,0 +1,217 @@
+// Package oidc implements the relying party side of OpenID Connect used by the tenant SSO login:
+// discovery, authorization code flow with PKCE and ID token verification against the IdP JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long discovery documents and JWKS are reused before being fetched again
+const metadataCacheTTL = 10 * time.Minute
+
+// Maximum size of the IdP responses that are read
+const maxResponseBytes = 1 << 20
+
+var (
+	// ErrDiscovery is returned when the OpenID Provider metadata cannot be fetched or is invalid
+	ErrDiscovery = errors.New("oidc: discovery failed")
+	// ErrTokenExchange is returned when the IdP refuses the authorization code
+	ErrTokenExchange = errors.New("oidc: token exchange failed")
+	// ErrInvalidIDToken is returned when the ID token signature or claims are not valid
+	ErrInvalidIDToken = errors.New("oidc: invalid id token")
+)
+
+// Discovery is the subset of the OpenID Provider metadata used by the login
+type Discovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// AuthRequest holds the parameters of an authorization request
+type AuthRequest struct {
+	ClientID      string
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	Nonce         string
+	CodeChallenge string
+	LoginHint     string
+}
+
+// TokenResponse is the answer of the token endpoint
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type cachedDiscovery struct {
+	discovery *Discovery
+	expiresAt time.Time
+}
+
+// Client talks to OpenID Providers; discovery documents and JWKS are cached per issuer
+type Client struct {
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	discoveries map[string]cachedDiscovery
+	keySets     map[string]*keySet
+}
+
+// NewClient creates a client whose requests to the IdPs time out after timeout. Only public
+// addresses are contacted unless allowPrivate is set (development).
+func NewClient(timeout time.Duration, allowPrivate bool) *Client {
+	return &Client{
+		httpClient:  newHTTPClient(timeout, allowPrivate),
+		discoveries: make(map[string]cachedDiscovery),
+		keySets:     make(map[string]*keySet),
+	}
+}
+
+// Discover fetches {issuer}/.well-known/openid-configuration and checks that it belongs to the issuer
+func (c *Client) Discover(ctx context.Context, issuer string) (*Discovery, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	c.mu.Lock()
+	cached, ok := c.discoveries[issuer]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.discovery, nil
+	}
+
+	var discovery Discovery
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch (%s)", ErrDiscovery, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: missing endpoints", ErrDiscovery)
+	}
+	if len(discovery.CodeChallengeMethods) > 0 && !contains(discovery.CodeChallengeMethods, "S256") {
+		return nil, fmt.Errorf("%w: PKCE S256 not supported", ErrDiscovery)
+	}
+
+	c.mu.Lock()
+	c.discoveries[issuer] = cachedDiscovery{discovery: &discovery, expiresAt: time.Now().Add(metadataCacheTTL)}
+	c.mu.Unlock()
+	return &discovery, nil
+}
+
+// AuthorizationURL builds the URL the browser is sent to (authorization code flow with PKCE S256)
+func AuthorizationURL(discovery *Discovery, req AuthRequest) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", req.ClientID)
+	params.Set("redirect_uri", req.RedirectURI)
+	params.Set("scope", strings.Join(req.Scopes, " "))
+	params.Set("state", req.State)
+	params.Set("nonce", req.Nonce)
+	params.Set("code_challenge", req.CodeChallenge)
+	params.Set("code_challenge_method", "S256")
+	if req.LoginHint != "" {
+		params.Set("login_hint", req.LoginHint)
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// Exchange trades the authorization code for the tokens (client_secret_basic + PKCE verifier)
+func (c *Client) Exchange(ctx context.Context, discovery *Discovery, clientID, clientSecret, code, redirectURI, codeVerifier string) (*TokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var idpError struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		_ = json.Unmarshal(body, &idpError)
+		return nil, fmt.Errorf("%w: status %d %s %s", ErrTokenExchange, resp.StatusCode, idpError.Error, idpError.Description)
+	}
+
+	var tokens TokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in the response", ErrTokenExchange)
+	}
+	return &tokens, nil
+}
+
+// CodeChallenge returns the PKCE S256 challenge of a verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (c *Client) getJSON(ctx context.Context, endpoint string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(dest)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodeChallenge(t *testing.T) {
+	tests := []struct {
+		verifier string
+		want     string
+	}{
+		// RFC 7636, appendix B
+		{verifier: "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", want: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+		{verifier: "", want: "47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU"},
+	}
+
+	for _, tt := range tests {
+		if got := CodeChallenge(tt.verifier); got != tt.want {
+			t.Errorf("CodeChallenge(%q) = %s, want %s", tt.verifier, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorizationURL(t *testing.T) {
+	req := AuthRequest{
+		ClientID:      testClientID,
+		RedirectURI:   "https://app.example.com/api/v1/auth/oidc/callback",
+		Scopes:        []string{"openid", "email", "profile"},
+		State:         "state-1",
+		Nonce:         "nonce-1",
+		CodeChallenge: CodeChallenge("verifier"),
+	}
+
+	tests := []struct {
+		name      string
+		endpoint  string
+		loginHint string
+		wantBase  string
+		wantExtra url.Values
+	}{
+		{name: "plain endpoint", endpoint: "https://idp.example.com/authorize", wantBase: "https://idp.example.com/authorize"},
+		{name: "endpoint with query", endpoint: "https://idp.example.com/authorize?tenant=acme", wantBase: "https://idp.example.com/authorize", wantExtra: url.Values{"tenant": {"acme"}}},
+		{name: "login hint", endpoint: "https://idp.example.com/authorize", loginHint: "ana@example.com", wantBase: "https://idp.example.com/authorize", wantExtra: url.Values{"login_hint": {"ana@example.com"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := req
+			r.LoginHint = tt.loginHint
+			parsed, err := url.Parse(AuthorizationURL(&Discovery{AuthorizationEndpoint: tt.endpoint}, r))
+			if err != nil {
+				t.Fatalf("parse url: %v", err)
+			}
+			if base := parsed.Scheme + "://" + parsed.Host + parsed.Path; base != tt.wantBase {
+				t.Errorf("base = %s, want %s", base, tt.wantBase)
+			}
+
+			want := url.Values{
+				"response_type":         {"code"},
+				"client_id":             {testClientID},
+				"redirect_uri":          {req.RedirectURI},
+				"scope":                 {"openid email profile"},
+				"state":                 {"state-1"},
+				"nonce":                 {"nonce-1"},
+				"code_challenge":        {CodeChallenge("verifier")},
+				"code_challenge_method": {"S256"},
+			}
+			for name, values := range tt.wantExtra {
+				want[name] = values
+			}
+			if got := parsed.Query(); got.Encode() != want.Encode() {
+				t.Errorf("query = %s, want %s", got.Encode(), want.Encode())
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name     string
+		document func(issuer string) map[string]any
+		wantErr  bool
+	}{
+		{
+			name: "valid",
+			document: func(issuer string) map[string]any {
+				return map[string]any{"issuer": issuer, "authorization_endpoint": issuer + "/authorize", "token_endpoint": issuer + "/token", "jwks_uri": issuer + "/jwks", "code_challenge_methods_supported": []string{"plain", "S256"}}
+			},
+		},
+		{
+			name: "issuer with trailing slash",
+			document: func(issuer string) map[string]any {
+				return map[string]any{"issuer": issuer + "/", "authorization_endpoint": issuer + "/authorize", "token_endpoint": issuer + "/token", "jwks_uri": issuer + "/jwks"}
+			},
+		},
+		{
+			name: "issuer of another IdP",
+			document: func(issuer string) map[string]any {
+				return map[string]any{"issuer": "https://evil.example.com", "authorization_endpoint": issuer + "/authorize", "token_endpoint": issuer + "/token", "jwks_uri": issuer + "/jwks"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing jwks_uri",
+			document: func(issuer string) map[string]any {
+				return map[string]any{"issuer": issuer, "authorization_endpoint": issuer + "/authorize", "token_endpoint": issuer + "/token"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "PKCE S256 not supported",
+			document: func(issuer string) map[string]any {
+				return map[string]any{"issuer": issuer, "authorization_endpoint": issuer + "/authorize", "token_endpoint": issuer + "/token", "jwks_uri": issuer + "/jwks", "code_challenge_methods_supported": []string{"plain"}}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/.well-known/openid-configuration" {
+					http.NotFound(w, r)
+					return
+				}
+				json.NewEncoder(w).Encode(tt.document(server.URL))
+			}))
+			defer server.Close()
+
+			discovery, err := NewClient(5*time.Second, true).Discover(context.Background(), server.URL+"/")
+			if tt.wantErr {
+				if !errors.Is(err, ErrDiscovery) {
+					t.Fatalf("err = %v, want ErrDiscovery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Discover: %v", err)
+			}
+			if discovery.JWKSURI != server.URL+"/jwks" {
+				t.Errorf("jwks_uri = %s", discovery.JWKSURI)
+			}
+		})
+	}
+}
+
+func TestExchange(t *testing.T) {
+	var form url.Values
+	var user, password string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		user, password, _ = r.BasicAuth()
+		switch form.Get("code") {
+		case "valid":
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "at", "token_type": "Bearer", "id_token": "id.token.value", "expires_in": 300})
+		case "no-id-token":
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "at", "token_type": "Bearer"})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "code expired"})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(5*time.Second, true)
+	discovery := &Discovery{TokenEndpoint: server.URL + "/token"}
+	ctx := context.Background()
+
+	tokens, err := client.Exchange(ctx, discovery, "client id", "s3cr&t", "valid", "https://app.example.com/cb", "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if tokens.IDToken != "id.token.value" {
+		t.Errorf("id_token = %q", tokens.IDToken)
+	}
+	if form.Get("grant_type") != "authorization_code" || form.Get("code_verifier") != "verifier-1" || form.Get("redirect_uri") != "https://app.example.com/cb" {
+		t.Errorf("form = %v", form)
+	}
+	// client_secret_basic: the credentials are form-urlencoded before the basic auth (RFC 6749, 2.3.1)
+	if user != "client+id" || password != "s3cr%26t" {
+		t.Errorf("basic auth = %q:%q", user, password)
+	}
+
+	for _, code := range []string{"no-id-token", "expired"} {
+		if _, err := client.Exchange(ctx, discovery, "client", "secret", code, "https://app.example.com/cb", "v"); !errors.Is(err, ErrTokenExchange) {
+			t.Errorf("code %s: err = %v, want ErrTokenExchange", code, err)
+		}
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the loopback server")
+	}))
+	defer server.Close()
+
+	_, err := NewClient(5*time.Second, false).Discover(context.Background(), server.URL)
+	if !errors.Is(err, ErrDiscovery) || !strings.Contains(err.Error(), ErrForbiddenAddress.Error()) {
+		t.Fatalf("err = %v, want the forbidden address refused", err)
+	}
+}
+
+func TestClientRedirects(t *testing.T) {
+	hops := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop/.well-known/openid-configuration":
+			hops++
+			http.Redirect(w, r, r.URL.Path, http.StatusFound)
+		case "/file/.well-known/openid-configuration":
+			http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(5*time.Second, true)
+	ctx := context.Background()
+
+	if _, err := client.Discover(ctx, server.URL+"/loop"); err == nil || hops != maxRedirects {
+		t.Errorf("redirect loop: err = %v after %d requests, want %d", err, hops, maxRedirects)
+	}
+	if _, err := client.Discover(ctx, server.URL+"/file"); err == nil || !strings.Contains(err.Error(), ErrForbiddenAddress.Error()) {
+		t.Errorf("redirect to file: err = %v", err)
+	}
+}
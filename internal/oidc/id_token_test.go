@@ -0,0 +1,273 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "saas-client"
+
+// testIdP is a loopback OpenID Provider serving the discovery document and the JWKS
+type testIdP struct {
+	server      *httptest.Server
+	rsaKey      *rsa.PrivateKey
+	ecKey       *ecdsa.PrivateKey
+	jwksFetches atomic.Int32
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ec key: %v", err)
+	}
+	idp := &testIdP{rsaKey: rsaKey, ecKey: ecKey}
+
+	b64 := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(idp.discovery())
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksFetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N), "e": b64(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X), "y": b64(ecKey.Y)},
+			{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": b64(rsaKey.N), "e": b64(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "OKP", "kid": "unsupported", "crv": "Ed25519", "x": "AAAA"},
+		}})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIdP) discovery() *Discovery {
+	return &Discovery{
+		Issuer:                idp.server.URL,
+		AuthorizationEndpoint: idp.server.URL + "/authorize",
+		TokenEndpoint:         idp.server.URL + "/token",
+		JWKSURI:               idp.server.URL + "/jwks",
+		CodeChallengeMethods:  []string{"S256"},
+	}
+}
+
+func (idp *testIdP) claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   testClientID,
+		"sub":   "idp-user-1",
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": "nonce-1",
+		"email": "ana@example.com",
+		"name":  "Ana",
+	}
+}
+
+func (idp *testIdP) sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	var key any
+	switch method.(type) {
+	case *jwt.SigningMethodECDSA:
+		key = idp.ecKey
+	case *jwt.SigningMethodHMAC:
+		key = []byte("shared-secret")
+	default:
+		key = idp.rsaKey
+	}
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return raw
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp := newTestIdP(t)
+
+	with := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := idp.claims()
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, idp.claims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("sign none: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims())
+	forged.Header["kid"] = "rsa-1"
+	forgedRaw, err := forged.SignedString(otherKey)
+	if err != nil {
+		t.Fatalf("sign forged: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		nonce   string
+		wantErr bool
+	}{
+		{name: "RS256", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", idp.claims())},
+		{name: "PS256", token: idp.sign(t, jwt.SigningMethodPS256, "rsa-1", idp.claims())},
+		{name: "ES256", token: idp.sign(t, jwt.SigningMethodES256, "ec-1", idp.claims())},
+		{name: "audience list with azp", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", with(jwt.MapClaims{"aud": []string{"other", testClientID}, "azp": testClientID}))},
+		{name: "expired within the leeway", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", with(jwt.MapClaims{"exp": time.Now().Add(-30 * time.Second).Unix()}))},
+		{name: "expired", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", with(jwt.MapClaims{"exp": time.Now().Add(-2 * time.Minute).Unix()})), wantErr: true},
+		{name: "without exp", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", with(jwt.MapClaims{"exp": nil})), wantErr: true},
+		{name: "other issuer", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", with(jwt.MapClaims{"iss": "https://evil.example.com"})), wantErr: true},
+		{name: "other audience", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", with(jwt.MapClaims{"aud": "other-client"})), wantErr: true},
+		{name: "azp of another client", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", with(jwt.MapClaims{"aud": []string{"other", testClientID}, "azp": "other"})), wantErr: true},
+		{name: "nonce mismatch", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", idp.claims()), nonce: "nonce-2", wantErr: true},
+		{name: "without nonce", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", with(jwt.MapClaims{"nonce": nil})), wantErr: true},
+		{name: "without sub", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-1", with(jwt.MapClaims{"sub": nil})), wantErr: true},
+		{name: "key of another kid", token: idp.sign(t, jwt.SigningMethodES256, "rsa-1", idp.claims()), wantErr: true},
+		{name: "encryption key", token: idp.sign(t, jwt.SigningMethodRS256, "enc-1", idp.claims()), wantErr: true},
+		{name: "unknown kid", token: idp.sign(t, jwt.SigningMethodRS256, "rsa-2", idp.claims()), wantErr: true},
+		{name: "without kid and several keys", token: idp.sign(t, jwt.SigningMethodRS256, "", idp.claims()), wantErr: true},
+		{name: "signed by another key", token: forgedRaw, wantErr: true},
+		{name: "HMAC", token: idp.sign(t, jwt.SigningMethodHS256, "rsa-1", idp.claims()), wantErr: true},
+		{name: "alg none", token: unsigned, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(5*time.Second, true)
+			nonce := tt.nonce
+			if nonce == "" {
+				nonce = "nonce-1"
+			}
+
+			idToken, err := client.VerifyIDToken(context.Background(), idp.discovery(), tt.token, testClientID, nonce)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIDToken: %v", err)
+			}
+			if idToken.Subject != "idp-user-1" || idToken.Email != "ana@example.com" || idToken.Name != "Ana" {
+				t.Errorf("id token = %+v", idToken)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenClaims(t *testing.T) {
+	idp := newTestIdP(t)
+	verified, unverified := true, false
+
+	tests := []struct {
+		name         string
+		claims       jwt.MapClaims
+		wantVerified *bool
+		wantAMR      []string
+		wantGroups   []string
+	}{
+		{name: "email_verified absent"},
+		{name: "email_verified bool", claims: jwt.MapClaims{"email_verified": true}, wantVerified: &verified},
+		{name: "email_verified string", claims: jwt.MapClaims{"email_verified": "false"}, wantVerified: &unverified},
+		{name: "amr list", claims: jwt.MapClaims{"amr": []string{"pwd", "mfa"}}, wantAMR: []string{"pwd", "mfa"}},
+		{name: "single group string", claims: jwt.MapClaims{"groups": "admins"}, wantGroups: []string{"admins"}},
+		{name: "group list ignores other types", claims: jwt.MapClaims{"groups": []any{"admins", 1, "devs"}}, wantGroups: []string{"admins", "devs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.claims()
+			for name, value := range tt.claims {
+				claims[name] = value
+			}
+			raw := idp.sign(t, jwt.SigningMethodRS256, "rsa-1", claims)
+
+			idToken, err := NewClient(5*time.Second, true).VerifyIDToken(context.Background(), idp.discovery(), raw, testClientID, "nonce-1")
+			if err != nil {
+				t.Fatalf("VerifyIDToken: %v", err)
+			}
+			if (idToken.EmailVerified == nil) != (tt.wantVerified == nil) ||
+				(tt.wantVerified != nil && *idToken.EmailVerified != *tt.wantVerified) {
+				t.Errorf("EmailVerified = %v, want %v", idToken.EmailVerified, tt.wantVerified)
+			}
+			if !equalStrings(idToken.AMR, tt.wantAMR) {
+				t.Errorf("AMR = %v, want %v", idToken.AMR, tt.wantAMR)
+			}
+			if got := idToken.StringList("groups"); !equalStrings(got, tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", got, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenJWKSCache(t *testing.T) {
+	idp := newTestIdP(t)
+	client := NewClient(5*time.Second, true)
+	ctx := context.Background()
+
+	valid := idp.sign(t, jwt.SigningMethodRS256, "rsa-1", idp.claims())
+	for i := 0; i < 3; i++ {
+		if _, err := client.VerifyIDToken(ctx, idp.discovery(), valid, testClientID, "nonce-1"); err != nil {
+			t.Fatalf("VerifyIDToken: %v", err)
+		}
+	}
+	if got := idp.jwksFetches.Load(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", got)
+	}
+
+	// Unknown kids do not refetch the JWKS more than once per jwksRefetchInterval
+	unknown := idp.sign(t, jwt.SigningMethodRS256, "rotated", idp.claims())
+	for i := 0; i < 3; i++ {
+		if _, err := client.VerifyIDToken(ctx, idp.discovery(), unknown, testClientID, "nonce-1"); err == nil {
+			t.Fatal("token of an unknown kid accepted")
+		}
+	}
+	if got := idp.jwksFetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times after unknown kids, want 1", got)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/models/admin"
+)
+
+const apiKeyColumns = `id, tenant_id, name, key_prefix, permissions, allowed_ips, expires_at,
+	last_used_at, last_used_ip, created_by, revoked_at, created_at`
+
+// APIKeyRepository stores the tenant API keys (master DB)
+type APIKeyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(pool *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{pool: pool}
+}
+
+// CreateAPIKey stores a new key; only the hash of the key is persisted
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *admin.TenantAPIKey, keyHash string) (*admin.TenantAPIKey, error) {
+	created, err := scanAPIKey(r.pool.QueryRow(ctx, `
+		INSERT INTO tenant_api_keys (tenant_id, name, key_prefix, key_hash, permissions, allowed_ips, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+apiKeyColumns,
+		key.TenantID, key.Name, key.KeyPrefix, keyHash, key.Permissions, key.AllowedIPs, key.ExpiresAt, key.CreatedBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListAPIKeys returns the keys of a tenant, newest first (revoked ones included)
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context, tenantID uuid.UUID) ([]admin.TenantAPIKey, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+apiKeyColumns+` FROM tenant_api_keys
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []admin.TenantAPIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	return keys, rows.Err()
+}
+
+// GetAPIKeyByHash returns the key with the given hash, or nil when there is none
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*admin.TenantAPIKey, error) {
+	key, err := scanAPIKey(r.pool.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM tenant_api_keys WHERE key_hash = $1`, keyHash,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// RevokeAPIKey revokes a key of the tenant; returns false when there is no active key with that id
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, tenantID, keyID uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE tenant_api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND tenant_id = $2 AND revoked_at IS NULL
+	`, keyID, tenantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// TouchAPIKey records the last use of a key
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, keyID uuid.UUID, ip string, usedAt time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE tenant_api_keys SET last_used_at = $2, last_used_ip = $3 WHERE id = $1`,
+		keyID, usedAt, ip,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record api key use: %w", err)
+	}
+
+	return nil
+}
+
+// UnknownPermissions returns the slugs that do not exist in the permissions table
+func (r *APIKeyRepository) UnknownPermissions(ctx context.Context, slugs []string) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT s FROM unnest($1::text[]) AS s
+		WHERE NOT EXISTS (SELECT 1 FROM permissions p WHERE p.slug = s)
+	`, slugs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permissions: %w", err)
+	}
+	defer rows.Close()
+
+	unknown := []string{}
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+		unknown = append(unknown, slug)
+	}
+
+	return unknown, rows.Err()
+}
+
+func scanAPIKey(row pgx.Row) (*admin.TenantAPIKey, error) {
+	var k admin.TenantAPIKey
+	err := row.Scan(
+		&k.ID, &k.TenantID, &k.Name, &k.KeyPrefix, &k.Permissions, &k.AllowedIPs, &k.ExpiresAt,
+		&k.LastUsedAt, &k.LastUsedIP, &k.CreatedBy, &k.RevokedAt, &k.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
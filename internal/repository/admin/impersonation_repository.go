@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/models/admin"
+)
+
+const impersonationSessionColumns = `s.id, s.sys_user_id, su.email, s.user_id, u.email, s.tenant_id, t.url_code,
+	s.reason, s.read_only, s.ip_address, s.expires_at, s.ended_at, s.ended_by,
+	(SELECT COUNT(*) FROM impersonation_requests r WHERE r.session_id = s.id), s.created_at`
+
+const impersonationSessionJoins = `FROM impersonation_sessions s
+	JOIN users u ON u.id = s.user_id
+	JOIN tenants t ON t.id = s.tenant_id
+	LEFT JOIN sys_users su ON su.id = s.sys_user_id`
+
+// ImpersonationRepository stores the impersonation sessions of the administrators and the audit trail
+// of the requests made with them (master DB)
+type ImpersonationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewImpersonationRepository(pool *pgxpool.Pool) *ImpersonationRepository {
+	return &ImpersonationRepository{pool: pool}
+}
+
+// CreateSession records a new session; its id is the jti of the impersonation token
+func (r *ImpersonationRepository) CreateSession(ctx context.Context, session *admin.ImpersonationSession) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO impersonation_sessions (id, sys_user_id, user_id, tenant_id, reason, read_only, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, session.ID, session.SysUserID, session.UserID, session.TenantID, session.Reason, session.ReadOnly,
+		session.IPAddress, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create impersonation session: %w", err)
+	}
+
+	return nil
+}
+
+// GetSession returns a session (nil when it does not exist)
+func (r *ImpersonationRepository) GetSession(ctx context.Context, id uuid.UUID) (*admin.ImpersonationSession, error) {
+	session, err := scanImpersonationSession(r.pool.QueryRow(ctx, `
+		SELECT `+impersonationSessionColumns+` `+impersonationSessionJoins+`
+		WHERE s.id = $1
+	`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impersonation session: %w", err)
+	}
+
+	return session, nil
+}
+
+// ListSessions returns the most recent sessions, optionally of one tenant and/or one administrator
+func (r *ImpersonationRepository) ListSessions(ctx context.Context, tenantID, sysUserID *uuid.UUID, limit int) ([]admin.ImpersonationSession, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+impersonationSessionColumns+` `+impersonationSessionJoins+`
+		WHERE ($1::uuid IS NULL OR s.tenant_id = $1) AND ($2::uuid IS NULL OR s.sys_user_id = $2)
+		ORDER BY s.created_at DESC
+		LIMIT $3
+	`, tenantID, sysUserID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list impersonation sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []admin.ImpersonationSession{}
+	for rows.Next() {
+		session, err := scanImpersonationSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan impersonation session: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// EndSession marks an active session as ended by an administrator and returns when its token
+// expires (zero time when the session was already ended or expired)
+func (r *ImpersonationRepository) EndSession(ctx context.Context, id, endedBy uuid.UUID) (time.Time, error) {
+	var expiresAt time.Time
+	err := r.pool.QueryRow(ctx, `
+		UPDATE impersonation_sessions SET ended_at = NOW(), ended_by = $2
+		WHERE id = $1 AND ended_at IS NULL AND expires_at > NOW()
+		RETURNING expires_at
+	`, id, endedBy).Scan(&expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to end impersonation session: %w", err)
+	}
+
+	return expiresAt, nil
+}
+
+// RecordRequest appends a Tenant API request made with an impersonation token to the audit trail
+func (r *ImpersonationRepository) RecordRequest(ctx context.Context, sessionID uuid.UUID, method, path string, status int, ip, userAgent string) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO impersonation_requests (session_id, method, path, status, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+	`, sessionID, method, path, status, ip, userAgent)
+	if err != nil {
+		return fmt.Errorf("failed to record impersonation request: %w", err)
+	}
+
+	return nil
+}
+
+// ListRequests returns the requests of a session in the order they were made
+func (r *ImpersonationRepository) ListRequests(ctx context.Context, sessionID uuid.UUID, limit int) ([]admin.ImpersonationRequest, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, method, path, status, ip_address, user_agent, created_at
+		FROM impersonation_requests
+		WHERE session_id = $1
+		ORDER BY created_at, id
+		LIMIT $2
+	`, sessionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list impersonation requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []admin.ImpersonationRequest{}
+	for rows.Next() {
+		var req admin.ImpersonationRequest
+		if err := rows.Scan(&req.ID, &req.Method, &req.Path, &req.Status, &req.IPAddress, &req.UserAgent, &req.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan impersonation request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+
+	return requests, rows.Err()
+}
+
+func scanImpersonationSession(row pgx.Row) (*admin.ImpersonationSession, error) {
+	var s admin.ImpersonationSession
+	err := row.Scan(&s.ID, &s.SysUserID, &s.SysUserEmail, &s.UserID, &s.UserEmail, &s.TenantID, &s.URLCode,
+		&s.Reason, &s.ReadOnly, &s.IPAddress, &s.ExpiresAt, &s.EndedAt, &s.EndedBy, &s.RequestCount, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -20,7 +20,7 @@ func NewPlanRepository(pool *pgxpool.Pool) *PlanRepository {
 // GetAllPlans retorna todos os planos
 func (r *PlanRepository) GetAllPlans(ctx context.Context) ([]admin.Plan, error) {
 	query := `
-		SELECT id, name, description, price, created_at, updated_at
+		SELECT id, name, description, price, max_db_connections, max_members, created_at, updated_at
 		FROM plans
 		ORDER BY name ASC
 	`
@@ -39,6 +39,8 @@ func (r *PlanRepository) GetAllPlans(ctx context.Context) ([]admin.Plan, error)
 			&plan.Name,
 			&plan.Description,
 			&plan.Price,
+			&plan.MaxDBConnections,
+			&plan.MaxMembers,
 			&plan.CreatedAt,
 			&plan.UpdatedAt,
 		); err != nil {
@@ -57,7 +59,7 @@ func (r *PlanRepository) GetAllPlans(ctx context.Context) ([]admin.Plan, error)
 // GetPlanByID retorna um plano por ID
 func (r *PlanRepository) GetPlanByID(ctx context.Context, planID uuid.UUID) (*admin.Plan, error) {
 	query := `
-		SELECT id, name, description, price, created_at, updated_at
+		SELECT id, name, description, price, max_db_connections, max_members, created_at, updated_at
 		FROM plans
 		WHERE id = $1
 	`
@@ -68,6 +70,8 @@ func (r *PlanRepository) GetPlanByID(ctx context.Context, planID uuid.UUID) (*ad
 		&plan.Name,
 		&plan.Description,
 		&plan.Price,
+		&plan.MaxDBConnections,
+		&plan.MaxMembers,
 		&plan.CreatedAt,
 		&plan.UpdatedAt,
 	)
@@ -80,19 +84,21 @@ func (r *PlanRepository) GetPlanByID(ctx context.Context, planID uuid.UUID) (*ad
 }
 
 // CreatePlan cria um novo plano
-func (r *PlanRepository) CreatePlan(ctx context.Context, name, description string, price float64) (*admin.Plan, error) {
+func (r *PlanRepository) CreatePlan(ctx context.Context, name, description string, price float64, maxDBConnections, maxMembers *int) (*admin.Plan, error) {
 	query := `
-		INSERT INTO plans (name, description, price)
-		VALUES ($1, $2, $3)
-		RETURNING id, name, description, price, created_at, updated_at
+		INSERT INTO plans (name, description, price, max_db_connections, max_members)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, name, description, price, max_db_connections, max_members, created_at, updated_at
 	`
 
 	var plan admin.Plan
-	err := r.pool.QueryRow(ctx, query, name, description, price).Scan(
+	err := r.pool.QueryRow(ctx, query, name, description, price, maxDBConnections, maxMembers).Scan(
 		&plan.ID,
 		&plan.Name,
 		&plan.Description,
 		&plan.Price,
+		&plan.MaxDBConnections,
+		&plan.MaxMembers,
 		&plan.CreatedAt,
 		&plan.UpdatedAt,
 	)
@@ -105,20 +111,22 @@ func (r *PlanRepository) CreatePlan(ctx context.Context, name, description strin
 }
 
 // UpdatePlan atualiza um plano existente
-func (r *PlanRepository) UpdatePlan(ctx context.Context, planID uuid.UUID, name, description string, price float64) (*admin.Plan, error) {
+func (r *PlanRepository) UpdatePlan(ctx context.Context, planID uuid.UUID, name, description string, price float64, maxDBConnections, maxMembers *int) (*admin.Plan, error) {
 	query := `
 		UPDATE plans
-		SET name = $2, description = $3, price = $4, updated_at = NOW()
+		SET name = $2, description = $3, price = $4, max_db_connections = $5, max_members = $6, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, name, description, price, created_at, updated_at
+		RETURNING id, name, description, price, max_db_connections, max_members, created_at, updated_at
 	`
 
 	var plan admin.Plan
-	err := r.pool.QueryRow(ctx, query, planID, name, description, price).Scan(
+	err := r.pool.QueryRow(ctx, query, planID, name, description, price, maxDBConnections, maxMembers).Scan(
 		&plan.ID,
 		&plan.Name,
 		&plan.Description,
 		&plan.Price,
+		&plan.MaxDBConnections,
+		&plan.MaxMembers,
 		&plan.CreatedAt,
 		&plan.UpdatedAt,
 	)
@@ -169,6 +169,23 @@ func (r *SysUserRepository) GetSysUserPermissions(ctx context.Context, sysUserID
 	return permissions, rows.Err()
 }
 
+// HasSysPermission verifica se alguma role do sys_user concede a permissão (slug)
+func (r *SysUserRepository) HasSysPermission(ctx context.Context, sysUserID uuid.UUID, slug string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM sys_permissions sp
+			INNER JOIN sys_role_permissions srp ON sp.id = srp.sys_permission_id
+			INNER JOIN sys_user_roles sur ON srp.sys_role_id = sur.sys_role_id
+			WHERE sur.sys_user_id = $1 AND sp.slug = $2
+		)
+	`
+
+	var allowed bool
+	err := r.pool.QueryRow(ctx, query, sysUserID, slug).Scan(&allowed)
+	return allowed, err
+}
+
 // AssignRoleToSysUser atribui uma role a um sys_user
 func (r *SysUserRepository) AssignRoleToSysUser(ctx context.Context, sysUserID, roleID uuid.UUID) error {
 	query := `
@@ -78,6 +78,87 @@ func (r *TenantRepository) GetTenantBySubdomain(ctx context.Context, subdomain s
 	return tenant, nil
 }
 
+// GetTenantByCustomDomain retrieves a tenant by its verified custom domain (public site routing)
+func (r *TenantRepository) GetTenantByCustomDomain(ctx context.Context, domain string) (*admin.Tenant, error) {
+	tenant := &admin.Tenant{}
+
+	query := `
+		SELECT t.id, t.db_code, t.url_code, t.subdomain, t.owner_id, t.plan_id, t.billing_cycle, t.status, t.created_at, t.updated_at
+		FROM tenants t
+		JOIN tenant_profiles tp ON tp.tenant_id = t.id
+		WHERE LOWER(tp.custom_domain) = $1
+		  AND tp.custom_domain_verified_at IS NOT NULL
+	`
+
+	err := r.pool.QueryRow(ctx, query, domain).Scan(
+		&tenant.ID,
+		&tenant.DBCode,
+		&tenant.URLCode,
+		&tenant.Subdomain,
+		&tenant.OwnerID,
+		&tenant.PlanID,
+		&tenant.BillingCycle,
+		&tenant.Status,
+		&tenant.CreatedAt,
+		&tenant.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return tenant, nil
+}
+
+// GetTenantRateLimits retrieves the rate limits of a tenant (admin override, then plan; nil = API default)
+func (r *TenantRepository) GetTenantRateLimits(ctx context.Context, tenantID uuid.UUID) (admin.RateLimits, error) {
+	var limits admin.RateLimits
+
+	query := `
+		SELECT
+			COALESCE(o.read_per_minute, p.rate_limit_read),
+			COALESCE(o.write_per_minute, p.rate_limit_write),
+			COALESCE(o.upload_per_minute, p.rate_limit_upload)
+		FROM tenants t
+		JOIN plans p ON p.id = t.plan_id
+		LEFT JOIN tenant_rate_limits o ON o.tenant_id = t.id
+		WHERE t.id = $1
+	`
+
+	err := r.pool.QueryRow(ctx, query, tenantID).Scan(&limits.Read, &limits.Write, &limits.Upload)
+	if err != nil {
+		return limits, fmt.Errorf("failed to get tenant rate limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+// GetTenantRequireTwoFactor reports whether the tenant requires its members to sign in with a second factor
+func (r *TenantRepository) GetTenantRequireTwoFactor(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	var required bool
+
+	err := r.pool.QueryRow(ctx, `SELECT require_two_factor FROM tenants WHERE id = $1`, tenantID).Scan(&required)
+	if err != nil {
+		return false, fmt.Errorf("failed to get tenant two-factor requirement: %w", err)
+	}
+
+	return required, nil
+}
+
+// GetTenantSSOOnly reports whether the tenant only accepts sessions opened by its enabled OIDC provider
+func (r *TenantRepository) GetTenantSSOOnly(ctx context.Context, tenantID uuid.UUID) (bool, error) {
+	var ssoOnly bool
+
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM tenant_oidc_providers WHERE tenant_id = $1 AND enabled AND sso_only)
+	`, tenantID).Scan(&ssoOnly)
+	if err != nil {
+		return false, fmt.Errorf("failed to get tenant sso requirement: %w", err)
+	}
+
+	return ssoOnly, nil
+}
+
 // CheckUserAccess verifies if a user has access to a tenant
 func (r *TenantRepository) CheckUserAccess(ctx context.Context, userID, tenantID uuid.UUID) (bool, error) {
 	var exists bool
@@ -163,7 +244,7 @@ func (r *TenantRepository) GetUserPermissions(ctx context.Context, userID, tenan
 		return permissions, nil
 	}
 
-	// For non-owners, get permissions based on their role
+	// For non-owners, get permissions based on their role (global, or a custom role of this tenant)
 	query := `
 		SELECT DISTINCT p.slug
 		FROM permissions p
@@ -171,6 +252,7 @@ func (r *TenantRepository) GetUserPermissions(ctx context.Context, userID, tenan
 		JOIN roles r ON rp.role_id = r.id
 		JOIN tenant_members tm ON tm.role_id = r.id
 		WHERE tm.user_id = $1 AND tm.tenant_id = $2
+		  AND (r.tenant_id IS NULL OR r.tenant_id = tm.tenant_id)
 	`
 
 	rows, err := r.pool.Query(ctx, query, userID, tenantID)
@@ -209,12 +291,13 @@ func (r *TenantRepository) GetUserRole(ctx context.Context, userID, tenantID uui
 		return "owner", nil
 	}
 
-	// For non-owners, get role from tenant_members
+	// For non-owners, get role from tenant_members (global, or a custom role of this tenant)
 	query := `
 		SELECT r.slug
 		FROM roles r
 		JOIN tenant_members tm ON tm.role_id = r.id
 		WHERE tm.user_id = $1 AND tm.tenant_id = $2
+		  AND (r.tenant_id IS NULL OR r.tenant_id = tm.tenant_id)
 	`
 
 	var roleSlug string
@@ -290,6 +373,7 @@ func (r *TenantRepository) GetTenantProfile(ctx context.Context, tenantID uuid.U
 			company_name,
 			is_company,
 			COALESCE(custom_domain, '') as custom_domain,
+			COALESCE(about, '') as about,
 			COALESCE(logo_url, '') as logo_url,
 			COALESCE(custom_settings, '{}'::jsonb) as custom_settings,
 			created_at,
@@ -304,6 +388,7 @@ func (r *TenantRepository) GetTenantProfile(ctx context.Context, tenantID uuid.U
 		&profile.CompanyName,
 		&profile.IsCompany,
 		&profile.CustomDomain,
+		&profile.About,
 		&profile.LogoURL,
 		&profile.CustomSettings,
 		&profile.CreatedAt,
@@ -0,0 +1,480 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/mailer"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+const (
+	// Finalidades dos tokens enviados por email
+	EmailTokenVerifyEmail   = "verify_email"
+	EmailTokenPasswordReset = "password_reset"
+
+	// Tamanho (em bytes aleatórios) do token enviado por email
+	emailTokenBytes = 32
+)
+
+var (
+	// ErrInvalidEmailToken é retornado quando o token não existe, expirou ou já foi usado
+	ErrInvalidEmailToken = errors.New("link inválido ou expirado")
+	// ErrEmailAlreadyVerified é retornado ao reenviar a verificação de um email já verificado
+	ErrEmailAlreadyVerified = errors.New("email já verificado")
+	// ErrEmailNotVerified é retornado no login quando AUTH_REQUIRE_VERIFIED_EMAIL está ativo
+	ErrEmailNotVerified = errors.New("email não verificado")
+)
+
+// emailSubject é o dono de um token enviado por email (users ou sys_users)
+type emailSubject struct {
+	ID       uuid.UUID
+	Email    string
+	FullName string
+	Verified bool
+}
+
+// AuthEmailService envia e consome os tokens de verificação de email e de redefinição de senha
+// dos usuários das APIs Admin (sys_users) e Tenant (users). Os tokens são de uso único, expiram e
+// só o hash fica no Master DB. Os emails de tenant usam a identidade visual do tenant
+// (perfil + setting "interface" do banco do tenant).
+type AuthEmailService struct {
+	masterPool   *pgxpool.Pool
+	dbManager    *database.Manager
+	mailer       mailer.Mailer
+	tokenService *AuthTokenService
+	config       *config.Config
+}
+
+// NewAuthEmailService recebe dbManager, mailer e tokenService nil quando o serviço só faz
+// manutenção, como no worker
+func NewAuthEmailService(masterPool *pgxpool.Pool, dbManager *database.Manager, m mailer.Mailer, tokenService *AuthTokenService, cfg *config.Config) *AuthEmailService {
+	return &AuthEmailService{
+		masterPool:   masterPool,
+		dbManager:    dbManager,
+		mailer:       m,
+		tokenService: tokenService,
+		config:       cfg,
+	}
+}
+
+// SendVerification envia o link de verificação de email. urlCode (opcional) escolhe a identidade
+// visual de um tenant do qual o usuário é membro.
+func (s *AuthEmailService) SendVerification(ctx context.Context, subjectType string, subjectID uuid.UUID, urlCode string) error {
+	subject, err := s.getSubject(ctx, subjectType, subjectID)
+	if err != nil {
+		return err
+	}
+	if subject.Verified {
+		return ErrEmailAlreadyVerified
+	}
+
+	tenantID, branding := s.branding(ctx, subjectType, subject.ID, urlCode)
+	ttl := time.Duration(s.config.Auth.EmailVerificationHours) * time.Hour
+
+	token, err := s.createToken(ctx, subjectType, subject, EmailTokenVerifyEmail, tenantID, ttl)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, subject.Email, mailer.ActionEmail{
+		Branding:    branding,
+		Subject:     fmt.Sprintf("Confirme seu email - %s", branding.CompanyName),
+		Greeting:    greeting(subject.FullName),
+		Intro:       fmt.Sprintf("Confirme que este é o seu email para concluir o cadastro em %s.", branding.CompanyName),
+		ButtonLabel: "Confirmar email",
+		Link:        s.link(subjectType, "verify-email", token, urlCode),
+		Footer:      fmt.Sprintf("O link expira em %s. Se você não criou esta conta, ignore este email.", formatTTL(ttl)),
+	})
+}
+
+// VerifyEmail consome o token de verificação e marca o email como verificado
+func (s *AuthEmailService) VerifyEmail(ctx context.Context, subjectType, token string) error {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	subjectID, email, err := s.consumeToken(ctx, tx, subjectType, EmailTokenVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+
+	// O email pode ter mudado depois do envio: só verifica se ainda é o mesmo
+	tag, err := tx.Exec(ctx,
+		fmt.Sprintf(`UPDATE %s SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW() WHERE id = $1 AND email = $2`, subjectTable(subjectType)),
+		subjectID, email,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar email: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidEmailToken
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao verificar email: %w", err)
+	}
+	return nil
+}
+
+// RequestPasswordReset envia o link de redefinição de senha. Não informa se o email existe:
+// um email desconhecido retorna nil sem enviar nada.
+func (s *AuthEmailService) RequestPasswordReset(ctx context.Context, subjectType, email, urlCode string) error {
+	subject, err := s.getSubjectByEmail(ctx, subjectType, email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tenantID, branding := s.branding(ctx, subjectType, subject.ID, urlCode)
+	ttl := time.Duration(s.config.Auth.PasswordResetMinutes) * time.Minute
+
+	// Só o link mais recente vale
+	if _, err := s.masterPool.Exec(ctx, `
+		UPDATE auth_email_tokens SET used_at = NOW()
+		WHERE subject_type = $1 AND subject_id = $2 AND purpose = $3 AND used_at IS NULL
+	`, subjectType, subject.ID, EmailTokenPasswordReset); err != nil {
+		return fmt.Errorf("erro ao invalidar links anteriores: %w", err)
+	}
+
+	token, err := s.createToken(ctx, subjectType, subject, EmailTokenPasswordReset, tenantID, ttl)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, subject.Email, mailer.ActionEmail{
+		Branding:    branding,
+		Subject:     fmt.Sprintf("Redefinição de senha - %s", branding.CompanyName),
+		Greeting:    greeting(subject.FullName),
+		Intro:       fmt.Sprintf("Recebemos um pedido para redefinir a senha da sua conta em %s.", branding.CompanyName),
+		ButtonLabel: "Redefinir senha",
+		Link:        s.link(subjectType, "reset-password", token, urlCode),
+		Footer:      fmt.Sprintf("O link expira em %s e só pode ser usado uma vez. Se você não pediu a redefinição, ignore este email: sua senha continua a mesma.", formatTTL(ttl)),
+	})
+}
+
+// ResetPassword consome o token de redefinição, troca a senha e encerra todas as sessões do usuário
+func (s *AuthEmailService) ResetPassword(ctx context.Context, subjectType, token, newPassword string) error {
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	subjectID, email, err := s.consumeToken(ctx, tx, subjectType, EmailTokenPasswordReset, token)
+	if err != nil {
+		return err
+	}
+
+	// Quem recebeu o link comprovou acesso à caixa de entrada: o email também fica verificado
+	tag, err := tx.Exec(ctx,
+		fmt.Sprintf(`UPDATE %s SET password_hash = $1, email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW() WHERE id = $2 AND email = $3`, subjectTable(subjectType)),
+		hashedPassword, subjectID, email,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao redefinir senha: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidEmailToken
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao redefinir senha: %w", err)
+	}
+
+	// Sessões abertas com a senha antiga não valem mais
+	if err := s.tokenService.LogoutAll(ctx, subjectType, subjectID); err != nil {
+		log.Printf("Warning: erro ao encerrar sessões de %s após redefinir a senha: %v", subjectID, err)
+	}
+	return nil
+}
+
+// SendInvitation envia o convite para entrar no tenant urlCode, com a identidade visual do tenant.
+// O token é gerado e guardado (como hash) pelo TenantMemberService.
+func (s *AuthEmailService) SendInvitation(ctx context.Context, email, urlCode, inviterName, roleName, token string, ttl time.Duration) error {
+	_, branding := s.tenantBranding(ctx, urlCode, nil)
+
+	intro := fmt.Sprintf("Você foi convidado para participar de %s como %s.", branding.CompanyName, roleName)
+	if inviterName != "" {
+		intro = fmt.Sprintf("%s convidou você para participar de %s como %s.", inviterName, branding.CompanyName, roleName)
+	}
+
+	return s.send(ctx, email, mailer.ActionEmail{
+		Branding:    branding,
+		Subject:     fmt.Sprintf("Convite para %s", branding.CompanyName),
+		Greeting:    greeting(""),
+		Intro:       intro,
+		ButtonLabel: "Aceitar convite",
+		Link:        s.link(TokenSubjectTenant, "accept-invitation", token, urlCode),
+		Footer:      fmt.Sprintf("O convite expira em %s. Se você não esperava este convite, ignore este email.", formatTTL(ttl)),
+	})
+}
+
+// EmailVerified informa se o usuário já verificou o email
+func (s *AuthEmailService) EmailVerified(ctx context.Context, subjectType string, subjectID uuid.UUID) (bool, error) {
+	subject, err := s.getSubject(ctx, subjectType, subjectID)
+	if err != nil {
+		return false, err
+	}
+	return subject.Verified, nil
+}
+
+// CheckLoginAllowed retorna ErrEmailNotVerified quando AUTH_REQUIRE_VERIFIED_EMAIL está ativo
+// e o usuário ainda não verificou o email
+func (s *AuthEmailService) CheckLoginAllowed(ctx context.Context, subjectType string, subjectID uuid.UUID) error {
+	if !s.config.Auth.RequireVerifiedEmail {
+		return nil
+	}
+	verified, err := s.EmailVerified(ctx, subjectType, subjectID)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// PurgeExpired remove os tokens de email vencidos e retorna quantos foram removidos
+func (s *AuthEmailService) PurgeExpired(ctx context.Context) (int64, error) {
+	tag, err := s.masterPool.Exec(ctx, `DELETE FROM auth_email_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao remover tokens de email vencidos: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// createToken grava o hash de um novo token e retorna o token em claro (só vai no email)
+func (s *AuthEmailService) createToken(ctx context.Context, subjectType string, subject *emailSubject, purpose string, tenantID *uuid.UUID, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateSecret(emailTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.masterPool.Exec(ctx, `
+		INSERT INTO auth_email_tokens (subject_type, subject_id, purpose, token_hash, email, tenant_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, subjectType, subject.ID, purpose, utils.HashToken(token), subject.Email, tenantID, time.Now().Add(ttl)); err != nil {
+		return "", fmt.Errorf("erro ao salvar token de email: %w", err)
+	}
+	return token, nil
+}
+
+// consumeToken marca o token como usado e retorna o dono e o email para o qual foi enviado
+func (s *AuthEmailService) consumeToken(ctx context.Context, tx pgx.Tx, subjectType, purpose, token string) (uuid.UUID, string, error) {
+	var subjectID uuid.UUID
+	var email string
+	err := tx.QueryRow(ctx, `
+		UPDATE auth_email_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND subject_type = $2 AND purpose = $3
+		  AND used_at IS NULL AND expires_at > NOW()
+		RETURNING subject_id, email
+	`, utils.HashToken(token), subjectType, purpose).Scan(&subjectID, &email)
+	if err == pgx.ErrNoRows {
+		return uuid.Nil, "", ErrInvalidEmailToken
+	}
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("erro ao buscar token de email: %w", err)
+	}
+	return subjectID, email, nil
+}
+
+// getSubject busca o usuário dono dos tokens pelo id
+func (s *AuthEmailService) getSubject(ctx context.Context, subjectType string, subjectID uuid.UUID) (*emailSubject, error) {
+	subject, err := s.querySubject(ctx, subjectType, "id = $1", subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	return subject, nil
+}
+
+// getSubjectByEmail busca o usuário pelo email (pgx.ErrNoRows se não existir ou estiver inativo)
+func (s *AuthEmailService) getSubjectByEmail(ctx context.Context, subjectType, email string) (*emailSubject, error) {
+	subject, err := s.querySubject(ctx, subjectType, "email = $1", utils.NormalizeEmail(email))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	return subject, err
+}
+
+func (s *AuthEmailService) querySubject(ctx context.Context, subjectType, where string, arg any) (*emailSubject, error) {
+	query := `
+		SELECT u.id, u.email, COALESCE(p.full_name, ''), u.email_verified_at IS NOT NULL
+		FROM users u
+		LEFT JOIN user_profiles p ON p.user_id = u.id
+		WHERE u.` + where
+	if subjectType == TokenSubjectAdmin {
+		query = `
+			SELECT id, email, full_name, email_verified_at IS NOT NULL
+			FROM sys_users
+			WHERE status = 'active' AND ` + where
+	}
+
+	var subject emailSubject
+	if err := s.masterPool.QueryRow(ctx, query, arg).Scan(&subject.ID, &subject.Email, &subject.FullName, &subject.Verified); err != nil {
+		return nil, err
+	}
+	return &subject, nil
+}
+
+// branding retorna a identidade visual do email: a do tenant urlCode quando o usuário é membro
+// dele, senão a da plataforma. Falhas ao ler o tenant caem na identidade da plataforma.
+func (s *AuthEmailService) branding(ctx context.Context, subjectType string, subjectID uuid.UUID, urlCode string) (*uuid.UUID, mailer.Branding) {
+	if subjectType != TokenSubjectTenant || urlCode == "" {
+		return nil, mailer.DefaultBranding(s.config.App.Name)
+	}
+	return s.tenantBranding(ctx, urlCode, &subjectID)
+}
+
+// tenantBranding retorna a identidade visual do tenant urlCode; com memberID, só quando o usuário é
+// membro dele (senão a da plataforma)
+func (s *AuthEmailService) tenantBranding(ctx context.Context, urlCode string, memberID *uuid.UUID) (*uuid.UUID, mailer.Branding) {
+	branding := mailer.DefaultBranding(s.config.App.Name)
+
+	var tenantID, dbCode uuid.UUID
+	var status shared.TenantStatus
+	var companyName, logoURL string
+	err := s.masterPool.QueryRow(ctx, `
+		SELECT t.id, t.db_code, t.status, COALESCE(p.company_name, ''), COALESCE(p.logo_url, '')
+		FROM tenants t
+		LEFT JOIN tenant_profiles p ON p.tenant_id = t.id
+		WHERE t.url_code = $1
+		  AND ($2::uuid IS NULL OR t.owner_id = $2 OR EXISTS(SELECT 1 FROM tenant_members m WHERE m.tenant_id = t.id AND m.user_id = $2))
+	`, urlCode, memberID).Scan(&tenantID, &dbCode, &status, &companyName, &logoURL)
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			log.Printf("Warning: erro ao buscar identidade visual do tenant %s: %v", urlCode, err)
+		}
+		return nil, branding
+	}
+
+	if companyName != "" {
+		branding.CompanyName = companyName
+	}
+	branding.LogoURL = logoURL
+
+	// Cores (e logo, se definido) vêm da setting "interface" do banco do tenant, que só existe quando ativo
+	if status == shared.TenantStatusActive {
+		if err := s.applyInterfaceSetting(ctx, dbCode, &branding); err != nil {
+			log.Printf("Warning: erro ao ler setting interface do tenant %s: %v", urlCode, err)
+		}
+	}
+	return &tenantID, branding
+}
+
+// applyInterfaceSetting aplica a setting "interface" ({"logo", "primary_color", "secondary_color"})
+func (s *AuthEmailService) applyInterfaceSetting(ctx context.Context, dbCode uuid.UUID, branding *mailer.Branding) error {
+	pool, err := s.dbManager.GetTenantPool(ctx, dbCode.String())
+	if err != nil {
+		return err
+	}
+
+	var raw []byte
+	err = pool.QueryRow(ctx, `SELECT value FROM settings WHERE key = 'interface'`).Scan(&raw)
+	if err == pgx.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var settings struct {
+		Logo           *string `json:"logo"`
+		PrimaryColor   string  `json:"primary_color"`
+		SecondaryColor string  `json:"secondary_color"`
+	}
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return err
+	}
+
+	if settings.Logo != nil && *settings.Logo != "" {
+		branding.LogoURL = *settings.Logo
+	}
+	if settings.PrimaryColor != "" {
+		branding.PrimaryColor = settings.PrimaryColor
+	}
+	if settings.SecondaryColor != "" {
+		branding.SecondaryColor = settings.SecondaryColor
+	}
+	return nil
+}
+
+// link monta o endereço do frontend que recebe o token (APP_ADMIN_URL ou APP_TENANT_URL)
+func (s *AuthEmailService) link(subjectType, path, token, urlCode string) string {
+	base := s.config.App.TenantURL
+	if subjectType == TokenSubjectAdmin {
+		base = s.config.App.AdminURL
+	}
+
+	query := url.Values{"token": {token}}
+	if urlCode != "" {
+		query.Set("tenant", urlCode)
+	}
+	return fmt.Sprintf("%s/%s?%s", base, path, query.Encode())
+}
+
+func (s *AuthEmailService) send(ctx context.Context, to string, email mailer.ActionEmail) error {
+	msg, err := email.Render()
+	if err != nil {
+		return err
+	}
+	msg.To = to
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("erro ao enviar email: %w", err)
+	}
+	return nil
+}
+
+// subjectTable é a tabela dos usuários de cada API
+func subjectTable(subjectType string) string {
+	if subjectType == TokenSubjectAdmin {
+		return "sys_users"
+	}
+	return "users"
+}
+
+func greeting(fullName string) string {
+	if fullName == "" {
+		return "Olá,"
+	}
+	return fmt.Sprintf("Olá, %s,", fullName)
+}
+
+// formatTTL descreve a validade do link ("7 dias", "12 horas", "60 minutos")
+func formatTTL(ttl time.Duration) string {
+	day := 24 * time.Hour
+	if ttl >= day && ttl%day == 0 {
+		if ttl == day {
+			return "1 dia"
+		}
+		return fmt.Sprintf("%d dias", int(ttl/day))
+	}
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		if ttl == time.Hour {
+			return "1 hora"
+		}
+		return fmt.Sprintf("%d horas", int(ttl.Hours()))
+	}
+	return fmt.Sprintf("%d minutos", int(ttl.Minutes()))
+}
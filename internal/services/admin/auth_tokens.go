@@ -0,0 +1,256 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+var (
+	// ErrInvalidRefreshToken é retornado quando o refresh token não existe, expirou ou foi revogado
+	ErrInvalidRefreshToken = errors.New("refresh token inválido ou expirado")
+	// ErrRefreshTokenReused é retornado quando um refresh token já rotacionado é apresentado de novo;
+	// a família inteira é revogada
+	ErrRefreshTokenReused = errors.New("refresh token já utilizado: sessão revogada")
+)
+
+const (
+	// Tokens da Admin API (sys_users)
+	TokenSubjectAdmin = "admin"
+	// Tokens da Tenant API (users)
+	TokenSubjectTenant = "tenant"
+
+	// Tamanho (em bytes aleatórios) do refresh token
+	refreshTokenBytes = 32
+)
+
+// TokenPair é o par de tokens entregue no login e em cada refresh
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// Validade do access token em segundos
+	ExpiresIn int `json:"expires_in"`
+}
+
+// TokenClient identifica o dispositivo que recebeu o refresh token
+type TokenClient struct {
+	UserAgent string
+	IPAddress string
+}
+
+// AuthTokenService emite access tokens curtos e refresh tokens opacos (guardados como hash no
+// Master DB) das APIs Admin e Tenant, e revoga tokens no logout
+type AuthTokenService struct {
+	masterPool  *pgxpool.Pool
+	redisClient *redis.Client
+	keys        *utils.JWTKeySet
+	config      *config.Config
+}
+
+// NewAuthTokenService recebe as chaves de assinatura da API que emite os tokens (nil quando o
+// serviço só faz manutenção, como no worker)
+func NewAuthTokenService(masterPool *pgxpool.Pool, redisClient *redis.Client, keys *utils.JWTKeySet, cfg *config.Config) *AuthTokenService {
+	return &AuthTokenService{
+		masterPool:  masterPool,
+		redisClient: redisClient,
+		keys:        keys,
+		config:      cfg,
+	}
+}
+
+// IssueTokens inicia uma nova sessão (família de refresh tokens) para o usuário. As opções (como o
+// segundo fator) valem para todos os access tokens emitidos pelos refreshes da sessão.
+func (s *AuthTokenService) IssueTokens(ctx context.Context, subjectType string, subjectID uuid.UUID, opts utils.TokenOptions, client TokenClient) (*TokenPair, error) {
+	pair, _, err := s.issue(ctx, s.masterPool, subjectType, subjectID, uuid.New(), opts, client)
+	return pair, err
+}
+
+// Refresh troca um refresh token válido por um novo par. O token apresentado é marcado como usado;
+// apresentá-lo de novo revoga a família e todos os access tokens do usuário.
+func (s *AuthTokenService) Refresh(ctx context.Context, subjectType, refreshToken string, client TokenClient) (*TokenPair, error) {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id, familyID, subjectID uuid.UUID
+	var expiresAt time.Time
+	var usedAt, revokedAt *time.Time
+	var opts utils.TokenOptions
+	err = tx.QueryRow(ctx, `
+		SELECT id, family_id, subject_id, expires_at, used_at, revoked_at, mfa, COALESCE(sso_tenant_id::text, ''), sso_mfa
+		FROM refresh_tokens
+		WHERE token_hash = $1 AND subject_type = $2
+		FOR UPDATE
+	`, utils.HashToken(refreshToken), subjectType).Scan(&id, &familyID, &subjectID, &expiresAt, &usedAt, &revokedAt, &opts.MFA, &opts.SSOTenantID, &opts.SSOMFA)
+	if err == pgx.ErrNoRows {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar refresh token: %w", err)
+	}
+
+	if usedAt != nil && revokedAt == nil {
+		// Reuso: o token foi copiado por outro cliente. Nenhum token da família vale mais.
+		if _, err := tx.Exec(ctx,
+			`UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`,
+			familyID,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao revogar sessão: %w", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("erro ao revogar sessão: %w", err)
+		}
+		// Os access tokens já emitidos pela família não são conhecidos: revoga os do usuário
+		// (as outras sessões obtêm novos pelo refresh)
+		if err := cache.RevokeSubjectAccessTokens(ctx, s.redisClient, cache.TokenSubject(subjectType, subjectID.String()), utils.AccessTokenTTL(s.config)); err != nil {
+			log.Printf("Warning: erro ao revogar access tokens de %s: %v", subjectID, err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if revokedAt != nil || time.Now().After(expiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	active, err := s.subjectActive(ctx, tx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Um papel passou a exigir 2FA depois do login: a sessão sem segundo fator não é renovada
+	if !opts.MFA {
+		required, err := twoFactorRequired(ctx, tx, subjectType, subjectID)
+		if err != nil {
+			return nil, err
+		}
+		if required {
+			return nil, ErrTwoFactorRequired
+		}
+	}
+
+	pair, newID, err := s.issue(ctx, tx, subjectType, subjectID, familyID, opts, client)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET used_at = NOW(), replaced_by = $1 WHERE id = $2`,
+		newID, id,
+	); err != nil {
+		return nil, fmt.Errorf("erro ao rotacionar refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao rotacionar refresh token: %w", err)
+	}
+	return pair, nil
+}
+
+// Logout revoga o access token atual (jti) e, se informado, a sessão do refresh token
+func (s *AuthTokenService) Logout(ctx context.Context, subjectType string, subjectID uuid.UUID, tokenID string, tokenExpiresAt time.Time, refreshToken string) error {
+	if refreshToken != "" {
+		if _, err := s.masterPool.Exec(ctx, `
+			UPDATE refresh_tokens SET revoked_at = NOW()
+			WHERE revoked_at IS NULL AND family_id = (
+				SELECT family_id FROM refresh_tokens
+				WHERE token_hash = $1 AND subject_type = $2 AND subject_id = $3
+			)
+		`, utils.HashToken(refreshToken), subjectType, subjectID); err != nil {
+			return fmt.Errorf("erro ao revogar refresh token: %w", err)
+		}
+	}
+
+	return cache.DenyAccessToken(ctx, s.redisClient, tokenID, tokenExpiresAt)
+}
+
+// LogoutAll revoga todas as sessões do usuário e todos os access tokens emitidos até agora
+func (s *AuthTokenService) LogoutAll(ctx context.Context, subjectType string, subjectID uuid.UUID) error {
+	if _, err := s.masterPool.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE subject_type = $1 AND subject_id = $2 AND revoked_at IS NULL
+	`, subjectType, subjectID); err != nil {
+		return fmt.Errorf("erro ao revogar sessões: %w", err)
+	}
+
+	return cache.RevokeSubjectAccessTokens(ctx, s.redisClient, cache.TokenSubject(subjectType, subjectID.String()), utils.AccessTokenTTL(s.config))
+}
+
+// PurgeExpired remove os refresh tokens vencidos e retorna quantos foram removidos
+func (s *AuthTokenService) PurgeExpired(ctx context.Context) (int64, error) {
+	tag, err := s.masterPool.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao remover refresh tokens vencidos: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// rowQuerier é satisfeito pelo pool e por transações
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// issue gera o access token e um refresh token da família e retorna o id do refresh token
+func (s *AuthTokenService) issue(ctx context.Context, q rowQuerier, subjectType string, subjectID, familyID uuid.UUID, opts utils.TokenOptions, client TokenClient) (*TokenPair, uuid.UUID, error) {
+	var accessToken string
+	var err error
+	switch subjectType {
+	case TokenSubjectAdmin:
+		accessToken, err = utils.GenerateAdminJWT(subjectID, opts, s.keys, s.config)
+	case TokenSubjectTenant:
+		accessToken, err = utils.GenerateTenantJWT(subjectID, opts, s.keys, s.config)
+	default:
+		return nil, uuid.Nil, fmt.Errorf("tipo de token desconhecido: %s", subjectType)
+	}
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("erro ao gerar access token: %w", err)
+	}
+
+	refreshToken, err := utils.GenerateSecret(refreshTokenBytes)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	var id uuid.UUID
+	expiresAt := time.Now().AddDate(0, 0, s.config.JWT.RefreshTokenDays)
+	err = q.QueryRow(ctx, `
+		INSERT INTO refresh_tokens (family_id, subject_type, subject_id, token_hash, expires_at, user_agent, ip_address, mfa, sso_tenant_id, sso_mfa)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, NULLIF($9, '')::uuid, $10)
+		RETURNING id
+	`, familyID, subjectType, subjectID, utils.HashToken(refreshToken), expiresAt, client.UserAgent, client.IPAddress, opts.MFA, opts.SSOTenantID, opts.SSOMFA).Scan(&id)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("erro ao salvar refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL(s.config).Seconds()),
+	}, id, nil
+}
+
+// subjectActive verifica se o dono do refresh token ainda pode autenticar
+func (s *AuthTokenService) subjectActive(ctx context.Context, q rowQuerier, subjectType string, subjectID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`
+	if subjectType == TokenSubjectAdmin {
+		query = `SELECT EXISTS(SELECT 1 FROM sys_users WHERE id = $1 AND status = 'active')`
+	}
+
+	var active bool
+	if err := q.QueryRow(ctx, query, subjectID).Scan(&active); err != nil {
+		return false, fmt.Errorf("erro ao verificar usuário: %w", err)
+	}
+	return active, nil
+}
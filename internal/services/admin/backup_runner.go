@@ -0,0 +1,757 @@
+package admin
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	"github.com/saas-multi-database-api/internal/storage"
+)
+
+const (
+	// Versão do formato do arquivo de backup (manifest.json)
+	backupFormatVersion = 1
+	// Tempo máximo de um backup/restore; jobs 'running' há mais tempo voltam para 'pending'
+	backupJobTimeout = 2 * time.Hour
+	// Prefixo dos arquivos de backup no storage (privado, ver storage.PrivatePrefixes)
+	backupStoragePrefix = "backups"
+)
+
+// BackupManifest descreve o conteúdo de um arquivo de backup (manifest.json).
+//
+// Layout do arquivo (zip):
+//
+//	manifest.json        este manifesto
+//	tables/{tabela}.csv  dados de cada tabela (COPY csv com cabeçalho)
+//	sequences.json       valor atual de cada sequence
+//	media.json           arquivos de mídia do tenant (referências no storage, não o conteúdo)
+type BackupManifest struct {
+	FormatVersion int                        `json:"format_version"`
+	BackupID      uuid.UUID                  `json:"backup_id"`
+	TenantID      uuid.UUID                  `json:"tenant_id"`
+	URLCode       string                     `json:"url_code"`
+	DBCode        string                     `json:"db_code"`
+	SchemaVersion int                        `json:"schema_version"`
+	Tables        []database.TableCopyResult `json:"tables"`
+	MediaCount    int                        `json:"media_count"`
+	CreatedAt     time.Time                  `json:"created_at"`
+}
+
+// BackupMedia é uma entrada do media.json
+type BackupMedia struct {
+	ID            string  `json:"id"`
+	ImageableType string  `json:"imageable_type"`
+	ImageableID   string  `json:"imageable_id"`
+	Variant       string  `json:"variant"`
+	MimeType      string  `json:"mime_type"`
+	FileSize      *int64  `json:"file_size,omitempty"`
+	StorageDriver string  `json:"storage_driver"`
+	StoragePath   string  `json:"storage_path"`
+	PublicURL     *string `json:"public_url,omitempty"`
+}
+
+// backupTarget é o tenant de um job de backup/restore
+type backupTarget struct {
+	TenantID  uuid.UUID
+	URLCode   string
+	DBCode    string
+	ClusterID *uuid.UUID
+	Status    shared.TenantStatus
+}
+
+// BackupRunner executa os backups e restores agendados (worker e CLI) e aplica a retenção
+type BackupRunner struct {
+	masterPool    *pgxpool.Pool
+	clusters      *database.ClusterRegistry
+	migrator      *database.TenantMigrator
+	dbCreds       *DBCredentialService
+	storageDriver storage.StorageDriver
+	redisClient   *redis.Client
+	cfg           *config.Config
+}
+
+func NewBackupRunner(
+	masterPool *pgxpool.Pool,
+	clusters *database.ClusterRegistry,
+	migrator *database.TenantMigrator,
+	dbCreds *DBCredentialService,
+	storageDriver storage.StorageDriver,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *BackupRunner {
+	return &BackupRunner{
+		masterPool:    masterPool,
+		clusters:      clusters,
+		migrator:      migrator,
+		dbCreds:       dbCreds,
+		storageDriver: storageDriver,
+		redisClient:   redisClient,
+		cfg:           cfg,
+	}
+}
+
+// RunPending executa os backups e restores 'pending' (um por vez, SKIP LOCKED entre workers).
+// Jobs 'running' há mais de backupJobTimeout (worker interrompido) voltam para a fila.
+func (r *BackupRunner) RunPending(ctx context.Context) error {
+	for _, table := range []string{"tenant_backups", "tenant_restores"} {
+		if err := requeueStaleJobs(ctx, r.masterPool, table, backupJobTimeout); err != nil {
+			return err
+		}
+	}
+
+	for {
+		backupID, err := claimNextJob(ctx, r.masterPool, "tenant_backups")
+		if err != nil {
+			return err
+		}
+		if backupID == nil {
+			break
+		}
+		if err := r.runBackup(ctx, *backupID); err != nil {
+			log.Printf("Backup %s falhou: %v", backupID, err)
+		}
+	}
+
+	for {
+		restoreID, err := claimNextJob(ctx, r.masterPool, "tenant_restores")
+		if err != nil {
+			return err
+		}
+		if restoreID == nil {
+			return nil
+		}
+		if err := r.runRestore(ctx, *restoreID); err != nil {
+			log.Printf("Restore %s falhou: %v", restoreID, err)
+		}
+	}
+}
+
+// Backup executa imediatamente um backup já registrado como 'pending' (usado pela CLI)
+func (r *BackupRunner) Backup(ctx context.Context, backupID uuid.UUID) error {
+	if err := claimJob(ctx, r.masterPool, "tenant_backups", backupID); err != nil {
+		return err
+	}
+	return r.runBackup(ctx, backupID)
+}
+
+// Restore executa imediatamente um restore já registrado como 'pending' (usado pela CLI)
+func (r *BackupRunner) Restore(ctx context.Context, restoreID uuid.UUID) error {
+	if err := claimJob(ctx, r.masterPool, "tenant_restores", restoreID); err != nil {
+		return err
+	}
+	return r.runRestore(ctx, restoreID)
+}
+
+// requeueStaleJobs devolve para 'pending' os jobs 'running' há mais que timeout (worker interrompido)
+func requeueStaleJobs(ctx context.Context, masterPool *pgxpool.Pool, table string, timeout time.Duration) error {
+	_, err := masterPool.Exec(ctx,
+		fmt.Sprintf(`UPDATE %s SET status = $1 WHERE status = $2 AND started_at < $3`, table),
+		shared.JobStatusPending, shared.JobStatusRunning, time.Now().Add(-timeout),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao reenfileirar jobs interrompidos: %w", err)
+	}
+	return nil
+}
+
+// claimNextJob marca o job pendente mais antigo da tabela como 'running' e retorna seu ID (nil = fila vazia)
+func claimNextJob(ctx context.Context, masterPool *pgxpool.Pool, table string) (*uuid.UUID, error) {
+	var id uuid.UUID
+	err := masterPool.QueryRow(ctx, fmt.Sprintf(`
+		UPDATE %[1]s SET status = $1, started_at = $2, error = NULL
+		WHERE id = (
+			SELECT id FROM %[1]s WHERE status = $3
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id
+	`, table), shared.JobStatusRunning, time.Now(), shared.JobStatusPending).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar jobs pendentes: %w", err)
+	}
+	return &id, nil
+}
+
+// claimJob marca um job específico como 'running'
+func claimJob(ctx context.Context, masterPool *pgxpool.Pool, table string, id uuid.UUID) error {
+	tag, err := masterPool.Exec(ctx,
+		fmt.Sprintf(`UPDATE %s SET status = $1, started_at = $2, error = NULL WHERE id = $3 AND status = $4`, table),
+		shared.JobStatusRunning, time.Now(), id, shared.JobStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("job %s não está pendente", id)
+	}
+	return nil
+}
+
+// runBackup gera o arquivo do backup, envia ao storage e aplica a retenção do tenant
+func (r *BackupRunner) runBackup(ctx context.Context, backupID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, backupJobTimeout)
+	defer cancel()
+
+	var target backupTarget
+	err := r.masterPool.QueryRow(ctx, `
+		SELECT t.id, t.url_code, t.db_code::text, t.cluster_id, t.status
+		FROM tenant_backups b JOIN tenants t ON t.id = b.tenant_id
+		WHERE b.id = $1
+	`, backupID).Scan(&target.TenantID, &target.URLCode, &target.DBCode, &target.ClusterID, &target.Status)
+	if err != nil {
+		return failJob(ctx, r.masterPool, "tenant_backups", backupID, fmt.Errorf("erro ao buscar tenant do backup: %w", err))
+	}
+
+	log.Printf("Iniciando backup %s do tenant %s", backupID, target.URLCode)
+
+	manifest, storagePath, size, err := r.createArchive(ctx, backupID, target)
+	if err != nil {
+		return failJob(ctx, r.masterPool, "tenant_backups", backupID, err)
+	}
+
+	tables, err := json.Marshal(manifest.Tables)
+	if err != nil {
+		return failJob(ctx, r.masterPool, "tenant_backups", backupID, err)
+	}
+	_, err = r.masterPool.Exec(ctx, `
+		UPDATE tenant_backups
+		SET status = $1, storage_path = $2, size_bytes = $3, schema_version = $4, tables = $5,
+		    media_count = $6, completed_at = $7
+		WHERE id = $8
+	`, shared.JobStatusCompleted, storagePath, size, manifest.SchemaVersion, tables,
+		manifest.MediaCount, time.Now(), backupID)
+	if err != nil {
+		return fmt.Errorf("erro ao concluir backup: %w", err)
+	}
+
+	log.Printf("Backup %s do tenant %s concluído (%d bytes, %d tabela(s), %d mídia(s))",
+		backupID, target.URLCode, size, len(manifest.Tables), manifest.MediaCount)
+
+	if _, err := r.PruneTenant(ctx, target.TenantID); err != nil {
+		log.Printf("Erro ao aplicar retenção de backups do tenant %s: %v", target.URLCode, err)
+	}
+	return nil
+}
+
+// createArchive gera o zip do backup em arquivo temporário (leitura em snapshot consistente)
+// e o envia ao storage em backups/{tenant_id}/{backup_id}.zip
+func (r *BackupRunner) createArchive(ctx context.Context, backupID uuid.UUID, target backupTarget) (*BackupManifest, string, int64, error) {
+	cluster, err := r.clusters.Get(ctx, target.ClusterID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	dsn, err := r.clusters.AdminDSN(cluster, database.TenantDBName(target.DBCode))
+	if err != nil {
+		return nil, "", 0, err
+	}
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao conectar no database do tenant: %w", err)
+	}
+	defer pool.Close()
+
+	version, err := r.migrator.CurrentVersion(ctx, pool)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao conectar no database do tenant: %w", err)
+	}
+	defer conn.Release()
+
+	// Todas as tabelas lidas no mesmo snapshot, sem bloquear as escritas do tenant
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao iniciar snapshot: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	file, err := os.CreateTemp("", "tenant-backup-*.zip")
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	manifest := &BackupManifest{
+		FormatVersion: backupFormatVersion,
+		BackupID:      backupID,
+		TenantID:      target.TenantID,
+		URLCode:       target.URLCode,
+		DBCode:        target.DBCode,
+		SchemaVersion: version,
+		CreatedAt:     time.Now(),
+	}
+
+	archive := zip.NewWriter(file)
+
+	tableNames, err := database.ListTenantTables(ctx, conn.Conn())
+	if err != nil {
+		return nil, "", 0, err
+	}
+	for _, table := range tableNames {
+		w, err := archive.Create("tables/" + table + ".csv")
+		if err != nil {
+			return nil, "", 0, err
+		}
+		rows, err := database.DumpTable(ctx, conn.Conn(), table, w)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		manifest.Tables = append(manifest.Tables, database.TableCopyResult{Table: table, Rows: rows})
+	}
+
+	sequences, err := database.ListSequences(ctx, conn.Conn())
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if err := writeArchiveJSON(archive, "sequences.json", sequences); err != nil {
+		return nil, "", 0, err
+	}
+
+	media, err := listBackupMedia(ctx, tx, tableNames)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	manifest.MediaCount = len(media)
+	if err := writeArchiveJSON(archive, "media.json", media); err != nil {
+		return nil, "", 0, err
+	}
+
+	if err := writeArchiveJSON(archive, "manifest.json", manifest); err != nil {
+		return nil, "", 0, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao finalizar arquivo de backup: %w", err)
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, "", 0, err
+	}
+
+	objectPath := path.Join(backupPrefix(target.TenantID), backupID.String()+".zip")
+	storagePath, err := r.storageDriver.UploadPrivate(ctx, file, objectPath)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao enviar backup ao storage: %w", err)
+	}
+
+	return manifest, storagePath, size, nil
+}
+
+// runRestore carrega um backup no database do tenant. O tenant fica 'migrating' (APIs recusam
+// requisições) durante o restore e volta ao status anterior ao final, com sucesso ou falha.
+func (r *BackupRunner) runRestore(ctx context.Context, restoreID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, backupJobTimeout)
+	defer cancel()
+
+	var target backupTarget
+	var mode, storagePath string
+	var previous *string
+	err := r.masterPool.QueryRow(ctx, `
+		SELECT t.id, t.url_code, t.db_code::text, t.cluster_id, t.status, rs.mode, rs.previous_status, b.storage_path
+		FROM tenant_restores rs
+		JOIN tenants t ON t.id = rs.tenant_id
+		JOIN tenant_backups b ON b.id = rs.backup_id
+		WHERE rs.id = $1
+	`, restoreID).Scan(&target.TenantID, &target.URLCode, &target.DBCode, &target.ClusterID, &target.Status,
+		&mode, &previous, &storagePath)
+	if err != nil {
+		return failJob(ctx, r.masterPool, "tenant_restores", restoreID, fmt.Errorf("erro ao buscar restore: %w", err))
+	}
+
+	// Retry de um restore interrompido: o tenant já está 'migrating' e o status anterior foi gravado
+	restoreStatus := target.Status
+	if previous != nil {
+		restoreStatus = shared.TenantStatus(*previous)
+	} else {
+		if restoreStatus == shared.TenantStatusMigrating {
+			restoreStatus = shared.TenantStatusActive
+		}
+		if _, err := r.masterPool.Exec(ctx,
+			`UPDATE tenant_restores SET previous_status = $1 WHERE id = $2`, restoreStatus, restoreID,
+		); err != nil {
+			return failJob(ctx, r.masterPool, "tenant_restores", restoreID, fmt.Errorf("erro ao gravar status do tenant: %w", err))
+		}
+	}
+
+	log.Printf("Iniciando restore %s do tenant %s (modo %s)", restoreID, target.URLCode, mode)
+
+	if err := setTenantStatus(ctx, r.masterPool, target.TenantID, shared.TenantStatusMigrating); err != nil {
+		return failJob(ctx, r.masterPool, "tenant_restores", restoreID, err)
+	}
+	invalidateTenantContext(ctx, r.redisClient, target.URLCode)
+
+	restoreErr := r.restoreArchive(ctx, target, mode, storagePath)
+
+	// Liberar o tenant mesmo em caso de falha (no modo 'existing' a carga é transacional)
+	if err := setTenantStatus(ctx, r.masterPool, target.TenantID, restoreStatus); err != nil {
+		log.Printf("Erro ao restaurar status do tenant %s: %v", target.URLCode, err)
+	}
+	if err := r.redisClient.Publish(ctx, database.TenantPoolRefreshChannel, target.DBCode).Err(); err != nil {
+		log.Printf("Erro ao publicar refresh do pool do tenant %s: %v", target.URLCode, err)
+	}
+
+	if restoreErr != nil {
+		return failJob(ctx, r.masterPool, "tenant_restores", restoreID, restoreErr)
+	}
+
+	if _, err := r.masterPool.Exec(ctx,
+		`UPDATE tenant_restores SET status = $1, completed_at = $2 WHERE id = $3`,
+		shared.JobStatusCompleted, time.Now(), restoreID,
+	); err != nil {
+		return fmt.Errorf("erro ao concluir restore: %w", err)
+	}
+
+	log.Printf("Restore %s do tenant %s concluído", restoreID, target.URLCode)
+	return nil
+}
+
+// restoreArchive baixa o arquivo do backup e reconstrói o database do tenant a partir dele
+func (r *BackupRunner) restoreArchive(ctx context.Context, target backupTarget, mode, storagePath string) error {
+	archive, cleanup, err := r.openArchive(ctx, storagePath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var manifest BackupManifest
+	if err := readArchiveJSON(archive, "manifest.json", &manifest); err != nil {
+		return err
+	}
+	if manifest.FormatVersion > backupFormatVersion {
+		return fmt.Errorf("formato de backup %d não suportado", manifest.FormatVersion)
+	}
+	if manifest.SchemaVersion > r.migrator.LatestVersion() {
+		return fmt.Errorf("backup na versão %d, mais recente que as migrations disponíveis (%d)",
+			manifest.SchemaVersion, r.migrator.LatestVersion())
+	}
+
+	cluster, err := r.clusters.Get(ctx, target.ClusterID)
+	if err != nil {
+		return err
+	}
+	adminPool, err := r.clusters.AdminPool(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	dbName := database.TenantDBName(target.DBCode)
+	dbIdent := pgx.Identifier{dbName}.Sanitize()
+	if mode == RestoreModeFresh {
+		if _, err := adminPool.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", dbIdent)); err != nil {
+			return fmt.Errorf("erro ao remover database do tenant: %w", err)
+		}
+		if _, err := adminPool.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s", dbIdent)); err != nil {
+			return fmt.Errorf("erro ao criar database do tenant: %w", err)
+		}
+	} else {
+		// Conexões das APIs são reabertas após o refresh do pool
+		if _, err := adminPool.Exec(ctx, `
+			SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+			WHERE datname = $1 AND pid <> pg_backend_pid()
+		`, dbName); err != nil {
+			return fmt.Errorf("erro ao encerrar conexões do tenant: %w", err)
+		}
+	}
+
+	dsn, err := r.clusters.AdminDSN(cluster, dbName)
+	if err != nil {
+		return err
+	}
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar no database do tenant: %w", err)
+	}
+	defer pool.Close()
+
+	// Schema na versão do backup para a carga; depois, atualizado até a mais recente
+	if _, err := r.migrator.MigrateTo(ctx, pool, manifest.SchemaVersion); err != nil {
+		return fmt.Errorf("erro ao aplicar schema do backup: %w", err)
+	}
+
+	if err := loadArchive(ctx, pool, archive, &manifest); err != nil {
+		return err
+	}
+
+	if _, err := r.migrator.Up(ctx, pool); err != nil {
+		return fmt.Errorf("erro ao atualizar schema após o restore: %w", err)
+	}
+
+	// Database recriado (modo fresh) precisa das permissões do login dedicado novamente
+	if err := r.dbCreds.Provision(ctx, target.TenantID, target.DBCode); err != nil {
+		return fmt.Errorf("erro ao reaplicar credenciais do tenant: %w", err)
+	}
+
+	return nil
+}
+
+// openArchive baixa o backup do storage para um arquivo temporário (zip exige leitura aleatória)
+func (r *BackupRunner) openArchive(ctx context.Context, storagePath string) (*zip.Reader, func(), error) {
+	reader, err := r.storageDriver.GetReader(ctx, storagePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao baixar backup: %w", err)
+	}
+	defer reader.Close()
+
+	file, err := os.CreateTemp("", "tenant-restore-*.zip")
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	size, err := io.Copy(file, reader)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("erro ao baixar backup: %w", err)
+	}
+
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("arquivo de backup inválido: %w", err)
+	}
+	return archive, cleanup, nil
+}
+
+// loadArchive esvazia as tabelas e carrega os dados do backup em uma única transação
+func loadArchive(ctx context.Context, pool *pgxpool.Pool, archive *zip.Reader, manifest *BackupManifest) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar no database do tenant: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	reset, err := database.TruncateTenantTables(ctx, conn.Conn())
+	if err != nil {
+		return err
+	}
+	defer reset()
+
+	for _, expected := range manifest.Tables {
+		entry, err := archive.Open("tables/" + expected.Table + ".csv")
+		if err != nil {
+			return fmt.Errorf("tabela %s ausente no backup: %w", expected.Table, err)
+		}
+		rows, err := database.LoadTable(ctx, conn.Conn(), expected.Table, entry)
+		entry.Close()
+		if err != nil {
+			return err
+		}
+		if rows != expected.Rows {
+			return fmt.Errorf("quantidade de linhas divergente em %s: backup %d, carregadas %d", expected.Table, expected.Rows, rows)
+		}
+	}
+
+	var sequences map[string]int64
+	if err := readArchiveJSON(archive, "sequences.json", &sequences); err != nil {
+		return err
+	}
+	if err := database.SetSequences(ctx, conn.Conn(), sequences); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao confirmar restore: %w", err)
+	}
+	return nil
+}
+
+// Prune aplica a retenção de backups em todos os tenants. Retorna a quantidade removida.
+func (r *BackupRunner) Prune(ctx context.Context) (int, error) {
+	rows, err := r.masterPool.Query(ctx, `SELECT DISTINCT tenant_id FROM tenant_backups`)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao listar tenants com backups: %w", err)
+	}
+	tenantIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return 0, fmt.Errorf("erro ao listar tenants com backups: %w", err)
+	}
+
+	pruned := 0
+	for _, tenantID := range tenantIDs {
+		n, err := r.PruneTenant(ctx, tenantID)
+		pruned += n
+		if err != nil {
+			return pruned, fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+	}
+	return pruned, nil
+}
+
+// PruneTenant remove os backups do tenant fora da retenção: além dos BackupRetentionCount mais
+// recentes ou mais antigos que BackupRetentionDays. O backup concluído mais recente nunca é
+// removido, nem backups com restore pendente. Falhas antigas também são descartadas.
+func (r *BackupRunner) PruneTenant(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	rows, err := r.masterPool.Query(ctx, `
+		SELECT b.id, b.status, b.storage_path, b.created_at
+		FROM tenant_backups b
+		WHERE b.tenant_id = $1 AND b.status IN ($2, $3)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM tenant_restores rs
+		      WHERE rs.backup_id = b.id AND rs.status IN ($4, $5)
+		  )
+		ORDER BY b.created_at DESC
+	`, tenantID, shared.JobStatusCompleted, shared.JobStatusFailed, shared.JobStatusPending, shared.JobStatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao listar backups: %w", err)
+	}
+
+	type candidate struct {
+		id          uuid.UUID
+		status      shared.JobStatus
+		storagePath *string
+		createdAt   time.Time
+	}
+	var backups []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.id, &c.status, &c.storagePath, &c.createdAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("erro ao ler backup: %w", err)
+		}
+		backups = append(backups, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("erro ao listar backups: %w", err)
+	}
+
+	keepCount := r.cfg.Tenants.BackupRetentionCount
+	var cutoff time.Time
+	if r.cfg.Tenants.BackupRetentionDays > 0 {
+		cutoff = time.Now().AddDate(0, 0, -r.cfg.Tenants.BackupRetentionDays)
+	}
+
+	pruned, completed := 0, 0
+	for _, b := range backups {
+		expired := !cutoff.IsZero() && b.createdAt.Before(cutoff)
+
+		if b.status == shared.JobStatusCompleted {
+			completed++
+			overCount := keepCount > 0 && completed > keepCount
+			if completed == 1 || (!overCount && !expired) {
+				continue
+			}
+		} else if !expired {
+			continue
+		}
+
+		if b.storagePath != nil {
+			if err := r.storageDriver.Delete(ctx, *b.storagePath); err != nil {
+				return pruned, fmt.Errorf("erro ao remover arquivo do backup %s: %w", b.id, err)
+			}
+		}
+		if _, err := r.masterPool.Exec(ctx, `DELETE FROM tenant_backups WHERE id = $1`, b.id); err != nil {
+			return pruned, fmt.Errorf("erro ao remover backup %s: %w", b.id, err)
+		}
+		pruned++
+	}
+
+	return pruned, nil
+}
+
+// failJob marca o job como 'failed' com a mensagem do erro e devolve o erro
+func failJob(ctx context.Context, masterPool *pgxpool.Pool, table string, id uuid.UUID, jobErr error) error {
+	if _, err := masterPool.Exec(context.WithoutCancel(ctx),
+		fmt.Sprintf(`UPDATE %s SET status = $1, error = $2, completed_at = $3 WHERE id = $4`, table),
+		shared.JobStatusFailed, jobErr.Error(), time.Now(), id,
+	); err != nil {
+		log.Printf("Erro ao registrar falha do job %s: %v", id, err)
+	}
+	return jobErr
+}
+
+// listBackupMedia lista as mídias do tenant para o media.json (vazio se o schema não tiver images)
+func listBackupMedia(ctx context.Context, tx pgx.Tx, tables []string) ([]BackupMedia, error) {
+	media := []BackupMedia{}
+	hasImages := false
+	for _, table := range tables {
+		if table == "images" {
+			hasImages = true
+		}
+	}
+	if !hasImages {
+		return media, nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id::text, imageable_type, imageable_id::text, variant::text, mime_type, file_size,
+		       storage_driver, storage_path, public_url
+		FROM images
+		ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar mídias: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m BackupMedia
+		if err := rows.Scan(&m.ID, &m.ImageableType, &m.ImageableID, &m.Variant, &m.MimeType, &m.FileSize,
+			&m.StorageDriver, &m.StoragePath, &m.PublicURL); err != nil {
+			return nil, fmt.Errorf("erro ao ler mídia: %w", err)
+		}
+		media = append(media, m)
+	}
+	return media, rows.Err()
+}
+
+func writeArchiveJSON(archive *zip.Writer, name string, v any) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func readArchiveJSON(archive *zip.Reader, name string, v any) error {
+	entry, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("%s ausente no backup: %w", name, err)
+	}
+	defer entry.Close()
+
+	if err := json.NewDecoder(entry).Decode(v); err != nil {
+		return fmt.Errorf("%s inválido: %w", name, err)
+	}
+	return nil
+}
+
+// backupPrefix é o prefixo dos backups de um tenant no storage
+func backupPrefix(tenantID uuid.UUID) string {
+	return path.Join(backupStoragePrefix, tenantID.String())
+}
@@ -0,0 +1,387 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// Políticas de placement de novos tenants (TENANT_PLACEMENT_POLICY)
+const (
+	PlacementLeastLoaded = "least_loaded" // Cluster compartilhado com menos tenants
+	PlacementByPlan      = "plan"         // Clusters reservados ao plano do tenant, senão least_loaded
+	PlacementByRegion    = "region"       // Clusters da região do tenant, senão least_loaded
+)
+
+var (
+	// ErrClusterNotFound é retornado quando o cluster não existe
+	ErrClusterNotFound = errors.New("cluster não encontrado")
+	// ErrNoClusterAvailable é retornado quando nenhum cluster pode receber o tenant
+	ErrNoClusterAvailable = errors.New("nenhum cluster disponível para o tenant")
+	// ErrClusterInUse é retornado ao remover um cluster que ainda hospeda tenants
+	ErrClusterInUse = errors.New("cluster ainda hospeda tenants")
+	// ErrInvalidCluster é retornado para dados de cluster inválidos ou sem conexão
+	ErrInvalidCluster = errors.New("cluster inválido")
+	// ErrInvalidMove é retornado para movimentações de cluster não permitidas
+	ErrInvalidMove = errors.New("movimentação de cluster não permitida")
+)
+
+// ClusterRequest representa os dados para criar/alterar um cluster (campos nil não são alterados)
+type ClusterRequest struct {
+	Name          *string               `json:"name"`
+	Host          *string               `json:"host"`
+	Port          *int                  `json:"port"`
+	PoolHost      *string               `json:"pool_host"`
+	PoolPort      *int                  `json:"pool_port"`
+	ReplicaHost   *string               `json:"replica_host"` // "" remove a réplica
+	ReplicaPort   *int                  `json:"replica_port"`
+	AdminUser     *string               `json:"admin_user"`
+	AdminPassword *string               `json:"admin_password"`
+	Region        *string               `json:"region"`
+	PlanID        *uuid.UUID            `json:"plan_id"`
+	Dedicated     *bool                 `json:"dedicated"`
+	MaxTenants    *int                  `json:"max_tenants"`
+	Status        *shared.ClusterStatus `json:"status"`
+}
+
+// MoveTenantRequest representa o pedido de mover um tenant para outro cluster
+type MoveTenantRequest struct {
+	ClusterID uuid.UUID `json:"cluster_id" binding:"required"`
+}
+
+// ClusterService gerencia o registro de clusters, o placement de tenants e as movimentações
+type ClusterService struct {
+	masterPool *pgxpool.Pool
+	clusters   *database.ClusterRegistry
+	queue      *ProvisioningQueue
+	cfg        *config.Config
+}
+
+func NewClusterService(
+	masterPool *pgxpool.Pool,
+	clusters *database.ClusterRegistry,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *ClusterService {
+	return &ClusterService{
+		masterPool: masterPool,
+		clusters:   clusters,
+		queue:      NewProvisioningQueue(redisClient),
+		cfg:        cfg,
+	}
+}
+
+// ListClusters lista os clusters com a quantidade de tenants de cada um
+func (s *ClusterService) ListClusters(ctx context.Context) ([]admin.DBCluster, error) {
+	return s.clusters.List(ctx)
+}
+
+// GetCluster retorna um cluster com a quantidade de tenants
+func (s *ClusterService) GetCluster(ctx context.Context, clusterID uuid.UUID) (*admin.DBCluster, error) {
+	clusters, err := s.clusters.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusters {
+		if clusters[i].ID == clusterID {
+			return &clusters[i], nil
+		}
+	}
+	return nil, ErrClusterNotFound
+}
+
+// CreateCluster registra um cluster após validar a conexão administrativa
+func (s *ClusterService) CreateCluster(ctx context.Context, req ClusterRequest) (*admin.DBCluster, error) {
+	if req.Name == nil || *req.Name == "" || req.Host == nil || *req.Host == "" {
+		return nil, fmt.Errorf("%w: name e host são obrigatórios", ErrInvalidCluster)
+	}
+
+	cluster := &admin.DBCluster{
+		Name:       *req.Name,
+		Host:       *req.Host,
+		Port:       5432,
+		PoolHost:   req.PoolHost,
+		PoolPort:   req.PoolPort,
+		AdminUser:  req.AdminUser,
+		Region:     req.Region,
+		PlanID:     req.PlanID,
+		MaxTenants: req.MaxTenants,
+		Status:     shared.ClusterStatusActive,
+	}
+	if req.Port != nil {
+		cluster.Port = *req.Port
+	}
+	if req.Dedicated != nil {
+		cluster.Dedicated = *req.Dedicated
+	}
+	if req.Status != nil {
+		cluster.Status = *req.Status
+	}
+	if req.AdminPassword != nil {
+		encrypted, err := utils.EncryptSecret(s.cfg.Tenants.DBCredentialsKey, *req.AdminPassword)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao criptografar senha do cluster: %w", err)
+		}
+		cluster.AdminPasswordEncrypted = &encrypted
+	}
+
+	if err := s.checkConnection(ctx, cluster); err != nil {
+		return nil, err
+	}
+
+	err := s.masterPool.QueryRow(ctx, `
+		INSERT INTO db_clusters (name, host, port, pool_host, pool_port, replica_host, replica_port, admin_user,
+			admin_password_encrypted, region, plan_id, dedicated, max_tenants, status)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`, cluster.Name, cluster.Host, cluster.Port, cluster.PoolHost, cluster.PoolPort, req.ReplicaHost, req.ReplicaPort,
+		cluster.AdminUser, cluster.AdminPasswordEncrypted, cluster.Region, cluster.PlanID, cluster.Dedicated,
+		cluster.MaxTenants, cluster.Status,
+	).Scan(&cluster.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar cluster: %w", err)
+	}
+
+	return s.GetCluster(ctx, cluster.ID)
+}
+
+// UpdateCluster altera um cluster. Mudanças de host/credenciais/réplica só afetam pools abertos depois
+// da alteração; use para manutenção, não para mover dados (ver MoveTenant).
+func (s *ClusterService) UpdateCluster(ctx context.Context, clusterID uuid.UUID, req ClusterRequest) (*admin.DBCluster, error) {
+	var encrypted *string
+	if req.AdminPassword != nil {
+		value, err := utils.EncryptSecret(s.cfg.Tenants.DBCredentialsKey, *req.AdminPassword)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao criptografar senha do cluster: %w", err)
+		}
+		encrypted = &value
+	}
+
+	tag, err := s.masterPool.Exec(ctx, `
+		UPDATE db_clusters SET
+			name = COALESCE($1, name),
+			host = COALESCE($2, host),
+			port = COALESCE($3, port),
+			pool_host = COALESCE($4, pool_host),
+			pool_port = COALESCE($5, pool_port),
+			replica_host = CASE WHEN $6::text IS NULL THEN replica_host ELSE NULLIF($6, '') END,
+			replica_port = COALESCE($7, replica_port),
+			admin_user = COALESCE($8, admin_user),
+			admin_password_encrypted = COALESCE($9, admin_password_encrypted),
+			region = COALESCE($10, region),
+			plan_id = COALESCE($11, plan_id),
+			dedicated = COALESCE($12, dedicated),
+			max_tenants = COALESCE($13, max_tenants),
+			status = COALESCE($14, status),
+			updated_at = $15
+		WHERE id = $16
+	`, req.Name, req.Host, req.Port, req.PoolHost, req.PoolPort, req.ReplicaHost, req.ReplicaPort, req.AdminUser,
+		encrypted, req.Region, req.PlanID, req.Dedicated, req.MaxTenants, req.Status, time.Now(), clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao atualizar cluster: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrClusterNotFound
+	}
+
+	return s.GetCluster(ctx, clusterID)
+}
+
+// DeleteCluster remove um cluster sem tenants (o cluster padrão não pode ser removido)
+func (s *ClusterService) DeleteCluster(ctx context.Context, clusterID uuid.UUID) error {
+	cluster, err := s.GetCluster(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.IsDefault {
+		return fmt.Errorf("%w: o cluster padrão não pode ser removido", ErrClusterInUse)
+	}
+	if cluster.TenantCount > 0 {
+		return ErrClusterInUse
+	}
+
+	if _, err := s.masterPool.Exec(ctx, `DELETE FROM db_clusters WHERE id = $1`, clusterID); err != nil {
+		return fmt.Errorf("erro ao remover cluster: %w", err)
+	}
+	return nil
+}
+
+// AssignCluster define o cluster de um tenant ainda sem cluster, conforme a política configurada.
+// Tenants com cluster já definido (criados com cluster_id ou retry do worker) mantêm o atual.
+func (s *ClusterService) AssignCluster(ctx context.Context, tenantID uuid.UUID) (*admin.DBCluster, error) {
+	var clusterID, planID *uuid.UUID
+	var region *string
+	err := s.masterPool.QueryRow(ctx,
+		`SELECT cluster_id, plan_id, region FROM tenants WHERE id = $1`, tenantID,
+	).Scan(&clusterID, &planID, &region)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if clusterID != nil {
+		return s.clusters.Get(ctx, clusterID)
+	}
+
+	cluster, err := s.selectCluster(ctx, planID, region)
+	if err != nil {
+		return nil, err
+	}
+
+	// Outro worker pode ter atribuído em paralelo: prevalece o primeiro
+	err = s.masterPool.QueryRow(ctx, `
+		UPDATE tenants SET cluster_id = COALESCE(cluster_id, $1), updated_at = $2
+		WHERE id = $3
+		RETURNING cluster_id
+	`, cluster.ID, time.Now(), tenantID).Scan(&clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao atribuir cluster: %w", err)
+	}
+
+	return s.clusters.Get(ctx, clusterID)
+}
+
+// selectCluster aplica a política de placement sobre os clusters ativos, não dedicados e com capacidade
+func (s *ClusterService) selectCluster(ctx context.Context, planID *uuid.UUID, region *string) (*admin.DBCluster, error) {
+	clusters, err := s.clusters.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var general, byPlan, byRegion []admin.DBCluster
+	for _, c := range clusters {
+		if c.Status != shared.ClusterStatusActive || c.Dedicated {
+			continue
+		}
+		if c.MaxTenants != nil && c.TenantCount >= *c.MaxTenants {
+			continue
+		}
+		if c.PlanID != nil {
+			// Clusters reservados a um plano só recebem tenants desse plano
+			if planID != nil && *c.PlanID == *planID {
+				byPlan = append(byPlan, c)
+			}
+			continue
+		}
+		general = append(general, c)
+		if region != nil && c.Region != nil && *c.Region == *region {
+			byRegion = append(byRegion, c)
+		}
+	}
+
+	candidates := general
+	switch s.cfg.Tenants.PlacementPolicy {
+	case PlacementByPlan:
+		if len(byPlan) > 0 {
+			candidates = byPlan
+		}
+	case PlacementByRegion:
+		if len(byRegion) > 0 {
+			candidates = byRegion
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoClusterAvailable
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].TenantCount < candidates[j].TenantCount
+	})
+
+	return &candidates[0], nil
+}
+
+// MoveTenant agenda a movimentação do database do tenant para outro cluster.
+// O worker coloca o tenant em 'migrating', copia os dados, troca o cluster e remove o database de origem.
+func (s *ClusterService) MoveTenant(ctx context.Context, tenantID uuid.UUID, req MoveTenantRequest) (*ProvisionEvent, error) {
+	var dbCode, urlCode string
+	var status shared.TenantStatus
+	var clusterID *uuid.UUID
+	var deletionScheduledAt *time.Time
+	err := s.masterPool.QueryRow(ctx, `
+		SELECT db_code::text, url_code, status, cluster_id, deletion_scheduled_at FROM tenants WHERE id = $1
+	`, tenantID).Scan(&dbCode, &urlCode, &status, &clusterID, &deletionScheduledAt)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+
+	if deletionScheduledAt != nil {
+		return nil, ErrTenantPendingDeletion
+	}
+	if status != shared.TenantStatusActive && status != shared.TenantStatusSuspended {
+		return nil, fmt.Errorf("%w: tenant está '%s'", ErrInvalidMove, status)
+	}
+
+	source, err := s.clusters.Get(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.clusters.Get(ctx, &req.ClusterID)
+	if err != nil {
+		return nil, ErrClusterNotFound
+	}
+
+	if target.ID == source.ID {
+		return nil, fmt.Errorf("%w: tenant já está no cluster %s", ErrInvalidMove, target.Name)
+	}
+	if target.Status == shared.ClusterStatusDisabled {
+		return nil, fmt.Errorf("%w: cluster %s está desativado", ErrInvalidMove, target.Name)
+	}
+	// O database mantém o mesmo nome: os dois clusters não podem ser o mesmo servidor
+	if target.Host == source.Host && target.Port == source.Port {
+		return nil, fmt.Errorf("%w: origem e destino apontam para o mesmo servidor", ErrInvalidMove)
+	}
+
+	event := ProvisionEvent{
+		Type:            ProvisionEventTypeMove,
+		TenantID:        tenantID,
+		DBCode:          dbCode,
+		URLCode:         urlCode,
+		Timestamp:       time.Now(),
+		SourceClusterID: clusterID,
+		TargetClusterID: &target.ID,
+		PreviousStatus:  status,
+	}
+
+	if err := s.queue.Enqueue(ctx, event); err != nil {
+		return nil, fmt.Errorf("erro ao agendar movimentação: %w", err)
+	}
+
+	return &event, nil
+}
+
+// checkConnection valida as credenciais administrativas do cluster antes de registrá-lo
+func (s *ClusterService) checkConnection(ctx context.Context, cluster *admin.DBCluster) error {
+	dsn, err := s.clusters.AdminDSN(cluster, "postgres")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("%w: não foi possível conectar no cluster %s: %v", ErrInvalidCluster, cluster.Name, err)
+	}
+	defer conn.Close(ctx)
+
+	return nil
+}
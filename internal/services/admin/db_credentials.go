@@ -0,0 +1,417 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+var (
+	// ErrTenantNotProvisioned é retornado quando o database do tenant ainda não existe
+	ErrTenantNotProvisioned = errors.New("tenant ainda não foi provisionado")
+	// ErrCredentialRotationPending é retornado ao rotacionar antes de o login anterior ser desativado
+	ErrCredentialRotationPending = errors.New("rotação anterior ainda em andamento; aguarde retire_after")
+	// ErrDBCredentialsNotFound é retornado para tenants ainda sem login dedicado
+	ErrDBCredentialsNotFound = errors.New("tenant não possui credenciais dedicadas; execute a rotação para criá-las")
+)
+
+// Tamanho (em bytes aleatórios) das senhas dos logins de tenant
+const tenantDBPasswordBytes = 32
+
+// DBCredentialInfo descreve as credenciais de um tenant (sem a senha)
+type DBCredentialInfo struct {
+	TenantID         uuid.UUID  `json:"tenant_id"`
+	GroupRole        string     `json:"group_role"`
+	Username         string     `json:"username"`
+	RetiringUsername *string    `json:"retiring_username,omitempty"`
+	RetireAfter      *time.Time `json:"retire_after,omitempty"`
+	RotatedAt        *time.Time `json:"rotated_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// DBCredentialService gerencia os logins Postgres dedicados de cada tenant.
+// Cada tenant tem uma role de grupo (NOLOGIN) com as permissões no seu database e dois logins
+// (_a/_b) membros do grupo. A rotação troca a senha do login inativo e passa a usá-lo, de modo
+// que conexões abertas com o login anterior continuam válidas até serem recicladas.
+type DBCredentialService struct {
+	masterPool  *pgxpool.Pool
+	clusters    *database.ClusterRegistry
+	redisClient *redis.Client
+	cfg         *config.Config
+}
+
+func NewDBCredentialService(
+	masterPool *pgxpool.Pool,
+	clusters *database.ClusterRegistry,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *DBCredentialService {
+	return &DBCredentialService{
+		masterPool:  masterPool,
+		clusters:    clusters,
+		redisClient: redisClient,
+		cfg:         cfg,
+	}
+}
+
+// Provision cria as roles do tenant no cluster onde ele está, restringe o database a elas e
+// grava a credencial ativa. É idempotente: se a credencial já existe, apenas reaplica a senha
+// armazenada no login ativo.
+func (s *DBCredentialService) Provision(ctx context.Context, tenantID uuid.UUID, dbCode string) error {
+	cluster, err := s.clusters.TenantCluster(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	return s.ProvisionOnCluster(ctx, cluster, tenantID, dbCode)
+}
+
+// ProvisionOnCluster é o Provision em um cluster específico (usado ao mover o tenant de cluster,
+// antes de tenants.cluster_id apontar para o destino)
+func (s *DBCredentialService) ProvisionOnCluster(ctx context.Context, cluster *admin.DBCluster, tenantID uuid.UUID, dbCode string) error {
+	adminPool, err := s.clusters.AdminPool(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ensureRoles(ctx, adminPool, dbCode); err != nil {
+		return err
+	}
+
+	if err := s.grantAccess(ctx, cluster, adminPool, dbCode); err != nil {
+		return err
+	}
+
+	group := database.TenantGroupRole(dbCode)
+	loginA, _ := database.TenantLoginRoles(dbCode)
+
+	password, err := utils.GenerateSecret(tenantDBPasswordBytes)
+	if err != nil {
+		return err
+	}
+	encrypted, err := utils.EncryptSecret(s.cfg.Tenants.DBCredentialsKey, password)
+	if err != nil {
+		return fmt.Errorf("erro ao criptografar senha do tenant: %w", err)
+	}
+
+	// A linha já existente (retry ou outro worker) prevalece sobre a senha gerada agora
+	var username, stored string
+	err = s.masterPool.QueryRow(ctx, `
+		INSERT INTO tenant_db_credentials (tenant_id, group_role, username, password_encrypted)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id) DO UPDATE SET tenant_id = EXCLUDED.tenant_id
+		RETURNING username, password_encrypted
+	`, tenantID, group, loginA, encrypted).Scan(&username, &stored)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar credenciais do tenant: %w", err)
+	}
+
+	if stored != encrypted {
+		password, err = utils.DecryptSecret(s.cfg.Tenants.DBCredentialsKey, stored)
+		if err != nil {
+			return fmt.Errorf("erro ao ler credenciais do tenant: %w", err)
+		}
+	}
+
+	return s.setLoginPassword(ctx, adminPool, username, password)
+}
+
+// Rotate gera uma nova senha no login inativo do tenant e passa a usá-lo. O login anterior
+// continua aceitando conexões até retire_after (ver RetireExpired). Tenants provisionados antes
+// das credenciais dedicadas recebem suas roles na primeira rotação.
+func (s *DBCredentialService) Rotate(ctx context.Context, tenantID uuid.UUID) (*DBCredentialInfo, error) {
+	var dbCode string
+	var status shared.TenantStatus
+	err := s.masterPool.QueryRow(ctx,
+		`SELECT db_code::text, status FROM tenants WHERE id = $1`, tenantID,
+	).Scan(&dbCode, &status)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if status == shared.TenantStatusProvisioning || status == shared.TenantStatusFailed {
+		return nil, ErrTenantNotProvisioned
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+	var retireAfter *time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT username, retire_after FROM tenant_db_credentials WHERE tenant_id = $1 FOR UPDATE`, tenantID,
+	).Scan(&current, &retireAfter)
+	if err == pgx.ErrNoRows {
+		tx.Rollback(ctx)
+		if err := s.Provision(ctx, tenantID, dbCode); err != nil {
+			return nil, err
+		}
+		s.publishRefresh(ctx, dbCode)
+		return s.GetInfo(ctx, tenantID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar credenciais do tenant: %w", err)
+	}
+
+	// O login inativo ainda pode estar em uso por pools que não foram recriados
+	if retireAfter != nil && retireAfter.After(time.Now()) {
+		return nil, ErrCredentialRotationPending
+	}
+
+	cluster, err := s.clusters.TenantCluster(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	adminPool, err := s.clusters.AdminPool(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	loginA, loginB := database.TenantLoginRoles(dbCode)
+	next := loginA
+	if current == loginA {
+		next = loginB
+	}
+
+	password, err := utils.GenerateSecret(tenantDBPasswordBytes)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptSecret(s.cfg.Tenants.DBCredentialsKey, password)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar senha do tenant: %w", err)
+	}
+
+	if err := s.setLoginPassword(ctx, adminPool, next, password); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	retiringUntil := now.Add(time.Duration(s.cfg.Tenants.DBCredentialsRetireMinutes) * time.Minute)
+	_, err = tx.Exec(ctx, `
+		UPDATE tenant_db_credentials SET
+			username = $1,
+			password_encrypted = $2,
+			retiring_username = $3,
+			retire_after = $4,
+			rotated_at = $5,
+			updated_at = $5
+		WHERE tenant_id = $6
+	`, next, encrypted, current, retiringUntil, now, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gravar nova credencial: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao gravar nova credencial: %w", err)
+	}
+
+	// APIs recriam seus pools com o novo login; o anterior segue válido até retire_after
+	s.publishRefresh(ctx, dbCode)
+
+	return s.GetInfo(ctx, tenantID)
+}
+
+// GetInfo retorna as credenciais do tenant sem a senha
+func (s *DBCredentialService) GetInfo(ctx context.Context, tenantID uuid.UUID) (*DBCredentialInfo, error) {
+	info := &DBCredentialInfo{TenantID: tenantID}
+	err := s.masterPool.QueryRow(ctx, `
+		SELECT group_role, username, retiring_username, retire_after, rotated_at, created_at
+		FROM tenant_db_credentials WHERE tenant_id = $1
+	`, tenantID).Scan(&info.GroupRole, &info.Username, &info.RetiringUsername, &info.RetireAfter, &info.RotatedAt, &info.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, ErrDBCredentialsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar credenciais do tenant: %w", err)
+	}
+	return info, nil
+}
+
+// RetireExpired desativa os logins anteriores cujo período de convivência terminou.
+// Retorna os logins desativados.
+func (s *DBCredentialService) RetireExpired(ctx context.Context) ([]string, error) {
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT tenant_id, retiring_username FROM tenant_db_credentials
+		WHERE retiring_username IS NOT NULL AND retire_after <= $1
+	`, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar credenciais a desativar: %w", err)
+	}
+
+	type retiring struct {
+		tenantID uuid.UUID
+		username string
+	}
+	var pending []retiring
+	for rows.Next() {
+		var r retiring
+		if err := rows.Scan(&r.tenantID, &r.username); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("erro ao ler credencial: %w", err)
+		}
+		pending = append(pending, r)
+	}
+	rows.Close()
+
+	var retired []string
+	for _, r := range pending {
+		// Só desativa se o login não voltou a ser o ativo (nova rotação nesse meio tempo)
+		tag, err := s.masterPool.Exec(ctx, `
+			UPDATE tenant_db_credentials SET retiring_username = NULL, retire_after = NULL, updated_at = $1
+			WHERE tenant_id = $2 AND retiring_username = $3 AND username <> $3
+		`, time.Now(), r.tenantID, r.username)
+		if err != nil {
+			return retired, fmt.Errorf("erro ao atualizar credencial: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			continue
+		}
+
+		cluster, err := s.clusters.TenantCluster(ctx, r.tenantID)
+		if err != nil {
+			return retired, err
+		}
+		adminPool, err := s.clusters.AdminPool(ctx, cluster)
+		if err != nil {
+			return retired, err
+		}
+
+		if _, err := adminPool.Exec(ctx, fmt.Sprintf(
+			"ALTER ROLE %s NOLOGIN PASSWORD NULL", pgx.Identifier{r.username}.Sanitize(),
+		)); err != nil {
+			return retired, fmt.Errorf("erro ao desativar login %s: %w", r.username, err)
+		}
+		retired = append(retired, r.username)
+	}
+
+	return retired, nil
+}
+
+// ensureRoles cria a role de grupo e os dois logins do tenant (sem senha até serem usados)
+func (s *DBCredentialService) ensureRoles(ctx context.Context, adminPool *pgxpool.Pool, dbCode string) error {
+	group := database.TenantGroupRole(dbCode)
+	loginA, loginB := database.TenantLoginRoles(dbCode)
+
+	statements := map[string]string{
+		group:  fmt.Sprintf("CREATE ROLE %s NOLOGIN", pgx.Identifier{group}.Sanitize()),
+		loginA: fmt.Sprintf("CREATE ROLE %s NOLOGIN IN ROLE %s", pgx.Identifier{loginA}.Sanitize(), pgx.Identifier{group}.Sanitize()),
+		loginB: fmt.Sprintf("CREATE ROLE %s NOLOGIN IN ROLE %s", pgx.Identifier{loginB}.Sanitize(), pgx.Identifier{group}.Sanitize()),
+	}
+
+	// O grupo precisa existir antes dos logins
+	for _, role := range []string{group, loginA, loginB} {
+		var exists bool
+		if err := adminPool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)`, role).Scan(&exists); err != nil {
+			return fmt.Errorf("erro ao verificar role %s: %w", role, err)
+		}
+		if exists {
+			continue
+		}
+		if _, err := adminPool.Exec(ctx, statements[role]); err != nil && !isDuplicateObject(err) {
+			return fmt.Errorf("erro ao criar role %s: %w", role, err)
+		}
+	}
+
+	return nil
+}
+
+// grantAccess deixa apenas a role do tenant (e superusers) conectar no database do tenant
+func (s *DBCredentialService) grantAccess(ctx context.Context, cluster *admin.DBCluster, adminPool *pgxpool.Pool, dbCode string) error {
+	dbName := database.TenantDBName(dbCode)
+	dbIdent := pgx.Identifier{dbName}.Sanitize()
+	group := pgx.Identifier{database.TenantGroupRole(dbCode)}.Sanitize()
+
+	var exists bool
+	if err := adminPool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)`, dbName).Scan(&exists); err != nil {
+		return fmt.Errorf("erro ao verificar database: %w", err)
+	}
+	if !exists {
+		return ErrTenantNotProvisioned
+	}
+
+	for _, stmt := range []string{
+		fmt.Sprintf("REVOKE ALL ON DATABASE %s FROM PUBLIC", dbIdent),
+		fmt.Sprintf("GRANT CONNECT, TEMPORARY ON DATABASE %s TO %s", dbIdent, group),
+	} {
+		if _, err := adminPool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("erro ao aplicar permissões no database %s: %w", dbName, err)
+		}
+	}
+
+	// Permissões dentro do database (conexão direta como superuser, não via pgbouncer)
+	dsn, err := s.clusters.AdminDSN(cluster, dbName)
+	if err != nil {
+		return err
+	}
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar no tenant DB: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	for _, stmt := range []string{
+		fmt.Sprintf("GRANT USAGE ON SCHEMA public TO %s", group),
+		fmt.Sprintf("GRANT SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER ON ALL TABLES IN SCHEMA public TO %s", group),
+		fmt.Sprintf("GRANT USAGE, SELECT, UPDATE ON ALL SEQUENCES IN SCHEMA public TO %s", group),
+		// Tabelas criadas por migrations futuras (aplicadas pelo superuser)
+		fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER ON TABLES TO %s", group),
+		fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT, UPDATE ON SEQUENCES TO %s", group),
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("erro ao aplicar permissões no schema do tenant: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// setLoginPassword habilita o login com a senha informada (senha hex, sem caracteres a escapar)
+func (s *DBCredentialService) setLoginPassword(ctx context.Context, adminPool *pgxpool.Pool, role, password string) error {
+	stmt := fmt.Sprintf("ALTER ROLE %s LOGIN PASSWORD '%s'", pgx.Identifier{role}.Sanitize(), password)
+	if _, err := adminPool.Exec(ctx, stmt); err != nil {
+		return fmt.Errorf("erro ao definir senha do login %s: %w", role, err)
+	}
+	return nil
+}
+
+// publishRefresh avisa as APIs para recriarem o pool do tenant; falhas apenas atrasam a troca
+func (s *DBCredentialService) publishRefresh(ctx context.Context, dbCode string) {
+	if err := s.redisClient.Publish(ctx, database.TenantPoolRefreshChannel, dbCode).Err(); err != nil {
+		fmt.Printf("Warning: erro ao publicar refresh do pool do tenant %s: %v\n", dbCode, err)
+	}
+}
+
+// dropTenantRoles remove os logins e a role de grupo do tenant (após o DROP DATABASE)
+func dropTenantRoles(ctx context.Context, adminPool *pgxpool.Pool, dbCode string) error {
+	loginA, loginB := database.TenantLoginRoles(dbCode)
+	stmt := fmt.Sprintf("DROP ROLE IF EXISTS %s, %s, %s",
+		pgx.Identifier{loginA}.Sanitize(),
+		pgx.Identifier{loginB}.Sanitize(),
+		pgx.Identifier{database.TenantGroupRole(dbCode)}.Sanitize(),
+	)
+	_, err := adminPool.Exec(ctx, stmt)
+	return err
+}
+
+// isDuplicateObject indica erro 42710 (role criada em paralelo por outro worker)
+func isDuplicateObject(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42710"
+}
@@ -0,0 +1,153 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/config"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+var (
+	// ErrImpersonationTargetNotFound é retornado quando o usuário ou o tenant não existem
+	ErrImpersonationTargetNotFound = errors.New("usuário ou tenant não encontrado")
+	// ErrImpersonationTenantInactive é retornado quando o tenant não está ativo
+	ErrImpersonationTenantInactive = errors.New("tenant não está ativo")
+	// ErrImpersonationNotMember é retornado quando o usuário não é membro do tenant
+	ErrImpersonationNotMember = errors.New("usuário não é membro do tenant")
+	// ErrImpersonationNotActive é retornado ao encerrar uma sessão inexistente, encerrada ou expirada
+	ErrImpersonationNotActive = errors.New("sessão de impersonação não encontrada ou já encerrada")
+)
+
+// ImpersonationToken é o access token da Tenant API entregue ao administrador
+type ImpersonationToken struct {
+	SessionID uuid.UUID `json:"session_id"`
+	Token     string    `json:"token"`
+	ExpiresIn int       `json:"expires_in"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ReadOnly  bool      `json:"read_only"`
+	UserID    uuid.UUID `json:"user_id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	URLCode   string    `json:"url_code"`
+}
+
+// ImpersonationService permite ao suporte ver a Tenant API como um usuário a vê: emite um access
+// token do usuário (sem refresh token) com o claim act do administrador, limitado a um tenant e,
+// por padrão, somente leitura. As sessões e as requisições feitas com elas ficam registradas.
+type ImpersonationService struct {
+	masterPool  *pgxpool.Pool
+	redisClient *redis.Client
+	repo        *adminRepo.ImpersonationRepository
+	tenantKeys  *utils.JWTKeySet
+	config      *config.Config
+}
+
+func NewImpersonationService(masterPool *pgxpool.Pool, redisClient *redis.Client, repo *adminRepo.ImpersonationRepository, tenantKeys *utils.JWTKeySet, cfg *config.Config) *ImpersonationService {
+	return &ImpersonationService{
+		masterPool:  masterPool,
+		redisClient: redisClient,
+		repo:        repo,
+		tenantKeys:  tenantKeys,
+		config:      cfg,
+	}
+}
+
+// Start abre uma sessão de impersonação. mfa repassa ao token se o administrador entrou com o
+// segundo fator (tenants que exigem o segundo fator recusam o token sem ele).
+func (s *ImpersonationService) Start(ctx context.Context, sysUserID uuid.UUID, req adminModels.StartImpersonationRequest, mfa bool, ip string) (*ImpersonationToken, error) {
+	var urlCode, status string
+	var member bool
+	err := s.masterPool.QueryRow(ctx, `
+		SELECT t.url_code, t.status,
+			t.owner_id = u.id OR EXISTS(SELECT 1 FROM tenant_members m WHERE m.tenant_id = t.id AND m.user_id = u.id)
+		FROM tenants t, users u
+		WHERE t.id = $1 AND u.id = $2
+	`, req.TenantID, req.UserID).Scan(&urlCode, &status, &member)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrImpersonationTargetNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário e tenant: %w", err)
+	}
+	if status != "active" {
+		return nil, ErrImpersonationTenantInactive
+	}
+	if !member {
+		return nil, ErrImpersonationNotMember
+	}
+
+	ttl := time.Duration(s.config.Auth.ImpersonationMinutes) * time.Minute
+	if req.DurationMinutes > 0 {
+		ttl = min(ttl, time.Duration(req.DurationMinutes)*time.Minute)
+	}
+	readOnly := req.ReadOnly == nil || *req.ReadOnly
+
+	sessionID := uuid.New()
+	expiresAt := time.Now().Add(ttl)
+	token, err := utils.GenerateTenantJWT(req.UserID, utils.TokenOptions{
+		MFA: mfa,
+		Actor: &utils.Actor{
+			SysUserID: sysUserID.String(),
+			TenantID:  req.TenantID.String(),
+			ReadOnly:  readOnly,
+		},
+		TokenID: sessionID.String(),
+		TTL:     ttl,
+	}, s.tenantKeys, s.config)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar token de impersonação: %w", err)
+	}
+
+	session := &adminModels.ImpersonationSession{
+		ID:        sessionID,
+		SysUserID: &sysUserID,
+		UserID:    req.UserID,
+		TenantID:  req.TenantID,
+		Reason:    req.Reason,
+		ReadOnly:  readOnly,
+		ExpiresAt: expiresAt,
+	}
+	if ip != "" {
+		session.IPAddress = &ip
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Impersonação %s: administrador %s como usuário %s no tenant %s (somente leitura: %t)",
+		sessionID, sysUserID, req.UserID, urlCode, readOnly)
+
+	return &ImpersonationToken{
+		SessionID: sessionID,
+		Token:     token,
+		ExpiresIn: int(ttl.Seconds()),
+		ExpiresAt: expiresAt,
+		ReadOnly:  readOnly,
+		UserID:    req.UserID,
+		TenantID:  req.TenantID,
+		URLCode:   urlCode,
+	}, nil
+}
+
+// End encerra a sessão antes de expirar: o token passa a ser recusado pela Tenant API
+func (s *ImpersonationService) End(ctx context.Context, sessionID, sysUserID uuid.UUID) error {
+	expiresAt, err := s.repo.EndSession(ctx, sessionID, sysUserID)
+	if err != nil {
+		return err
+	}
+	if expiresAt.IsZero() {
+		return ErrImpersonationNotActive
+	}
+
+	return cache.DenyAccessToken(ctx, s.redisClient, sessionID.String(), expiresAt)
+}
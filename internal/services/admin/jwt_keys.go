@@ -0,0 +1,269 @@
+package admin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// JWTKeysRefreshChannel recebe a API (admin ou tenant) cujas chaves de assinatura foram rotacionadas
+const JWTKeysRefreshChannel = "jwt:keys:refresh"
+
+// Margem além da validade do token mais longo durante a qual a chave anterior ainda valida tokens
+// (réplicas que ainda não recarregaram as chaves continuam assinando com ela por alguns instantes)
+const jwtKeyRetireMargin = 5 * time.Minute
+
+// retiredKeyVerifyWindow é por quanto tempo uma chave retirada ainda valida tokens: a validade do
+// token mais longo que ela pode ter assinado (access token ou impersonação) mais a margem
+func retiredKeyVerifyWindow(cfg *config.Config) time.Duration {
+	impersonationTTL := time.Duration(cfg.Auth.ImpersonationMinutes) * time.Minute
+	return max(utils.AccessTokenTTL(cfg), impersonationTTL) + jwtKeyRetireMargin
+}
+
+var (
+	// ErrJWTKeyExists é retornado ao gerar a primeira chave de uma API que já tem chave ativa
+	ErrJWTKeyExists = errors.New("a API já possui chave JWT ativa; use a rotação")
+	// ErrJWTKeysEncryptionKey é retornado quando JWT_KEYS_ENCRYPTION_KEY não foi configurada
+	ErrJWTKeysEncryptionKey = errors.New("JWT_KEYS_ENCRYPTION_KEY não configurada")
+	// ErrInvalidTokenAPI é retornado para uma API diferente de admin e tenant
+	ErrInvalidTokenAPI = errors.New("api deve ser admin ou tenant")
+)
+
+// JWTKeyInfo descreve uma chave de assinatura (sem a chave privada)
+type JWTKeyInfo struct {
+	KID         string     `json:"kid"`
+	API         string     `json:"api"`
+	Algorithm   string     `json:"algorithm"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RotatedAt   *time.Time `json:"rotated_at,omitempty"`
+	VerifyUntil *time.Time `json:"verify_until,omitempty"`
+}
+
+// JWTKeyService gerencia as chaves Ed25519 que assinam os tokens das APIs Admin e Tenant.
+// As chaves ficam no Master DB (privada cifrada com JWT_KEYS_ENCRYPTION_KEY); a rotação cria uma
+// nova chave ativa e mantém a anterior validando tokens até eles expirarem.
+type JWTKeyService struct {
+	masterPool  *pgxpool.Pool
+	redisClient *redis.Client
+	cfg         *config.Config
+}
+
+func NewJWTKeyService(masterPool *pgxpool.Pool, redisClient *redis.Client, cfg *config.Config) *JWTKeyService {
+	return &JWTKeyService{
+		masterPool:  masterPool,
+		redisClient: redisClient,
+		cfg:         cfg,
+	}
+}
+
+// KeySet cria o conjunto de chaves de uma API, já carregado. Garante uma chave ativa para a API.
+func (s *JWTKeyService) KeySet(ctx context.Context, api string) (*utils.JWTKeySet, error) {
+	if _, err := s.Generate(ctx, api); err != nil && !errors.Is(err, ErrJWTKeyExists) {
+		return nil, err
+	}
+
+	keys := utils.NewJWTKeySet(func(ctx context.Context) ([]utils.JWTKey, error) {
+		return s.loadKeys(ctx, api)
+	})
+	if err := keys.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ListenRefresh recarrega as chaves da API quando outra instância rotaciona, até ctx ser cancelado
+func (s *JWTKeyService) ListenRefresh(ctx context.Context, api string, keys *utils.JWTKeySet) {
+	pubsub := s.redisClient.Subscribe(ctx, JWTKeysRefreshChannel)
+	defer pubsub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-pubsub.Channel():
+			if !ok {
+				return
+			}
+			if msg.Payload != api {
+				continue
+			}
+			if err := keys.Reload(ctx); err != nil {
+				log.Printf("Erro ao recarregar chaves JWT (%s): %v", api, err)
+			}
+		}
+	}
+}
+
+// Generate cria a primeira chave de assinatura da API
+func (s *JWTKeyService) Generate(ctx context.Context, api string) (*JWTKeyInfo, error) {
+	return s.insertKey(ctx, api, false)
+}
+
+// Rotate cria uma nova chave ativa; as anteriores passam a só validar tokens até verify_until
+func (s *JWTKeyService) Rotate(ctx context.Context, api string) (*JWTKeyInfo, error) {
+	info, err := s.insertKey(ctx, api, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.redisClient.Publish(ctx, JWTKeysRefreshChannel, api).Err(); err != nil {
+		log.Printf("Warning: erro ao publicar rotação de chaves JWT: %v", err)
+	}
+	return info, nil
+}
+
+// List retorna as chaves da API (ativas e em retirada), mais recentes primeiro
+func (s *JWTKeyService) List(ctx context.Context, api string) ([]JWTKeyInfo, error) {
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT kid, api, algorithm, status, created_at, rotated_at, verify_until
+		FROM jwt_signing_keys
+		WHERE api = $1
+		ORDER BY created_at DESC
+	`, api)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar chaves JWT: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []JWTKeyInfo{}
+	for rows.Next() {
+		var k JWTKeyInfo
+		if err := rows.Scan(&k.KID, &k.API, &k.Algorithm, &k.Status, &k.CreatedAt, &k.RotatedAt, &k.VerifyUntil); err != nil {
+			return nil, fmt.Errorf("erro ao ler chave JWT: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Prune remove as chaves em retirada cujos tokens já expiraram
+func (s *JWTKeyService) Prune(ctx context.Context) (int64, error) {
+	tag, err := s.masterPool.Exec(ctx,
+		`DELETE FROM jwt_signing_keys WHERE status = 'retiring' AND verify_until < NOW()`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao remover chaves JWT vencidas: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// insertKey gera e grava uma nova chave ativa. Sem rotate, falha se a API já tem chave ativa.
+func (s *JWTKeyService) insertKey(ctx context.Context, api string, rotate bool) (*JWTKeyInfo, error) {
+	if api != TokenSubjectAdmin && api != TokenSubjectTenant {
+		return nil, ErrInvalidTokenAPI
+	}
+	if s.cfg.JWT.KeysEncryptionKey == "" {
+		return nil, ErrJWTKeysEncryptionKey
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Réplicas subindo juntas não devem criar duas chaves
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('jwt_signing_keys:' || $1))`, api); err != nil {
+		return nil, fmt.Errorf("erro ao obter lock: %w", err)
+	}
+
+	if rotate {
+		verifyUntil := time.Now().Add(retiredKeyVerifyWindow(s.cfg))
+		if _, err := tx.Exec(ctx, `
+			UPDATE jwt_signing_keys SET status = 'retiring', rotated_at = NOW(), verify_until = $1
+			WHERE api = $2 AND status = 'active'
+		`, verifyUntil, api); err != nil {
+			return nil, fmt.Errorf("erro ao retirar chave JWT anterior: %w", err)
+		}
+	} else {
+		var exists bool
+		if err := tx.QueryRow(ctx,
+			`SELECT EXISTS(SELECT 1 FROM jwt_signing_keys WHERE api = $1 AND status = 'active')`, api,
+		).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("erro ao verificar chaves JWT: %w", err)
+		}
+		if exists {
+			return nil, ErrJWTKeyExists
+		}
+	}
+
+	key, err := utils.GenerateJWTKey()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptSecret(s.cfg.JWT.KeysEncryptionKey, base64.StdEncoding.EncodeToString(key.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+
+	info := JWTKeyInfo{KID: key.ID, API: api, Algorithm: "EdDSA", Status: "active"}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO jwt_signing_keys (kid, api, algorithm, public_key, private_key, status)
+		VALUES ($1, $2, 'EdDSA', $3, $4, 'active')
+		RETURNING created_at
+	`, key.ID, api, base64.StdEncoding.EncodeToString(key.PublicKey), encrypted).Scan(&info.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar chave JWT: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao salvar chave JWT: %w", err)
+	}
+	return &info, nil
+}
+
+// loadKeys lê as chaves que validam tokens da API; só as ativas têm a chave privada decifrada
+func (s *JWTKeyService) loadKeys(ctx context.Context, api string) ([]utils.JWTKey, error) {
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT kid, public_key, private_key, status, created_at
+		FROM jwt_signing_keys
+		WHERE api = $1 AND (status = 'active' OR verify_until > NOW())
+	`, api)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar chaves JWT: %w", err)
+	}
+
+	defer rows.Close()
+
+	keys := []utils.JWTKey{}
+	for rows.Next() {
+		var kid, encodedPublic, encryptedPrivate, status string
+		var createdAt time.Time
+		if err := rows.Scan(&kid, &encodedPublic, &encryptedPrivate, &status, &createdAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler chave JWT: %w", err)
+		}
+
+		publicKey, err := base64.StdEncoding.DecodeString(encodedPublic)
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("chave pública JWT %s inválida", kid)
+		}
+		key := utils.JWTKey{ID: kid, PublicKey: publicKey, CreatedAt: createdAt}
+
+		if status == "active" {
+			decrypted, err := utils.DecryptSecret(s.cfg.JWT.KeysEncryptionKey, encryptedPrivate)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao decifrar chave JWT %s: %w", kid, err)
+			}
+			privateKey, err := base64.StdEncoding.DecodeString(decrypted)
+			if err != nil || len(privateKey) != ed25519.PrivateKeySize {
+				return nil, fmt.Errorf("chave privada JWT %s inválida", kid)
+			}
+			key.PrivateKey = privateKey
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao carregar chaves JWT: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+func TestRetiredKeyVerifyWindow(t *testing.T) {
+	tests := []struct {
+		name                 string
+		accessMinutes        int
+		impersonationMinutes int
+		want                 time.Duration
+	}{
+		{name: "impersonation longer than access token", accessMinutes: 15, impersonationMinutes: 30, want: 35 * time.Minute},
+		{name: "access token longer than impersonation", accessMinutes: 60, impersonationMinutes: 30, want: 65 * time.Minute},
+		{name: "same validity", accessMinutes: 15, impersonationMinutes: 15, want: 20 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				JWT:  config.JWTConfig{AccessTokenMinutes: tt.accessMinutes},
+				Auth: config.AuthConfig{ImpersonationMinutes: tt.impersonationMinutes},
+			}
+			if got := retiredKeyVerifyWindow(cfg); got != tt.want {
+				t.Errorf("retiredKeyVerifyWindow = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotationKeepsImpersonationTokenValid(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		JWT:  config.JWTConfig{AccessTokenMinutes: 15},
+		Auth: config.AuthConfig{ImpersonationMinutes: 30},
+	}
+
+	oldKey, err := utils.GenerateJWTKey()
+	if err != nil {
+		t.Fatalf("GenerateJWTKey: %v", err)
+	}
+	newKey, err := utils.GenerateJWTKey()
+	if err != nil {
+		t.Fatalf("GenerateJWTKey: %v", err)
+	}
+	now := time.Now()
+	oldKey.CreatedAt = now.Add(-time.Hour)
+	newKey.CreatedAt = now
+
+	// Same filter as loadKeys: a retired key is only loaded while verify_until > NOW()
+	verifyUntil := now.Add(retiredKeyVerifyWindow(cfg))
+	at := now
+	keys := utils.NewJWTKeySet(func(ctx context.Context) ([]utils.JWTKey, error) {
+		if at.Equal(now) {
+			return []utils.JWTKey{*oldKey}, nil
+		}
+		loaded := []utils.JWTKey{*newKey}
+		if verifyUntil.After(at) {
+			retired := *oldKey
+			retired.PrivateKey = nil
+			loaded = append(loaded, retired)
+		}
+		return loaded, nil
+	})
+	if err := keys.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	ttl := time.Duration(cfg.Auth.ImpersonationMinutes) * time.Minute
+	token, err := utils.GenerateTenantJWT(uuid.New(), utils.TokenOptions{
+		Actor: &utils.Actor{SysUserID: uuid.NewString(), TenantID: uuid.NewString(), ReadOnly: true},
+		TTL:   ttl,
+	}, keys, cfg)
+	if err != nil {
+		t.Fatalf("GenerateTenantJWT: %v", err)
+	}
+
+	// Rotated right after the token was issued; one minute before it expires the old key still verifies it
+	at = now.Add(ttl - time.Minute)
+	if err := keys.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, err := utils.ValidateTenantJWT(ctx, token, keys); err != nil {
+		t.Errorf("impersonation token refused after rotation: %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+const (
+	// Falhas seguidas de uma conta a partir das quais cada nova tentativa espera (1s, 2s, 4s...)
+	loginDelayAfter = 2
+	loginDelayBase  = time.Second
+	loginDelayMax   = 30 * time.Second
+
+	// Escopos dos bloqueios
+	LockoutScopeAccount = "account"
+	LockoutScopeIP      = "ip"
+)
+
+var (
+	// ErrLoginLocked é retornado enquanto a conta ou o IP estão bloqueados por excesso de falhas
+	ErrLoginLocked = errors.New("muitas tentativas de login: acesso bloqueado temporariamente")
+	// ErrLoginThrottled é retornado quando a próxima tentativa da conta ainda precisa esperar
+	ErrLoginThrottled = errors.New("aguarde antes de tentar novamente")
+	// ErrUnlockTargetRequired é retornado ao desbloquear sem informar email nem IP
+	ErrUnlockTargetRequired = errors.New("informe email ou ip_address")
+)
+
+// LockoutEvent é um bloqueio ou desbloqueio registrado em login_lockout_events
+type LockoutEvent struct {
+	ID             uuid.UUID  `json:"id"`
+	SubjectType    string     `json:"subject_type"`
+	Event          string     `json:"event"`
+	Scope          string     `json:"scope"`
+	Email          *string    `json:"email,omitempty"`
+	IPAddress      *string    `json:"ip_address,omitempty"`
+	FailedAttempts *int       `json:"failed_attempts,omitempty"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	ActorID        *uuid.UUID `json:"actor_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// LoginGuardService protege os logins das APIs Admin e Tenant contra força bruta: conta falhas por
+// conta (email) e por IP no Redis, atrasa progressivamente as tentativas da conta e bloqueia conta
+// ou IP por AUTH_LOGIN_LOCKOUT_MINUTES ao atingir o limite. As contas são identificadas pelo hash do
+// email, exista ele ou não, para que as respostas não revelem quais contas existem. Erros do Redis
+// liberam o login (como o rate limit).
+type LoginGuardService struct {
+	masterPool  *pgxpool.Pool
+	redisClient *redis.Client
+	config      *config.Config
+}
+
+func NewLoginGuardService(masterPool *pgxpool.Pool, redisClient *redis.Client, cfg *config.Config) *LoginGuardService {
+	return &LoginGuardService{
+		masterPool:  masterPool,
+		redisClient: redisClient,
+		config:      cfg,
+	}
+}
+
+// Check é chamado antes de verificar a senha. Retorna ErrLoginLocked ou ErrLoginThrottled com o
+// tempo até a próxima tentativa.
+func (s *LoginGuardService) Check(ctx context.Context, subjectType, email, ip string) (time.Duration, error) {
+	account := loginAccountID(email)
+
+	// As três chaves são sempre consultadas: o tempo de resposta não depende do que existe
+	pipe := s.redisClient.Pipeline()
+	accountLock := pipe.PTTL(ctx, loginLockKey(subjectType, LockoutScopeAccount, account))
+	ipLock := pipe.PTTL(ctx, loginLockKey(subjectType, LockoutScopeIP, ip))
+	delay := pipe.PTTL(ctx, loginDelayKey(subjectType, account))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Warning: proteção de login indisponível: %v", err)
+		return 0, nil
+	}
+
+	if ttl := max(accountLock.Val(), ipLock.Val()); ttl > 0 {
+		return ttl, ErrLoginLocked
+	}
+	if ttl := delay.Val(); ttl > 0 {
+		return ttl, ErrLoginThrottled
+	}
+	return 0, nil
+}
+
+// RecordFailure conta uma senha errada (ou email inexistente) para a conta e para o IP, aplicando
+// o atraso progressivo e os bloqueios
+func (s *LoginGuardService) RecordFailure(ctx context.Context, subjectType, email, ip string) {
+	account := loginAccountID(email)
+	window := s.lockoutDuration()
+
+	pipe := s.redisClient.TxPipeline()
+	accountFailures := pipe.Incr(ctx, loginFailKey(subjectType, LockoutScopeAccount, account))
+	pipe.Expire(ctx, loginFailKey(subjectType, LockoutScopeAccount, account), window)
+	ipFailures := pipe.Incr(ctx, loginFailKey(subjectType, LockoutScopeIP, ip))
+	pipe.Expire(ctx, loginFailKey(subjectType, LockoutScopeIP, ip), window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Warning: erro ao registrar falha de login: %v", err)
+		return
+	}
+
+	failures := accountFailures.Val()
+	switch {
+	case s.config.Auth.LoginMaxAttempts > 0 && failures >= int64(s.config.Auth.LoginMaxAttempts):
+		s.lock(ctx, subjectType, LockoutScopeAccount, account, email, ip, failures)
+	case failures >= loginDelayAfter:
+		if err := s.redisClient.Set(ctx, loginDelayKey(subjectType, account), 1, loginDelay(failures)).Err(); err != nil {
+			log.Printf("Warning: erro ao registrar atraso de login: %v", err)
+		}
+	}
+
+	if s.config.Auth.LoginIPMaxAttempts > 0 && ipFailures.Val() >= int64(s.config.Auth.LoginIPMaxAttempts) {
+		s.lock(ctx, subjectType, LockoutScopeIP, ip, "", ip, ipFailures.Val())
+	}
+}
+
+// RecordSuccess zera as falhas e o atraso da conta após um login com a senha correta
+func (s *LoginGuardService) RecordSuccess(ctx context.Context, subjectType, email string) {
+	account := loginAccountID(email)
+	if err := s.redisClient.Del(ctx,
+		loginFailKey(subjectType, LockoutScopeAccount, account),
+		loginDelayKey(subjectType, account),
+	).Err(); err != nil {
+		log.Printf("Warning: erro ao zerar falhas de login: %v", err)
+	}
+}
+
+// Unlock remove o bloqueio, as falhas e o atraso de uma conta (email) e/ou de um IP. O desbloqueio
+// é registrado com o administrador que o fez. Retorna se havia algum bloqueio ativo.
+func (s *LoginGuardService) Unlock(ctx context.Context, subjectType, email, ip string, actorID uuid.UUID) (bool, error) {
+	if email == "" && ip == "" {
+		return false, ErrUnlockTargetRequired
+	}
+
+	type target struct {
+		scope, id, email, ip string
+	}
+	var targets []target
+	if email != "" {
+		email = utils.NormalizeEmail(email)
+		targets = append(targets, target{LockoutScopeAccount, loginAccountID(email), email, ""})
+	}
+	if ip != "" {
+		targets = append(targets, target{LockoutScopeIP, ip, "", ip})
+	}
+
+	wasLocked := false
+	for _, t := range targets {
+		keys := []string{loginLockKey(subjectType, t.scope, t.id), loginFailKey(subjectType, t.scope, t.id)}
+		if t.scope == LockoutScopeAccount {
+			keys = append(keys, loginDelayKey(subjectType, t.id))
+		}
+
+		pipe := s.redisClient.TxPipeline()
+		locked := pipe.Del(ctx, keys[0])
+		pipe.Del(ctx, keys[1:]...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return false, fmt.Errorf("erro ao desbloquear login: %w", err)
+		}
+		if locked.Val() == 0 {
+			continue
+		}
+
+		wasLocked = true
+		if _, err := s.masterPool.Exec(ctx, `
+			INSERT INTO login_lockout_events (subject_type, event, scope, email, ip_address, actor_id)
+			VALUES ($1, 'unlocked', $2, NULLIF($3, ''), NULLIF($4, ''), $5)
+		`, subjectType, t.scope, t.email, t.ip, actorID); err != nil {
+			return true, fmt.Errorf("erro ao registrar desbloqueio: %w", err)
+		}
+	}
+	return wasLocked, nil
+}
+
+// ListEvents lista os bloqueios e desbloqueios mais recentes (filtros opcionais)
+func (s *LoginGuardService) ListEvents(ctx context.Context, subjectType, email string, limit int) ([]LockoutEvent, error) {
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT id, subject_type, event, scope, email, ip_address, failed_attempts, locked_until, actor_id, created_at
+		FROM login_lockout_events
+		WHERE ($1 = '' OR subject_type = $1) AND ($2 = '' OR email = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, subjectType, utils.NormalizeEmail(email), limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar bloqueios: %w", err)
+	}
+	defer rows.Close()
+
+	events := []LockoutEvent{}
+	for rows.Next() {
+		var e LockoutEvent
+		if err := rows.Scan(&e.ID, &e.SubjectType, &e.Event, &e.Scope, &e.Email, &e.IPAddress,
+			&e.FailedAttempts, &e.LockedUntil, &e.ActorID, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler bloqueio: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// lock bloqueia a conta ou o IP, zera as falhas e registra o bloqueio (uma vez por bloqueio)
+func (s *LoginGuardService) lock(ctx context.Context, subjectType, scope, id, email, ip string, failures int64) {
+	duration := s.lockoutDuration()
+	lockedUntil := time.Now().Add(duration)
+
+	created, err := s.redisClient.SetNX(ctx, loginLockKey(subjectType, scope, id), lockedUntil.Unix(), duration).Result()
+	if err != nil {
+		log.Printf("Warning: erro ao bloquear login (%s): %v", scope, err)
+		return
+	}
+	s.redisClient.Del(ctx, loginFailKey(subjectType, scope, id))
+	if !created {
+		return
+	}
+
+	if scope == LockoutScopeAccount {
+		log.Printf("Login bloqueado (%s) após %d falhas: conta %s até %s", subjectType, failures, email, lockedUntil.Format(time.RFC3339))
+	} else {
+		log.Printf("Login bloqueado (%s) após %d falhas: IP %s até %s", subjectType, failures, ip, lockedUntil.Format(time.RFC3339))
+		email = ""
+	}
+	if _, err := s.masterPool.Exec(ctx, `
+		INSERT INTO login_lockout_events (subject_type, event, scope, email, ip_address, failed_attempts, locked_until)
+		VALUES ($1, 'locked', $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6)
+	`, subjectType, scope, email, ip, failures, lockedUntil); err != nil {
+		log.Printf("Warning: erro ao registrar bloqueio de login: %v", err)
+	}
+}
+
+// loginDelay retorna a espera antes da próxima tentativa da conta após failures falhas seguidas
+func loginDelay(failures int64) time.Duration {
+	if failures < loginDelayAfter {
+		return 0
+	}
+	delay := loginDelayBase << min(failures-loginDelayAfter, 8)
+	if delay > loginDelayMax {
+		return loginDelayMax
+	}
+	return delay
+}
+
+func (s *LoginGuardService) lockoutDuration() time.Duration {
+	return time.Duration(s.config.Auth.LoginLockoutMinutes) * time.Minute
+}
+
+// loginAccountID identifica a conta pelo hash do email normalizado (o email não vai para o Redis)
+func loginAccountID(email string) string {
+	return utils.HashToken(utils.NormalizeEmail(email))
+}
+
+func loginFailKey(subjectType, scope, id string) string {
+	return fmt.Sprintf("auth:login:fail:%s:%s:%s", subjectType, scope, id)
+}
+
+func loginLockKey(subjectType, scope, id string) string {
+	return fmt.Sprintf("auth:login:lock:%s:%s:%s", subjectType, scope, id)
+}
+
+func loginDelayKey(subjectType, account string) string {
+	return fmt.Sprintf("auth:login:delay:%s:%s", subjectType, account)
+}
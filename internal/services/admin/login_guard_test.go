@@ -0,0 +1,202 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
+)
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 1, want: 0},
+		{failures: 2, want: time.Second},
+		{failures: 3, want: 2 * time.Second},
+		{failures: 4, want: 4 * time.Second},
+		{failures: 6, want: 16 * time.Second},
+		{failures: 7, want: loginDelayMax},
+		{failures: 1000, want: loginDelayMax},
+	}
+
+	for _, tt := range tests {
+		if got := loginDelay(tt.failures); got != tt.want {
+			t.Errorf("loginDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// testLoginGuard builds a guard over the test Redis. The master pool points to a closed port: the
+// lockout events are not recorded (the insert fails and is logged), only the Redis state is tested.
+func testLoginGuard(t *testing.T, maxAttempts, ipMaxAttempts int) *LoginGuardService {
+	t.Helper()
+	client := testRedis(t)
+
+	pool, err := pgxpool.New(context.Background(), "postgres://test@127.0.0.1:1/test?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("pgxpool.New: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	cfg := &config.Config{Auth: config.AuthConfig{
+		LoginMaxAttempts:    maxAttempts,
+		LoginIPMaxAttempts:  ipMaxAttempts,
+		LoginLockoutMinutes: 15,
+	}}
+	return NewLoginGuardService(pool, client, cfg)
+}
+
+func TestLoginGuardThresholds(t *testing.T) {
+	type attempt struct {
+		email, ip string
+	}
+	const (
+		ana   = "ana@example.com"
+		bruno = "bruno@example.com"
+		ip1   = "203.0.113.10"
+		ip2   = "203.0.113.20"
+	)
+
+	tests := []struct {
+		name          string
+		maxAttempts   int
+		ipMaxAttempts int
+		failures      []attempt
+		success       string
+		check         attempt
+		subjectType   string
+		wantErr       error
+		wantMinTTL    time.Duration
+		wantMaxTTL    time.Duration
+	}{
+		{
+			name:        "first failure does not delay",
+			maxAttempts: 5, ipMaxAttempts: 20,
+			failures: []attempt{{ana, ip1}},
+			check:    attempt{ana, ip1},
+		},
+		{
+			name:        "second failure delays the account",
+			maxAttempts: 5, ipMaxAttempts: 20,
+			failures: []attempt{{ana, ip1}, {ana, ip1}},
+			check:    attempt{ana, ip2},
+			wantErr:  ErrLoginThrottled, wantMinTTL: time.Millisecond, wantMaxTTL: time.Second,
+		},
+		{
+			name:        "delay grows with the failures",
+			maxAttempts: 10, ipMaxAttempts: 20,
+			failures: []attempt{{ana, ip1}, {ana, ip1}, {ana, ip1}, {ana, ip1}},
+			check:    attempt{ana, ip1},
+			wantErr:  ErrLoginThrottled, wantMinTTL: 3 * time.Second, wantMaxTTL: 4 * time.Second,
+		},
+		{
+			name:        "email is normalized",
+			maxAttempts: 5, ipMaxAttempts: 20,
+			failures: []attempt{{ana, ip1}, {" Ana@Example.COM ", ip1}},
+			check:    attempt{ana, ip1},
+			wantErr:  ErrLoginThrottled, wantMinTTL: time.Millisecond, wantMaxTTL: time.Second,
+		},
+		{
+			name:        "other accounts are not delayed",
+			maxAttempts: 5, ipMaxAttempts: 20,
+			failures: []attempt{{ana, ip1}, {ana, ip1}},
+			check:    attempt{bruno, ip1},
+		},
+		{
+			name:        "success clears the failures and the delay",
+			maxAttempts: 5, ipMaxAttempts: 20,
+			failures: []attempt{{ana, ip1}, {ana, ip1}},
+			success:  ana,
+			check:    attempt{ana, ip1},
+		},
+		{
+			name:        "account is locked at the limit",
+			maxAttempts: 3, ipMaxAttempts: 20,
+			failures: []attempt{{ana, ip1}, {ana, ip1}, {ana, ip1}},
+			check:    attempt{ana, ip2},
+			wantErr:  ErrLoginLocked, wantMinTTL: 14 * time.Minute, wantMaxTTL: 15 * time.Minute,
+		},
+		{
+			name:        "success does not lift a lock",
+			maxAttempts: 3, ipMaxAttempts: 20,
+			failures: []attempt{{ana, ip1}, {ana, ip1}, {ana, ip1}},
+			success:  ana,
+			check:    attempt{ana, ip1},
+			wantErr:  ErrLoginLocked, wantMinTTL: 14 * time.Minute, wantMaxTTL: 15 * time.Minute,
+		},
+		{
+			name:        "IP is locked across accounts",
+			maxAttempts: 5, ipMaxAttempts: 3,
+			failures: []attempt{{ana, ip1}, {bruno, ip1}, {"carla@example.com", ip1}},
+			check:    attempt{"daniel@example.com", ip1},
+			wantErr:  ErrLoginLocked, wantMinTTL: 14 * time.Minute, wantMaxTTL: 15 * time.Minute,
+		},
+		{
+			name:        "IP lock does not affect other IPs",
+			maxAttempts: 5, ipMaxAttempts: 3,
+			failures: []attempt{{ana, ip1}, {bruno, ip1}, {"carla@example.com", ip1}},
+			check:    attempt{"daniel@example.com", ip2},
+		},
+		{
+			name:        "zero limits disable the locks",
+			maxAttempts: 0, ipMaxAttempts: 0,
+			failures: []attempt{{ana, ip1}, {ana, ip1}, {ana, ip1}, {ana, ip1}, {ana, ip1}, {ana, ip1}, {ana, ip1}, {ana, ip1}},
+			check:    attempt{ana, ip1},
+			wantErr:  ErrLoginThrottled, wantMinTTL: 16 * time.Second, wantMaxTTL: loginDelayMax,
+		},
+		{
+			name:        "admin and tenant logins are counted apart",
+			maxAttempts: 3, ipMaxAttempts: 20,
+			failures:    []attempt{{ana, ip1}, {ana, ip1}, {ana, ip1}},
+			check:       attempt{ana, ip1},
+			subjectType: "admin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			guard := testLoginGuard(t, tt.maxAttempts, tt.ipMaxAttempts)
+
+			for _, f := range tt.failures {
+				guard.RecordFailure(ctx, "tenant", f.email, f.ip)
+			}
+			if tt.success != "" {
+				guard.RecordSuccess(ctx, "tenant", tt.success)
+			}
+
+			subjectType := tt.subjectType
+			if subjectType == "" {
+				subjectType = "tenant"
+			}
+			ttl, err := guard.Check(ctx, subjectType, tt.check.email, tt.check.ip)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && ttl != 0 {
+				t.Errorf("Check ttl = %s without error", ttl)
+			}
+			if err != nil && (ttl < tt.wantMinTTL || ttl > tt.wantMaxTTL) {
+				t.Errorf("Check ttl = %s, want between %s and %s", ttl, tt.wantMinTTL, tt.wantMaxTTL)
+			}
+		})
+	}
+}
+
+func TestLoginGuardFailsOpenWithoutRedis(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	guard := NewLoginGuardService(nil, client, &config.Config{})
+
+	ttl, err := guard.Check(context.Background(), "tenant", "ana@example.com", "203.0.113.10")
+	if err != nil || ttl != 0 {
+		t.Fatalf("Check = (%s, %v), want the login allowed", ttl, err)
+	}
+}
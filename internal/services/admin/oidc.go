@@ -0,0 +1,793 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/config"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/oidc"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+const (
+	// Tempo para o usuário concluir o login no IdP (validade do state)
+	oidcLoginTTL = 10 * time.Minute
+	// Tamanho (em bytes aleatórios) do state, do nonce e do code_verifier (PKCE)
+	oidcRandomBytes = 32
+	// Tempo máximo de cada chamada ao IdP
+	oidcHTTPTimeout = 10 * time.Second
+)
+
+var (
+	// ErrOIDCNotConfigured é retornado quando o tenant não tem SSO configurado ou ativo
+	ErrOIDCNotConfigured = errors.New("SSO não configurado para este tenant")
+	// ErrOIDCInvalidConfig é retornado quando a configuração de SSO enviada não é válida
+	ErrOIDCInvalidConfig = errors.New("configuração de SSO inválida")
+	// ErrOIDCInvalidState é retornado quando o state não existe, expirou ou já foi usado
+	ErrOIDCInvalidState = errors.New("login SSO inválido ou expirado: tente novamente")
+	// ErrOIDCLoginFailed é retornado quando o IdP recusa o code ou o ID token não é válido
+	ErrOIDCLoginFailed = errors.New("não foi possível validar o login no provedor de identidade")
+	// ErrOIDCDomainNotAllowed é retornado quando o domínio do email não está entre os permitidos
+	ErrOIDCDomainNotAllowed = errors.New("domínio do email não permitido para este tenant")
+	// ErrOIDCEmailNotVerified é retornado quando o IdP informa que o email não foi verificado
+	ErrOIDCEmailNotVerified = errors.New("email não verificado pelo provedor de identidade")
+	// ErrOIDCInvalidEmail é retornado quando o email do ID token não é um endereço válido
+	ErrOIDCInvalidEmail = errors.New("email inválido no provedor de identidade")
+	// ErrOIDCAccountLinkRequired é retornado quando já existe uma conta com o email do IdP que não pode
+	// ser vinculada automaticamente: o usuário entra com a senha e confirma o vínculo (LinkAccount)
+	ErrOIDCAccountLinkRequired = errors.New("já existe uma conta com este email: entre com a senha para vincular o SSO")
+	// ErrOIDCInvalidLink é retornado quando o link_token não existe, expirou ou é de outro usuário
+	ErrOIDCInvalidLink = errors.New("vínculo SSO inválido ou expirado: faça o login SSO novamente")
+	// ErrOIDCUserNotProvisioned é retornado quando o usuário não tem conta e a criação automática está desligada
+	ErrOIDCUserNotProvisioned = errors.New("usuário sem conta na plataforma: peça acesso ao administrador do tenant")
+)
+
+// Valores do claim amr (RFC 8176) que indicam autenticação com mais de um fator no IdP
+var oidcMFAMethods = map[string]bool{"mfa": true, "otp": true, "hwk": true, "sc": true}
+
+// OIDCLoginStart é a resposta do início do login SSO: o frontend redireciona para authorization_url
+type OIDCLoginStart struct {
+	AuthorizationURL string `json:"authorization_url"`
+	ExpiresIn        int    `json:"expires_in"`
+}
+
+// OIDCStatus informa à tela de login se o tenant tem SSO e se ele é obrigatório
+type OIDCStatus struct {
+	Enabled bool `json:"enabled"`
+	SSOOnly bool `json:"sso_only"`
+}
+
+// OIDCLoginResult é o usuário autenticado pelo IdP, já vinculado e membro do tenant
+type OIDCLoginResult struct {
+	UserID   uuid.UUID
+	TenantID uuid.UUID
+	URLCode  string
+	// O IdP informou autenticação com mais de um fator (claim amr)
+	MFA bool
+}
+
+// OIDCLinkRequiredError acompanha ErrOIDCAccountLinkRequired com o token do vínculo pendente
+type OIDCLinkRequiredError struct {
+	LinkToken string
+	ExpiresIn int
+}
+
+func (e *OIDCLinkRequiredError) Error() string { return ErrOIDCAccountLinkRequired.Error() }
+
+func (e *OIDCLinkRequiredError) Unwrap() error { return ErrOIDCAccountLinkRequired }
+
+// oidcPendingLink é a conta do IdP aguardando o usuário dono do email confirmar o vínculo
+type oidcPendingLink struct {
+	UserID   uuid.UUID `json:"user_id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	Issuer   string    `json:"issuer"`
+	Subject  string    `json:"subject"`
+	Email    string    `json:"email"`
+	Groups   []string  `json:"groups"`
+}
+
+// oidcLoginState é guardado no Redis entre o redirecionamento ao IdP e o callback
+type oidcLoginState struct {
+	TenantID     uuid.UUID `json:"tenant_id"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+}
+
+// OIDCService faz o login SSO (OpenID Connect, authorization code com PKCE) dos usuários de um
+// tenant no IdP configurado por ele: vincula a conta do IdP (issuer + sub) a um usuário existente
+// pelo email ou cria o usuário, garante a associação ao tenant e mapeia os grupos do IdP para
+// papéis do tenant
+type OIDCService struct {
+	masterPool  *pgxpool.Pool
+	redisClient *redis.Client
+	config      *config.Config
+	client      *oidc.Client
+}
+
+func NewOIDCService(masterPool *pgxpool.Pool, redisClient *redis.Client, cfg *config.Config) *OIDCService {
+	return &OIDCService{
+		masterPool:  masterPool,
+		redisClient: redisClient,
+		config:      cfg,
+		client:      oidc.NewClient(oidcHTTPTimeout, cfg.App.IsDevelopment()),
+	}
+}
+
+// GetProvider retorna a configuração de SSO do tenant (sem o client_secret)
+func (s *OIDCService) GetProvider(ctx context.Context, tenantID uuid.UUID) (*adminModels.OIDCProvider, error) {
+	return s.loadProvider(ctx, tenantID)
+}
+
+// SaveProvider cria ou altera o SSO do tenant. O issuer é validado pela discovery do IdP e os
+// papéis precisam existir no tenant (owner não pode ser atribuído pelo IdP).
+func (s *OIDCService) SaveProvider(ctx context.Context, tenantID uuid.UUID, urlCode string, req adminModels.SaveOIDCProviderRequest) (*adminModels.OIDCProvider, error) {
+	issuer := strings.TrimSuffix(strings.TrimSpace(req.Issuer), "/")
+	issuerURL, err := url.Parse(issuer)
+	if err != nil || issuerURL.Host == "" {
+		return nil, fmt.Errorf("%w: issuer inválido", ErrOIDCInvalidConfig)
+	}
+	if issuerURL.Scheme != "https" && !(issuerURL.Scheme == "http" && s.config.App.IsDevelopment()) {
+		return nil, fmt.Errorf("%w: o issuer deve usar https", ErrOIDCInvalidConfig)
+	}
+	// O issuer não pode apontar para a rede interna (em desenvolvimento o IdP de teste é local)
+	if !s.config.App.IsDevelopment() {
+		if err := oidc.CheckHost(ctx, issuerURL.Hostname()); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrOIDCInvalidConfig, err)
+		}
+	}
+
+	scopes := normalizeList(req.Scopes, false)
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	if !containsString(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	groupsClaim := strings.TrimSpace(req.GroupsClaim)
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+	defaultRole := strings.TrimSpace(req.DefaultRole)
+	if defaultRole == "" {
+		defaultRole = "member"
+	}
+	groupRoles := req.GroupRoles
+	if groupRoles == nil {
+		groupRoles = []adminModels.OIDCGroupRole{}
+	}
+
+	// Os papéis precisam existir (globais ou do tenant)
+	for _, role := range append([]string{defaultRole}, groupRoleSlugs(groupRoles)...) {
+		if _, err := resolveMemberRole(ctx, s.masterPool, tenantID, role); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, fmt.Errorf("%w: papel '%s' não encontrado", ErrOIDCInvalidConfig, role)
+			}
+			return nil, err
+		}
+	}
+
+	encryptedSecret := ""
+	if req.ClientSecret != "" {
+		encryptedSecret, err = utils.EncryptSecret(s.config.Auth.OIDCEncryptionKey, req.ClientSecret)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao cifrar client_secret: %w", err)
+		}
+	} else {
+		var exists bool
+		if err := s.masterPool.QueryRow(ctx,
+			`SELECT EXISTS(SELECT 1 FROM tenant_oidc_providers WHERE tenant_id = $1)`, tenantID,
+		).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("erro ao buscar SSO do tenant: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: client_secret é obrigatório", ErrOIDCInvalidConfig)
+		}
+	}
+
+	// O IdP precisa responder à discovery (e suportar PKCE S256)
+	if _, err := s.client.Discover(ctx, issuer); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCInvalidConfig, err)
+	}
+
+	groupRolesJSON, err := json.Marshal(groupRoles)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar group_roles: %w", err)
+	}
+	autoCreate := req.AutoCreateUsers == nil || *req.AutoCreateUsers
+	enabled := req.Enabled == nil || *req.Enabled
+
+	if _, err := s.masterPool.Exec(ctx, `
+		INSERT INTO tenant_oidc_providers
+			(tenant_id, issuer, client_id, client_secret, scopes, allowed_domains, groups_claim, group_roles,
+			 default_role, auto_create_users, sso_only, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			issuer = EXCLUDED.issuer,
+			client_id = EXCLUDED.client_id,
+			client_secret = COALESCE(NULLIF($4, ''), tenant_oidc_providers.client_secret),
+			scopes = EXCLUDED.scopes,
+			allowed_domains = EXCLUDED.allowed_domains,
+			groups_claim = EXCLUDED.groups_claim,
+			group_roles = EXCLUDED.group_roles,
+			default_role = EXCLUDED.default_role,
+			auto_create_users = EXCLUDED.auto_create_users,
+			sso_only = EXCLUDED.sso_only,
+			enabled = EXCLUDED.enabled,
+			updated_at = NOW()
+	`, tenantID, issuer, strings.TrimSpace(req.ClientID), encryptedSecret, scopes, normalizeList(req.AllowedDomains, true),
+		groupsClaim, groupRolesJSON, defaultRole, autoCreate, req.SSOOnly, enabled); err != nil {
+		return nil, fmt.Errorf("erro ao salvar SSO do tenant: %w", err)
+	}
+
+	// sso_only faz parte do contexto do tenant
+	if err := cache.InvalidateTenantContext(ctx, s.redisClient, cache.TenantContextEvent{URLCode: urlCode}); err != nil {
+		log.Printf("Warning: erro ao invalidar contexto do tenant %s: %v", urlCode, err)
+	}
+
+	return s.loadProvider(ctx, tenantID)
+}
+
+// DeleteProvider remove o SSO do tenant (as contas vinculadas continuam existindo)
+func (s *OIDCService) DeleteProvider(ctx context.Context, tenantID uuid.UUID, urlCode string) error {
+	tag, err := s.masterPool.Exec(ctx, `DELETE FROM tenant_oidc_providers WHERE tenant_id = $1`, tenantID)
+	if err != nil {
+		return fmt.Errorf("erro ao remover SSO do tenant: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOIDCNotConfigured
+	}
+
+	if err := cache.InvalidateTenantContext(ctx, s.redisClient, cache.TenantContextEvent{URLCode: urlCode}); err != nil {
+		log.Printf("Warning: erro ao invalidar contexto do tenant %s: %v", urlCode, err)
+	}
+	return nil
+}
+
+// Status informa se o tenant (url_code ou subdomínio) tem SSO ativo e se ele é obrigatório
+func (s *OIDCService) Status(ctx context.Context, tenantRef string) (*OIDCStatus, error) {
+	tenantID, _, err := s.resolveTenant(ctx, tenantRef)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &OIDCStatus{}
+	err = s.masterPool.QueryRow(ctx,
+		`SELECT enabled, enabled AND sso_only FROM tenant_oidc_providers WHERE tenant_id = $1`, tenantID,
+	).Scan(&status.Enabled, &status.SSOOnly)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("erro ao buscar SSO do tenant: %w", err)
+	}
+	return status, nil
+}
+
+// BeginLogin gera state, nonce e code_verifier (PKCE) e retorna a URL de autorização do IdP do
+// tenant (url_code ou subdomínio)
+func (s *OIDCService) BeginLogin(ctx context.Context, tenantRef, loginHint string) (*OIDCLoginStart, error) {
+	tenantID, _, err := s.resolveTenant(ctx, tenantRef)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := s.loadProvider(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !provider.Enabled {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	discovery, err := s.client.Discover(ctx, provider.Issuer)
+	if err != nil {
+		log.Printf("Warning: discovery do IdP do tenant %s falhou: %v", tenantID, err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	var state, nonce, verifier string
+	for _, value := range []*string{&state, &nonce, &verifier} {
+		if *value, err = utils.GenerateSecret(oidcRandomBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := json.Marshal(oidcLoginState{TenantID: tenantID, Nonce: nonce, CodeVerifier: verifier})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar login SSO: %w", err)
+	}
+	if err := s.redisClient.Set(ctx, oidcStateKey(state), payload, oidcLoginTTL).Err(); err != nil {
+		return nil, fmt.Errorf("erro ao salvar login SSO: %w", err)
+	}
+
+	return &OIDCLoginStart{
+		AuthorizationURL: oidc.AuthorizationURL(discovery, oidc.AuthRequest{
+			ClientID:      provider.ClientID,
+			RedirectURI:   s.config.Auth.OIDCRedirectURL,
+			Scopes:        provider.Scopes,
+			State:         state,
+			Nonce:         nonce,
+			CodeChallenge: oidc.CodeChallenge(verifier),
+			LoginHint:     loginHint,
+		}),
+		ExpiresIn: int(oidcLoginTTL.Seconds()),
+	}, nil
+}
+
+// CompleteLogin troca o code pelo ID token, valida o usuário (domínio e email verificado), vincula
+// ou cria a conta e garante a associação ao tenant com o papel dos grupos do IdP
+func (s *OIDCService) CompleteLogin(ctx context.Context, code, state string) (*OIDCLoginResult, error) {
+	payload, err := s.redisClient.GetDel(ctx, oidcStateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrOIDCInvalidState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar login SSO: %w", err)
+	}
+	var login oidcLoginState
+	if err := json.Unmarshal(payload, &login); err != nil {
+		return nil, ErrOIDCInvalidState
+	}
+
+	provider, err := s.loadProvider(ctx, login.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !provider.Enabled {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	idToken, err := s.exchangeCode(ctx, provider, code, login)
+	if err != nil {
+		log.Printf("Warning: login SSO do tenant %s recusado: %v", login.TenantID, err)
+		return nil, ErrOIDCLoginFailed
+	}
+	return s.linkUser(ctx, provider, idToken)
+}
+
+// exchangeCode troca o code no token endpoint do IdP e valida o ID token recebido
+func (s *OIDCService) exchangeCode(ctx context.Context, provider *adminModels.OIDCProvider, code string, login oidcLoginState) (*oidc.IDToken, error) {
+	discovery, err := s.client.Discover(ctx, provider.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.client.Exchange(ctx, discovery, provider.ClientID, provider.ClientSecret, code, s.config.Auth.OIDCRedirectURL, login.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.VerifyIDToken(ctx, discovery, tokens.IDToken, provider.ClientID, login.Nonce)
+}
+
+// linkUser vincula (ou cria) o usuário do ID token e garante sua associação ao tenant
+func (s *OIDCService) linkUser(ctx context.Context, provider *adminModels.OIDCProvider, idToken *oidc.IDToken) (*OIDCLoginResult, error) {
+	email := utils.NormalizeEmail(idToken.Email)
+	if email == "" {
+		log.Printf("Warning: ID token do tenant %s sem claim email", provider.TenantID)
+		return nil, ErrOIDCLoginFailed
+	}
+	if !utils.ValidEmail(email) {
+		log.Printf("Warning: ID token do tenant %s com email inválido: %q", provider.TenantID, email)
+		return nil, ErrOIDCInvalidEmail
+	}
+	if idToken.EmailVerified != nil && !*idToken.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+	if len(provider.AllowedDomains) > 0 && !containsString(provider.AllowedDomains, email[strings.LastIndex(email, "@")+1:]) {
+		return nil, ErrOIDCDomainNotAllowed
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var urlCode, status string
+	if err := tx.QueryRow(ctx, `SELECT url_code, status FROM tenants WHERE id = $1`, provider.TenantID).Scan(&urlCode, &status); err != nil {
+		return nil, fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if status != "active" {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	// 1. Conta do IdP já vinculada
+	var userID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		UPDATE user_identities SET email = $3, tenant_id = $4, last_login_at = NOW()
+		WHERE issuer = $1 AND subject = $2
+		RETURNING user_id
+	`, provider.Issuer, idToken.Subject, email, provider.TenantID).Scan(&userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("erro ao buscar conta vinculada: %w", err)
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		// 2. Usuário existente com o mesmo email, ou 3. criação just-in-time
+		err = tx.QueryRow(ctx, `SELECT id FROM users WHERE email = $1`, email).Scan(&userID)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			if !provider.AutoCreateUsers {
+				return nil, ErrOIDCUserNotProvisioned
+			}
+			userID, err = createSSOUser(ctx, tx, email, idToken.Name)
+		case err == nil:
+			// O IdP é configurado pelo tenant: uma conta que não é dele só é vinculada com a senha
+			trusted, err := autoLinkAllowed(ctx, tx, provider, userID, email)
+			if err != nil {
+				return nil, err
+			}
+			if !trusted {
+				return nil, s.requireLink(ctx, oidcPendingLink{
+					UserID:   userID,
+					TenantID: provider.TenantID,
+					Issuer:   provider.Issuer,
+					Subject:  idToken.Subject,
+					Email:    email,
+					Groups:   idToken.StringList(provider.GroupsClaim),
+				})
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao vincular usuário: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO user_identities (user_id, tenant_id, issuer, subject, email, last_login_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+		`, userID, provider.TenantID, provider.Issuer, idToken.Subject, email); err != nil {
+			return nil, fmt.Errorf("erro ao vincular conta do IdP: %w", err)
+		}
+	}
+
+	changed, err := s.syncMembership(ctx, tx, provider, userID, idToken.StringList(provider.GroupsClaim))
+	if err != nil {
+		return nil, err
+	}
+
+	// O tenant do SSO passa a ser o último acessado (a resposta do login traz a configuração dele)
+	if _, err := tx.Exec(ctx, `UPDATE users SET last_tenant_logged = $2 WHERE id = $1`, userID, urlCode); err != nil {
+		return nil, fmt.Errorf("erro ao atualizar último tenant: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao concluir login SSO: %w", err)
+	}
+
+	if changed {
+		s.invalidateMember(ctx, provider.TenantID, userID)
+	}
+
+	mfa := false
+	for _, method := range idToken.AMR {
+		if oidcMFAMethods[method] {
+			mfa = true
+			break
+		}
+	}
+
+	return &OIDCLoginResult{
+		UserID:   userID,
+		TenantID: provider.TenantID,
+		URLCode:  urlCode,
+		MFA:      mfa,
+	}, nil
+}
+
+// LinkAccount conclui um vínculo pendente (account_link_required) para o usuário autenticado com a
+// senha: vincula a conta do IdP e o associa ao tenant do SSO. O link_token é de uso único.
+func (s *OIDCService) LinkAccount(ctx context.Context, userID uuid.UUID, linkToken string) (*OIDCLoginResult, error) {
+	payload, err := s.redisClient.GetDel(ctx, oidcLinkKey(linkToken)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrOIDCInvalidLink
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar vínculo SSO: %w", err)
+	}
+	var link oidcPendingLink
+	if err := json.Unmarshal(payload, &link); err != nil || link.UserID != userID {
+		return nil, ErrOIDCInvalidLink
+	}
+
+	provider, err := s.loadProvider(ctx, link.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !provider.Enabled || provider.Issuer != link.Issuer {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var urlCode, status string
+	if err := tx.QueryRow(ctx, `SELECT url_code, status FROM tenants WHERE id = $1`, provider.TenantID).Scan(&urlCode, &status); err != nil {
+		return nil, fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if status != "active" {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	// A conta do IdP pode ter sido vinculada a outro usuário enquanto o vínculo estava pendente
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO user_identities (user_id, tenant_id, issuer, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (issuer, subject) DO NOTHING
+	`, userID, provider.TenantID, link.Issuer, link.Subject, link.Email)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao vincular conta do IdP: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrOIDCInvalidLink
+	}
+
+	changed, err := s.syncMembership(ctx, tx, provider, userID, link.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao concluir vínculo SSO: %w", err)
+	}
+
+	if changed {
+		s.invalidateMember(ctx, provider.TenantID, userID)
+	}
+
+	return &OIDCLoginResult{
+		UserID:   userID,
+		TenantID: provider.TenantID,
+		URLCode:  urlCode,
+	}, nil
+}
+
+// requireLink guarda o vínculo pendente e retorna o erro com o link_token para o usuário confirmar
+// com a senha
+func (s *OIDCService) requireLink(ctx context.Context, link oidcPendingLink) error {
+	token, err := utils.GenerateSecret(oidcRandomBytes)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(link)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar vínculo SSO: %w", err)
+	}
+	if err := s.redisClient.Set(ctx, oidcLinkKey(token), payload, oidcLoginTTL).Err(); err != nil {
+		return fmt.Errorf("erro ao salvar vínculo SSO: %w", err)
+	}
+	return &OIDCLinkRequiredError{LinkToken: token, ExpiresIn: int(oidcLoginTTL.Seconds())}
+}
+
+// invalidateMember descarta o contexto em cache do usuário no tenant após mudar sua associação
+func (s *OIDCService) invalidateMember(ctx context.Context, tenantID, userID uuid.UUID) {
+	if err := cache.InvalidateTenantContext(ctx, s.redisClient, cache.TenantContextEvent{TenantID: &tenantID, UserID: &userID}); err != nil {
+		log.Printf("Warning: erro ao invalidar permissões de %s no tenant %s: %v", userID, tenantID, err)
+	}
+}
+
+// syncMembership associa o usuário ao tenant (papel dos grupos ou o padrão) e, para quem já é
+// membro, aplica o papel mapeado dos grupos. O owner nunca é alterado. Retorna se algo mudou.
+func (s *OIDCService) syncMembership(ctx context.Context, tx pgx.Tx, provider *adminModels.OIDCProvider, userID uuid.UUID, groups []string) (bool, error) {
+	var mappedRole string
+	for _, mapping := range provider.GroupRoles {
+		if containsString(groups, mapping.Group) {
+			mappedRole = mapping.Role
+			break
+		}
+	}
+
+	var isOwner, member bool
+	var currentRole *string
+	err := tx.QueryRow(ctx, `
+		SELECT t.owner_id IS NOT DISTINCT FROM $2, m.user_id IS NOT NULL, r.slug
+		FROM tenants t
+		LEFT JOIN tenant_members m ON m.tenant_id = t.id AND m.user_id = $2
+		LEFT JOIN roles r ON r.id = m.role_id
+		WHERE t.id = $1
+	`, provider.TenantID, userID).Scan(&isOwner, &member, &currentRole)
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar associação ao tenant: %w", err)
+	}
+
+	if isOwner || (currentRole != nil && *currentRole == "owner") {
+		return false, nil
+	}
+
+	if member {
+		if mappedRole == "" || (currentRole != nil && *currentRole == mappedRole) {
+			return false, nil
+		}
+		roleID, err := resolveMemberRole(ctx, tx, provider.TenantID, mappedRole)
+		if err != nil {
+			return false, fmt.Errorf("papel '%s' do SSO não encontrado: %w", mappedRole, err)
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE tenant_members SET role_id = $3, updated_at = NOW() WHERE tenant_id = $1 AND user_id = $2`,
+			provider.TenantID, userID, roleID,
+		); err != nil {
+			return false, fmt.Errorf("erro ao atualizar papel do membro: %w", err)
+		}
+		return true, nil
+	}
+
+	role := mappedRole
+	if role == "" {
+		role = provider.DefaultRole
+	}
+	roleID, err := resolveMemberRole(ctx, tx, provider.TenantID, role)
+	if err != nil {
+		return false, fmt.Errorf("papel '%s' do SSO não encontrado: %w", role, err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO tenant_members (tenant_id, user_id, role_id, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+	`, provider.TenantID, userID, roleID); err != nil {
+		return false, fmt.Errorf("erro ao associar usuário ao tenant: %w", err)
+	}
+	return true, nil
+}
+
+// loadProvider lê a configuração de SSO do tenant com o client_secret decifrado
+func (s *OIDCService) loadProvider(ctx context.Context, tenantID uuid.UUID) (*adminModels.OIDCProvider, error) {
+	p := &adminModels.OIDCProvider{}
+	var encryptedSecret string
+	var groupRoles []byte
+	err := s.masterPool.QueryRow(ctx, `
+		SELECT tenant_id, issuer, client_id, client_secret, scopes, allowed_domains, groups_claim, group_roles,
+			default_role, auto_create_users, sso_only, enabled, created_at, updated_at
+		FROM tenant_oidc_providers
+		WHERE tenant_id = $1
+	`, tenantID).Scan(&p.TenantID, &p.Issuer, &p.ClientID, &encryptedSecret, &p.Scopes, &p.AllowedDomains, &p.GroupsClaim,
+		&groupRoles, &p.DefaultRole, &p.AutoCreateUsers, &p.SSOOnly, &p.Enabled, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrOIDCNotConfigured
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar SSO do tenant: %w", err)
+	}
+
+	if err := json.Unmarshal(groupRoles, &p.GroupRoles); err != nil {
+		return nil, fmt.Errorf("erro ao ler group_roles: %w", err)
+	}
+	p.ClientSecret, err = utils.DecryptSecret(s.config.Auth.OIDCEncryptionKey, encryptedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decifrar client_secret: %w", err)
+	}
+	p.RedirectURI = s.config.Auth.OIDCRedirectURL
+	return p, nil
+}
+
+// resolveTenant encontra o tenant ativo pelo url_code ou pelo subdomínio
+func (s *OIDCService) resolveTenant(ctx context.Context, tenantRef string) (uuid.UUID, string, error) {
+	var tenantID uuid.UUID
+	var urlCode string
+	err := s.masterPool.QueryRow(ctx, `
+		SELECT id, url_code FROM tenants
+		WHERE (url_code = $1 OR subdomain = $1) AND status = 'active'
+		ORDER BY (url_code = $1) DESC
+		LIMIT 1
+	`, tenantRef).Scan(&tenantID, &urlCode)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, "", ErrOIDCNotConfigured
+	}
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	return tenantID, urlCode, nil
+}
+
+// createSSOUser cria o usuário de um login SSO: email já verificado pelo IdP e senha aleatória
+// (pode ser definida depois pelo "esqueci minha senha")
+func createSSOUser(ctx context.Context, tx pgx.Tx, email, name string) (uuid.UUID, error) {
+	randomPassword, err := utils.GenerateSecret(oidcRandomBytes)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	passwordHash, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("erro ao gerar senha: %w", err)
+	}
+
+	var userID uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO users (email, password_hash, email_verified_at)
+		VALUES ($1, $2, NOW())
+		RETURNING id
+	`, email, passwordHash).Scan(&userID); err != nil {
+		return uuid.Nil, fmt.Errorf("erro ao criar usuário: %w", err)
+	}
+
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO user_profiles (user_id, full_name) VALUES ($1, $2)`, userID, name); err != nil {
+		return uuid.Nil, fmt.Errorf("erro ao criar perfil: %w", err)
+	}
+	return userID, nil
+}
+
+// autoLinkAllowed informa se a conta existente com o email do IdP pode ser vinculada sem a senha:
+// quando o usuário já é membro (ou owner) do tenant do SSO, ou quando o domínio do email está em
+// allowed_domains e é o domínio customizado que o tenant verificou no DNS
+func autoLinkAllowed(ctx context.Context, q rowQuerier, provider *adminModels.OIDCProvider, userID uuid.UUID, email string) (bool, error) {
+	_, domain, _ := strings.Cut(email, "@")
+	domainAllowed := containsString(provider.AllowedDomains, domain)
+
+	var allowed bool
+	if err := q.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM tenant_members WHERE tenant_id = $1 AND user_id = $2)
+			OR EXISTS(SELECT 1 FROM tenants WHERE id = $1 AND owner_id = $2)
+			OR ($4 AND EXISTS(
+				SELECT 1 FROM tenant_profiles
+				WHERE tenant_id = $1 AND LOWER(custom_domain) = $3 AND custom_domain_verified_at IS NOT NULL
+			))
+	`, provider.TenantID, userID, domain, domainAllowed).Scan(&allowed); err != nil {
+		return false, fmt.Errorf("erro ao verificar vínculo automático: %w", err)
+	}
+	return allowed, nil
+}
+
+// resolveMemberRole retorna o id de um papel que pode ser dado a membros do tenant (do tenant ou
+// global; owner e global_admin não). pgx.ErrNoRows quando não existe.
+func resolveMemberRole(ctx context.Context, q rowQuerier, tenantID uuid.UUID, slug string) (uuid.UUID, error) {
+	var roleID uuid.UUID
+	err := q.QueryRow(ctx, `
+		SELECT id FROM roles
+		WHERE slug = $2 AND (tenant_id = $1 OR tenant_id IS NULL) AND slug NOT IN ('owner', 'global_admin')
+		ORDER BY tenant_id NULLS LAST
+		LIMIT 1
+	`, tenantID, slug).Scan(&roleID)
+	return roleID, err
+}
+
+func oidcStateKey(state string) string {
+	return "auth:oidc:" + utils.HashToken(state)
+}
+
+func oidcLinkKey(linkToken string) string {
+	return "auth:oidc:link:" + utils.HashToken(linkToken)
+}
+
+func groupRoleSlugs(mappings []adminModels.OIDCGroupRole) []string {
+	slugs := make([]string, 0, len(mappings))
+	for _, m := range mappings {
+		slugs = append(slugs, m.Role)
+	}
+	return slugs
+}
+
+// normalizeList remove espaços, vazios e repetidos (e converte para minúsculas quando lower)
+func normalizeList(values []string, lower bool) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if lower {
+			v = strings.ToLower(v)
+		}
+		if v != "" && !containsString(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
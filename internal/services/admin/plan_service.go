@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
 	adminModels "github.com/saas-multi-database-api/internal/models/admin"
 	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
 )
@@ -59,13 +60,15 @@ func (s *PlanService) GetAllPlansWithCache(ctx context.Context) ([]adminModels.P
 		}
 
 		planResponses = append(planResponses, adminModels.PlanResponse{
-			ID:          plan.ID,
-			Name:        plan.Name,
-			Description: plan.Description,
-			Price:       plan.Price,
-			Features:    features,
-			CreatedAt:   plan.CreatedAt,
-			UpdatedAt:   plan.UpdatedAt,
+			ID:               plan.ID,
+			Name:             plan.Name,
+			Description:      plan.Description,
+			Price:            plan.Price,
+			Features:         features,
+			MaxDBConnections: plan.MaxDBConnections,
+			MaxMembers:       plan.MaxMembers,
+			CreatedAt:        plan.CreatedAt,
+			UpdatedAt:        plan.UpdatedAt,
 		})
 	}
 
@@ -103,13 +106,15 @@ func (s *PlanService) GetPlanByIDWithCache(ctx context.Context, planID uuid.UUID
 	}
 
 	planResponse := &adminModels.PlanResponse{
-		ID:          plan.ID,
-		Name:        plan.Name,
-		Description: plan.Description,
-		Price:       plan.Price,
-		Features:    features,
-		CreatedAt:   plan.CreatedAt,
-		UpdatedAt:   plan.UpdatedAt,
+		ID:               plan.ID,
+		Name:             plan.Name,
+		Description:      plan.Description,
+		Price:            plan.Price,
+		Features:         features,
+		MaxDBConnections: plan.MaxDBConnections,
+		MaxMembers:       plan.MaxMembers,
+		CreatedAt:        plan.CreatedAt,
+		UpdatedAt:        plan.UpdatedAt,
 	}
 
 	// Cachear por 24 horas
@@ -163,8 +168,8 @@ func (s *PlanService) InvalidatePlanCache(ctx context.Context, planID uuid.UUID)
 }
 
 // CreatePlan cria um plano e invalida cache
-func (s *PlanService) CreatePlan(ctx context.Context, name, description string, price float64) (*adminModels.Plan, error) {
-	plan, err := s.planRepo.CreatePlan(ctx, name, description, price)
+func (s *PlanService) CreatePlan(ctx context.Context, name, description string, price float64, maxDBConnections, maxMembers *int) (*adminModels.Plan, error) {
+	plan, err := s.planRepo.CreatePlan(ctx, name, description, price, maxDBConnections, maxMembers)
 	if err != nil {
 		return nil, err
 	}
@@ -179,8 +184,8 @@ func (s *PlanService) CreatePlan(ctx context.Context, name, description string,
 }
 
 // UpdatePlan atualiza um plano e invalida cache
-func (s *PlanService) UpdatePlan(ctx context.Context, planID uuid.UUID, name, description string, price float64) (*adminModels.Plan, error) {
-	plan, err := s.planRepo.UpdatePlan(ctx, planID, name, description, price)
+func (s *PlanService) UpdatePlan(ctx context.Context, planID uuid.UUID, name, description string, price float64, maxDBConnections, maxMembers *int) (*adminModels.Plan, error) {
+	plan, err := s.planRepo.UpdatePlan(ctx, planID, name, description, price, maxDBConnections, maxMembers)
 	if err != nil {
 		return nil, err
 	}
@@ -218,5 +223,21 @@ func (s *PlanService) AddFeaturesToPlan(ctx context.Context, planID uuid.UUID, f
 		fmt.Printf("Warning: failed to invalidate plan cache: %v\n", err)
 	}
 
+	// Tenants do plano passam a ver as novas features nas Tenant APIs
+	if err := cache.InvalidateTenantContext(ctx, s.redis, cache.TenantContextEvent{All: true}); err != nil {
+		fmt.Printf("Warning: failed to invalidate tenant contexts: %v\n", err)
+	}
+
 	return nil
 }
+
+// FeaturesChanged invalida os caches que incluem features (planos e contexto dos tenants)
+// Deve ser chamado quando uma feature é alterada
+func (s *PlanService) FeaturesChanged(ctx context.Context) {
+	if err := s.InvalidatePlansCache(ctx); err != nil {
+		fmt.Printf("Warning: failed to invalidate plans cache: %v\n", err)
+	}
+	if err := cache.InvalidateTenantContext(ctx, s.redis, cache.TenantContextEvent{All: true}); err != nil {
+		fmt.Printf("Warning: failed to invalidate tenant contexts: %v\n", err)
+	}
+}
@@ -0,0 +1,283 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/models/shared"
+)
+
+// Etapas do provisionamento, na ordem em que acontecem
+const (
+	ProvisioningStepQueued          = "queued"
+	ProvisioningStepDatabaseCreated = "database_created"
+	ProvisioningStepSchemaApplied   = "schema_applied"
+	ProvisioningStepSeedData        = "seed_data"
+	ProvisioningStepActive          = "active"
+	ProvisioningStepFailed          = "failed"
+)
+
+const (
+	ProvisioningEventCompleted = "completed"
+	ProvisioningEventFailed    = "failed"
+
+	// Canal Redis com os eventos em tempo real de um tenant
+	provisioningEventsChannel = "tenant:provision:events:%s"
+)
+
+// provisioningSteps são as etapas exibidas no progresso
+var provisioningSteps = []struct {
+	Step  string
+	Label string
+}{
+	{ProvisioningStepDatabaseCreated, "Banco de dados criado"},
+	{ProvisioningStepSchemaApplied, "Schema aplicado"},
+	{ProvisioningStepSeedData, "Dados iniciais"},
+	{ProvisioningStepActive, "Tenant ativo"},
+}
+
+// ProvisioningEvent representa uma etapa registrada pelo worker
+type ProvisioningEvent struct {
+	ID        int64     `json:"id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	Step      string    `json:"step"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+	Attempt   int       `json:"attempt"`
+	Final     bool      `json:"final"` // Falha definitiva (sem novas tentativas)
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Terminal indica se o provisionamento terminou (sucesso ou falha definitiva)
+func (e ProvisioningEvent) Terminal() bool {
+	return e.Step == ProvisioningStepActive || (e.Step == ProvisioningStepFailed && e.Final)
+}
+
+// ProvisioningStep é o estado de uma etapa no progresso
+type ProvisioningStep struct {
+	Step        string     `json:"step"`
+	Label       string     `json:"label"`
+	Status      string     `json:"status"` // pending, completed, failed
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ProvisioningProgress é o resumo do provisionamento de um tenant
+type ProvisioningProgress struct {
+	TenantID  uuid.UUID           `json:"tenant_id"`
+	Status    shared.TenantStatus `json:"status"`
+	Done      bool                `json:"done"`
+	Attempt   int                 `json:"attempt"`
+	LastError string              `json:"last_error,omitempty"`
+	Steps     []ProvisioningStep  `json:"steps"`
+	Events    []ProvisioningEvent `json:"events"`
+}
+
+// ProvisioningTracker persiste e publica os eventos de progresso do provisionamento
+type ProvisioningTracker struct {
+	masterPool  *pgxpool.Pool
+	redisClient *redis.Client
+}
+
+func NewProvisioningTracker(masterPool *pgxpool.Pool, redisClient *redis.Client) *ProvisioningTracker {
+	return &ProvisioningTracker{
+		masterPool:  masterPool,
+		redisClient: redisClient,
+	}
+}
+
+// Record persiste o evento no Master DB e publica no canal do tenant
+func (t *ProvisioningTracker) Record(ctx context.Context, event ProvisioningEvent) (*ProvisioningEvent, error) {
+	err := t.masterPool.QueryRow(ctx, `
+		INSERT INTO tenant_provisioning_events (tenant_id, step, status, message, attempt, final)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, event.TenantID, event.Step, event.Status, event.Message, event.Attempt, event.Final).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar evento de provisionamento: %w", err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return &event, fmt.Errorf("erro ao serializar evento de provisionamento: %w", err)
+	}
+
+	// Clientes que perderem a publicação recuperam pelo histórico persistido
+	if err := t.redisClient.Publish(ctx, fmt.Sprintf(provisioningEventsChannel, event.TenantID), payload).Err(); err != nil {
+		return &event, fmt.Errorf("erro ao publicar evento de provisionamento: %w", err)
+	}
+
+	return &event, nil
+}
+
+// ListEvents retorna os eventos do tenant com id maior que afterID, em ordem
+func (t *ProvisioningTracker) ListEvents(ctx context.Context, tenantID uuid.UUID, afterID int64) ([]ProvisioningEvent, error) {
+	rows, err := t.masterPool.Query(ctx, `
+		SELECT id, tenant_id, step, status, COALESCE(message, ''), attempt, final, created_at
+		FROM tenant_provisioning_events
+		WHERE tenant_id = $1 AND id > $2
+		ORDER BY id
+	`, tenantID, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar eventos de provisionamento: %w", err)
+	}
+	defer rows.Close()
+
+	events := []ProvisioningEvent{}
+	for rows.Next() {
+		var e ProvisioningEvent
+		if err := rows.Scan(&e.ID, &e.TenantID, &e.Step, &e.Status, &e.Message, &e.Attempt, &e.Final, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler evento de provisionamento: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// GetProgress monta o progresso etapa a etapa a partir do histórico de eventos
+func (t *ProvisioningTracker) GetProgress(ctx context.Context, tenantID uuid.UUID) (*ProvisioningProgress, error) {
+	var status shared.TenantStatus
+	if err := t.masterPool.QueryRow(ctx, `SELECT status FROM tenants WHERE id = $1`, tenantID).Scan(&status); err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	events, err := t.ListEvents(ctx, tenantID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &ProvisioningProgress{
+		TenantID: tenantID,
+		Status:   status,
+		Events:   events,
+	}
+
+	completed := make(map[string]time.Time)
+	var last *ProvisioningEvent
+	for i := range events {
+		e := events[i]
+		// Um novo enfileiramento (replay da DLQ) reinicia o progresso
+		if e.Step == ProvisioningStepQueued {
+			completed = make(map[string]time.Time)
+			progress.Attempt = 0
+		}
+		if e.Status == ProvisioningEventCompleted {
+			completed[e.Step] = e.CreatedAt
+		}
+		if e.Attempt > progress.Attempt {
+			progress.Attempt = e.Attempt
+		}
+		last = &events[i]
+	}
+
+	failed := last != nil && last.Step == ProvisioningStepFailed
+	if failed {
+		progress.LastError = last.Message
+	}
+
+	// Tenants provisionados antes do histórico existir não têm eventos
+	provisioned := status != shared.TenantStatusProvisioning && status != shared.TenantStatusFailed
+
+	markedFailure := false
+	for _, s := range provisioningSteps {
+		step := ProvisioningStep{Step: s.Step, Label: s.Label, Status: "pending"}
+		if at, ok := completed[s.Step]; ok {
+			step.Status = ProvisioningEventCompleted
+			step.CompletedAt = &at
+		} else if provisioned {
+			step.Status = ProvisioningEventCompleted
+		} else if failed && !markedFailure {
+			step.Status = ProvisioningEventFailed
+			markedFailure = true
+		}
+		progress.Steps = append(progress.Steps, step)
+	}
+
+	progress.Done = provisioned || (last != nil && last.Terminal())
+
+	return progress, nil
+}
+
+// Stream envia no canal retornado o histórico após lastEventID e depois os eventos em tempo real.
+// O canal é fechado quando o provisionamento termina ou quando ctx é cancelado.
+func (t *ProvisioningTracker) Stream(ctx context.Context, tenantID uuid.UUID, lastEventID int64) (<-chan ProvisioningEvent, error) {
+	// Assinar antes de ler o histórico para não perder eventos entre as duas etapas
+	pubsub := t.redisClient.Subscribe(ctx, fmt.Sprintf(provisioningEventsChannel, tenantID))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("erro ao assinar eventos de provisionamento: %w", err)
+	}
+
+	history, err := t.ListEvents(ctx, tenantID, lastEventID)
+	if err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	progress, err := t.GetProgress(ctx, tenantID)
+	if err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	out := make(chan ProvisioningEvent)
+
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		sentID := lastEventID
+		for _, e := range history {
+			select {
+			case out <- e:
+				sentID = e.ID
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if progress.Done {
+			return
+		}
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var e ProvisioningEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+					continue
+				}
+
+				// Já enviado pelo histórico
+				if e.ID <= sentID {
+					continue
+				}
+
+				select {
+				case out <- e:
+					sentID = e.ID
+				case <-ctx.Done():
+					return
+				}
+
+				if e.Terminal() {
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,350 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Stream com os eventos de provisionamento (consumer group garante entrega at-least-once)
+	provisionStreamKey = "tenant:provision:stream"
+	// Consumer group dos workers de provisionamento
+	provisionGroup = "provisioners"
+	// ZSET com eventos aguardando nova tentativa (score = unix timestamp de quando devem voltar ao stream)
+	provisionRetryKey = "tenant:provision:retry"
+	// HASH tenant_id -> DeadLetter com eventos que esgotaram as tentativas
+	provisionDLQKey = "tenant:provision:dlq"
+	// Lista usada antes do stream (BRPop); eventos remanescentes são migrados pelo worker
+	legacyProvisionQueueKey = "tenant:provision:queue"
+
+	// ProvisionMaxAttempts é o número máximo de tentativas antes de mover o evento para a DLQ
+	ProvisionMaxAttempts = 5
+	// Backoff exponencial: 5s, 10s, 20s, 40s... limitado a provisionMaxBackoff
+	provisionBaseBackoff = 5 * time.Second
+	provisionMaxBackoff  = 5 * time.Minute
+)
+
+// ErrDeadLetterNotFound é retornado quando não existe evento na DLQ para o tenant
+var ErrDeadLetterNotFound = errors.New("evento não encontrado na dead-letter queue")
+
+// promoteRetryScript devolve um evento do ZSET de retries ao stream de forma atômica: o XADD vem
+// antes do ZREM, então um erro no XADD mantém o evento agendado. Retorna 0 se outro worker já o promoveu.
+var promoteRetryScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('XADD', KEYS[2], '*', 'event', ARGV[1])
+redis.call('ZREM', KEYS[1], ARGV[1])
+return 1
+`)
+
+// replayDeadLetterScript republica um evento da DLQ e só então o remove, de forma atômica: um erro no
+// XADD mantém o evento na DLQ. Retorna 0 se outro replay já o republicou.
+var replayDeadLetterScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('XADD', KEYS[2], '*', 'event', ARGV[2])
+redis.call('HDEL', KEYS[1], ARGV[1])
+return 1
+`)
+
+// migrateLegacyScript move o evento mais antigo da lista legada para o stream de forma atômica (XADD
+// antes do RPOP). Eventos inválidos são descartados pelo worker ao ler o stream. Retorna 0 com a lista vazia.
+var migrateLegacyScript = redis.NewScript(`
+local event = redis.call('LINDEX', KEYS[1], -1)
+if not event then
+	return 0
+end
+redis.call('XADD', KEYS[2], '*', 'event', event)
+redis.call('RPOP', KEYS[1])
+return 1
+`)
+
+// ProvisionMessage é um evento lido do stream, com o ID necessário para o ACK
+type ProvisionMessage struct {
+	ID    string
+	Event ProvisionEvent
+}
+
+// DeadLetter representa um evento que esgotou as tentativas de provisionamento
+type DeadLetter struct {
+	Event    ProvisionEvent `json:"event"`
+	Error    string         `json:"error"`
+	FailedAt time.Time      `json:"failed_at"`
+}
+
+// ProvisioningQueue encapsula a fila de provisionamento no Redis (stream, retries e DLQ)
+type ProvisioningQueue struct {
+	redisClient *redis.Client
+}
+
+func NewProvisioningQueue(redisClient *redis.Client) *ProvisioningQueue {
+	return &ProvisioningQueue{
+		redisClient: redisClient,
+	}
+}
+
+// Enqueue publica um evento de provisionamento no stream
+func (q *ProvisioningQueue) Enqueue(ctx context.Context, event ProvisionEvent) error {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar evento: %w", err)
+	}
+
+	return q.redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: provisionStreamKey,
+		Values: map[string]interface{}{"event": eventJSON},
+	}).Err()
+}
+
+// EnsureGroup cria o consumer group (e o stream) caso ainda não existam
+func (q *ProvisioningQueue) EnsureGroup(ctx context.Context) error {
+	err := q.redisClient.XGroupCreateMkStream(ctx, provisionStreamKey, provisionGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("erro ao criar consumer group: %w", err)
+	}
+	return nil
+}
+
+// MigrateLegacyQueue move eventos pendentes da antiga lista (BRPop) para o stream
+func (q *ProvisioningQueue) MigrateLegacyQueue(ctx context.Context) (int, error) {
+	moved := 0
+	for {
+		n, err := migrateLegacyScript.Run(ctx, q.redisClient, []string{legacyProvisionQueueKey, provisionStreamKey}).Int()
+		if err != nil {
+			return moved, err
+		}
+		if n == 0 {
+			return moved, nil
+		}
+		moved++
+	}
+}
+
+// Read bloqueia até block esperando novos eventos para o consumer
+func (q *ProvisioningQueue) Read(ctx context.Context, consumer string, block time.Duration) ([]ProvisionMessage, error) {
+	streams, err := q.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    provisionGroup,
+		Consumer: consumer,
+		Streams:  []string{provisionStreamKey, ">"},
+		Count:    1,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []ProvisionMessage
+	for _, stream := range streams {
+		messages = append(messages, q.decode(ctx, stream.Messages)...)
+	}
+	return messages, nil
+}
+
+// Reclaim assume eventos entregues a outro consumer que ficaram sem ACK por mais de minIdle
+// (ex: worker que caiu no meio do provisionamento)
+func (q *ProvisioningQueue) Reclaim(ctx context.Context, consumer string, minIdle time.Duration) ([]ProvisionMessage, error) {
+	xMessages, _, err := q.redisClient.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   provisionStreamKey,
+		Group:    provisionGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0",
+		Count:    10,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.decode(ctx, xMessages), nil
+}
+
+// Ack confirma o processamento do evento e o remove do stream
+func (q *ProvisioningQueue) Ack(ctx context.Context, messageID string) error {
+	pipe := q.redisClient.TxPipeline()
+	pipe.XAck(ctx, provisionStreamKey, provisionGroup, messageID)
+	pipe.XDel(ctx, provisionStreamKey, messageID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Fail registra a falha de uma tentativa: agenda nova tentativa com backoff exponencial
+// ou, se as tentativas se esgotaram, move o evento para a DLQ. Retorna true se foi para a DLQ.
+func (q *ProvisioningQueue) Fail(ctx context.Context, msg ProvisionMessage, cause error) (bool, error) {
+	event := msg.Event
+	event.Attempt++
+	event.LastError = cause.Error()
+
+	deadLettered := event.Attempt >= ProvisionMaxAttempts
+
+	if deadLettered {
+		entry, err := json.Marshal(DeadLetter{
+			Event:    event,
+			Error:    cause.Error(),
+			FailedAt: time.Now(),
+		})
+		if err != nil {
+			return false, fmt.Errorf("erro ao serializar dead letter: %w", err)
+		}
+
+		if err := q.redisClient.HSet(ctx, provisionDLQKey, event.TenantID.String(), entry).Err(); err != nil {
+			return false, fmt.Errorf("erro ao mover evento para DLQ: %w", err)
+		}
+	} else {
+		eventJSON, err := json.Marshal(event)
+		if err != nil {
+			return false, fmt.Errorf("erro ao serializar evento: %w", err)
+		}
+
+		due := time.Now().Add(provisionBackoff(event.Attempt))
+		if err := q.redisClient.ZAdd(ctx, provisionRetryKey, redis.Z{
+			Score:  float64(due.Unix()),
+			Member: eventJSON,
+		}).Err(); err != nil {
+			return false, fmt.Errorf("erro ao agendar nova tentativa: %w", err)
+		}
+	}
+
+	// Só confirma depois de persistir o retry/DLQ para não perder o evento
+	if err := q.Ack(ctx, msg.ID); err != nil {
+		return deadLettered, fmt.Errorf("erro ao confirmar evento: %w", err)
+	}
+
+	return deadLettered, nil
+}
+
+// PromoteDueRetries devolve ao stream os eventos cujo backoff já expirou
+func (q *ProvisioningQueue) PromoteDueRetries(ctx context.Context) (int, error) {
+	due, err := q.redisClient.ZRangeByScore(ctx, provisionRetryKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	promoted := 0
+	for _, member := range due {
+		// O script garante que apenas um worker promove cada evento e que ele não se perde no caminho
+		moved, err := promoteRetryScript.Run(ctx, q.redisClient, []string{provisionRetryKey, provisionStreamKey}, member).Int()
+		if err != nil {
+			return promoted, err
+		}
+		promoted += moved
+	}
+
+	return promoted, nil
+}
+
+// ListDeadLetters retorna os eventos na DLQ, do mais recente para o mais antigo
+func (q *ProvisioningQueue) ListDeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	entries, err := q.redisClient.HGetAll(ctx, provisionDLQKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler DLQ: %w", err)
+	}
+
+	deadLetters := make([]DeadLetter, 0, len(entries))
+	for _, entry := range entries {
+		var deadLetter DeadLetter
+		if err := json.Unmarshal([]byte(entry), &deadLetter); err != nil {
+			continue
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	sort.Slice(deadLetters, func(i, j int) bool {
+		return deadLetters[i].FailedAt.After(deadLetters[j].FailedAt)
+	})
+
+	return deadLetters, nil
+}
+
+// GetDeadLetter retorna o evento na DLQ de um tenant
+func (q *ProvisioningQueue) GetDeadLetter(ctx context.Context, tenantID uuid.UUID) (*DeadLetter, error) {
+	entry, err := q.redisClient.HGet(ctx, provisionDLQKey, tenantID.String()).Result()
+	if err == redis.Nil {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler DLQ: %w", err)
+	}
+
+	var deadLetter DeadLetter
+	if err := json.Unmarshal([]byte(entry), &deadLetter); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar dead letter: %w", err)
+	}
+
+	return &deadLetter, nil
+}
+
+// Replay publica novamente o evento da DLQ com o contador de tentativas zerado e o remove da DLQ
+func (q *ProvisioningQueue) Replay(ctx context.Context, tenantID uuid.UUID) (*ProvisionEvent, error) {
+	deadLetter, err := q.GetDeadLetter(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := deadLetter.Event
+	event.Attempt = 0
+	event.LastError = ""
+	event.Timestamp = time.Now()
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar evento: %w", err)
+	}
+
+	replayed, err := replayDeadLetterScript.Run(ctx, q.redisClient,
+		[]string{provisionDLQKey, provisionStreamKey}, tenantID.String(), eventJSON).Int()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao republicar evento: %w", err)
+	}
+	if replayed == 0 {
+		// Outro replay concorrente já republicou
+		return nil, ErrDeadLetterNotFound
+	}
+
+	return &event, nil
+}
+
+// decode converte mensagens do stream em ProvisionMessage; mensagens inválidas são descartadas
+func (q *ProvisioningQueue) decode(ctx context.Context, xMessages []redis.XMessage) []ProvisionMessage {
+	messages := make([]ProvisionMessage, 0, len(xMessages))
+	for _, xMessage := range xMessages {
+		raw, _ := xMessage.Values["event"].(string)
+
+		var event ProvisionEvent
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			q.Ack(ctx, xMessage.ID)
+			continue
+		}
+
+		messages = append(messages, ProvisionMessage{ID: xMessage.ID, Event: event})
+	}
+	return messages
+}
+
+// provisionBackoff calcula o atraso da próxima tentativa
+func provisionBackoff(attempt int) time.Duration {
+	backoff := provisionBaseBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= provisionMaxBackoff {
+			return provisionMaxBackoff
+		}
+	}
+	return backoff
+}
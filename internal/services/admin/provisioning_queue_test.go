@@ -0,0 +1,232 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestProvisionBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: 5 * time.Second},
+		{attempt: 1, want: 5 * time.Second},
+		{attempt: 2, want: 10 * time.Second},
+		{attempt: 3, want: 20 * time.Second},
+		{attempt: 4, want: 40 * time.Second},
+		{attempt: 6, want: 160 * time.Second},
+		{attempt: 7, want: provisionMaxBackoff},
+		{attempt: 50, want: provisionMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := provisionBackoff(tt.attempt); got != tt.want {
+			t.Errorf("provisionBackoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestProvisioningQueueRetriesThenDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	queue := NewProvisioningQueue(testRedis(t))
+	if err := queue.EnsureGroup(ctx); err != nil {
+		t.Fatalf("EnsureGroup: %v", err)
+	}
+
+	event := ProvisionEvent{TenantID: uuid.New(), DBCode: "db_test", URLCode: "TEST", Timestamp: time.Now()}
+	if err := queue.Enqueue(ctx, event); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	cause := errors.New("database unavailable")
+	for attempt := 1; attempt <= ProvisionMaxAttempts; attempt++ {
+		messages, err := queue.Read(ctx, "worker-test", 100*time.Millisecond)
+		if err != nil || len(messages) != 1 {
+			t.Fatalf("attempt %d: Read = %v, %v", attempt, messages, err)
+		}
+		if got := messages[0].Event.Attempt; got != attempt-1 {
+			t.Fatalf("attempt %d: event.Attempt = %d, want %d", attempt, got, attempt-1)
+		}
+
+		deadLettered, err := queue.Fail(ctx, messages[0], cause)
+		if err != nil {
+			t.Fatalf("attempt %d: Fail: %v", attempt, err)
+		}
+		if want := attempt == ProvisionMaxAttempts; deadLettered != want {
+			t.Fatalf("attempt %d: deadLettered = %v, want %v", attempt, deadLettered, want)
+		}
+		if deadLettered {
+			break
+		}
+
+		// The retry waits for its backoff in the ZSET; not yet due, nothing is promoted
+		if promoted, err := queue.PromoteDueRetries(ctx); err != nil || promoted != 0 {
+			t.Fatalf("attempt %d: PromoteDueRetries before backoff = %d, %v", attempt, promoted, err)
+		}
+		dueNow(t, queue)
+		if promoted, err := queue.PromoteDueRetries(ctx); err != nil || promoted != 1 {
+			t.Fatalf("attempt %d: PromoteDueRetries = %d, %v", attempt, promoted, err)
+		}
+	}
+
+	deadLetter, err := queue.GetDeadLetter(ctx, event.TenantID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter: %v", err)
+	}
+	if deadLetter.Event.Attempt != ProvisionMaxAttempts || deadLetter.Error != cause.Error() {
+		t.Errorf("dead letter = %+v", deadLetter)
+	}
+	if messages, _ := queue.Read(ctx, "worker-test", 100*time.Millisecond); len(messages) != 0 {
+		t.Errorf("dead-lettered event still in the stream: %+v", messages)
+	}
+
+	replayed, err := queue.Replay(ctx, event.TenantID)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if replayed.Attempt != 0 || replayed.LastError != "" {
+		t.Errorf("replayed event = %+v, want attempt and error reset", replayed)
+	}
+	if _, err := queue.GetDeadLetter(ctx, event.TenantID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("GetDeadLetter after replay: err = %v, want ErrDeadLetterNotFound", err)
+	}
+	if _, err := queue.Replay(ctx, event.TenantID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("second Replay: err = %v, want ErrDeadLetterNotFound", err)
+	}
+
+	messages, err := queue.Read(ctx, "worker-test", 100*time.Millisecond)
+	if err != nil || len(messages) != 1 || messages[0].Event.TenantID != event.TenantID {
+		t.Fatalf("Read after replay = %+v, %v", messages, err)
+	}
+}
+
+func TestPromoteDueRetriesKeepsEventOnError(t *testing.T) {
+	ctx := context.Background()
+	client := testRedis(t)
+	queue := NewProvisioningQueue(client)
+
+	member := `{"tenant_id":"` + uuid.NewString() + `","attempt":1}`
+	if err := client.ZAdd(ctx, provisionRetryKey, redis.Z{Score: float64(time.Now().Add(-time.Second).Unix()), Member: member}).Err(); err != nil {
+		t.Fatalf("ZAdd: %v", err)
+	}
+	// A key of the wrong type makes the XADD fail inside the script
+	if err := client.Set(ctx, provisionStreamKey, "not a stream", 0).Err(); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if _, err := queue.PromoteDueRetries(ctx); err == nil {
+		t.Fatal("PromoteDueRetries succeeded with a broken stream")
+	}
+	if _, err := client.ZScore(ctx, provisionRetryKey, member).Result(); err != nil {
+		t.Fatalf("retry lost after a failed promotion: %v", err)
+	}
+
+	client.Del(ctx, provisionStreamKey)
+	if promoted, err := queue.PromoteDueRetries(ctx); err != nil || promoted != 1 {
+		t.Fatalf("PromoteDueRetries = %d, %v", promoted, err)
+	}
+	if n := client.ZCard(ctx, provisionRetryKey).Val(); n != 0 {
+		t.Errorf("%d retries left after the promotion", n)
+	}
+	if n := client.XLen(ctx, provisionStreamKey).Val(); n != 1 {
+		t.Errorf("stream has %d events, want 1", n)
+	}
+}
+
+func TestReplayKeepsDeadLetterOnError(t *testing.T) {
+	ctx := context.Background()
+	client := testRedis(t)
+	queue := NewProvisioningQueue(client)
+
+	tenantID := uuid.New()
+	entry := `{"event":{"tenant_id":"` + tenantID.String() + `","attempt":5},"error":"boom","failed_at":"2026-01-01T00:00:00Z"}`
+	if err := client.HSet(ctx, provisionDLQKey, tenantID.String(), entry).Err(); err != nil {
+		t.Fatalf("HSet: %v", err)
+	}
+	if err := client.Set(ctx, provisionStreamKey, "not a stream", 0).Err(); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if _, err := queue.Replay(ctx, tenantID); err == nil || errors.Is(err, ErrDeadLetterNotFound) {
+		t.Fatalf("Replay with a broken stream: err = %v", err)
+	}
+	if _, err := queue.GetDeadLetter(ctx, tenantID); err != nil {
+		t.Fatalf("dead letter lost after a failed replay: %v", err)
+	}
+
+	client.Del(ctx, provisionStreamKey)
+	if _, err := queue.Replay(ctx, tenantID); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if _, err := queue.GetDeadLetter(ctx, tenantID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("GetDeadLetter after replay: err = %v", err)
+	}
+}
+
+func TestMigrateLegacyQueue(t *testing.T) {
+	ctx := context.Background()
+	client := testRedis(t)
+	queue := NewProvisioningQueue(client)
+	if err := queue.EnsureGroup(ctx); err != nil {
+		t.Fatalf("EnsureGroup: %v", err)
+	}
+
+	// The old producer used LPUSH and the old worker BRPOP: the oldest event is at the tail
+	first, second := uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{first, second} {
+		if err := client.LPush(ctx, legacyProvisionQueueKey, `{"tenant_id":"`+id.String()+`"}`).Err(); err != nil {
+			t.Fatalf("LPush: %v", err)
+		}
+	}
+
+	// A failing XADD keeps the events in the legacy list
+	client.Rename(ctx, provisionStreamKey, provisionStreamKey+":saved")
+	client.Set(ctx, provisionStreamKey, "not a stream", 0)
+	if _, err := queue.MigrateLegacyQueue(ctx); err == nil {
+		t.Fatal("MigrateLegacyQueue succeeded with a broken stream")
+	}
+	if n := client.LLen(ctx, legacyProvisionQueueKey).Val(); n != 2 {
+		t.Fatalf("legacy list has %d events after a failed migration, want 2", n)
+	}
+	client.Del(ctx, provisionStreamKey)
+	client.Rename(ctx, provisionStreamKey+":saved", provisionStreamKey)
+
+	moved, err := queue.MigrateLegacyQueue(ctx)
+	if err != nil || moved != 2 {
+		t.Fatalf("MigrateLegacyQueue = %d, %v", moved, err)
+	}
+	if n := client.LLen(ctx, legacyProvisionQueueKey).Val(); n != 0 {
+		t.Errorf("legacy list has %d events left", n)
+	}
+
+	for _, want := range []uuid.UUID{first, second} {
+		messages, err := queue.Read(ctx, "worker-test", 100*time.Millisecond)
+		if err != nil || len(messages) != 1 || messages[0].Event.TenantID != want {
+			t.Fatalf("Read = %+v, %v, want tenant %s", messages, err, want)
+		}
+	}
+}
+
+// dueNow moves every scheduled retry to the past, as if its backoff had expired
+func dueNow(t *testing.T, queue *ProvisioningQueue) {
+	t.Helper()
+	ctx := context.Background()
+	members, err := queue.redisClient.ZRange(ctx, provisionRetryKey, 0, -1).Result()
+	if err != nil {
+		t.Fatalf("ZRange: %v", err)
+	}
+	for _, member := range members {
+		if err := queue.redisClient.ZAddXX(ctx, provisionRetryKey, redis.Z{
+			Score:  float64(time.Now().Add(-time.Second).Unix()),
+			Member: member,
+		}).Err(); err != nil {
+			t.Fatalf("ZAddXX: %v", err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/models/admin"
+)
+
+// RateLimitService gerencia os limites de requisições da Tenant API por plano e por tenant
+type RateLimitService struct {
+	masterPool  *pgxpool.Pool
+	redisClient *redis.Client
+	config      *config.Config
+}
+
+func NewRateLimitService(masterPool *pgxpool.Pool, redisClient *redis.Client, cfg *config.Config) *RateLimitService {
+	return &RateLimitService{
+		masterPool:  masterPool,
+		redisClient: redisClient,
+		config:      cfg,
+	}
+}
+
+// GetPlanRateLimits retorna os limites do plano (nil = padrão da Tenant API)
+func (s *RateLimitService) GetPlanRateLimits(ctx context.Context, planID uuid.UUID) (*admin.RateLimits, error) {
+	var limits admin.RateLimits
+	err := s.masterPool.QueryRow(ctx,
+		`SELECT rate_limit_read, rate_limit_write, rate_limit_upload FROM plans WHERE id = $1`,
+		planID,
+	).Scan(&limits.Read, &limits.Write, &limits.Upload)
+	if err == pgx.ErrNoRows {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar limites do plano: %w", err)
+	}
+	return &limits, nil
+}
+
+// SetPlanRateLimits substitui os limites do plano; vale para todos os tenants do plano sem override
+func (s *RateLimitService) SetPlanRateLimits(ctx context.Context, planID uuid.UUID, limits admin.RateLimits) (*admin.RateLimits, error) {
+	tag, err := s.masterPool.Exec(ctx, `
+		UPDATE plans SET rate_limit_read = $1, rate_limit_write = $2, rate_limit_upload = $3, updated_at = NOW()
+		WHERE id = $4
+	`, limits.Read, limits.Write, limits.Upload, planID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar limites do plano: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrPlanNotFound
+	}
+
+	// Os limites ficam no contexto em cache de cada tenant das Tenant APIs
+	if err := cache.InvalidateTenantContext(ctx, s.redisClient, cache.TenantContextEvent{All: true}); err != nil {
+		fmt.Printf("Warning: erro ao invalidar contexto dos tenants: %v\n", err)
+	}
+
+	return &limits, nil
+}
+
+// GetTenantRateLimits retorna os limites do plano do tenant, o override do admin e os limites efetivos
+func (s *RateLimitService) GetTenantRateLimits(ctx context.Context, tenantID uuid.UUID) (*admin.TenantRateLimits, error) {
+	result := &admin.TenantRateLimits{TenantID: tenantID}
+
+	var override admin.RateLimits
+	var hasOverride bool
+	err := s.masterPool.QueryRow(ctx, `
+		SELECT p.rate_limit_read, p.rate_limit_write, p.rate_limit_upload,
+		       o.read_per_minute, o.write_per_minute, o.upload_per_minute, o.tenant_id IS NOT NULL
+		FROM tenants t
+		JOIN plans p ON p.id = t.plan_id
+		LEFT JOIN tenant_rate_limits o ON o.tenant_id = t.id
+		WHERE t.id = $1
+	`, tenantID).Scan(&result.Plan.Read, &result.Plan.Write, &result.Plan.Upload,
+		&override.Read, &override.Write, &override.Upload, &hasOverride)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar limites do tenant: %w", err)
+	}
+	if hasOverride {
+		result.Override = &override
+	}
+
+	defaults := admin.RateLimits{
+		Read:   &s.config.Tenants.RateLimitReadPerMinute,
+		Write:  &s.config.Tenants.RateLimitWritePerMinute,
+		Upload: &s.config.Tenants.RateLimitUploadPerMinute,
+	}
+	effective := override.Merge(result.Plan).Merge(defaults)
+	result.Effective = map[string]int{
+		"read":   *effective.Read,
+		"write":  *effective.Write,
+		"upload": *effective.Upload,
+	}
+
+	return result, nil
+}
+
+// SetTenantRateLimits grava o override dos limites de um tenant (campos nil seguem o plano)
+func (s *RateLimitService) SetTenantRateLimits(ctx context.Context, tenantID uuid.UUID, limits admin.RateLimits, updatedBy uuid.UUID) (*admin.TenantRateLimits, error) {
+	urlCode, err := s.tenantURLCode(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.masterPool.Exec(ctx, `
+		INSERT INTO tenant_rate_limits (tenant_id, read_per_minute, write_per_minute, upload_per_minute, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			read_per_minute = EXCLUDED.read_per_minute,
+			write_per_minute = EXCLUDED.write_per_minute,
+			upload_per_minute = EXCLUDED.upload_per_minute,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`, tenantID, limits.Read, limits.Write, limits.Upload, updatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar limites do tenant: %w", err)
+	}
+
+	invalidateTenantContext(ctx, s.redisClient, urlCode)
+	return s.GetTenantRateLimits(ctx, tenantID)
+}
+
+// DeleteTenantRateLimits remove o override; o tenant volta aos limites do plano
+func (s *RateLimitService) DeleteTenantRateLimits(ctx context.Context, tenantID uuid.UUID) (*admin.TenantRateLimits, error) {
+	urlCode, err := s.tenantURLCode(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.masterPool.Exec(ctx, `DELETE FROM tenant_rate_limits WHERE tenant_id = $1`, tenantID); err != nil {
+		return nil, fmt.Errorf("erro ao remover limites do tenant: %w", err)
+	}
+
+	invalidateTenantContext(ctx, s.redisClient, urlCode)
+	return s.GetTenantRateLimits(ctx, tenantID)
+}
+
+func (s *RateLimitService) tenantURLCode(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	var urlCode string
+	err := s.masterPool.QueryRow(ctx, `SELECT url_code FROM tenants WHERE id = $1`, tenantID).Scan(&urlCode)
+	if err == pgx.ErrNoRows {
+		return "", ErrTenantNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	return urlCode, nil
+}
@@ -0,0 +1,308 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	"github.com/saas-multi-database-api/internal/storage"
+)
+
+// Tempo máximo de uma cópia para sandbox; cópias 'running' há mais tempo voltam para 'pending'
+const sandboxCloneTimeout = 2 * time.Hour
+
+// sandboxCloneResult é o resultado da cópia de um tenant para o sandbox
+type sandboxCloneResult struct {
+	SchemaVersion int
+	Tables        []database.TableCopyResult
+	MediaCount    int
+	MissingMedia  int
+}
+
+// SandboxRunner executa as cópias para sandbox agendadas e agenda a exclusão dos sandboxes vencidos
+type SandboxRunner struct {
+	masterPool    *pgxpool.Pool
+	clusters      *database.ClusterRegistry
+	migrator      *database.TenantMigrator
+	dbCreds       *DBCredentialService
+	storageDriver storage.StorageDriver
+	redisClient   *redis.Client
+}
+
+func NewSandboxRunner(
+	masterPool *pgxpool.Pool,
+	clusters *database.ClusterRegistry,
+	migrator *database.TenantMigrator,
+	dbCreds *DBCredentialService,
+	storageDriver storage.StorageDriver,
+	redisClient *redis.Client,
+) *SandboxRunner {
+	return &SandboxRunner{
+		masterPool:    masterPool,
+		clusters:      clusters,
+		migrator:      migrator,
+		dbCreds:       dbCreds,
+		storageDriver: storageDriver,
+		redisClient:   redisClient,
+	}
+}
+
+// RunPending executa as cópias 'pending' (uma por vez, SKIP LOCKED entre workers)
+func (r *SandboxRunner) RunPending(ctx context.Context) error {
+	if err := requeueStaleJobs(ctx, r.masterPool, "tenant_sandbox_clones", sandboxCloneTimeout); err != nil {
+		return err
+	}
+
+	for {
+		cloneID, err := claimNextJob(ctx, r.masterPool, "tenant_sandbox_clones")
+		if err != nil {
+			return err
+		}
+		if cloneID == nil {
+			return nil
+		}
+		if err := r.runClone(ctx, *cloneID); err != nil {
+			log.Printf("Cópia para sandbox %s falhou: %v", cloneID, err)
+		}
+	}
+}
+
+// ExpireSandboxes agenda a exclusão imediata dos sandboxes com validade vencida.
+// O purge (database, storage e Master DB) fica com o TenantPurger.
+func (r *SandboxRunner) ExpireSandboxes(ctx context.Context) ([]string, error) {
+	now := time.Now()
+	rows, err := r.masterPool.Query(ctx, `
+		UPDATE tenants SET
+			status = CASE WHEN status = $1 THEN $2 ELSE status END,
+			deletion_requested_at = $3,
+			deletion_scheduled_at = $3,
+			updated_at = $3
+		WHERE is_sandbox AND sandbox_expires_at <= $3 AND deletion_scheduled_at IS NULL
+		RETURNING url_code
+	`, shared.TenantStatusActive, shared.TenantStatusSuspended, now)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao expirar sandboxes: %w", err)
+	}
+	urlCodes, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, urlCode := range urlCodes {
+		invalidateTenantContext(ctx, r.redisClient, urlCode)
+	}
+	return urlCodes, nil
+}
+
+// runClone cria o database do sandbox a partir da origem, copia as mídias e libera o sandbox
+func (r *SandboxRunner) runClone(ctx context.Context, cloneID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, sandboxCloneTimeout)
+	defer cancel()
+
+	var source, sandbox backupTarget
+	err := r.masterPool.QueryRow(ctx, `
+		SELECT s.id, s.url_code, s.db_code::text, s.cluster_id, s.status,
+		       t.id, t.url_code, t.db_code::text, t.cluster_id, t.status
+		FROM tenant_sandbox_clones c
+		JOIN tenants s ON s.id = c.source_tenant_id
+		JOIN tenants t ON t.id = c.sandbox_tenant_id
+		WHERE c.id = $1
+	`, cloneID).Scan(&source.TenantID, &source.URLCode, &source.DBCode, &source.ClusterID, &source.Status,
+		&sandbox.TenantID, &sandbox.URLCode, &sandbox.DBCode, &sandbox.ClusterID, &sandbox.Status)
+	if err != nil {
+		return failJob(ctx, r.masterPool, "tenant_sandbox_clones", cloneID, fmt.Errorf("erro ao buscar tenants da cópia: %w", err))
+	}
+
+	log.Printf("Iniciando cópia do tenant %s para o sandbox %s", source.URLCode, sandbox.URLCode)
+
+	result, cloneErr := r.cloneTenant(ctx, source, sandbox)
+	if cloneErr != nil {
+		if err := setTenantStatus(ctx, r.masterPool, sandbox.TenantID, shared.TenantStatusFailed); err != nil {
+			log.Printf("Erro ao marcar sandbox %s como falho: %v", sandbox.URLCode, err)
+		}
+		return failJob(ctx, r.masterPool, "tenant_sandbox_clones", cloneID, cloneErr)
+	}
+
+	if err := setTenantStatus(ctx, r.masterPool, sandbox.TenantID, shared.TenantStatusActive); err != nil {
+		return failJob(ctx, r.masterPool, "tenant_sandbox_clones", cloneID, err)
+	}
+
+	tables, err := json.Marshal(result.Tables)
+	if err != nil {
+		return failJob(ctx, r.masterPool, "tenant_sandbox_clones", cloneID, err)
+	}
+	_, err = r.masterPool.Exec(ctx, `
+		UPDATE tenant_sandbox_clones
+		SET status = $1, schema_version = $2, tables = $3, media_count = $4, missing_media = $5, completed_at = $6
+		WHERE id = $7
+	`, shared.JobStatusCompleted, result.SchemaVersion, tables, result.MediaCount, result.MissingMedia, time.Now(), cloneID)
+	if err != nil {
+		return fmt.Errorf("erro ao concluir cópia: %w", err)
+	}
+
+	log.Printf("Sandbox %s criado a partir do tenant %s (%d tabela(s), %d mídia(s), %d ausente(s))",
+		sandbox.URLCode, source.URLCode, len(result.Tables), result.MediaCount, result.MissingMedia)
+	return nil
+}
+
+// cloneTenant recria o database do sandbox com o schema da origem, copia os dados (lidos em snapshot
+// consistente, sem bloquear o tenant de origem), copia as mídias e cria o login dedicado do sandbox.
+// É idempotente: o database do sandbox é recriado a cada tentativa.
+func (r *SandboxRunner) cloneTenant(ctx context.Context, source, sandbox backupTarget) (*sandboxCloneResult, error) {
+	sourceCluster, err := r.clusters.Get(ctx, source.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	sandboxCluster, err := r.clusters.Get(ctx, sandbox.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceDSN, err := r.clusters.AdminDSN(sourceCluster, database.TenantDBName(source.DBCode))
+	if err != nil {
+		return nil, err
+	}
+	sourcePool, err := pgxpool.New(ctx, sourceDSN)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar no database de origem: %w", err)
+	}
+	defer sourcePool.Close()
+
+	version, err := r.migrator.CurrentVersion(ctx, sourcePool)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Recriar o database do sandbox no schema da origem
+	adminPool, err := r.clusters.AdminPool(ctx, sandboxCluster)
+	if err != nil {
+		return nil, err
+	}
+	dbName := database.TenantDBName(sandbox.DBCode)
+	dbIdent := pgx.Identifier{dbName}.Sanitize()
+	if _, err := adminPool.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", dbIdent)); err != nil {
+		return nil, fmt.Errorf("erro ao limpar database do sandbox: %w", err)
+	}
+	if _, err := adminPool.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s", dbIdent)); err != nil {
+		return nil, fmt.Errorf("erro ao criar database do sandbox: %w", err)
+	}
+
+	sandboxDSN, err := r.clusters.AdminDSN(sandboxCluster, dbName)
+	if err != nil {
+		return nil, err
+	}
+	sandboxPool, err := pgxpool.New(ctx, sandboxDSN)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar no database do sandbox: %w", err)
+	}
+	defer sandboxPool.Close()
+
+	if _, err := r.migrator.MigrateTo(ctx, sandboxPool, version); err != nil {
+		return nil, fmt.Errorf("erro ao aplicar schema no sandbox: %w", err)
+	}
+
+	// 2. Copiar os dados
+	tables, err := r.copyData(ctx, sourcePool, sandboxPool)
+	if err != nil {
+		return nil, err
+	}
+	result := &sandboxCloneResult{SchemaVersion: version, Tables: tables}
+
+	// 3. Copiar as mídias para o prefixo do sandbox e reescrever images.storage_path/public_url
+	if err := r.copyMedia(ctx, sandboxPool, source.TenantID, sandbox.TenantID, result); err != nil {
+		return nil, err
+	}
+
+	// 4. Login dedicado do sandbox
+	if err := r.dbCreds.Provision(ctx, sandbox.TenantID, sandbox.DBCode); err != nil {
+		return nil, fmt.Errorf("erro ao criar credenciais do sandbox: %w", err)
+	}
+
+	return result, nil
+}
+
+// copyData copia os dados com conexões dedicadas; a origem é lida em uma transação
+// somente leitura (snapshot único para todas as tabelas)
+func (r *SandboxRunner) copyData(ctx context.Context, sourcePool, sandboxPool *pgxpool.Pool) ([]database.TableCopyResult, error) {
+	src, err := sourcePool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar na origem: %w", err)
+	}
+	defer src.Release()
+
+	tx, err := src.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar snapshot: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	dst, err := sandboxPool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar no sandbox: %w", err)
+	}
+	defer dst.Release()
+
+	results, err := database.CopyTenantData(ctx, src.Conn(), dst.Conn())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao copiar dados: %w", err)
+	}
+	return results, nil
+}
+
+// copyMedia copia os arquivos de {origem}/ para {sandbox}/ no storage e atualiza as referências
+// no database do sandbox. Arquivos ausentes na origem têm apenas a referência reescrita.
+func (r *SandboxRunner) copyMedia(ctx context.Context, sandboxPool *pgxpool.Pool, sourceID, sandboxID uuid.UUID, result *sandboxCloneResult) error {
+	rows, err := sandboxPool.Query(ctx, `SELECT DISTINCT storage_path FROM images ORDER BY storage_path`)
+	if err != nil {
+		return fmt.Errorf("erro ao listar mídias: %w", err)
+	}
+	paths, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("erro ao listar mídias: %w", err)
+	}
+
+	sourcePrefix := sourceID.String() + "/"
+	for _, oldPath := range paths {
+		if !strings.HasPrefix(oldPath, sourcePrefix) {
+			continue // Já reescrita em uma tentativa anterior
+		}
+		newPath := sandboxID.String() + "/" + strings.TrimPrefix(oldPath, sourcePrefix)
+
+		storagePath, publicURL, err := r.copyFile(ctx, oldPath, newPath)
+		if err != nil {
+			log.Printf("Mídia %s não copiada para o sandbox: %v", oldPath, err)
+			storagePath, publicURL = newPath, r.storageDriver.GetPublicURL(newPath)
+			result.MissingMedia++
+		} else {
+			result.MediaCount++
+		}
+
+		if _, err := sandboxPool.Exec(ctx,
+			`UPDATE images SET storage_path = $1, public_url = $2 WHERE storage_path = $3`,
+			storagePath, publicURL, oldPath,
+		); err != nil {
+			return fmt.Errorf("erro ao atualizar mídia %s: %w", oldPath, err)
+		}
+	}
+	return nil
+}
+
+// copyFile copia um arquivo do storage para outro caminho
+func (r *SandboxRunner) copyFile(ctx context.Context, from, to string) (string, string, error) {
+	reader, err := r.storageDriver.GetReader(ctx, from)
+	if err != nil {
+		return "", "", err
+	}
+	defer reader.Close()
+
+	return r.storageDriver.Upload(ctx, reader, to)
+}
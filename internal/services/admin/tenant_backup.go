@@ -0,0 +1,291 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/models/shared"
+)
+
+// Modos de restore
+const (
+	RestoreModeExisting = "existing" // Esvazia o database atual e carrega o backup
+	RestoreModeFresh    = "fresh"    // Recria o database do zero antes da carga
+)
+
+var (
+	// ErrBackupNotFound é retornado quando o backup não existe (ou é de outro tenant)
+	ErrBackupNotFound = errors.New("backup não encontrado")
+	// ErrRestoreNotFound é retornado quando o restore não existe (ou é de outro tenant)
+	ErrRestoreNotFound = errors.New("restore não encontrado")
+	// ErrBackupInProgress é retornado quando já existe backup ou restore pendente para o tenant
+	ErrBackupInProgress = errors.New("já existe backup ou restore em andamento para o tenant")
+	// ErrBackupNotRestorable é retornado ao restaurar um backup que não foi concluído
+	ErrBackupNotRestorable = errors.New("apenas backups concluídos podem ser restaurados")
+	// ErrInvalidRestoreMode é retornado para modos diferentes de 'existing' e 'fresh'
+	ErrInvalidRestoreMode = errors.New("modo de restore inválido: use 'existing' ou 'fresh'")
+	// ErrTenantNotBackupable é retornado quando o tenant não está ativo ou suspenso
+	ErrTenantNotBackupable = errors.New("tenant precisa estar 'active' ou 'suspended' para backup/restore")
+)
+
+// RestoreRequest é o corpo de POST /tenants/:tenant_id/backups/:backup_id/restore
+type RestoreRequest struct {
+	Mode string `json:"mode"` // existing (padrão) ou fresh
+}
+
+const backupColumns = `id, tenant_id, status, storage_path, size_bytes, schema_version, tables,
+	media_count, error, requested_by, started_at, completed_at, created_at`
+
+const restoreColumns = `id, tenant_id, backup_id, mode, status, previous_status, error,
+	requested_by, started_at, completed_at, created_at`
+
+// BackupService registra e consulta backups/restores de tenants.
+// A execução fica com o worker (BackupRunner), que processa as linhas 'pending'.
+type BackupService struct {
+	masterPool *pgxpool.Pool
+}
+
+func NewBackupService(masterPool *pgxpool.Pool) *BackupService {
+	return &BackupService{masterPool: masterPool}
+}
+
+// RequestBackup agenda um backup do tenant
+func (s *BackupService) RequestBackup(ctx context.Context, tenantID uuid.UUID, requestedBy *uuid.UUID) (*admin.TenantBackup, error) {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockTenantForBackup(ctx, tx, tenantID); err != nil {
+		return nil, err
+	}
+
+	backup, err := scanBackup(tx.QueryRow(ctx, `
+		INSERT INTO tenant_backups (tenant_id, status, requested_by)
+		VALUES ($1, $2, $3)
+		RETURNING `+backupColumns,
+		tenantID, shared.JobStatusPending, requestedBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar backup: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao registrar backup: %w", err)
+	}
+	return backup, nil
+}
+
+// ListBackups lista os backups do tenant, do mais recente para o mais antigo
+func (s *BackupService) ListBackups(ctx context.Context, tenantID uuid.UUID) ([]admin.TenantBackup, error) {
+	if err := s.ensureTenant(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT `+backupColumns+` FROM tenant_backups
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar backups: %w", err)
+	}
+	defer rows.Close()
+
+	backups := []admin.TenantBackup{}
+	for rows.Next() {
+		backup, err := scanBackup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler backup: %w", err)
+		}
+		backups = append(backups, *backup)
+	}
+	return backups, rows.Err()
+}
+
+// GetBackup retorna um backup do tenant
+func (s *BackupService) GetBackup(ctx context.Context, tenantID, backupID uuid.UUID) (*admin.TenantBackup, error) {
+	backup, err := scanBackup(s.masterPool.QueryRow(ctx,
+		`SELECT `+backupColumns+` FROM tenant_backups WHERE id = $1 AND tenant_id = $2`,
+		backupID, tenantID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrBackupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar backup: %w", err)
+	}
+	return backup, nil
+}
+
+// RequestRestore agenda a restauração de um backup concluído no database do tenant
+func (s *BackupService) RequestRestore(ctx context.Context, tenantID, backupID uuid.UUID, req RestoreRequest, requestedBy *uuid.UUID) (*admin.TenantRestore, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = RestoreModeExisting
+	}
+	if mode != RestoreModeExisting && mode != RestoreModeFresh {
+		return nil, ErrInvalidRestoreMode
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockTenantForBackup(ctx, tx, tenantID); err != nil {
+		return nil, err
+	}
+
+	var status shared.JobStatus
+	err = tx.QueryRow(ctx,
+		`SELECT status FROM tenant_backups WHERE id = $1 AND tenant_id = $2`, backupID, tenantID,
+	).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil, ErrBackupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar backup: %w", err)
+	}
+	if status != shared.JobStatusCompleted {
+		return nil, ErrBackupNotRestorable
+	}
+
+	restore, err := scanRestore(tx.QueryRow(ctx, `
+		INSERT INTO tenant_restores (tenant_id, backup_id, mode, status, requested_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+restoreColumns,
+		tenantID, backupID, mode, shared.JobStatusPending, requestedBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar restore: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao registrar restore: %w", err)
+	}
+	return restore, nil
+}
+
+// ListRestores lista os restores do tenant, do mais recente para o mais antigo
+func (s *BackupService) ListRestores(ctx context.Context, tenantID uuid.UUID) ([]admin.TenantRestore, error) {
+	if err := s.ensureTenant(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT `+restoreColumns+` FROM tenant_restores
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar restores: %w", err)
+	}
+	defer rows.Close()
+
+	restores := []admin.TenantRestore{}
+	for rows.Next() {
+		restore, err := scanRestore(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler restore: %w", err)
+		}
+		restores = append(restores, *restore)
+	}
+	return restores, rows.Err()
+}
+
+// GetRestore retorna um restore do tenant
+func (s *BackupService) GetRestore(ctx context.Context, tenantID, restoreID uuid.UUID) (*admin.TenantRestore, error) {
+	restore, err := scanRestore(s.masterPool.QueryRow(ctx,
+		`SELECT `+restoreColumns+` FROM tenant_restores WHERE id = $1 AND tenant_id = $2`,
+		restoreID, tenantID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrRestoreNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar restore: %w", err)
+	}
+	return restore, nil
+}
+
+// ensureTenant verifica se o tenant existe
+func (s *BackupService) ensureTenant(ctx context.Context, tenantID uuid.UUID) error {
+	var exists bool
+	err := s.masterPool.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM tenants WHERE id = $1)`, tenantID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if !exists {
+		return ErrTenantNotFound
+	}
+	return nil
+}
+
+// lockTenantForBackup trava o tenant na transação e valida se um novo backup/restore pode ser agendado:
+// o tenant precisa ter database utilizável e não pode haver outro job pendente ou em execução
+func lockTenantForBackup(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID) error {
+	var status shared.TenantStatus
+	var pendingDeletion bool
+	err := tx.QueryRow(ctx,
+		`SELECT status, deletion_scheduled_at IS NOT NULL FROM tenants WHERE id = $1 FOR UPDATE`, tenantID,
+	).Scan(&status, &pendingDeletion)
+	if err == pgx.ErrNoRows {
+		return ErrTenantNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if pendingDeletion {
+		return ErrTenantPendingDeletion
+	}
+	if status != shared.TenantStatusActive && status != shared.TenantStatusSuspended {
+		return ErrTenantNotBackupable
+	}
+
+	var busy bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM tenant_backups WHERE tenant_id = $1 AND status IN ($2, $3))
+		    OR EXISTS(SELECT 1 FROM tenant_restores WHERE tenant_id = $1 AND status IN ($2, $3))
+	`, tenantID, shared.JobStatusPending, shared.JobStatusRunning).Scan(&busy)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar jobs do tenant: %w", err)
+	}
+	if busy {
+		return ErrBackupInProgress
+	}
+	return nil
+}
+
+func scanBackup(row pgx.Row) (*admin.TenantBackup, error) {
+	var b admin.TenantBackup
+	err := row.Scan(
+		&b.ID, &b.TenantID, &b.Status, &b.StoragePath, &b.SizeBytes, &b.SchemaVersion, &b.Tables,
+		&b.MediaCount, &b.Error, &b.RequestedBy, &b.StartedAt, &b.CompletedAt, &b.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func scanRestore(row pgx.Row) (*admin.TenantRestore, error) {
+	var r admin.TenantRestore
+	err := row.Scan(
+		&r.ID, &r.TenantID, &r.BackupID, &r.Mode, &r.Status, &r.PreviousStatus, &r.Error,
+		&r.RequestedBy, &r.StartedAt, &r.CompletedAt, &r.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+var (
+	// ErrNoCustomDomain é retornado quando o tenant não configurou um domínio customizado
+	ErrNoCustomDomain = errors.New("tenant não possui domínio customizado")
+	// ErrCustomDomainNotVerified é retornado quando o registro TXT de verificação não foi encontrado
+	ErrCustomDomainNotVerified = errors.New("registro TXT de verificação não encontrado no DNS do domínio")
+	// ErrCustomDomainInUse é retornado quando o domínio já foi verificado por outro tenant
+	ErrCustomDomainInUse = errors.New("domínio já verificado por outro tenant")
+)
+
+const (
+	// Prefixo do registro TXT que comprova a posse do domínio customizado
+	customDomainTXTPrefix = "_saas-verify."
+	// Tamanho (em bytes aleatórios) do token de verificação
+	customDomainTokenBytes = 16
+)
+
+// CustomDomainVerification descreve o registro DNS que o tenant deve criar para verificar o domínio
+type CustomDomainVerification struct {
+	Domain     string     `json:"domain"`
+	RecordName string     `json:"record_name"`
+	RecordType string     `json:"record_type"`
+	Token      string     `json:"token"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// GetCustomDomainVerification retorna as instruções de verificação do domínio customizado,
+// gerando o token na primeira chamada
+func (s *TenantService) GetCustomDomainVerification(ctx context.Context, tenantID uuid.UUID) (*CustomDomainVerification, error) {
+	var domain string
+	var token *string
+	var verifiedAt *time.Time
+	err := s.masterPool.QueryRow(ctx,
+		`SELECT COALESCE(custom_domain, ''), custom_domain_verification_token, custom_domain_verified_at
+		 FROM tenant_profiles WHERE tenant_id = $1`,
+		tenantID,
+	).Scan(&domain, &token, &verifiedAt)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar domínio do tenant: %w", err)
+	}
+
+	domain = utils.NormalizeHost(domain)
+	if domain == "" {
+		return nil, ErrNoCustomDomain
+	}
+
+	if token == nil {
+		newToken, err := utils.GenerateSecret(customDomainTokenBytes)
+		if err != nil {
+			return nil, err
+		}
+		// Outra chamada concorrente pode ter gerado o token antes: mantém o primeiro
+		err = s.masterPool.QueryRow(ctx, `
+			UPDATE tenant_profiles
+			SET custom_domain_verification_token = COALESCE(custom_domain_verification_token, $1), updated_at = NOW()
+			WHERE tenant_id = $2
+			RETURNING custom_domain_verification_token
+		`, newToken, tenantID).Scan(&token)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao gerar token de verificação: %w", err)
+		}
+	}
+
+	return &CustomDomainVerification{
+		Domain:     domain,
+		RecordName: customDomainTXTPrefix + domain,
+		RecordType: "TXT",
+		Token:      *token,
+		Verified:   verifiedAt != nil,
+		VerifiedAt: verifiedAt,
+	}, nil
+}
+
+// VerifyCustomDomain consulta o registro TXT do domínio e, se o token conferir, libera o domínio
+// para a resolução por Host das rotas públicas (storefront)
+func (s *TenantService) VerifyCustomDomain(ctx context.Context, tenantID uuid.UUID) (*CustomDomainVerification, error) {
+	verification, err := s.GetCustomDomainVerification(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if verification.Verified {
+		return verification, nil
+	}
+
+	records, err := net.DefaultResolver.LookupTXT(ctx, verification.RecordName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCustomDomainNotVerified, err)
+	}
+	found := false
+	for _, record := range records {
+		if record == verification.Token {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrCustomDomainNotVerified
+	}
+
+	var inUse bool
+	err = s.masterPool.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM tenant_profiles
+			WHERE LOWER(custom_domain) = $1 AND custom_domain_verified_at IS NOT NULL AND tenant_id <> $2
+		)
+	`, verification.Domain, tenantID).Scan(&inUse)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar domínio: %w", err)
+	}
+	if inUse {
+		return nil, ErrCustomDomainInUse
+	}
+
+	now := time.Now()
+	if _, err := s.masterPool.Exec(ctx,
+		`UPDATE tenant_profiles SET custom_domain_verified_at = $1, updated_at = $1 WHERE tenant_id = $2`,
+		now, tenantID,
+	); err != nil {
+		return nil, fmt.Errorf("erro ao salvar verificação do domínio: %w", err)
+	}
+
+	// Remove um mapeamento antigo do host (o domínio pode ter pertencido a outro tenant)
+	s.invalidateHostCache(ctx, verification.Domain)
+
+	verification.Verified = true
+	verification.VerifiedAt = &now
+	return verification, nil
+}
+
+// invalidateHostCache remove o mapeamento host -> url_code usado pelo TenantHostMiddleware
+func (s *TenantService) invalidateHostCache(ctx context.Context, host string) {
+	if host == "" {
+		return
+	}
+	if err := s.redisClient.Del(ctx, fmt.Sprintf("tenant:host:%s", host)).Err(); err != nil {
+		fmt.Printf("Warning: erro ao invalidar cache do host %s: %v\n", host, err)
+	}
+}
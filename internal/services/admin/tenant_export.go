@@ -0,0 +1,349 @@
+package admin
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	"github.com/saas-multi-database-api/internal/storage"
+)
+
+const (
+	// Tempo máximo de um export; exports 'running' há mais tempo voltam para 'pending'
+	exportJobTimeout = 1 * time.Hour
+	// Prefixo dos arquivos de export no storage (privado, ver storage.PrivatePrefixes)
+	exportStoragePrefix = "exports"
+)
+
+// exportTables são as tabelas do tenant incluídas no export (as ausentes no schema são ignoradas)
+var exportTables = []string{"products", "services", "customers", "orders", "order_items", "settings", "images"}
+
+// ExportManifest descreve o conteúdo de um export (manifest.json).
+//
+// Layout do arquivo (zip):
+//
+//	manifest.json        este manifesto
+//	json/{tabela}.json   registros de cada tabela em JSON (array de objetos)
+//	csv/{tabela}.csv     registros de cada tabela em CSV (com cabeçalho)
+//	media/{path}         arquivos originais das mídias (images.storage_path)
+type ExportManifest struct {
+	ExportID     uuid.UUID                  `json:"export_id"`
+	URLCode      string                     `json:"url_code"`
+	Tables       []database.TableCopyResult `json:"tables"`
+	MediaCount   int                        `json:"media_count"`
+	MissingMedia []string                   `json:"missing_media,omitempty"` // Referenciadas, mas ausentes no storage
+	CreatedAt    time.Time                  `json:"created_at"`
+}
+
+// ExportRunner gera os exports de dados solicitados pelos tenants (worker) e remove os vencidos
+type ExportRunner struct {
+	masterPool    *pgxpool.Pool
+	clusters      *database.ClusterRegistry
+	storageDriver storage.StorageDriver
+	cfg           *config.Config
+}
+
+func NewExportRunner(
+	masterPool *pgxpool.Pool,
+	clusters *database.ClusterRegistry,
+	storageDriver storage.StorageDriver,
+	cfg *config.Config,
+) *ExportRunner {
+	return &ExportRunner{
+		masterPool:    masterPool,
+		clusters:      clusters,
+		storageDriver: storageDriver,
+		cfg:           cfg,
+	}
+}
+
+// RunPending gera os exports 'pending' (SKIP LOCKED entre workers)
+func (r *ExportRunner) RunPending(ctx context.Context) error {
+	if err := requeueStaleJobs(ctx, r.masterPool, "tenant_exports", exportJobTimeout); err != nil {
+		return err
+	}
+
+	for {
+		exportID, err := claimNextJob(ctx, r.masterPool, "tenant_exports")
+		if err != nil {
+			return err
+		}
+		if exportID == nil {
+			return nil
+		}
+		if err := r.runExport(ctx, *exportID); err != nil {
+			log.Printf("Export %s falhou: %v", exportID, err)
+		}
+	}
+}
+
+// ExpireOld remove do storage os exports vencidos e os marca como 'expired'. Retorna a quantidade.
+func (r *ExportRunner) ExpireOld(ctx context.Context) (int, error) {
+	rows, err := r.masterPool.Query(ctx, `
+		SELECT id, storage_path FROM tenant_exports
+		WHERE status = $1 AND expires_at <= $2
+	`, shared.JobStatusCompleted, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar exports vencidos: %w", err)
+	}
+
+	type expired struct {
+		id          uuid.UUID
+		storagePath *string
+	}
+	var items []expired
+	for rows.Next() {
+		var e expired
+		if err := rows.Scan(&e.id, &e.storagePath); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("erro ao ler export: %w", err)
+		}
+		items = append(items, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("erro ao buscar exports vencidos: %w", err)
+	}
+
+	removed := 0
+	for _, e := range items {
+		if e.storagePath != nil {
+			if err := r.storageDriver.Delete(ctx, *e.storagePath); err != nil {
+				return removed, fmt.Errorf("erro ao remover arquivo do export %s: %w", e.id, err)
+			}
+		}
+		if _, err := r.masterPool.Exec(ctx,
+			`UPDATE tenant_exports SET status = $1, storage_path = NULL WHERE id = $2`,
+			shared.JobStatusExpired, e.id,
+		); err != nil {
+			return removed, fmt.Errorf("erro ao expirar export %s: %w", e.id, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// runExport gera o zip do export, envia ao storage e define a validade do arquivo
+func (r *ExportRunner) runExport(ctx context.Context, exportID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, exportJobTimeout)
+	defer cancel()
+
+	var target backupTarget
+	err := r.masterPool.QueryRow(ctx, `
+		SELECT t.id, t.url_code, t.db_code::text, t.cluster_id, t.status
+		FROM tenant_exports e JOIN tenants t ON t.id = e.tenant_id
+		WHERE e.id = $1
+	`, exportID).Scan(&target.TenantID, &target.URLCode, &target.DBCode, &target.ClusterID, &target.Status)
+	if err != nil {
+		return failJob(ctx, r.masterPool, "tenant_exports", exportID, fmt.Errorf("erro ao buscar tenant do export: %w", err))
+	}
+
+	log.Printf("Iniciando export %s do tenant %s", exportID, target.URLCode)
+
+	manifest, storagePath, size, err := r.createArchive(ctx, exportID, target)
+	if err != nil {
+		return failJob(ctx, r.masterPool, "tenant_exports", exportID, err)
+	}
+
+	tables, err := json.Marshal(manifest.Tables)
+	if err != nil {
+		return failJob(ctx, r.masterPool, "tenant_exports", exportID, err)
+	}
+	now := time.Now()
+	_, err = r.masterPool.Exec(ctx, `
+		UPDATE tenant_exports
+		SET status = $1, storage_path = $2, size_bytes = $3, tables = $4, media_count = $5,
+		    completed_at = $6, expires_at = $7
+		WHERE id = $8
+	`, shared.JobStatusCompleted, storagePath, size, tables, manifest.MediaCount,
+		now, now.Add(time.Duration(r.cfg.Tenants.ExportRetentionHours)*time.Hour), exportID)
+	if err != nil {
+		return fmt.Errorf("erro ao concluir export: %w", err)
+	}
+
+	log.Printf("Export %s do tenant %s concluído (%d bytes, %d mídia(s))", exportID, target.URLCode, size, manifest.MediaCount)
+	return nil
+}
+
+// createArchive gera o zip em arquivo temporário (leitura em snapshot consistente)
+// e o envia ao storage em exports/{tenant_id}/{export_id}.zip
+func (r *ExportRunner) createArchive(ctx context.Context, exportID uuid.UUID, target backupTarget) (*ExportManifest, string, int64, error) {
+	cluster, err := r.clusters.Get(ctx, target.ClusterID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	dsn, err := r.clusters.AdminDSN(cluster, database.TenantDBName(target.DBCode))
+	if err != nil {
+		return nil, "", 0, err
+	}
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao conectar no database do tenant: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao iniciar snapshot: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	file, err := os.CreateTemp("", "tenant-export-*.zip")
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	manifest := &ExportManifest{
+		ExportID:  exportID,
+		URLCode:   target.URLCode,
+		CreatedAt: time.Now(),
+	}
+	archive := zip.NewWriter(file)
+
+	existing, err := database.ListTenantTables(ctx, conn)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	present := map[string]bool{}
+	for _, table := range existing {
+		present[table] = true
+	}
+
+	for _, table := range exportTables {
+		if !present[table] {
+			continue
+		}
+
+		w, err := archive.Create("json/" + table + ".json")
+		if err != nil {
+			return nil, "", 0, err
+		}
+		rows, err := writeTableJSON(ctx, conn, table, w)
+		if err != nil {
+			return nil, "", 0, err
+		}
+
+		w, err = archive.Create("csv/" + table + ".csv")
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if _, err := database.DumpTable(ctx, conn, table, w); err != nil {
+			return nil, "", 0, err
+		}
+
+		manifest.Tables = append(manifest.Tables, database.TableCopyResult{Table: table, Rows: rows})
+	}
+
+	if present["images"] {
+		if err := r.addMedia(ctx, conn, archive, manifest); err != nil {
+			return nil, "", 0, err
+		}
+	}
+
+	if err := writeArchiveJSON(archive, "manifest.json", manifest); err != nil {
+		return nil, "", 0, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao finalizar arquivo do export: %w", err)
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, "", 0, err
+	}
+
+	objectPath := path.Join(exportPrefix(target.TenantID), exportID.String()+".zip")
+	storagePath, err := r.storageDriver.UploadPrivate(ctx, file, objectPath)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("erro ao enviar export ao storage: %w", err)
+	}
+
+	return manifest, storagePath, size, nil
+}
+
+// addMedia copia os arquivos das mídias do tenant para media/ no zip
+func (r *ExportRunner) addMedia(ctx context.Context, conn *pgx.Conn, archive *zip.Writer, manifest *ExportManifest) error {
+	rows, err := conn.Query(ctx, `SELECT DISTINCT storage_path FROM images ORDER BY storage_path`)
+	if err != nil {
+		return fmt.Errorf("erro ao listar mídias: %w", err)
+	}
+	paths, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("erro ao listar mídias: %w", err)
+	}
+
+	for _, storagePath := range paths {
+		reader, err := r.storageDriver.GetReader(ctx, storagePath)
+		if err != nil {
+			manifest.MissingMedia = append(manifest.MissingMedia, storagePath)
+			continue
+		}
+
+		w, err := archive.Create(path.Join("media", path.Clean("/"+storagePath)))
+		if err == nil {
+			_, err = io.Copy(w, reader)
+		}
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("erro ao copiar mídia %s: %w", storagePath, err)
+		}
+		manifest.MediaCount++
+	}
+	return nil
+}
+
+// writeTableJSON grava a tabela como um array JSON, uma linha por vez
+func writeTableJSON(ctx context.Context, conn *pgx.Conn, table string, w io.Writer) (int64, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf("SELECT row_to_json(t)::text FROM %s t", pgx.Identifier{"public", table}.Sanitize()))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao ler tabela %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, err
+	}
+	var count int64
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			return count, fmt.Errorf("erro ao ler tabela %s: %w", table, err)
+		}
+		separator := "\n  "
+		if count > 0 {
+			separator = ",\n  "
+		}
+		if _, err := io.WriteString(w, separator+row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("erro ao ler tabela %s: %w", table, err)
+	}
+
+	_, err = io.WriteString(w, "\n]\n")
+	return count, err
+}
+
+// exportPrefix é o prefixo dos exports de um tenant no storage
+func exportPrefix(tenantID uuid.UUID) string {
+	return path.Join(exportStoragePrefix, tenantID.String())
+}
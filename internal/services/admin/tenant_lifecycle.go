@@ -0,0 +1,311 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+var (
+	// ErrTenantNotFound é retornado quando o tenant não existe no Master DB
+	ErrTenantNotFound = errors.New("tenant não encontrado")
+	// ErrInvalidStatusTransition é retornado para mudanças de status não permitidas
+	ErrInvalidStatusTransition = errors.New("mudança de status não permitida")
+	// ErrTenantPendingDeletion é retornado quando o tenant tem exclusão agendada
+	ErrTenantPendingDeletion = errors.New("tenant com exclusão agendada; cancele a exclusão antes de alterá-lo")
+	// ErrDeletionNotConfirmed é retornado quando a confirmação não corresponde ao url_code do tenant
+	ErrDeletionNotConfirmed = errors.New("confirmação inválida: informe o url_code do tenant em 'confirm'")
+	// ErrDeletionNotScheduled é retornado ao cancelar exclusão de um tenant sem exclusão agendada
+	ErrDeletionNotScheduled = errors.New("tenant não possui exclusão agendada")
+	// ErrTenantPurging é retornado quando o purge do tenant já está em andamento
+	ErrTenantPurging = errors.New("purge do tenant já está em andamento")
+	// ErrPlanNotFound é retornado quando o plano informado não existe
+	ErrPlanNotFound = errors.New("plano não encontrado")
+)
+
+// UpdateTenantRequest representa as alterações permitidas em um tenant (campos nil não são alterados)
+type UpdateTenantRequest struct {
+	Name         *string              `json:"name"`
+	CompanyName  *string              `json:"company_name"`
+	IsCompany    *bool                `json:"is_company"`
+	About        *string              `json:"about"`
+	CustomDomain *string              `json:"custom_domain"`
+	LogoURL      *string              `json:"logo_url"`
+	Status       *shared.TenantStatus `json:"status"`
+	PlanID       *uuid.UUID           `json:"plan_id"`
+	BillingCycle *shared.BillingCycle `json:"billing_cycle"`
+}
+
+// DeleteTenantRequest representa o pedido de exclusão definitiva de um tenant
+type DeleteTenantRequest struct {
+	Confirm   string `json:"confirm"`    // Deve ser igual ao url_code do tenant
+	GraceDays *int   `json:"grace_days"` // Opcional: dias até o purge (0 = no próximo ciclo do worker)
+}
+
+// tenantState é o estado mínimo do tenant usado nas validações
+type tenantState struct {
+	URLCode             string
+	Status              shared.TenantStatus
+	DeletionScheduledAt *time.Time
+}
+
+// getTenantState busca status e exclusão agendada, bloqueando a linha até o fim da transação
+func (s *TenantService) getTenantState(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID) (*tenantState, error) {
+	state := &tenantState{}
+	err := tx.QueryRow(ctx,
+		`SELECT url_code, status, deletion_scheduled_at FROM tenants WHERE id = $1 FOR UPDATE`,
+		tenantID,
+	).Scan(&state.URLCode, &state.Status, &state.DeletionScheduledAt)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	return state, nil
+}
+
+// invalidateTenantContext descarta o tenant resolvido em cache pelas Tenant APIs (status, plano e features)
+func invalidateTenantContext(ctx context.Context, redisClient *redis.Client, urlCode string) {
+	if err := cache.InvalidateTenantContext(ctx, redisClient, cache.TenantContextEvent{URLCode: urlCode}); err != nil {
+		fmt.Printf("Warning: erro ao invalidar contexto do tenant %s: %v\n", urlCode, err)
+	}
+}
+
+// validateStatusChange permite apenas active <-> suspended em tenants já provisionados
+func validateStatusChange(state *tenantState, status shared.TenantStatus) error {
+	if state.DeletionScheduledAt != nil {
+		return ErrTenantPendingDeletion
+	}
+	if status != shared.TenantStatusActive && status != shared.TenantStatusSuspended {
+		return fmt.Errorf("%w: status deve ser 'active' ou 'suspended'", ErrInvalidStatusTransition)
+	}
+	if state.Status != shared.TenantStatusActive && state.Status != shared.TenantStatusSuspended {
+		return fmt.Errorf("%w: tenant está '%s'", ErrInvalidStatusTransition, state.Status)
+	}
+	return nil
+}
+
+// UpdateTenant altera perfil, plano e/ou status do tenant em uma única transação
+func (s *TenantService) UpdateTenant(ctx context.Context, tenantID uuid.UUID, req UpdateTenantRequest) (*admin.Tenant, error) {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	state, err := s.getTenantState(ctx, tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.DeletionScheduledAt != nil {
+		return nil, ErrTenantPendingDeletion
+	}
+
+	now := time.Now()
+
+	// Status (active <-> suspended)
+	if req.Status != nil && *req.Status != state.Status {
+		if err := validateStatusChange(state, *req.Status); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE tenants SET status = $1, updated_at = $2 WHERE id = $3`,
+			*req.Status, now, tenantID,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao atualizar status: %w", err)
+		}
+	}
+
+	// Plano e ciclo de cobrança
+	if req.PlanID != nil {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM plans WHERE id = $1)`, *req.PlanID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("erro ao verificar plano: %w", err)
+		}
+		if !exists {
+			return nil, ErrPlanNotFound
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE tenants SET plan_id = $1, updated_at = $2 WHERE id = $3`,
+			*req.PlanID, now, tenantID,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao alterar plano: %w", err)
+		}
+	}
+
+	if req.BillingCycle != nil {
+		if _, err := tx.Exec(ctx,
+			`UPDATE tenants SET billing_cycle = $1, updated_at = $2 WHERE id = $3`,
+			*req.BillingCycle, now, tenantID,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao alterar ciclo de cobrança: %w", err)
+		}
+	}
+
+	// Domínio customizado alterado: volta a exigir verificação por DNS antes de rotear o novo host
+	var previousDomain string
+	if req.CustomDomain != nil {
+		domain := utils.NormalizeHost(*req.CustomDomain)
+		req.CustomDomain = &domain
+
+		if err := tx.QueryRow(ctx,
+			`SELECT LOWER(COALESCE(custom_domain, '')) FROM tenant_profiles WHERE tenant_id = $1`,
+			tenantID,
+		).Scan(&previousDomain); err != nil && err != pgx.ErrNoRows {
+			return nil, fmt.Errorf("erro ao buscar domínio do tenant: %w", err)
+		}
+		if domain != previousDomain {
+			if _, err := tx.Exec(ctx, `
+				UPDATE tenant_profiles SET
+					custom_domain_verification_token = NULL,
+					custom_domain_verified_at = NULL
+				WHERE tenant_id = $1
+			`, tenantID); err != nil {
+				return nil, fmt.Errorf("erro ao redefinir verificação do domínio: %w", err)
+			}
+		} else {
+			previousDomain = ""
+		}
+	}
+
+	// Perfil (nome fica em custom_settings, como no CreateTenant)
+	if req.Name != nil || req.CompanyName != nil || req.IsCompany != nil || req.About != nil || req.CustomDomain != nil || req.LogoURL != nil {
+		nameJSON := []byte("{}")
+		if req.Name != nil {
+			nameJSON, err = json.Marshal(map[string]string{"name": *req.Name})
+			if err != nil {
+				return nil, fmt.Errorf("erro ao serializar custom_settings: %w", err)
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE tenant_profiles SET
+				company_name = COALESCE($1, company_name),
+				is_company = COALESCE($2, is_company),
+				about = COALESCE($3, about),
+				custom_domain = COALESCE($4, custom_domain),
+				logo_url = COALESCE($5, logo_url),
+				custom_settings = COALESCE(custom_settings, '{}'::jsonb) || $6::jsonb,
+				updated_at = $7
+			WHERE tenant_id = $8
+		`, req.CompanyName, req.IsCompany, req.About, req.CustomDomain, req.LogoURL, nameJSON, now, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao atualizar perfil do tenant: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao salvar alterações: %w", err)
+	}
+
+	s.invalidateHostCache(ctx, previousDomain)
+	if req.Status != nil || req.PlanID != nil {
+		invalidateTenantContext(ctx, s.redisClient, state.URLCode)
+	}
+
+	return s.GetTenantByID(ctx, tenantID)
+}
+
+// SetTenantStatus suspende ou reativa um tenant
+func (s *TenantService) SetTenantStatus(ctx context.Context, tenantID uuid.UUID, status shared.TenantStatus) (*admin.Tenant, error) {
+	return s.UpdateTenant(ctx, tenantID, UpdateTenantRequest{Status: &status})
+}
+
+// ScheduleTenantDeletion suspende o tenant e agenda o purge definitivo após o período de carência.
+// O purge (DROP DATABASE, storage, Master DB e cache) é executado pelo worker.
+func (s *TenantService) ScheduleTenantDeletion(ctx context.Context, tenantID uuid.UUID, req DeleteTenantRequest, defaultGraceDays int, requestedBy uuid.UUID) (*admin.Tenant, error) {
+	graceDays := defaultGraceDays
+	if req.GraceDays != nil {
+		graceDays = *req.GraceDays
+	}
+	if graceDays < 0 {
+		return nil, fmt.Errorf("grace_days não pode ser negativo")
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	state, err := s.getTenantState(ctx, tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Confirm != state.URLCode {
+		return nil, ErrDeletionNotConfirmed
+	}
+
+	if state.DeletionScheduledAt != nil {
+		return nil, ErrTenantPendingDeletion
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(time.Duration(graceDays) * 24 * time.Hour)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE tenants SET
+			status = $1,
+			deletion_requested_at = $2,
+			deletion_requested_by = $3,
+			deletion_scheduled_at = $4,
+			updated_at = $2
+		WHERE id = $5
+	`, shared.TenantStatusSuspended, now, requestedBy, scheduledAt, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agendar exclusão: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao agendar exclusão: %w", err)
+	}
+
+	invalidateTenantContext(ctx, s.redisClient, state.URLCode)
+
+	return s.GetTenantByID(ctx, tenantID)
+}
+
+// CancelTenantDeletion cancela uma exclusão agendada; o tenant continua suspenso até ser reativado
+func (s *TenantService) CancelTenantDeletion(ctx context.Context, tenantID uuid.UUID) (*admin.Tenant, error) {
+	purging, err := IsPurging(ctx, s.redisClient, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar purge: %w", err)
+	}
+	if purging {
+		return nil, ErrTenantPurging
+	}
+
+	tag, err := s.masterPool.Exec(ctx, `
+		UPDATE tenants SET
+			deletion_requested_at = NULL,
+			deletion_requested_by = NULL,
+			deletion_scheduled_at = NULL,
+			updated_at = $1
+		WHERE id = $2 AND deletion_scheduled_at IS NOT NULL
+	`, time.Now(), tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao cancelar exclusão: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		if _, err := s.GetTenantByID(ctx, tenantID); err != nil {
+			return nil, ErrTenantNotFound
+		}
+		return nil, ErrDeletionNotScheduled
+	}
+
+	return s.GetTenantByID(ctx, tenantID)
+}
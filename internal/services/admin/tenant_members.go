@@ -0,0 +1,573 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/config"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// Tamanho em bytes do token aleatório dos convites
+const invitationTokenBytes = 32
+
+var (
+	// ErrMemberNotFound é retornado quando o usuário não é membro do tenant
+	ErrMemberNotFound = errors.New("membro não encontrado")
+	// ErrMemberIsOwner é retornado ao trocar o papel ou remover o owner (use a transferência de propriedade)
+	ErrMemberIsOwner = errors.New("o owner do tenant não pode ser alterado nem removido; transfira a propriedade antes")
+	// ErrAlreadyMember é retornado ao convidar um email que já é membro do tenant
+	ErrAlreadyMember = errors.New("o usuário já é membro do tenant")
+	// ErrMemberLimitReached é retornado quando membros e convites pendentes já somam o limite do plano
+	ErrMemberLimitReached = errors.New("limite de membros do plano atingido")
+	// ErrInvitationNotFound é retornado ao revogar um convite inexistente ou que não está pendente
+	ErrInvitationNotFound = errors.New("convite não encontrado ou não está pendente")
+	// ErrInvalidInvitation é retornado quando o token não é de um convite pendente (aceito, revogado ou expirado)
+	ErrInvalidInvitation = errors.New("convite inválido ou expirado")
+	// ErrInvitationEmailMismatch é retornado quando o usuário logado não tem o email do convite
+	ErrInvitationEmailMismatch = errors.New("o convite foi enviado para outro email")
+	// ErrInvitationAccountExists é retornado no cadastro pelo convite quando o email já tem conta (faça login e aceite)
+	ErrInvitationAccountExists = errors.New("já existe uma conta com o email do convite; faça login para aceitá-lo")
+	// ErrTransferToSelf é retornado ao transferir a propriedade para o próprio owner
+	ErrTransferToSelf = errors.New("o usuário já é o owner do tenant")
+	// ErrNotTenantOwner é retornado quando quem transfere a propriedade não é o owner
+	ErrNotTenantOwner = errors.New("somente o owner pode transferir o tenant")
+)
+
+// Status do convite, calculado a partir das datas
+const invitationStatusColumn = `CASE
+		WHEN i.accepted_at IS NOT NULL THEN 'accepted'
+		WHEN i.revoked_at IS NOT NULL THEN 'revoked'
+		WHEN i.expires_at <= NOW() THEN 'expired'
+		ELSE 'pending'
+	END`
+
+// AcceptedInvitation é o resultado do aceite: o usuário passa a ser membro do tenant com o papel do convite
+type AcceptedInvitation struct {
+	UserID   uuid.UUID `json:"user_id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	URLCode  string    `json:"url_code"`
+	Role     string    `json:"role"`
+}
+
+// pendingInvitation é um convite pendente lido pelo token
+type pendingInvitation struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	URLCode     string
+	TenantName  string
+	Email       string
+	Role        string
+	RoleName    string
+	InviterName string
+	ExpiresAt   time.Time
+}
+
+// TenantMemberService gerencia os membros de um tenant: convites por email (com papel e validade),
+// aceite por usuários existentes e novos, troca de papel, remoção e transferência de propriedade.
+// O limite de membros vem do plano (plans.max_members) e conta os convites pendentes.
+type TenantMemberService struct {
+	masterPool   *pgxpool.Pool
+	redisClient  *redis.Client
+	emailService *AuthEmailService
+	config       *config.Config
+}
+
+func NewTenantMemberService(masterPool *pgxpool.Pool, redisClient *redis.Client, emailService *AuthEmailService, cfg *config.Config) *TenantMemberService {
+	return &TenantMemberService{
+		masterPool:   masterPool,
+		redisClient:  redisClient,
+		emailService: emailService,
+		config:       cfg,
+	}
+}
+
+// ListMembers lista os membros do tenant com o perfil e o papel, o owner primeiro
+func (s *TenantMemberService) ListMembers(ctx context.Context, tenantID uuid.UUID) ([]adminModels.TenantMember, error) {
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT m.user_id, m.tenant_id, m.role_id,
+			CASE WHEN t.owner_id = m.user_id THEN 'owner' ELSE COALESCE(r.slug, '') END,
+			COALESCE(r.name, ''), t.owner_id IS NOT DISTINCT FROM m.user_id,
+			u.email, COALESCE(p.full_name, ''), p.avatar_url, u.email_verified_at IS NOT NULL,
+			m.created_at, m.updated_at
+		FROM tenant_members m
+		JOIN tenants t ON t.id = m.tenant_id
+		JOIN users u ON u.id = m.user_id
+		LEFT JOIN user_profiles p ON p.user_id = m.user_id
+		LEFT JOIN roles r ON r.id = m.role_id
+		WHERE m.tenant_id = $1
+		ORDER BY t.owner_id IS NOT DISTINCT FROM m.user_id DESC, m.created_at
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar membros: %w", err)
+	}
+	defer rows.Close()
+
+	members := []adminModels.TenantMember{}
+	for rows.Next() {
+		var m adminModels.TenantMember
+		if err := rows.Scan(&m.UserID, &m.TenantID, &m.RoleID, &m.Role, &m.RoleName, &m.IsOwner,
+			&m.Email, &m.FullName, &m.AvatarURL, &m.EmailVerified, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler membro: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// MemberPermissions retorna as permissões do papel atual do membro (o owner não pode ser alterado)
+func (s *TenantMemberService) MemberPermissions(ctx context.Context, tenantID, userID uuid.UUID) ([]string, error) {
+	var isOwner bool
+	var permissions []string
+	err := s.masterPool.QueryRow(ctx, `
+		SELECT t.owner_id IS NOT DISTINCT FROM m.user_id OR COALESCE(r.slug, '') = 'owner',
+			ARRAY(SELECT p.slug FROM role_permissions rp JOIN permissions p ON p.id = rp.permission_id
+				WHERE rp.role_id = m.role_id ORDER BY p.slug)
+		FROM tenant_members m
+		JOIN tenants t ON t.id = m.tenant_id
+		LEFT JOIN roles r ON r.id = m.role_id
+		WHERE m.tenant_id = $1 AND m.user_id = $2
+	`, tenantID, userID).Scan(&isOwner, &permissions)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar membro: %w", err)
+	}
+	if isOwner {
+		return nil, ErrMemberIsOwner
+	}
+	return permissions, nil
+}
+
+// UpdateMemberRole troca o papel de um membro; vale a partir da próxima requisição dele
+func (s *TenantMemberService) UpdateMemberRole(ctx context.Context, tenantID, userID, roleID uuid.UUID) error {
+	tag, err := s.masterPool.Exec(ctx, `
+		UPDATE tenant_members m SET role_id = $3, updated_at = NOW()
+		FROM tenants t
+		WHERE t.id = m.tenant_id AND m.tenant_id = $1 AND m.user_id = $2
+		  AND t.owner_id IS DISTINCT FROM m.user_id
+	`, tenantID, userID, roleID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar papel do membro: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return s.memberNotUpdatable(ctx, tenantID, userID)
+	}
+
+	s.invalidateMember(ctx, tenantID, userID)
+	return nil
+}
+
+// RemoveMember remove um membro do tenant; o acesso dele acaba na próxima requisição
+func (s *TenantMemberService) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	tag, err := s.masterPool.Exec(ctx, `
+		DELETE FROM tenant_members m
+		USING tenants t
+		WHERE t.id = m.tenant_id AND m.tenant_id = $1 AND m.user_id = $2
+		  AND t.owner_id IS DISTINCT FROM m.user_id
+	`, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao remover membro: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return s.memberNotUpdatable(ctx, tenantID, userID)
+	}
+
+	s.invalidateMember(ctx, tenantID, userID)
+	return nil
+}
+
+// TransferOwnership passa o tenant para outro membro. O owner atual continua membro, com o papel admin.
+func (s *TenantMemberService) TransferOwnership(ctx context.Context, tenantID, currentOwnerID, newOwnerID uuid.UUID) error {
+	if currentOwnerID == newOwnerID {
+		return ErrTransferToSelf
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var urlCode string
+	var isOwner, isMember bool
+	err = tx.QueryRow(ctx, `
+		SELECT t.url_code, t.owner_id IS NOT DISTINCT FROM $2,
+			EXISTS(SELECT 1 FROM tenant_members m WHERE m.tenant_id = t.id AND m.user_id = $3)
+		FROM tenants t
+		WHERE t.id = $1
+		FOR UPDATE
+	`, tenantID, currentOwnerID, newOwnerID).Scan(&urlCode, &isOwner, &isMember)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if !isOwner {
+		return ErrNotTenantOwner
+	}
+	if !isMember {
+		return ErrMemberNotFound
+	}
+
+	adminRoleID, err := resolveMemberRole(ctx, tx, tenantID, "admin")
+	if err != nil {
+		return fmt.Errorf("erro ao buscar papel admin: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE tenants SET owner_id = $2, updated_at = NOW() WHERE id = $1`, tenantID, newOwnerID); err != nil {
+		return fmt.Errorf("erro ao transferir tenant: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE tenant_members SET role_id = (SELECT id FROM roles WHERE slug = 'owner' AND tenant_id IS NULL), updated_at = NOW()
+		WHERE tenant_id = $1 AND user_id = $2
+	`, tenantID, newOwnerID); err != nil {
+		return fmt.Errorf("erro ao atualizar papel do novo owner: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO tenant_members (tenant_id, user_id, role_id, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (tenant_id, user_id) DO UPDATE SET role_id = EXCLUDED.role_id, updated_at = NOW()
+	`, tenantID, currentOwnerID, adminRoleID); err != nil {
+		return fmt.Errorf("erro ao atualizar papel do owner anterior: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	log.Printf("Tenant %s transferido de %s para %s", urlCode, currentOwnerID, newOwnerID)
+
+	if err := cache.InvalidateTenantContext(ctx, s.redisClient, cache.TenantContextEvent{URLCode: urlCode, TenantID: &tenantID}); err != nil {
+		log.Printf("Warning: erro ao invalidar contexto do tenant %s: %v", tenantID, err)
+	}
+	return nil
+}
+
+// ListInvitations lista os convites do tenant, os mais recentes primeiro
+func (s *TenantMemberService) ListInvitations(ctx context.Context, tenantID uuid.UUID) ([]adminModels.TenantInvitation, error) {
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT i.id, i.tenant_id, i.email, i.role_id, r.slug, r.name, i.invited_by, u.email,
+			`+invitationStatusColumn+`, i.expires_at, i.accepted_at, i.created_at
+		FROM tenant_invitations i
+		JOIN roles r ON r.id = i.role_id
+		LEFT JOIN users u ON u.id = i.invited_by
+		WHERE i.tenant_id = $1
+		ORDER BY i.created_at DESC
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar convites: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []adminModels.TenantInvitation{}
+	for rows.Next() {
+		var i adminModels.TenantInvitation
+		if err := rows.Scan(&i.ID, &i.TenantID, &i.Email, &i.RoleID, &i.Role, &i.RoleName, &i.InvitedBy, &i.InvitedByEmail,
+			&i.Status, &i.ExpiresAt, &i.AcceptedAt, &i.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler convite: %w", err)
+		}
+		invitations = append(invitations, i)
+	}
+	return invitations, rows.Err()
+}
+
+// Invite convida um email para o tenant com o papel role e envia o link por email. Um convite
+// pendente para o mesmo email é substituído. Membros e convites pendentes não passam de max_members.
+// inviterID é nil quando o convite é feito com uma chave de API.
+func (s *TenantMemberService) Invite(ctx context.Context, tenantID uuid.UUID, inviterID *uuid.UUID, email string, role *adminModels.Role) (*adminModels.TenantInvitation, error) {
+	email = utils.NormalizeEmail(email)
+
+	token, err := utils.GenerateSecret(invitationTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	ttl := time.Duration(s.config.Auth.InvitationHours) * time.Hour
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// A linha do tenant serializa os convites concorrentes (o limite é conferido dentro da transação)
+	var urlCode string
+	var maxMembers *int
+	var alreadyMember bool
+	err = tx.QueryRow(ctx, `
+		SELECT t.url_code, p.max_members,
+			EXISTS(
+				SELECT 1 FROM tenant_members m JOIN users u ON u.id = m.user_id
+				WHERE m.tenant_id = t.id AND u.email = $2
+			)
+		FROM tenants t
+		LEFT JOIN plans p ON p.id = t.plan_id
+		WHERE t.id = $1
+		FOR UPDATE OF t
+	`, tenantID, email).Scan(&urlCode, &maxMembers, &alreadyMember)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if alreadyMember {
+		return nil, ErrAlreadyMember
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE tenant_invitations SET revoked_at = NOW()
+		WHERE tenant_id = $1 AND email = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, tenantID, email); err != nil {
+		return nil, fmt.Errorf("erro ao substituir convite pendente: %w", err)
+	}
+
+	if maxMembers != nil {
+		var seats int
+		if err := tx.QueryRow(ctx, `
+			SELECT (SELECT COUNT(*) FROM tenant_members WHERE tenant_id = $1)
+				+ (SELECT COUNT(*) FROM tenant_invitations
+					WHERE tenant_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW())
+		`, tenantID).Scan(&seats); err != nil {
+			return nil, fmt.Errorf("erro ao contar membros: %w", err)
+		}
+		if seats >= *maxMembers {
+			return nil, fmt.Errorf("%w (%d)", ErrMemberLimitReached, *maxMembers)
+		}
+	}
+
+	invitation := adminModels.TenantInvitation{
+		TenantID:  tenantID,
+		Email:     email,
+		RoleID:    role.ID,
+		Role:      role.Slug,
+		RoleName:  role.Name,
+		InvitedBy: inviterID,
+		Status:    "pending",
+	}
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO tenant_invitations (tenant_id, email, role_id, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, expires_at, created_at
+	`, tenantID, email, role.ID, utils.HashToken(token), inviterID, time.Now().Add(ttl)).Scan(
+		&invitation.ID, &invitation.ExpiresAt, &invitation.CreatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("erro ao criar convite: %w", err)
+	}
+
+	var inviterName string
+	if inviterID != nil {
+		var inviterEmail string
+		if err := tx.QueryRow(ctx, `
+			SELECT COALESCE(p.full_name, ''), u.email FROM users u LEFT JOIN user_profiles p ON p.user_id = u.id WHERE u.id = $1
+		`, *inviterID).Scan(&inviterName, &inviterEmail); err != nil {
+			return nil, fmt.Errorf("erro ao buscar quem convidou: %w", err)
+		}
+		invitation.InvitedByEmail = &inviterEmail
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	if err := s.emailService.SendInvitation(ctx, email, urlCode, inviterName, role.Name, token, ttl); err != nil {
+		log.Printf("Warning: erro ao enviar convite %s para %s: %v", invitation.ID, email, err)
+	}
+
+	return &invitation, nil
+}
+
+// RevokeInvitation revoga um convite pendente: o link deixa de funcionar
+func (s *TenantMemberService) RevokeInvitation(ctx context.Context, tenantID, invitationID uuid.UUID) error {
+	tag, err := s.masterPool.Exec(ctx, `
+		UPDATE tenant_invitations SET revoked_at = NOW()
+		WHERE id = $2 AND tenant_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+	`, tenantID, invitationID)
+	if err != nil {
+		return fmt.Errorf("erro ao revogar convite: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Preview retorna o convite do token para a tela de aceite, e se o email já tem conta
+func (s *TenantMemberService) Preview(ctx context.Context, token string) (*adminModels.InvitationPreview, error) {
+	invitation, err := findPendingInvitation(ctx, s.masterPool, token, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var accountExists bool
+	if err := s.masterPool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`, invitation.Email).Scan(&accountExists); err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+
+	return &adminModels.InvitationPreview{
+		Email:         invitation.Email,
+		TenantName:    invitation.TenantName,
+		URLCode:       invitation.URLCode,
+		RoleName:      invitation.RoleName,
+		InviterName:   invitation.InviterName,
+		ExpiresAt:     invitation.ExpiresAt,
+		AccountExists: accountExists,
+	}, nil
+}
+
+// Accept aceita o convite com a conta do usuário logado, que precisa ter o email do convite.
+// O link recebido no email também confirma o email da conta.
+func (s *TenantMemberService) Accept(ctx context.Context, token string, userID uuid.UUID) (*AcceptedInvitation, error) {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	invitation, err := findPendingInvitation(ctx, tx, token, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var email string
+	if err := tx.QueryRow(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&email); err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if email != invitation.Email {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	if err := acceptInvitation(ctx, tx, invitation, userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	s.invalidateMember(ctx, invitation.TenantID, userID)
+	return &AcceptedInvitation{UserID: userID, TenantID: invitation.TenantID, URLCode: invitation.URLCode, Role: invitation.Role}, nil
+}
+
+// Signup cria a conta do convidado (com o email do convite, já verificado) e aceita o convite
+func (s *TenantMemberService) Signup(ctx context.Context, req adminModels.InvitationSignupRequest) (*AcceptedInvitation, error) {
+	passwordHash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	invitation, err := findPendingInvitation(ctx, tx, req.Token, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var userID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		INSERT INTO users (email, password_hash, email_verified_at)
+		VALUES ($1, $2, NOW())
+		RETURNING id
+	`, invitation.Email, passwordHash).Scan(&userID)
+	if isUniqueViolation(err) {
+		return nil, ErrInvitationAccountExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar usuário: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO user_profiles (user_id, full_name) VALUES ($1, $2)`, userID, strings.TrimSpace(req.FullName)); err != nil {
+		return nil, fmt.Errorf("erro ao criar perfil: %w", err)
+	}
+
+	if err := acceptInvitation(ctx, tx, invitation, userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	s.invalidateMember(ctx, invitation.TenantID, userID)
+	return &AcceptedInvitation{UserID: userID, TenantID: invitation.TenantID, URLCode: invitation.URLCode, Role: invitation.Role}, nil
+}
+
+// memberNotUpdatable explica por que a troca de papel ou a remoção não alterou nenhuma linha
+func (s *TenantMemberService) memberNotUpdatable(ctx context.Context, tenantID, userID uuid.UUID) error {
+	var member bool
+	if err := s.masterPool.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM tenant_members WHERE tenant_id = $1 AND user_id = $2)`,
+		tenantID, userID,
+	).Scan(&member); err != nil {
+		return fmt.Errorf("erro ao buscar membro: %w", err)
+	}
+	if member {
+		return ErrMemberIsOwner
+	}
+	return ErrMemberNotFound
+}
+
+func (s *TenantMemberService) invalidateMember(ctx context.Context, tenantID, userID uuid.UUID) {
+	if err := cache.InvalidateTenantContext(ctx, s.redisClient, cache.TenantContextEvent{TenantID: &tenantID, UserID: &userID}); err != nil {
+		log.Printf("Warning: erro ao invalidar contexto do tenant %s: %v", tenantID, err)
+	}
+}
+
+// findPendingInvitation busca o convite pendente do token (com lock, dentro de uma transação de aceite)
+func findPendingInvitation(ctx context.Context, q rowQuerier, token string, lock bool) (*pendingInvitation, error) {
+	query := `
+		SELECT i.id, i.tenant_id, t.url_code, COALESCE(NULLIF(tp.company_name, ''), t.url_code), i.email,
+			r.slug, r.name, COALESCE(p.full_name, ''), i.expires_at
+		FROM tenant_invitations i
+		JOIN tenants t ON t.id = i.tenant_id
+		JOIN roles r ON r.id = i.role_id
+		LEFT JOIN tenant_profiles tp ON tp.tenant_id = i.tenant_id
+		LEFT JOIN user_profiles p ON p.user_id = i.invited_by
+		WHERE i.token_hash = $1 AND i.accepted_at IS NULL AND i.revoked_at IS NULL AND i.expires_at > NOW()`
+	if lock {
+		query += ` FOR UPDATE OF i`
+	}
+
+	var inv pendingInvitation
+	err := q.QueryRow(ctx, query, utils.HashToken(token)).Scan(
+		&inv.ID, &inv.TenantID, &inv.URLCode, &inv.TenantName, &inv.Email,
+		&inv.Role, &inv.RoleName, &inv.InviterName, &inv.ExpiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar convite: %w", err)
+	}
+	return &inv, nil
+}
+
+// acceptInvitation associa o usuário ao tenant com o papel do convite (quem já é membro, por exemplo
+// pelo SSO, mantém o papel atual) e marca o convite como aceito
+func acceptInvitation(ctx context.Context, tx pgx.Tx, invitation *pendingInvitation, userID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO tenant_members (tenant_id, user_id, role_id, created_at, updated_at)
+		SELECT tenant_id, $2, role_id, NOW(), NOW() FROM tenant_invitations WHERE id = $1
+		ON CONFLICT (tenant_id, user_id) DO NOTHING
+	`, invitation.ID, userID); err != nil {
+		return fmt.Errorf("erro ao associar usuário ao tenant: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE tenant_invitations SET accepted_at = NOW(), accepted_by = $2 WHERE id = $1
+	`, invitation.ID, userID); err != nil {
+		return fmt.Errorf("erro ao aceitar convite: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1
+	`, userID); err != nil {
+		return fmt.Errorf("erro ao confirmar email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/models/shared"
+)
+
+// TenantMover move o database de um tenant entre clusters (executado pelo worker)
+type TenantMover struct {
+	masterPool  *pgxpool.Pool
+	clusters    *database.ClusterRegistry
+	migrator    *database.TenantMigrator
+	dbCreds     *DBCredentialService
+	redisClient *redis.Client
+}
+
+func NewTenantMover(
+	masterPool *pgxpool.Pool,
+	clusters *database.ClusterRegistry,
+	migrator *database.TenantMigrator,
+	dbCreds *DBCredentialService,
+	redisClient *redis.Client,
+) *TenantMover {
+	return &TenantMover{
+		masterPool:  masterPool,
+		clusters:    clusters,
+		migrator:    migrator,
+		dbCreds:     dbCreds,
+		redisClient: redisClient,
+	}
+}
+
+// Move copia o database do tenant para o cluster de destino e passa a roteá-lo para lá.
+// O tenant fica 'migrating' (APIs recusam requisições) durante a cópia. É idempotente:
+// o database de destino é recriado a cada tentativa e a origem só é removida após a troca.
+func (m *TenantMover) Move(ctx context.Context, event ProvisionEvent) error {
+	if event.TargetClusterID == nil {
+		return fmt.Errorf("evento de movimentação sem cluster de destino")
+	}
+
+	var currentID *uuid.UUID
+	err := m.masterPool.QueryRow(ctx,
+		`SELECT cluster_id FROM tenants WHERE id = $1`, event.TenantID,
+	).Scan(&currentID)
+	if err == pgx.ErrNoRows {
+		log.Printf("Tenant %s não existe mais no Master DB, ignorando movimentação", event.URLCode)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+
+	source, err := m.clusters.Get(ctx, event.SourceClusterID)
+	if err != nil {
+		return err
+	}
+	target, err := m.clusters.Get(ctx, event.TargetClusterID)
+	if err != nil {
+		return err
+	}
+
+	current, err := m.clusters.Get(ctx, currentID)
+	if err != nil {
+		return err
+	}
+
+	dbName := database.TenantDBName(event.DBCode)
+
+	// Retry após a troca de cluster: falta apenas remover a origem
+	if current.ID == target.ID {
+		return m.dropSource(ctx, source, event.DBCode)
+	}
+	if current.ID != source.ID {
+		log.Printf("Tenant %s não está mais no cluster de origem, ignorando movimentação", event.URLCode)
+		return nil
+	}
+
+	// 1. Bloquear o tenant e encerrar conexões na origem (nenhuma escrita durante a cópia)
+	if err := setTenantStatus(ctx, m.masterPool, event.TenantID, shared.TenantStatusMigrating); err != nil {
+		return err
+	}
+	invalidateTenantContext(ctx, m.redisClient, event.URLCode)
+	sourceAdmin, err := m.clusters.AdminPool(ctx, source)
+	if err != nil {
+		return err
+	}
+	if _, err := sourceAdmin.Exec(ctx, `
+		SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+		WHERE datname = $1 AND pid <> pg_backend_pid()
+	`, dbName); err != nil {
+		return fmt.Errorf("erro ao encerrar conexões na origem: %w", err)
+	}
+
+	sourceDSN, err := m.clusters.AdminDSN(source, dbName)
+	if err != nil {
+		return err
+	}
+	sourcePool, err := pgxpool.New(ctx, sourceDSN)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar no database de origem: %w", err)
+	}
+	defer sourcePool.Close()
+
+	version, err := m.migrator.CurrentVersion(ctx, sourcePool)
+	if err != nil {
+		return err
+	}
+
+	// 2. Recriar o database no destino com o mesmo schema da origem
+	targetAdmin, err := m.clusters.AdminPool(ctx, target)
+	if err != nil {
+		return err
+	}
+	dbIdent := pgx.Identifier{dbName}.Sanitize()
+	if _, err := targetAdmin.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", dbIdent)); err != nil {
+		return fmt.Errorf("erro ao limpar database de destino: %w", err)
+	}
+	if _, err := targetAdmin.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s", dbIdent)); err != nil {
+		return fmt.Errorf("erro ao criar database de destino: %w", err)
+	}
+
+	targetDSN, err := m.clusters.AdminDSN(target, dbName)
+	if err != nil {
+		return err
+	}
+	targetPool, err := pgxpool.New(ctx, targetDSN)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar no database de destino: %w", err)
+	}
+	defer targetPool.Close()
+
+	if _, err := m.migrator.MigrateTo(ctx, targetPool, version); err != nil {
+		return fmt.Errorf("erro ao aplicar schema no destino: %w", err)
+	}
+
+	// 3. Copiar os dados
+	if err := m.copyData(ctx, sourcePool, targetPool); err != nil {
+		return err
+	}
+
+	// 4. Login dedicado do tenant no destino (mesma senha armazenada)
+	if err := m.dbCreds.ProvisionOnCluster(ctx, target, event.TenantID, event.DBCode); err != nil {
+		return fmt.Errorf("erro ao criar credenciais no destino: %w", err)
+	}
+
+	// 5. Trocar o cluster e liberar o tenant
+	restore := event.PreviousStatus
+	if restore == "" {
+		restore = shared.TenantStatusActive
+	}
+	_, err = m.masterPool.Exec(ctx,
+		`UPDATE tenants SET cluster_id = $1, status = $2, updated_at = $3 WHERE id = $4`,
+		target.ID, restore, time.Now(), event.TenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar cluster do tenant: %w", err)
+	}
+
+	invalidateTenantContext(ctx, m.redisClient, event.URLCode)
+
+	// APIs recriam o pool do tenant apontando para o novo cluster
+	if err := m.redisClient.Publish(ctx, database.TenantPoolRefreshChannel, event.DBCode).Err(); err != nil {
+		log.Printf("Erro ao publicar refresh do pool do tenant %s: %v", event.URLCode, err)
+	}
+
+	// 6. Remover o database e as roles da origem
+	return m.dropSource(ctx, source, event.DBCode)
+}
+
+// Abort devolve o tenant ao status anterior após a movimentação esgotar as tentativas.
+// O tenant continua no cluster de origem; o database parcial no destino é removido.
+func (m *TenantMover) Abort(ctx context.Context, event ProvisionEvent) error {
+	restore := event.PreviousStatus
+	if restore == "" {
+		restore = shared.TenantStatusActive
+	}
+	tag, err := m.masterPool.Exec(ctx, `
+		UPDATE tenants SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4 AND cluster_id IS NOT DISTINCT FROM $5
+	`, restore, time.Now(), event.TenantID, shared.TenantStatusMigrating, event.SourceClusterID)
+	if err != nil {
+		return fmt.Errorf("erro ao restaurar status do tenant: %w", err)
+	}
+	if tag.RowsAffected() == 0 || event.TargetClusterID == nil {
+		return nil
+	}
+
+	target, err := m.clusters.Get(ctx, event.TargetClusterID)
+	if err != nil {
+		return err
+	}
+	return m.dropSource(ctx, target, event.DBCode)
+}
+
+// copyData copia os dados com conexões dedicadas (COPY não passa por pool de transações)
+func (m *TenantMover) copyData(ctx context.Context, sourcePool, targetPool *pgxpool.Pool) error {
+	src, err := sourcePool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar na origem: %w", err)
+	}
+	defer src.Release()
+
+	dst, err := targetPool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar no destino: %w", err)
+	}
+	defer dst.Release()
+
+	results, err := database.CopyTenantData(ctx, src.Conn(), dst.Conn())
+	if err != nil {
+		return fmt.Errorf("erro ao copiar dados: %w", err)
+	}
+
+	var rows int64
+	for _, r := range results {
+		rows += r.Rows
+	}
+	log.Printf("%d tabela(s) e %d linha(s) copiadas", len(results), rows)
+	return nil
+}
+
+// dropSource remove o database e as roles do tenant no cluster informado (origem da movimentação)
+func (m *TenantMover) dropSource(ctx context.Context, source *admin.DBCluster, dbCode string) error {
+	adminPool, err := m.clusters.AdminPool(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	dbIdent := pgx.Identifier{database.TenantDBName(dbCode)}.Sanitize()
+	if _, err := adminPool.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", dbIdent)); err != nil {
+		return fmt.Errorf("erro ao remover database de origem: %w", err)
+	}
+
+	if err := dropTenantRoles(ctx, adminPool, dbCode); err != nil {
+		return fmt.Errorf("erro ao remover roles de origem: %w", err)
+	}
+
+	return nil
+}
+
+// setTenantStatus atualiza o status do tenant no Master DB
+func setTenantStatus(ctx context.Context, masterPool *pgxpool.Pool, tenantID any, status shared.TenantStatus) error {
+	_, err := masterPool.Exec(ctx,
+		`UPDATE tenants SET status = $1, updated_at = $2 WHERE id = $3`, status, time.Now(), tenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar status do tenant: %w", err)
+	}
+	return nil
+}
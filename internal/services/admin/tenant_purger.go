@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/storage"
+)
+
+const (
+	// Lock por tenant para que apenas um worker execute o purge (e a exclusão não seja cancelada no meio)
+	tenantPurgeLockKey = "tenant:purge:lock:%s"
+	tenantPurgeLockTTL = 30 * time.Minute
+	// Quantidade máxima de tenants purgados por ciclo
+	tenantPurgeBatchSize = 10
+)
+
+// TenantPurger executa a exclusão definitiva de tenants cujo período de carência expirou
+type TenantPurger struct {
+	masterPool    *pgxpool.Pool
+	clusters      *database.ClusterRegistry
+	redisClient   *redis.Client
+	storageDriver storage.StorageDriver
+}
+
+func NewTenantPurger(
+	masterPool *pgxpool.Pool,
+	clusters *database.ClusterRegistry,
+	redisClient *redis.Client,
+	storageDriver storage.StorageDriver,
+) *TenantPurger {
+	return &TenantPurger{
+		masterPool:    masterPool,
+		clusters:      clusters,
+		redisClient:   redisClient,
+		storageDriver: storageDriver,
+	}
+}
+
+// PurgeDueTenants purga os tenants com deletion_scheduled_at vencido.
+// Retorna os url_codes purgados; falhas individuais não interrompem o lote.
+func (p *TenantPurger) PurgeDueTenants(ctx context.Context) ([]string, []error) {
+	rows, err := p.masterPool.Query(ctx, `
+		SELECT id FROM tenants
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $1
+		ORDER BY deletion_scheduled_at
+		LIMIT $2
+	`, time.Now(), tenantPurgeBatchSize)
+	if err != nil {
+		return nil, []error{fmt.Errorf("erro ao buscar tenants para purge: %w", err)}
+	}
+
+	var tenantIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, []error{fmt.Errorf("erro ao ler tenant: %w", err)}
+		}
+		tenantIDs = append(tenantIDs, id)
+	}
+	rows.Close()
+
+	var purged []string
+	var errs []error
+	for _, id := range tenantIDs {
+		urlCode, err := p.PurgeTenant(ctx, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", id, err))
+			continue
+		}
+		if urlCode != "" {
+			purged = append(purged, urlCode)
+		}
+	}
+
+	return purged, errs
+}
+
+// PurgeTenant remove definitivamente um tenant com exclusão vencida: database, arquivos,
+// registros do Master DB e cache. Cada etapa é idempotente, então um purge interrompido
+// é retomado no próximo ciclo. Retorna o url_code purgado ("" se nada foi feito).
+func (p *TenantPurger) PurgeTenant(ctx context.Context, tenantID uuid.UUID) (string, error) {
+	lockKey := fmt.Sprintf(tenantPurgeLockKey, tenantID)
+	acquired, err := p.redisClient.SetNX(ctx, lockKey, time.Now().Unix(), tenantPurgeLockTTL).Result()
+	if err != nil {
+		return "", fmt.Errorf("erro ao obter lock de purge: %w", err)
+	}
+	if !acquired {
+		return "", nil // Outro worker já está purgando
+	}
+	defer p.redisClient.Del(context.Background(), lockKey)
+
+	// Revalidar com o lock: a exclusão pode ter sido cancelada
+	var dbCode, urlCode string
+	var scheduledAt *time.Time
+	var clusterID *uuid.UUID
+	err = p.masterPool.QueryRow(ctx,
+		`SELECT db_code::text, url_code, deletion_scheduled_at, cluster_id FROM tenants WHERE id = $1`,
+		tenantID,
+	).Scan(&dbCode, &urlCode, &scheduledAt, &clusterID)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if scheduledAt == nil || scheduledAt.After(time.Now()) {
+		return "", nil
+	}
+
+	cluster, err := p.clusters.Get(ctx, clusterID)
+	if err != nil {
+		return "", err
+	}
+	adminPool, err := p.clusters.AdminPool(ctx, cluster)
+	if err != nil {
+		return "", err
+	}
+
+	// 1. Dropar database do tenant (FORCE derruba conexões abertas das APIs)
+	dbName := database.TenantDBName(dbCode)
+	if _, err := adminPool.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", dbName)); err != nil {
+		return "", fmt.Errorf("erro ao dropar database %s: %w", dbName, err)
+	}
+
+	// Logins dedicados do tenant (não possuem mais objetos após o DROP DATABASE)
+	if err := dropTenantRoles(ctx, adminPool, dbCode); err != nil {
+		return "", fmt.Errorf("erro ao remover roles do tenant: %w", err)
+	}
+
+	// 2. Remover arquivos do tenant ({tenant_uuid}/...), backups e exports
+	if err := p.storageDriver.DeletePrefix(ctx, tenantID.String()); err != nil {
+		return "", fmt.Errorf("erro ao remover arquivos do tenant: %w", err)
+	}
+	if err := p.storageDriver.DeletePrefix(ctx, backupPrefix(tenantID)); err != nil {
+		return "", fmt.Errorf("erro ao remover backups do tenant: %w", err)
+	}
+	if err := p.storageDriver.DeletePrefix(ctx, exportPrefix(tenantID)); err != nil {
+		return "", fmt.Errorf("erro ao remover exports do tenant: %w", err)
+	}
+
+	// 3. Remover registros do Master DB (profiles, roles, members e credenciais via ON DELETE CASCADE)
+	tx, err := p.masterPool.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET last_tenant_logged = NULL WHERE last_tenant_logged = $1`, urlCode); err != nil {
+		return "", fmt.Errorf("erro ao limpar last_tenant_logged: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM tenants WHERE id = $1`, tenantID); err != nil {
+		return "", fmt.Errorf("erro ao remover tenant do Master DB: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("erro ao remover tenant do Master DB: %w", err)
+	}
+
+	// 4. Invalidar cache e eventos pendentes do tenant
+	pipe := p.redisClient.Pipeline()
+	pipe.Del(ctx, fmt.Sprintf("tenant:urlcode:%s", urlCode))
+	pipe.HDel(ctx, provisionDLQKey, tenantID.String())
+	if _, err := pipe.Exec(ctx); err != nil {
+		// Tenant já foi removido; o cache expira sozinho e o middleware não encontra mais o tenant
+		fmt.Printf("Warning: erro ao invalidar cache do tenant %s: %v\n", urlCode, err)
+	}
+	if err := cache.InvalidateTenantContext(ctx, p.redisClient, cache.TenantContextEvent{URLCode: urlCode, TenantID: &tenantID}); err != nil {
+		fmt.Printf("Warning: erro ao invalidar contexto do tenant %s: %v\n", urlCode, err)
+	}
+
+	return urlCode, nil
+}
+
+// IsPurging indica se o purge do tenant está em andamento
+func IsPurging(ctx context.Context, redisClient *redis.Client, tenantID uuid.UUID) (bool, error) {
+	n, err := redisClient.Exists(ctx, fmt.Sprintf(tenantPurgeLockKey, tenantID)).Result()
+	return n > 0, err
+}
@@ -0,0 +1,348 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	adminModels "github.com/saas-multi-database-api/internal/models/admin"
+)
+
+var (
+	// ErrTenantRoleNotFound é retornado quando o papel não existe ou não é do tenant (papéis globais não são alterados)
+	ErrTenantRoleNotFound = errors.New("papel não encontrado")
+	// ErrTenantRoleInvalidSlug é retornado quando o slug tem caracteres fora de [a-z0-9_]
+	ErrTenantRoleInvalidSlug = errors.New("slug inválido: use letras minúsculas, números e _, começando por uma letra")
+	// ErrTenantRoleSlugTaken é retornado quando o slug já é de um papel do tenant ou de um papel global
+	ErrTenantRoleSlugTaken = errors.New("já existe um papel com este slug")
+	// ErrTenantRoleInUse é retornado ao remover um papel de membros, de convites pendentes ou do SSO do tenant
+	ErrTenantRoleInUse = errors.New("papel em uso por membros, convites ou pelo SSO do tenant")
+	// ErrPermissionNotAssignable é retornado com permissões inexistentes ou de features fora do plano
+	ErrPermissionNotAssignable = errors.New("permissões inexistentes ou fora do plano do tenant")
+)
+
+var tenantRoleSlugPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// user_role das chaves de API (middleware.APIKeyRole): nunca pode ser o slug de um papel
+const apiKeyRoleSlug = "api_key"
+
+const tenantRoleColumns = `r.id, r.tenant_id, r.name, r.slug,
+	ARRAY(SELECT p.slug FROM role_permissions rp JOIN permissions p ON p.id = rp.permission_id
+		WHERE rp.role_id = r.id ORDER BY p.slug),
+	(SELECT COUNT(*) FROM tenant_members m WHERE m.role_id = r.id AND m.tenant_id = $1),
+	r.created_at, r.updated_at`
+
+// Permissões que o tenant pode dar aos papéis: as de um módulo (prefixo do slug = features.code) só
+// quando a feature está no plano do tenant; as demais (user_m, setg_m, data_x) sempre
+const assignablePermissionsQuery = `
+	SELECT p.id, p.name, p.slug, COALESCE(p.description, ''), p.created_at, p.updated_at
+	FROM permissions p
+	LEFT JOIN features f ON f.code = split_part(p.slug, '_', 1)
+	WHERE f.id IS NULL OR EXISTS (
+		SELECT 1 FROM tenants t JOIN plan_features pf ON pf.plan_id = t.plan_id
+		WHERE t.id = $1 AND pf.feature_id = f.id
+	)
+	ORDER BY p.slug`
+
+// TenantRoleService gerencia os papéis próprios de um tenant (roles.tenant_id) e as permissões deles.
+// Os papéis globais (admin, member) podem ser dados aos membros, mas não são alterados pelo tenant.
+type TenantRoleService struct {
+	masterPool  *pgxpool.Pool
+	redisClient *redis.Client
+}
+
+func NewTenantRoleService(masterPool *pgxpool.Pool, redisClient *redis.Client) *TenantRoleService {
+	return &TenantRoleService{
+		masterPool:  masterPool,
+		redisClient: redisClient,
+	}
+}
+
+// ListRoles lista os papéis que podem ser dados aos membros do tenant: os globais e os do tenant
+func (s *TenantRoleService) ListRoles(ctx context.Context, tenantID uuid.UUID) ([]adminModels.Role, error) {
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT `+tenantRoleColumns+`
+		FROM roles r
+		WHERE (r.tenant_id = $1 OR r.tenant_id IS NULL) AND r.slug NOT IN ('owner', 'global_admin')
+		ORDER BY r.tenant_id NULLS FIRST, r.name
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar papéis: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []adminModels.Role{}
+	for rows.Next() {
+		role, err := scanTenantRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler papel: %w", err)
+		}
+		roles = append(roles, *role)
+	}
+
+	return roles, rows.Err()
+}
+
+// GetAssignableRole retorna o papel (global ou do tenant) que pode ser dado a membros pelo slug
+func (s *TenantRoleService) GetAssignableRole(ctx context.Context, tenantID uuid.UUID, slug string) (*adminModels.Role, error) {
+	role, err := scanTenantRole(s.masterPool.QueryRow(ctx, `
+		SELECT `+tenantRoleColumns+`
+		FROM roles r
+		WHERE r.slug = $2 AND (r.tenant_id = $1 OR r.tenant_id IS NULL) AND r.slug NOT IN ('owner', 'global_admin')
+		ORDER BY r.tenant_id NULLS LAST
+		LIMIT 1
+	`, tenantID, slug))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTenantRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar papel: %w", err)
+	}
+	return role, nil
+}
+
+// ListAssignablePermissions lista as permissões que os papéis do tenant podem receber no plano atual
+func (s *TenantRoleService) ListAssignablePermissions(ctx context.Context, tenantID uuid.UUID) ([]adminModels.Permission, error) {
+	rows, err := s.masterPool.Query(ctx, assignablePermissionsQuery, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar permissões: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []adminModels.Permission{}
+	for rows.Next() {
+		var p adminModels.Permission
+		if err := rows.Scan(&p.ID, &p.Name, &p.Slug, &p.Description, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler permissão: %w", err)
+		}
+		permissions = append(permissions, p)
+	}
+
+	return permissions, rows.Err()
+}
+
+// CreateRole cria um papel do tenant com as permissões informadas
+func (s *TenantRoleService) CreateRole(ctx context.Context, tenantID uuid.UUID, req adminModels.CreateTenantRoleRequest) (*adminModels.Role, error) {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !tenantRoleSlugPattern.MatchString(slug) {
+		return nil, ErrTenantRoleInvalidSlug
+	}
+	if slug == apiKeyRoleSlug {
+		return nil, ErrTenantRoleSlugTaken
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Um papel do tenant com o slug de um global (owner, admin...) seria confundido com ele
+	var global bool
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM roles WHERE tenant_id IS NULL AND slug = $1)`, slug,
+	).Scan(&global); err != nil {
+		return nil, fmt.Errorf("erro ao verificar slug: %w", err)
+	}
+	if global {
+		return nil, ErrTenantRoleSlugTaken
+	}
+
+	permissionIDs, err := assignablePermissionIDs(ctx, tx, tenantID, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	var roleID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		INSERT INTO roles (tenant_id, name, slug) VALUES ($1, $2, $3)
+		RETURNING id
+	`, tenantID, strings.TrimSpace(req.Name), slug).Scan(&roleID)
+	if isUniqueViolation(err) {
+		return nil, ErrTenantRoleSlugTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar papel: %w", err)
+	}
+
+	if err := setRolePermissions(ctx, tx, roleID, permissionIDs); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	return s.getRole(ctx, tenantID, roleID)
+}
+
+// UpdateRole renomeia um papel do tenant e substitui as permissões dele. Os membros com o papel
+// passam a ter as novas permissões na próxima requisição.
+func (s *TenantRoleService) UpdateRole(ctx context.Context, tenantID, roleID uuid.UUID, req adminModels.UpdateTenantRoleRequest) (*adminModels.Role, error) {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE roles SET name = $3, updated_at = NOW()
+		WHERE id = $2 AND tenant_id = $1
+	`, tenantID, roleID, strings.TrimSpace(req.Name))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao atualizar papel: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrTenantRoleNotFound
+	}
+
+	permissionIDs, err := assignablePermissionIDs(ctx, tx, tenantID, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := setRolePermissions(ctx, tx, roleID, permissionIDs); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	if err := cache.InvalidateTenantContext(ctx, s.redisClient, cache.TenantContextEvent{TenantID: &tenantID}); err != nil {
+		log.Printf("Warning: erro ao invalidar contexto do tenant %s: %v", tenantID, err)
+	}
+
+	return s.getRole(ctx, tenantID, roleID)
+}
+
+// DeleteRole remove um papel do tenant que não está com nenhum membro, convite pendente nem no SSO do tenant
+func (s *TenantRoleService) DeleteRole(ctx context.Context, tenantID, roleID uuid.UUID) error {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var inUse bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM tenant_members m WHERE m.role_id = r.id)
+			OR EXISTS(
+				SELECT 1 FROM tenant_invitations i
+				WHERE i.role_id = r.id AND i.accepted_at IS NULL AND i.revoked_at IS NULL AND i.expires_at > NOW()
+			)
+			OR EXISTS(
+				SELECT 1 FROM tenant_oidc_providers o
+				WHERE o.tenant_id = r.tenant_id
+				  AND (o.default_role = r.slug OR o.group_roles @> jsonb_build_array(jsonb_build_object('role', r.slug)))
+			)
+		FROM roles r
+		WHERE r.id = $2 AND r.tenant_id = $1
+		FOR UPDATE OF r
+	`, tenantID, roleID).Scan(&inUse)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTenantRoleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao buscar papel: %w", err)
+	}
+	if inUse {
+		return ErrTenantRoleInUse
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM roles WHERE id = $1`, roleID); err != nil {
+		return fmt.Errorf("erro ao remover papel: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+	return nil
+}
+
+func (s *TenantRoleService) getRole(ctx context.Context, tenantID, roleID uuid.UUID) (*adminModels.Role, error) {
+	role, err := scanTenantRole(s.masterPool.QueryRow(ctx, `
+		SELECT `+tenantRoleColumns+` FROM roles r WHERE r.id = $2 AND r.tenant_id = $1
+	`, tenantID, roleID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTenantRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar papel: %w", err)
+	}
+	return role, nil
+}
+
+// assignablePermissionIDs resolve os slugs para ids, recusando os que o tenant não pode dar
+func assignablePermissionIDs(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID, slugs []string) ([]uuid.UUID, error) {
+	rows, err := tx.Query(ctx, assignablePermissionsQuery, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar permissões: %w", err)
+	}
+	assignable := map[string]uuid.UUID{}
+	for rows.Next() {
+		var p adminModels.Permission
+		if err := rows.Scan(&p.ID, &p.Name, &p.Slug, &p.Description, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("erro ao ler permissão: %w", err)
+		}
+		assignable[p.Slug] = p.ID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao listar permissões: %w", err)
+	}
+
+	var ids []uuid.UUID
+	var rejected []string
+	for _, slug := range normalizeList(slugs, false) {
+		id, ok := assignable[slug]
+		if !ok {
+			rejected = append(rejected, slug)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(rejected) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPermissionNotAssignable, strings.Join(rejected, ", "))
+	}
+	return ids, nil
+}
+
+// setRolePermissions substitui as permissões do papel
+func setRolePermissions(ctx context.Context, tx pgx.Tx, roleID uuid.UUID, permissionIDs []uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return fmt.Errorf("erro ao remover permissões do papel: %w", err)
+	}
+	if len(permissionIDs) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT $1, unnest($2::uuid[])
+	`, roleID, permissionIDs); err != nil {
+		return fmt.Errorf("erro ao salvar permissões do papel: %w", err)
+	}
+	return nil
+}
+
+func scanTenantRole(row pgx.Row) (*adminModels.Role, error) {
+	var r adminModels.Role
+	if err := row.Scan(&r.ID, &r.TenantID, &r.Name, &r.Slug, &r.Permissions, &r.MemberCount, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
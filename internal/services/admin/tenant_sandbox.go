@@ -0,0 +1,282 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+// Validade máxima de um sandbox
+const maxSandboxTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrSandboxCloneNotFound é retornado quando a cópia não existe (ou é de outro tenant)
+	ErrSandboxCloneNotFound = errors.New("cópia para sandbox não encontrada")
+	// ErrSandboxOfSandbox é retornado ao tentar clonar um tenant que já é sandbox
+	ErrSandboxOfSandbox = errors.New("não é possível criar sandbox a partir de outro sandbox")
+	// ErrSandboxCloneInProgress é retornado quando já existe cópia pendente para o tenant
+	ErrSandboxCloneInProgress = errors.New("já existe cópia para sandbox em andamento para o tenant")
+	// ErrInvalidSandboxTTL é retornado para validades fora do intervalo permitido
+	ErrInvalidSandboxTTL = errors.New("ttl_hours deve estar entre 1 e 720")
+	// ErrSandboxSourceNotReady é retornado quando o tenant de origem não está ativo ou suspenso
+	ErrSandboxSourceNotReady = errors.New("tenant precisa estar 'active' ou 'suspended' para ser copiado")
+	// ErrInvalidSandboxSubdomain é retornado quando o subdomain normalizado tem tamanho inválido
+	ErrInvalidSandboxSubdomain = errors.New("subdomain deve ter entre 3 e 50 caracteres após normalização")
+	// ErrSubdomainTaken é retornado quando o subdomain já pertence a outro tenant
+	ErrSubdomainTaken = errors.New("subdomain já está em uso")
+)
+
+// CreateSandboxRequest é o corpo de POST /tenants/:tenant_id/sandboxes
+type CreateSandboxRequest struct {
+	Subdomain string `json:"subdomain,omitempty"` // Opcional: padrão {subdomain}-sandbox-{xxxx}
+	TTLHours  *int   `json:"ttl_hours,omitempty"` // Opcional: padrão TENANT_SANDBOX_TTL_HOURS
+}
+
+const sandboxCloneColumns = `id, source_tenant_id, sandbox_tenant_id, status, schema_version, tables,
+	media_count, missing_media, error, requested_by, started_at, completed_at, created_at`
+
+// SandboxService cria tenants sandbox (cópias de um tenant para reproduzir bugs e testar mudanças).
+// O tenant sandbox é registrado na hora com status 'provisioning'; a cópia do database e das
+// mídias fica com o worker (SandboxRunner).
+type SandboxService struct {
+	masterPool *pgxpool.Pool
+	cfg        *config.Config
+}
+
+func NewSandboxService(masterPool *pgxpool.Pool, cfg *config.Config) *SandboxService {
+	return &SandboxService{masterPool: masterPool, cfg: cfg}
+}
+
+// CreateSandbox registra o tenant sandbox (novo db_code, url_code e subdomain, mesmo plano, owner,
+// membros e cluster da origem) e agenda a cópia dos dados
+func (s *SandboxService) CreateSandbox(ctx context.Context, sourceID uuid.UUID, req CreateSandboxRequest, requestedBy *uuid.UUID) (*admin.TenantSandboxClone, error) {
+	ttl := time.Duration(s.cfg.Tenants.SandboxTTLHours) * time.Hour
+	if req.TTLHours != nil {
+		ttl = time.Duration(*req.TTLHours) * time.Hour
+	}
+	if ttl < time.Hour || ttl > maxSandboxTTL {
+		return nil, ErrInvalidSandboxTTL
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var source admin.Tenant
+	var pendingDeletion bool
+	err = tx.QueryRow(ctx, `
+		SELECT id, subdomain, owner_id, plan_id, billing_cycle, status, cluster_id, region, is_sandbox,
+		       deletion_scheduled_at IS NOT NULL
+		FROM tenants WHERE id = $1 FOR UPDATE
+	`, sourceID).Scan(&source.ID, &source.Subdomain, &source.OwnerID, &source.PlanID, &source.BillingCycle,
+		&source.Status, &source.ClusterID, &source.Region, &source.IsSandbox, &pendingDeletion)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tenant: %w", err)
+	}
+	if source.IsSandbox {
+		return nil, ErrSandboxOfSandbox
+	}
+	if pendingDeletion {
+		return nil, ErrTenantPendingDeletion
+	}
+	if source.Status != shared.TenantStatusActive && source.Status != shared.TenantStatusSuspended {
+		return nil, ErrSandboxSourceNotReady
+	}
+
+	var busy bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM tenant_sandbox_clones WHERE source_tenant_id = $1 AND status IN ($2, $3))`,
+		sourceID, shared.JobStatusPending, shared.JobStatusRunning,
+	).Scan(&busy)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar cópias do tenant: %w", err)
+	}
+	if busy {
+		return nil, ErrSandboxCloneInProgress
+	}
+
+	subdomain, err := s.sandboxSubdomain(ctx, tx, source.Subdomain, req.Subdomain)
+	if err != nil {
+		return nil, err
+	}
+	urlCode, err := uniqueURLCode(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	sandboxID := uuid.New()
+	dbCode := uuid.New()
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO tenants (id, db_code, url_code, subdomain, owner_id, plan_id, billing_cycle, status, cluster_id, region,
+		                     is_sandbox, sandbox_source_id, sandbox_expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, true, $11, $12, $13, $13)
+	`, sandboxID, dbCode, urlCode, subdomain, source.OwnerID, source.PlanID, source.BillingCycle,
+		shared.TenantStatusProvisioning, source.ClusterID, source.Region, sourceID, expiresAt, now)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar tenant sandbox: %w", err)
+	}
+
+	// Perfil da origem, sem o domínio customizado (continua apontando para o tenant real)
+	_, err = tx.Exec(ctx, `
+		INSERT INTO tenant_profiles (tenant_id, company_name, is_company, about, custom_domain, logo_url, custom_settings, created_at, updated_at)
+		SELECT $1, company_name, is_company, about, NULL, logo_url,
+		       COALESCE(custom_settings, '{}'::jsonb) || jsonb_build_object('name', COALESCE(custom_settings->>'name', '') || ' (sandbox)'),
+		       $2, $2
+		FROM tenant_profiles WHERE tenant_id = $3
+	`, sandboxID, now, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao copiar perfil do tenant: %w", err)
+	}
+
+	// Membros da origem (roles específicas da origem não são copiadas)
+	_, err = tx.Exec(ctx, `
+		INSERT INTO tenant_members (tenant_id, user_id, role_id, created_at, updated_at)
+		SELECT $1, m.user_id, CASE WHEN r.tenant_id IS NULL THEN m.role_id END, $2, $2
+		FROM tenant_members m LEFT JOIN roles r ON r.id = m.role_id
+		WHERE m.tenant_id = $3
+	`, sandboxID, now, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao copiar membros do tenant: %w", err)
+	}
+
+	clone, err := scanSandboxClone(tx.QueryRow(ctx, `
+		INSERT INTO tenant_sandbox_clones (source_tenant_id, sandbox_tenant_id, status, requested_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+sandboxCloneColumns,
+		sourceID, sandboxID, shared.JobStatusPending, requestedBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar cópia: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao registrar cópia: %w", err)
+	}
+
+	clone.Sandbox = &admin.Tenant{
+		ID:               sandboxID,
+		DBCode:           dbCode,
+		URLCode:          urlCode,
+		Subdomain:        subdomain,
+		OwnerID:          source.OwnerID,
+		PlanID:           source.PlanID,
+		BillingCycle:     source.BillingCycle,
+		Status:           shared.TenantStatusProvisioning,
+		ClusterID:        source.ClusterID,
+		Region:           source.Region,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		IsSandbox:        true,
+		SandboxSourceID:  &sourceID,
+		SandboxExpiresAt: &expiresAt,
+	}
+	return clone, nil
+}
+
+// ListSandboxClones lista as cópias para sandbox do tenant, da mais recente para a mais antiga
+func (s *SandboxService) ListSandboxClones(ctx context.Context, sourceID uuid.UUID) ([]admin.TenantSandboxClone, error) {
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT `+sandboxCloneColumns+` FROM tenant_sandbox_clones
+		WHERE source_tenant_id = $1
+		ORDER BY created_at DESC
+	`, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar cópias: %w", err)
+	}
+	defer rows.Close()
+
+	clones := []admin.TenantSandboxClone{}
+	for rows.Next() {
+		clone, err := scanSandboxClone(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler cópia: %w", err)
+		}
+		clones = append(clones, *clone)
+	}
+	return clones, rows.Err()
+}
+
+// GetSandboxClone retorna uma cópia para sandbox do tenant
+func (s *SandboxService) GetSandboxClone(ctx context.Context, sourceID, cloneID uuid.UUID) (*admin.TenantSandboxClone, error) {
+	clone, err := scanSandboxClone(s.masterPool.QueryRow(ctx,
+		`SELECT `+sandboxCloneColumns+` FROM tenant_sandbox_clones WHERE id = $1 AND source_tenant_id = $2`,
+		cloneID, sourceID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrSandboxCloneNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar cópia: %w", err)
+	}
+	return clone, nil
+}
+
+// sandboxSubdomain valida o subdomain pedido ou gera {origem}-sandbox-{xxxx}
+func (s *SandboxService) sandboxSubdomain(ctx context.Context, tx pgx.Tx, sourceSubdomain, requested string) (string, error) {
+	subdomain := utils.NormalizeSlug(requested)
+	if requested == "" {
+		suffix := "-sandbox-" + strings.ToLower(utils.GenerateURLCode()[:4])
+		base := sourceSubdomain
+		if len(base)+len(suffix) > 50 {
+			base = base[:50-len(suffix)]
+		}
+		subdomain = base + suffix
+	}
+	if len(subdomain) < 3 || len(subdomain) > 50 {
+		return "", ErrInvalidSandboxSubdomain
+	}
+
+	var taken bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM tenants WHERE subdomain = $1)`, subdomain).Scan(&taken); err != nil {
+		return "", fmt.Errorf("erro ao verificar subdomain: %w", err)
+	}
+	if taken {
+		return "", ErrSubdomainTaken
+	}
+	return subdomain, nil
+}
+
+// uniqueURLCode gera um url_code ainda não usado por nenhum tenant
+func uniqueURLCode(ctx context.Context, tx pgx.Tx) (string, error) {
+	for attempts := 0; attempts < 10; attempts++ {
+		urlCode := utils.GenerateURLCode()
+		var taken bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM tenants WHERE url_code = $1)`, urlCode).Scan(&taken); err != nil {
+			return "", fmt.Errorf("erro ao verificar url_code: %w", err)
+		}
+		if !taken {
+			return urlCode, nil
+		}
+	}
+	return "", fmt.Errorf("falha ao gerar url_code único após 10 tentativas")
+}
+
+func scanSandboxClone(row pgx.Row) (*admin.TenantSandboxClone, error) {
+	var c admin.TenantSandboxClone
+	err := row.Scan(
+		&c.ID, &c.SourceTenantID, &c.SandboxTenantID, &c.Status, &c.SchemaVersion, &c.Tables,
+		&c.MediaCount, &c.MissingMedia, &c.Error, &c.RequestedBy, &c.StartedAt, &c.CompletedAt, &c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
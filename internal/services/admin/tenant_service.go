@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/config"
 	"github.com/saas-multi-database-api/internal/models/admin"
 	"github.com/saas-multi-database-api/internal/models/shared"
 	adminRepo "github.com/saas-multi-database-api/internal/repository/admin"
@@ -20,6 +21,9 @@ type TenantService struct {
 	userRepo    *adminRepo.UserRepository
 	redisClient *redis.Client
 	masterPool  *pgxpool.Pool
+	queue       *ProvisioningQueue
+	tracker     *ProvisioningTracker
+	cfg         *config.Config
 }
 
 func NewTenantService(
@@ -27,12 +31,16 @@ func NewTenantService(
 	userRepo *adminRepo.UserRepository,
 	redisClient *redis.Client,
 	masterPool *pgxpool.Pool,
+	cfg *config.Config,
 ) *TenantService {
 	return &TenantService{
 		repo:        repo,
 		userRepo:    userRepo,
 		redisClient: redisClient,
 		masterPool:  masterPool,
+		queue:       NewProvisioningQueue(redisClient),
+		tracker:     NewProvisioningTracker(masterPool, redisClient),
+		cfg:         cfg,
 	}
 }
 
@@ -47,15 +55,36 @@ type CreateTenantRequest struct {
 	CompanyName  string              `json:"company_name"`
 	IsCompany    bool                `json:"is_company"`
 	CustomDomain string              `json:"custom_domain,omitempty"`
-	Industry     string              `json:"industry,omitempty"` // Deprecated: usar custom_settings
+	Industry     string              `json:"industry,omitempty"`   // Deprecated: usar custom_settings
+	Region       string              `json:"region,omitempty"`     // Usado pela política de placement "region"
+	ClusterID    *uuid.UUID          `json:"cluster_id,omitempty"` // Opcional: fixa o cluster (ex.: servidor dedicado)
 }
 
+// Tipos de evento processados pelo worker (vazio = provisionamento)
+const (
+	ProvisionEventTypeProvision = "provision"
+	ProvisionEventTypeMove      = "move" // Mover o database do tenant para outro cluster
+)
+
 // ProvisionEvent representa o evento de provisionamento publicado no Redis
 type ProvisionEvent struct {
+	Type      string    `json:"type,omitempty"`
 	TenantID  uuid.UUID `json:"tenant_id"`
 	DBCode    string    `json:"db_code"`
 	URLCode   string    `json:"url_code"`
 	Timestamp time.Time `json:"timestamp"`
+	Attempt   int       `json:"attempt"`              // Tentativas já falhadas
+	LastError string    `json:"last_error,omitempty"` // Erro da última tentativa
+
+	// Somente para eventos "move"
+	SourceClusterID *uuid.UUID          `json:"source_cluster_id,omitempty"` // nil = cluster padrão
+	TargetClusterID *uuid.UUID          `json:"target_cluster_id,omitempty"`
+	PreviousStatus  shared.TenantStatus `json:"previous_status,omitempty"` // Status restaurado ao final
+}
+
+// IsMove indica se o evento é uma movimentação de cluster
+func (e ProvisionEvent) IsMove() bool {
+	return e.Type == ProvisionEventTypeMove
 }
 
 // CreateTenant cria um novo tenant de forma síncrona no Master DB
@@ -108,12 +137,22 @@ func (s *TenantService) CreateTenant(ctx context.Context, req CreateTenantReques
 	// Gerar IDs e códigos
 	tenantID := uuid.New()
 	dbCode := uuid.New().String() // UUID completo como db_code
+	clusterID := req.ClusterID
+
+	// Reservar um database pré-criado do warm pool (o worker só aplica credenciais e dados iniciais)
+	claimedCode, claimedCluster, err := s.claimWarmDatabase(ctx, tenantID, req)
+	if err != nil {
+		fmt.Printf("Warning: erro ao reservar database do warm pool: %v\n", err)
+	} else if claimedCode != "" {
+		dbCode = claimedCode
+		clusterID = claimedCluster
+	}
 
 	// Criar tenant no Master DB com status 'provisioning'
 	query := `
-		INSERT INTO tenants (id, db_code, url_code, subdomain, owner_id, plan_id, billing_cycle, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id, db_code, url_code, subdomain, owner_id, plan_id, billing_cycle, status, created_at, updated_at
+		INSERT INTO tenants (id, db_code, url_code, subdomain, owner_id, plan_id, billing_cycle, status, cluster_id, region, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12)
+		RETURNING id, db_code, url_code, subdomain, owner_id, plan_id, billing_cycle, status, cluster_id, region, created_at, updated_at
 	`
 
 	now := time.Now()
@@ -130,6 +169,8 @@ func (s *TenantService) CreateTenant(ctx context.Context, req CreateTenantReques
 		req.PlanID,
 		req.BillingCycle,
 		"provisioning",
+		clusterID,
+		req.Region,
 		now,
 		now,
 	).Scan(
@@ -141,11 +182,16 @@ func (s *TenantService) CreateTenant(ctx context.Context, req CreateTenantReques
 		&tenant.PlanID,
 		&tenant.BillingCycle,
 		&tenant.Status,
+		&tenant.ClusterID,
+		&tenant.Region,
 		&tenant.CreatedAt,
 		&tenant.UpdatedAt,
 	)
 
 	if err != nil {
+		if claimedCode != "" {
+			s.unclaimWarmDatabase(ctx, tenantID)
+		}
 		return nil, fmt.Errorf("erro ao criar tenant: %w", err)
 	}
 
@@ -209,13 +255,10 @@ func (s *TenantService) CreateTenant(ctx context.Context, req CreateTenantReques
 		Timestamp: now,
 	}
 
-	eventJSON, err := json.Marshal(event)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao serializar evento: %w", err)
-	}
+	s.recordQueued(ctx, tenantID, "tenant criado")
 
 	// Publicar na fila do Redis
-	err = s.redisClient.LPush(ctx, "tenant:provision:queue", eventJSON).Err()
+	err = s.queue.Enqueue(ctx, event)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao publicar evento de provisionamento: %w", err)
 	}
@@ -247,10 +290,67 @@ func (s *TenantService) UpdateTenantStatus(ctx context.Context, tenantID uuid.UU
 	return nil
 }
 
+// ListFailedProvisionings retorna os provisionamentos que esgotaram as tentativas (DLQ)
+func (s *TenantService) ListFailedProvisionings(ctx context.Context) ([]DeadLetter, error) {
+	return s.queue.ListDeadLetters(ctx)
+}
+
+// GetFailedProvisioning retorna o provisionamento na DLQ de um tenant
+func (s *TenantService) GetFailedProvisioning(ctx context.Context, tenantID uuid.UUID) (*DeadLetter, error) {
+	return s.queue.GetDeadLetter(ctx, tenantID)
+}
+
+// ReplayProvisioning republica um provisionamento da DLQ e volta o tenant para 'provisioning'
+func (s *TenantService) ReplayProvisioning(ctx context.Context, tenantID uuid.UUID) (*ProvisionEvent, error) {
+	event, err := s.queue.Replay(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Uma movimentação de cluster que falhou deixou o tenant no cluster de origem: o worker
+	// volta a colocá-lo em 'migrating' quando reprocessar
+	if event.IsMove() {
+		return event, nil
+	}
+
+	if err := s.UpdateTenantStatus(ctx, tenantID, string(shared.TenantStatusProvisioning)); err != nil {
+		return nil, err
+	}
+
+	s.recordQueued(ctx, tenantID, "provisionamento republicado da DLQ")
+
+	return event, nil
+}
+
+// GetProvisioningProgress retorna o progresso etapa a etapa do provisionamento
+func (s *TenantService) GetProvisioningProgress(ctx context.Context, tenantID uuid.UUID) (*ProvisioningProgress, error) {
+	return s.tracker.GetProgress(ctx, tenantID)
+}
+
+// StreamProvisioning retorna os eventos de provisionamento após lastEventID seguidos dos eventos em tempo real
+func (s *TenantService) StreamProvisioning(ctx context.Context, tenantID uuid.UUID, lastEventID int64) (<-chan ProvisioningEvent, error) {
+	return s.tracker.Stream(ctx, tenantID, lastEventID)
+}
+
+// recordQueued registra o início de um provisionamento; falhas não impedem o enfileiramento
+func (s *TenantService) recordQueued(ctx context.Context, tenantID uuid.UUID, message string) {
+	_, err := s.tracker.Record(ctx, ProvisioningEvent{
+		TenantID: tenantID,
+		Step:     ProvisioningStepQueued,
+		Status:   ProvisioningEventCompleted,
+		Message:  message,
+	})
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
 // GetTenantByID retorna um tenant pelo ID
 func (s *TenantService) GetTenantByID(ctx context.Context, tenantID uuid.UUID) (*admin.Tenant, error) {
 	query := `
-		SELECT id, db_code, url_code, owner_id, plan_id, status, created_at, updated_at
+		SELECT id, db_code, url_code, subdomain, owner_id, plan_id, billing_cycle, status, cluster_id, region,
+		       created_at, updated_at, deletion_requested_at, deletion_scheduled_at,
+		       is_sandbox, sandbox_source_id, sandbox_expires_at
 		FROM tenants
 		WHERE id = $1
 	`
@@ -260,11 +360,20 @@ func (s *TenantService) GetTenantByID(ctx context.Context, tenantID uuid.UUID) (
 		&tenant.ID,
 		&tenant.DBCode,
 		&tenant.URLCode,
+		&tenant.Subdomain,
 		&tenant.OwnerID,
 		&tenant.PlanID,
+		&tenant.BillingCycle,
 		&tenant.Status,
+		&tenant.ClusterID,
+		&tenant.Region,
 		&tenant.CreatedAt,
 		&tenant.UpdatedAt,
+		&tenant.DeletionRequestedAt,
+		&tenant.DeletionScheduledAt,
+		&tenant.IsSandbox,
+		&tenant.SandboxSourceID,
+		&tenant.SandboxExpiresAt,
 	)
 
 	if err != nil {
@@ -0,0 +1,653 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/saas-multi-database-api/internal/cache"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+const (
+	// Quantidade de códigos de recuperação entregues ao ativar (ou regenerar) o segundo fator
+	recoveryCodeCount = 10
+	// Validade do mfa_token entre a senha e o segundo fator
+	twoFactorChallengeTTL = 5 * time.Minute
+	// Códigos errados aceitos por mfa_token antes de exigir a senha de novo
+	twoFactorMaxAttempts = 5
+	// Tamanho (em bytes aleatórios) do mfa_token
+	twoFactorChallengeBytes = 32
+)
+
+var (
+	// ErrTwoFactorRequired é retornado quando um papel (sys_roles) ou tenant exige o segundo fator
+	ErrTwoFactorRequired = errors.New("autenticação em dois fatores obrigatória")
+	// ErrTwoFactorAlreadyEnabled é retornado ao iniciar o cadastro com o segundo fator já ativo
+	ErrTwoFactorAlreadyEnabled = errors.New("autenticação em dois fatores já está ativa")
+	// ErrTwoFactorNotEnabled é retornado ao desativar ou regenerar códigos sem o segundo fator ativo
+	ErrTwoFactorNotEnabled = errors.New("autenticação em dois fatores não está ativa")
+	// ErrTwoFactorNotSetUp é retornado ao confirmar um código sem ter iniciado o cadastro
+	ErrTwoFactorNotSetUp = errors.New("cadastro do segundo fator não iniciado")
+	// ErrInvalidTwoFactorCode é retornado quando o código TOTP ou de recuperação não confere
+	ErrInvalidTwoFactorCode = errors.New("código de verificação inválido")
+	// ErrInvalidTwoFactorChallenge é retornado quando o mfa_token não existe, expirou ou esgotou as tentativas
+	ErrInvalidTwoFactorChallenge = errors.New("mfa_token inválido ou expirado: faça login novamente")
+	// ErrInvalidPassword é retornado quando a senha de confirmação não confere
+	ErrInvalidPassword = errors.New("senha incorreta")
+	// ErrSysRoleNotFound é retornado ao alterar a exigência de um papel inexistente
+	ErrSysRoleNotFound = errors.New("papel não encontrado")
+)
+
+// TwoFactorStatus descreve o segundo fator de um usuário
+type TwoFactorStatus struct {
+	Enabled   bool       `json:"enabled"`
+	EnabledAt *time.Time `json:"enabled_at,omitempty"`
+	// Exigido por um papel (admins) ou por um tenant do qual o usuário é membro: não pode ser desativado
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetup é o segredo a cadastrar no app autenticador (provisioning_uri vira o QR code)
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorChallenge é a resposta do login quando a senha confere mas falta o segundo fator
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool `json:"two_factor_required"`
+	// O usuário ainda não cadastrou o segundo fator, mas um papel o exige: o cadastro é feito com o mfa_token
+	SetupRequired bool   `json:"setup_required"`
+	MFAToken      string `json:"mfa_token"`
+	ExpiresIn     int    `json:"expires_in"`
+}
+
+// TwoFactorLogin é o login que aguarda o segundo fator, guardado no desafio: o email conta os
+// códigos errados no LoginGuardService e SSOTenantID mantém o tenant de um login SSO na sessão
+type TwoFactorLogin struct {
+	Email       string
+	SSOTenantID string
+}
+
+// RoleTwoFactorPolicy é a exigência de segundo fator de um papel de sistema
+type RoleTwoFactorPolicy struct {
+	ID               int    `json:"id"`
+	Name             string `json:"name"`
+	Slug             string `json:"slug"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
+}
+
+// TwoFactorService gerencia o segundo fator (TOTP + códigos de recuperação) dos usuários das APIs
+// Admin (sys_users) e Tenant (users), o login em duas etapas e as exigências por papel e por tenant
+type TwoFactorService struct {
+	masterPool  *pgxpool.Pool
+	redisClient *redis.Client
+	config      *config.Config
+}
+
+func NewTwoFactorService(masterPool *pgxpool.Pool, redisClient *redis.Client, cfg *config.Config) *TwoFactorService {
+	return &TwoFactorService{
+		masterPool:  masterPool,
+		redisClient: redisClient,
+		config:      cfg,
+	}
+}
+
+// Status retorna a situação do segundo fator do usuário
+func (s *TwoFactorService) Status(ctx context.Context, subjectType string, subjectID uuid.UUID) (*TwoFactorStatus, error) {
+	status := &TwoFactorStatus{}
+	err := s.masterPool.QueryRow(ctx, `
+		SELECT enabled_at FROM two_factor_credentials WHERE subject_type = $1 AND subject_id = $2
+	`, subjectType, subjectID).Scan(&status.EnabledAt)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("erro ao buscar segundo fator: %w", err)
+	}
+	status.Enabled = status.EnabledAt != nil
+
+	if status.Enabled {
+		if err := s.masterPool.QueryRow(ctx, `
+			SELECT COUNT(*) FROM two_factor_recovery_codes
+			WHERE subject_type = $1 AND subject_id = $2 AND used_at IS NULL
+		`, subjectType, subjectID).Scan(&status.RecoveryCodesRemaining); err != nil {
+			return nil, fmt.Errorf("erro ao contar códigos de recuperação: %w", err)
+		}
+	}
+
+	status.Required, err = s.requiredAnywhere(ctx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Setup gera um novo segredo (pendente até o primeiro código ser confirmado em Enable).
+// Chamar de novo substitui o segredo pendente.
+func (s *TwoFactorService) Setup(ctx context.Context, subjectType string, subjectID uuid.UUID) (*TwoFactorSetup, error) {
+	var email string
+	if err := s.masterPool.QueryRow(ctx,
+		fmt.Sprintf(`SELECT email FROM %s WHERE id = $1`, subjectTable(subjectType)), subjectID,
+	).Scan(&email); err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptSecret(s.config.Auth.TwoFactorEncryptionKey, secret)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao cifrar segredo: %w", err)
+	}
+
+	// Não sobrescreve um segundo fator já ativo
+	tag, err := s.masterPool.Exec(ctx, `
+		INSERT INTO two_factor_credentials (subject_type, subject_id, secret)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (subject_type, subject_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = NOW()
+		WHERE two_factor_credentials.enabled_at IS NULL
+	`, subjectType, subjectID, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar segredo: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	return &TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(secret, s.config.App.Name, email),
+	}, nil
+}
+
+// Enable ativa o segundo fator com o primeiro código do app e retorna os códigos de recuperação
+// (exibidos uma única vez)
+func (s *TwoFactorService) Enable(ctx context.Context, subjectType string, subjectID uuid.UUID, code string) ([]string, error) {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	cred, err := s.lockCredential(ctx, tx, subjectType, subjectID)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTwoFactorNotSetUp
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cred.enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTP(cred.secret, code, time.Now(), cred.lastUsedStep)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE two_factor_credentials SET enabled_at = NOW(), last_used_step = $3, updated_at = NOW()
+		WHERE subject_type = $1 AND subject_id = $2
+	`, subjectType, subjectID, step); err != nil {
+		return nil, fmt.Errorf("erro ao ativar segundo fator: %w", err)
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, tx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao ativar segundo fator: %w", err)
+	}
+	return codes, nil
+}
+
+// Disable remove o segundo fator após confirmar a senha e um código (TOTP ou de recuperação).
+// Não é permitido quando um papel ou tenant do usuário o exige.
+func (s *TwoFactorService) Disable(ctx context.Context, subjectType string, subjectID uuid.UUID, password, code string) error {
+	if err := s.checkPassword(ctx, subjectType, subjectID, password); err != nil {
+		return err
+	}
+
+	required, err := s.requiredAnywhere(ctx, subjectType, subjectID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.verify(ctx, tx, subjectType, subjectID, code); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM two_factor_credentials WHERE subject_type = $1 AND subject_id = $2`,
+		subjectType, subjectID,
+	); err != nil {
+		return fmt.Errorf("erro ao desativar segundo fator: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM two_factor_recovery_codes WHERE subject_type = $1 AND subject_id = $2`,
+		subjectType, subjectID,
+	); err != nil {
+		return fmt.Errorf("erro ao remover códigos de recuperação: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao desativar segundo fator: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes invalida os códigos de recuperação atuais e retorna novos, após
+// confirmar um código TOTP
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, subjectType string, subjectID uuid.UUID, code string) ([]string, error) {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	cred, err := s.lockCredential(ctx, tx, subjectType, subjectID)
+	if err == pgx.ErrNoRows || (err == nil && !cred.enabled) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyTOTP(ctx, tx, subjectType, subjectID, cred, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, tx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao regenerar códigos de recuperação: %w", err)
+	}
+	return codes, nil
+}
+
+// BeginLogin é chamado após a senha conferir. Retorna nil quando o login pode ser concluído sem
+// segundo fator; caso contrário, o desafio com o mfa_token para LoginSetup/CompleteLogin (login é
+// guardado no desafio, ver ChallengeLogin).
+func (s *TwoFactorService) BeginLogin(ctx context.Context, subjectType string, subjectID uuid.UUID, login TwoFactorLogin) (*TwoFactorChallenge, error) {
+	var enabled bool
+	if err := s.masterPool.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM two_factor_credentials
+			WHERE subject_type = $1 AND subject_id = $2 AND enabled_at IS NOT NULL
+		)
+	`, subjectType, subjectID).Scan(&enabled); err != nil {
+		return nil, fmt.Errorf("erro ao buscar segundo fator: %w", err)
+	}
+
+	setupRequired := false
+	if !enabled {
+		// Tenants exigem o segundo fator ao acessar o tenant (TenantMiddleware), não no login
+		required, err := twoFactorRequired(ctx, s.masterPool, subjectType, subjectID)
+		if err != nil {
+			return nil, err
+		}
+		if !required {
+			return nil, nil
+		}
+		setupRequired = true
+	}
+
+	token, err := utils.GenerateSecret(twoFactorChallengeBytes)
+	if err != nil {
+		return nil, err
+	}
+	key := twoFactorChallengeKey(token)
+	pipe := s.redisClient.TxPipeline()
+	pipe.HSet(ctx, key, "subject_type", subjectType, "subject_id", subjectID.String(),
+		"email", login.Email, "sso_tenant_id", login.SSOTenantID, "attempts", 0)
+	pipe.Expire(ctx, key, twoFactorChallengeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao salvar desafio de login: %w", err)
+	}
+
+	return &TwoFactorChallenge{
+		TwoFactorRequired: true,
+		SetupRequired:     setupRequired,
+		MFAToken:          token,
+		ExpiresIn:         int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// LoginSetup inicia o cadastro do segundo fator durante o login, quando um papel o exige e o
+// usuário ainda não o tem (setup_required)
+func (s *TwoFactorService) LoginSetup(ctx context.Context, subjectType, mfaToken string) (*TwoFactorSetup, error) {
+	subjectID, err := s.challengeSubject(ctx, subjectType, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.Setup(ctx, subjectType, subjectID)
+}
+
+// CompleteLogin valida o segundo fator do desafio e retorna o usuário autenticado. Se o cadastro
+// foi feito durante o login, o código o ativa e os códigos de recuperação são retornados.
+func (s *TwoFactorService) CompleteLogin(ctx context.Context, subjectType, mfaToken, code string) (uuid.UUID, []string, error) {
+	subjectID, err := s.challengeSubject(ctx, subjectType, mfaToken)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	var pending bool
+	if err := s.masterPool.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM two_factor_credentials
+			WHERE subject_type = $1 AND subject_id = $2 AND enabled_at IS NULL
+		)
+	`, subjectType, subjectID).Scan(&pending); err != nil {
+		return uuid.Nil, nil, fmt.Errorf("erro ao buscar segundo fator: %w", err)
+	}
+
+	var recoveryCodes []string
+	if pending {
+		recoveryCodes, err = s.Enable(ctx, subjectType, subjectID, code)
+	} else {
+		err = s.Verify(ctx, subjectType, subjectID, code)
+	}
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		s.challengeFailed(ctx, mfaToken)
+		return uuid.Nil, nil, err
+	}
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	// O mfa_token é de uso único
+	if err := s.redisClient.Del(ctx, twoFactorChallengeKey(mfaToken)).Err(); err != nil {
+		log.Printf("Warning: erro ao remover desafio de login: %v", err)
+	}
+	return subjectID, recoveryCodes, nil
+}
+
+// Verify confere um código TOTP ou de recuperação (que é consumido)
+func (s *TwoFactorService) Verify(ctx context.Context, subjectType string, subjectID uuid.UUID, code string) error {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.verify(ctx, tx, subjectType, subjectID, code); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao confirmar código: %w", err)
+	}
+	return nil
+}
+
+// ListRolePolicies lista os papéis de sistema com a exigência de segundo fator
+func (s *TwoFactorService) ListRolePolicies(ctx context.Context) ([]RoleTwoFactorPolicy, error) {
+	rows, err := s.masterPool.Query(ctx, `SELECT id, name, slug, require_two_factor FROM sys_roles ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar papéis: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []RoleTwoFactorPolicy{}
+	for rows.Next() {
+		var p RoleTwoFactorPolicy
+		if err := rows.Scan(&p.ID, &p.Name, &p.Slug, &p.RequireTwoFactor); err != nil {
+			return nil, fmt.Errorf("erro ao ler papel: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// SetRoleRequirement torna o segundo fator obrigatório (ou opcional) para os sys_users do papel.
+// Sessões abertas sem o segundo fator deixam de ser renovadas no próximo refresh.
+func (s *TwoFactorService) SetRoleRequirement(ctx context.Context, roleID int, required bool) (*RoleTwoFactorPolicy, error) {
+	p := &RoleTwoFactorPolicy{}
+	err := s.masterPool.QueryRow(ctx, `
+		UPDATE sys_roles SET require_two_factor = $2, updated_at = NOW() WHERE id = $1
+		RETURNING id, name, slug, require_two_factor
+	`, roleID, required).Scan(&p.ID, &p.Name, &p.Slug, &p.RequireTwoFactor)
+	if err == pgx.ErrNoRows {
+		return nil, ErrSysRoleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao atualizar papel: %w", err)
+	}
+	return p, nil
+}
+
+// SetTenantRequirement torna o segundo fator obrigatório (ou opcional) para os membros do tenant.
+// O contexto em cache do tenant é invalidado para a exigência valer na próxima requisição.
+func (s *TwoFactorService) SetTenantRequirement(ctx context.Context, tenantID uuid.UUID, urlCode string, required bool) error {
+	if _, err := s.masterPool.Exec(ctx,
+		`UPDATE tenants SET require_two_factor = $2, updated_at = NOW() WHERE id = $1`,
+		tenantID, required,
+	); err != nil {
+		return fmt.Errorf("erro ao atualizar tenant: %w", err)
+	}
+
+	if err := cache.InvalidateTenantContext(ctx, s.redisClient, cache.TenantContextEvent{URLCode: urlCode}); err != nil {
+		log.Printf("Warning: erro ao invalidar contexto do tenant %s: %v", urlCode, err)
+	}
+	return nil
+}
+
+// twoFactorCredential é o segundo fator decifrado de um usuário
+type twoFactorCredential struct {
+	secret       string
+	enabled      bool
+	lastUsedStep int64
+}
+
+// lockCredential lê o segundo fator com lock da linha (pgx.ErrNoRows quando não há cadastro)
+func (s *TwoFactorService) lockCredential(ctx context.Context, tx pgx.Tx, subjectType string, subjectID uuid.UUID) (*twoFactorCredential, error) {
+	var encrypted string
+	var enabledAt *time.Time
+	cred := &twoFactorCredential{}
+	err := tx.QueryRow(ctx, `
+		SELECT secret, enabled_at, last_used_step FROM two_factor_credentials
+		WHERE subject_type = $1 AND subject_id = $2
+		FOR UPDATE
+	`, subjectType, subjectID).Scan(&encrypted, &enabledAt, &cred.lastUsedStep)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar segundo fator: %w", err)
+	}
+
+	cred.secret, err = utils.DecryptSecret(s.config.Auth.TwoFactorEncryptionKey, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decifrar segredo: %w", err)
+	}
+	cred.enabled = enabledAt != nil
+	return cred, nil
+}
+
+// verify aceita um código TOTP do segundo fator ativo ou um código de recuperação não usado
+func (s *TwoFactorService) verify(ctx context.Context, tx pgx.Tx, subjectType string, subjectID uuid.UUID, code string) error {
+	cred, err := s.lockCredential(ctx, tx, subjectType, subjectID)
+	if err == pgx.ErrNoRows || (err == nil && !cred.enabled) {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.verifyTOTP(ctx, tx, subjectType, subjectID, cred, code); err != ErrInvalidTwoFactorCode {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE two_factor_recovery_codes SET used_at = NOW()
+		WHERE subject_type = $1 AND subject_id = $2 AND code_hash = $3 AND used_at IS NULL
+	`, subjectType, subjectID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("erro ao consumir código de recuperação: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// verifyTOTP confere o código TOTP e registra o passo usado (o mesmo código não vale duas vezes)
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, tx pgx.Tx, subjectType string, subjectID uuid.UUID, cred *twoFactorCredential, code string) error {
+	step, ok := utils.ValidateTOTP(cred.secret, code, time.Now(), cred.lastUsedStep)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE two_factor_credentials SET last_used_step = $3, updated_at = NOW()
+		WHERE subject_type = $1 AND subject_id = $2
+	`, subjectType, subjectID, step); err != nil {
+		return fmt.Errorf("erro ao registrar código: %w", err)
+	}
+	return nil
+}
+
+// replaceRecoveryCodes substitui os códigos de recuperação do usuário e retorna os novos em texto claro
+func (s *TwoFactorService) replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, subjectType string, subjectID uuid.UUID) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM two_factor_recovery_codes WHERE subject_type = $1 AND subject_id = $2`,
+		subjectType, subjectID,
+	); err != nil {
+		return nil, fmt.Errorf("erro ao remover códigos de recuperação: %w", err)
+	}
+	for _, code := range codes {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO two_factor_recovery_codes (subject_type, subject_id, code_hash) VALUES ($1, $2, $3)
+		`, subjectType, subjectID, utils.HashToken(utils.NormalizeRecoveryCode(code))); err != nil {
+			return nil, fmt.Errorf("erro ao salvar códigos de recuperação: %w", err)
+		}
+	}
+	return codes, nil
+}
+
+func (s *TwoFactorService) checkPassword(ctx context.Context, subjectType string, subjectID uuid.UUID, password string) error {
+	var hash string
+	if err := s.masterPool.QueryRow(ctx,
+		fmt.Sprintf(`SELECT password_hash FROM %s WHERE id = $1`, subjectTable(subjectType)), subjectID,
+	).Scan(&hash); err != nil {
+		return fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if !utils.CheckPasswordHash(password, hash) {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// requiredAnywhere informa se algum papel (admins) ou tenant do usuário exige o segundo fator
+func (s *TwoFactorService) requiredAnywhere(ctx context.Context, subjectType string, subjectID uuid.UUID) (bool, error) {
+	if subjectType == TokenSubjectAdmin {
+		return twoFactorRequired(ctx, s.masterPool, subjectType, subjectID)
+	}
+
+	var required bool
+	if err := s.masterPool.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM tenant_members tm
+			JOIN tenants t ON t.id = tm.tenant_id
+			WHERE tm.user_id = $1 AND t.require_two_factor
+		)
+	`, subjectID).Scan(&required); err != nil {
+		return false, fmt.Errorf("erro ao verificar exigência de segundo fator: %w", err)
+	}
+	return required, nil
+}
+
+// challengeSubject retorna o usuário de um mfa_token válido
+func (s *TwoFactorService) challengeSubject(ctx context.Context, subjectType, mfaToken string) (uuid.UUID, error) {
+	values, err := s.redisClient.HGetAll(ctx, twoFactorChallengeKey(mfaToken)).Result()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("erro ao buscar desafio de login: %w", err)
+	}
+	if values["subject_type"] != subjectType {
+		return uuid.Nil, ErrInvalidTwoFactorChallenge
+	}
+	if attempts, _ := strconv.Atoi(values["attempts"]); attempts >= twoFactorMaxAttempts {
+		return uuid.Nil, ErrInvalidTwoFactorChallenge
+	}
+	subjectID, err := uuid.Parse(values["subject_id"])
+	if err != nil {
+		return uuid.Nil, ErrInvalidTwoFactorChallenge
+	}
+	return subjectID, nil
+}
+
+// ChallengeLogin retorna o login que gerou o mfa_token (vazio se o desafio não existe mais). Deve ser
+// lido antes de CompleteLogin, que descarta o desafio.
+func (s *TwoFactorService) ChallengeLogin(ctx context.Context, subjectType, mfaToken string) TwoFactorLogin {
+	values, err := s.redisClient.HMGet(ctx, twoFactorChallengeKey(mfaToken), "subject_type", "email", "sso_tenant_id").Result()
+	if err != nil || len(values) != 3 {
+		return TwoFactorLogin{}
+	}
+	if t, _ := values[0].(string); t != subjectType {
+		return TwoFactorLogin{}
+	}
+	email, _ := values[1].(string)
+	ssoTenantID, _ := values[2].(string)
+	return TwoFactorLogin{Email: email, SSOTenantID: ssoTenantID}
+}
+
+// challengeFailed conta um código errado; ao atingir o limite o mfa_token é descartado
+func (s *TwoFactorService) challengeFailed(ctx context.Context, mfaToken string) {
+	key := twoFactorChallengeKey(mfaToken)
+	attempts, err := s.redisClient.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		log.Printf("Warning: erro ao registrar tentativa de segundo fator: %v", err)
+		return
+	}
+	if attempts >= twoFactorMaxAttempts {
+		s.redisClient.Del(ctx, key)
+	}
+}
+
+func twoFactorChallengeKey(mfaToken string) string {
+	return "auth:mfa:" + utils.HashToken(mfaToken)
+}
+
+// twoFactorRequired informa se o login do usuário exige o segundo fator: para sys_users, quando
+// algum dos seus papéis o exige. Para users a exigência é por tenant e vale no acesso ao tenant.
+func twoFactorRequired(ctx context.Context, q rowQuerier, subjectType string, subjectID uuid.UUID) (bool, error) {
+	if subjectType != TokenSubjectAdmin {
+		return false, nil
+	}
+
+	var required bool
+	if err := q.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM sys_user_roles ur
+			JOIN sys_roles r ON r.id = ur.sys_role_id
+			WHERE ur.sys_user_id = $1 AND r.require_two_factor
+		)
+	`, subjectID).Scan(&required); err != nil {
+		return false, fmt.Errorf("erro ao verificar exigência de segundo fator: %w", err)
+	}
+	return required, nil
+}
@@ -0,0 +1,469 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/database"
+	"github.com/saas-multi-database-api/internal/models/admin"
+	"github.com/saas-multi-database-api/internal/models/shared"
+)
+
+// Status das linhas de tenant_db_pool
+const (
+	warmPoolStatusReady     = "ready"
+	warmPoolStatusMigrating = "migrating"
+	warmPoolStatusClaimed   = "claimed"
+)
+
+const (
+	// Reservas sem tenant após esse tempo são consideradas abandonadas (CreateTenant falhou no meio)
+	warmPoolClaimTTL = 1 * time.Hour
+	// Re-migrações interrompidas (worker caiu) voltam para 'ready' após esse tempo
+	warmPoolMigratingTTL = 30 * time.Minute
+	// Advisory locks (conexão direta do cluster) que serializam template e refill entre workers
+	templateLockID int64 = 0x74656d706c617465
+	warmPoolLockID int64 = 0x7761726d706f6f6c
+)
+
+// errLockBusy indica que outro worker está com o lock
+var errLockBusy = errors.New("operação em andamento em outro worker")
+
+// WarmPool acelera o provisionamento: mantém em cada cluster um database template já migrado
+// (CREATE DATABASE ... TEMPLATE) e um pool de databases pré-criados que o CreateTenant reserva.
+// Usado pelo worker; a reserva em si é feita pelo TenantService (claimWarmDatabase).
+type WarmPool struct {
+	masterPool *pgxpool.Pool
+	clusters   *database.ClusterRegistry
+	placement  *ClusterService
+	migrator   *database.TenantMigrator
+	cfg        *config.Config
+	templates  sync.Map // map[string]int (cluster ID -> versão do template)
+}
+
+func NewWarmPool(
+	masterPool *pgxpool.Pool,
+	clusters *database.ClusterRegistry,
+	placement *ClusterService,
+	migrator *database.TenantMigrator,
+	cfg *config.Config,
+) *WarmPool {
+	return &WarmPool{
+		masterPool: masterPool,
+		clusters:   clusters,
+		placement:  placement,
+		migrator:   migrator,
+		cfg:        cfg,
+	}
+}
+
+// CreateDatabase cria o database no cluster clonando o template (se habilitado e atualizado).
+// Sem template, ou se a clonagem falhar, cria um database vazio; as migrations aplicadas em
+// seguida pelo worker completam o schema. Retorna true se o template foi usado.
+func (p *WarmPool) CreateDatabase(ctx context.Context, cluster *admin.DBCluster, dbName string) (bool, error) {
+	adminPool, err := p.clusters.AdminPool(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+	dbIdent := pgx.Identifier{dbName}.Sanitize()
+
+	if p.cfg.Tenants.TemplateDB {
+		if err := p.EnsureTemplate(ctx, cluster); err != nil {
+			log.Printf("Template indisponível no cluster %s, criando database vazio: %v", cluster.Name, err)
+		} else {
+			stmt := fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", dbIdent, pgx.Identifier{database.TenantTemplateDBName}.Sanitize())
+			_, err := adminPool.Exec(ctx, stmt)
+			if err == nil {
+				return true, nil
+			}
+			if isDuplicateDatabase(err) {
+				return false, nil
+			}
+			// Ex.: template removido ou em uso; força nova verificação na próxima vez
+			p.templates.Delete(cluster.ID.String())
+			log.Printf("Erro ao clonar template no cluster %s, criando database vazio: %v", cluster.Name, err)
+		}
+	}
+
+	if _, err := adminPool.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s", dbIdent)); err != nil && !isDuplicateDatabase(err) {
+		return false, fmt.Errorf("erro ao criar database: %w", err)
+	}
+	return false, nil
+}
+
+// EnsureTemplate cria o template do cluster (se não existir) e o migra até a versão mais recente
+func (p *WarmPool) EnsureTemplate(ctx context.Context, cluster *admin.DBCluster) error {
+	key := cluster.ID.String()
+	latest := p.migrator.LatestVersion()
+	if version, ok := p.templates.Load(key); ok && version.(int) == latest {
+		return nil
+	}
+
+	adminPool, err := p.clusters.AdminPool(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	// Enquanto o template é migrado ninguém pode cloná-lo (CREATE DATABASE recusa templates com conexões)
+	return withAdvisoryLock(ctx, adminPool, templateLockID, func() error {
+		dbIdent := pgx.Identifier{database.TenantTemplateDBName}.Sanitize()
+
+		var exists bool
+		if err := adminPool.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)`, database.TenantTemplateDBName,
+		).Scan(&exists); err != nil {
+			return fmt.Errorf("erro ao verificar template: %w", err)
+		}
+		if !exists {
+			log.Printf("Criando template %s no cluster %s", database.TenantTemplateDBName, cluster.Name)
+			if _, err := adminPool.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s", dbIdent)); err != nil && !isDuplicateDatabase(err) {
+				return fmt.Errorf("erro ao criar template: %w", err)
+			}
+		}
+
+		result, err := p.migrate(ctx, cluster, database.TenantTemplateDBName)
+		if err != nil {
+			return fmt.Errorf("erro ao migrar template: %w", err)
+		}
+		if len(result.Applied) > 0 {
+			log.Printf("Template do cluster %s migrado: versão %d -> %d", cluster.Name, result.From, result.To)
+		}
+
+		// Marca como template (impede DROP acidental e permite clonagem)
+		if _, err := adminPool.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s WITH IS_TEMPLATE true", dbIdent)); err != nil {
+			return fmt.Errorf("erro ao marcar template: %w", err)
+		}
+
+		p.templates.Store(key, result.To)
+		return nil
+	})
+}
+
+// EnsureTemplates atualiza o template de todos os clusters ativos
+func (p *WarmPool) EnsureTemplates(ctx context.Context) []error {
+	if !p.cfg.Tenants.TemplateDB {
+		return nil
+	}
+
+	clusters, err := p.clusters.List(ctx)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for i := range clusters {
+		if clusters[i].Status != shared.ClusterStatusActive {
+			continue
+		}
+		if err := p.EnsureTemplate(ctx, &clusters[i]); err != nil && !errors.Is(err, errLockBusy) {
+			errs = append(errs, fmt.Errorf("cluster %s: %w", clusters[i].Name, err))
+		}
+	}
+	return errs
+}
+
+// Refill completa o warm pool até TENANT_WARM_POOL_SIZE databases disponíveis.
+// Retorna quantos databases foram criados.
+func (p *WarmPool) Refill(ctx context.Context) (int, error) {
+	size := p.cfg.Tenants.WarmPoolSize
+	if size <= 0 {
+		return 0, nil
+	}
+
+	defaultCluster, err := p.clusters.Get(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	lockPool, err := p.clusters.AdminPool(ctx, defaultCluster)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	err = withAdvisoryLock(ctx, lockPool, warmPoolLockID, func() error {
+		var available int
+		if err := p.masterPool.QueryRow(ctx,
+			`SELECT COUNT(*) FROM tenant_db_pool WHERE status IN ($1, $2)`,
+			warmPoolStatusReady, warmPoolStatusMigrating,
+		).Scan(&available); err != nil {
+			return fmt.Errorf("erro ao contar warm pool: %w", err)
+		}
+
+		for ; available < size; available++ {
+			// Databases do pool ficam em clusters compartilhados (sem plano, região ou dedicação)
+			cluster, err := p.placement.selectCluster(ctx, nil, nil)
+			if err != nil {
+				return err
+			}
+
+			dbCode := uuid.New()
+			dbName := database.TenantDBName(dbCode.String())
+			if _, err := p.CreateDatabase(ctx, cluster, dbName); err != nil {
+				return err
+			}
+
+			result, err := p.migrate(ctx, cluster, dbName)
+			if err != nil {
+				return fmt.Errorf("erro ao migrar database %s: %w", dbName, err)
+			}
+
+			now := time.Now()
+			if _, err := p.masterPool.Exec(ctx, `
+				INSERT INTO tenant_db_pool (cluster_id, db_code, schema_version, status, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $5)
+			`, cluster.ID, dbCode, result.To, warmPoolStatusReady, now); err != nil {
+				return fmt.Errorf("erro ao registrar database %s no warm pool: %w", dbName, err)
+			}
+			created++
+		}
+		return nil
+	})
+	if errors.Is(err, errLockBusy) {
+		return created, nil
+	}
+	return created, err
+}
+
+// Remigrate leva os databases disponíveis do pool para a versão de schema mais recente.
+// Retorna quantos databases foram migrados.
+func (p *WarmPool) Remigrate(ctx context.Context) (int, error) {
+	latest := p.migrator.LatestVersion()
+	migrated := 0
+
+	for {
+		// A linha fica 'migrating' para não ser reservada durante a migração
+		var id uuid.UUID
+		var clusterID uuid.UUID
+		var dbCode string
+		err := p.masterPool.QueryRow(ctx, `
+			UPDATE tenant_db_pool SET status = $1, updated_at = $2
+			WHERE id = (
+				SELECT id FROM tenant_db_pool
+				WHERE status = $3 AND schema_version < $4
+				ORDER BY created_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, cluster_id, db_code::text
+		`, warmPoolStatusMigrating, time.Now(), warmPoolStatusReady, latest).Scan(&id, &clusterID, &dbCode)
+		if err == pgx.ErrNoRows {
+			return migrated, nil
+		}
+		if err != nil {
+			return migrated, fmt.Errorf("erro ao buscar databases do warm pool: %w", err)
+		}
+
+		version, migrateErr := p.remigrateEntry(ctx, clusterID, dbCode)
+
+		// Em caso de erro a linha volta para 'ready' na versão anterior e é tentada no próximo ciclo
+		_, err = p.masterPool.Exec(ctx, `
+			UPDATE tenant_db_pool SET status = $1, schema_version = GREATEST(schema_version, $2), updated_at = $3
+			WHERE id = $4
+		`, warmPoolStatusReady, version, time.Now(), id)
+		if migrateErr != nil {
+			return migrated, migrateErr
+		}
+		if err != nil {
+			return migrated, fmt.Errorf("erro ao atualizar warm pool: %w", err)
+		}
+		migrated++
+	}
+}
+
+// remigrateEntry migra um database do pool e retorna a versão alcançada
+func (p *WarmPool) remigrateEntry(ctx context.Context, clusterID uuid.UUID, dbCode string) (int, error) {
+	cluster, err := p.clusters.Get(ctx, &clusterID)
+	if err != nil {
+		return 0, err
+	}
+
+	dbName := database.TenantDBName(dbCode)
+	result, err := p.migrate(ctx, cluster, dbName)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao migrar database %s do warm pool: %w", dbName, err)
+	}
+	return result.To, nil
+}
+
+// Release remove a reserva do tenant após o provisionamento (o database passa a ser do tenant)
+func (p *WarmPool) Release(ctx context.Context, tenantID uuid.UUID) error {
+	_, err := p.masterPool.Exec(ctx, `DELETE FROM tenant_db_pool WHERE tenant_id = $1`, tenantID)
+	return err
+}
+
+// Cleanup destrava re-migrações interrompidas e remove databases reservados por um
+// CreateTenant que não chegou a criar o tenant
+func (p *WarmPool) Cleanup(ctx context.Context) error {
+	now := time.Now()
+	if _, err := p.masterPool.Exec(ctx, `
+		UPDATE tenant_db_pool SET status = $1, updated_at = $2
+		WHERE status = $3 AND updated_at < $4
+	`, warmPoolStatusReady, now, warmPoolStatusMigrating, now.Add(-warmPoolMigratingTTL)); err != nil {
+		return fmt.Errorf("erro ao destravar warm pool: %w", err)
+	}
+
+	rows, err := p.masterPool.Query(ctx, `
+		SELECT p.id, p.cluster_id, p.db_code::text FROM tenant_db_pool p
+		WHERE p.status = $1 AND p.claimed_at < $2
+			AND NOT EXISTS (SELECT 1 FROM tenants t WHERE t.id = p.tenant_id)
+	`, warmPoolStatusClaimed, now.Add(-warmPoolClaimTTL))
+	if err != nil {
+		return fmt.Errorf("erro ao buscar reservas abandonadas: %w", err)
+	}
+
+	type orphan struct {
+		id        uuid.UUID
+		clusterID uuid.UUID
+		dbCode    string
+	}
+	var orphans []orphan
+	for rows.Next() {
+		var o orphan
+		if err := rows.Scan(&o.id, &o.clusterID, &o.dbCode); err != nil {
+			rows.Close()
+			return err
+		}
+		orphans = append(orphans, o)
+	}
+	rows.Close()
+
+	for _, o := range orphans {
+		cluster, err := p.clusters.Get(ctx, &o.clusterID)
+		if err != nil {
+			return err
+		}
+		adminPool, err := p.clusters.AdminPool(ctx, cluster)
+		if err != nil {
+			return err
+		}
+
+		dbIdent := pgx.Identifier{database.TenantDBName(o.dbCode)}.Sanitize()
+		if _, err := adminPool.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", dbIdent)); err != nil {
+			return fmt.Errorf("erro ao remover database abandonado: %w", err)
+		}
+		if _, err := p.masterPool.Exec(ctx, `DELETE FROM tenant_db_pool WHERE id = $1`, o.id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrate aplica as migrations em um database do cluster (conexão direta, fechada ao final)
+func (p *WarmPool) migrate(ctx context.Context, cluster *admin.DBCluster, dbName string) (*database.MigrationResult, error) {
+	dsn, err := p.clusters.AdminDSN(cluster, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	return p.migrator.Up(ctx, pool)
+}
+
+// claimWarmDatabase reserva um database pronto do warm pool para o tenant, respeitando a
+// política de placement. Retorna db_code e cluster do database ("" se não houver).
+func (s *TenantService) claimWarmDatabase(ctx context.Context, tenantID uuid.UUID, req CreateTenantRequest) (string, *uuid.UUID, error) {
+	var region *string
+	switch s.cfg.Tenants.PlacementPolicy {
+	case PlacementByPlan:
+		// Tenants de planos com cluster reservado são posicionados pelo worker
+		var reserved bool
+		if err := s.masterPool.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM db_clusters WHERE plan_id = $1 AND status = 'active' AND NOT dedicated)
+		`, req.PlanID).Scan(&reserved); err != nil {
+			return "", nil, err
+		}
+		if reserved {
+			return "", nil, nil
+		}
+	case PlacementByRegion:
+		if req.Region != "" {
+			var inRegion bool
+			if err := s.masterPool.QueryRow(ctx, `
+				SELECT EXISTS (SELECT 1 FROM db_clusters WHERE region = $1 AND status = 'active' AND NOT dedicated AND plan_id IS NULL)
+			`, req.Region).Scan(&inRegion); err != nil {
+				return "", nil, err
+			}
+			if inRegion {
+				region = &req.Region
+			}
+		}
+	}
+
+	var dbCode string
+	var clusterID uuid.UUID
+	err := s.masterPool.QueryRow(ctx, `
+		UPDATE tenant_db_pool SET status = $1, tenant_id = $2, claimed_at = $3, updated_at = $3
+		WHERE id = (
+			SELECT p.id FROM tenant_db_pool p
+			JOIN db_clusters c ON c.id = p.cluster_id
+			WHERE p.status = $4 AND c.status = 'active'
+				AND (p.cluster_id = $5 OR ($5::uuid IS NULL AND NOT c.dedicated AND c.plan_id IS NULL))
+				AND ($6::text IS NULL OR c.region = $6)
+			ORDER BY p.schema_version DESC, p.created_at
+			LIMIT 1
+			FOR UPDATE OF p SKIP LOCKED
+		)
+		RETURNING db_code::text, cluster_id
+	`, warmPoolStatusClaimed, tenantID, time.Now(), warmPoolStatusReady, req.ClusterID, region).Scan(&dbCode, &clusterID)
+	if err == pgx.ErrNoRows {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	return dbCode, &clusterID, nil
+}
+
+// unclaimWarmDatabase devolve ao pool o database reservado quando a criação do tenant falha
+func (s *TenantService) unclaimWarmDatabase(ctx context.Context, tenantID uuid.UUID) {
+	_, err := s.masterPool.Exec(ctx, `
+		UPDATE tenant_db_pool SET status = $1, tenant_id = NULL, claimed_at = NULL, updated_at = $2
+		WHERE tenant_id = $3
+	`, warmPoolStatusReady, time.Now(), tenantID)
+	if err != nil {
+		fmt.Printf("Warning: erro ao devolver database ao warm pool: %v\n", err)
+	}
+}
+
+// withAdvisoryLock executa fn com um advisory lock de sessão em uma conexão direta do cluster.
+// Retorna errLockBusy se outro processo já tem o lock.
+func withAdvisoryLock(ctx context.Context, pool *pgxpool.Pool, lockID int64, fn func() error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao obter conexão: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&locked); err != nil {
+		return fmt.Errorf("erro ao obter lock: %w", err)
+	}
+	if !locked {
+		return errLockBusy
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	return fn()
+}
+
+// isDuplicateDatabase indica erro 42P04 (database criado em paralelo por outro worker)
+func isDuplicateDatabase(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P04"
+}
@@ -0,0 +1,202 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/saas-multi-database-api/internal/config"
+	"github.com/saas-multi-database-api/internal/models/shared"
+	tenantmodel "github.com/saas-multi-database-api/internal/models/tenant"
+	"github.com/saas-multi-database-api/internal/storage"
+	"github.com/saas-multi-database-api/internal/utils"
+)
+
+var (
+	// ErrExportNotFound is returned when the export does not exist for the tenant
+	ErrExportNotFound = errors.New("export not found")
+	// ErrExportInProgress is returned when the tenant already has a pending or running export
+	ErrExportInProgress = errors.New("an export is already in progress for this tenant")
+	// ErrInvalidDownloadLink is returned for tampered or expired download links
+	ErrInvalidDownloadLink = errors.New("invalid or expired download link")
+	// ErrExportUnavailable is returned when the export file is not (or no longer) available
+	ErrExportUnavailable = errors.New("export file is not available")
+)
+
+const exportColumns = `id, tenant_id, requested_by, requested_by_api_key, status, storage_path, size_bytes, tables, media_count,
+	error, expires_at, started_at, completed_at, created_at`
+
+// ExportService schedules tenant data exports and issues their expiring download links.
+// The export itself is built by the worker (admin.ExportRunner).
+type ExportService struct {
+	masterPool *pgxpool.Pool
+	storage    storage.StorageDriver
+	cfg        *config.Config
+}
+
+func NewExportService(masterPool *pgxpool.Pool, storageDriver storage.StorageDriver, cfg *config.Config) *ExportService {
+	return &ExportService{
+		masterPool: masterPool,
+		storage:    storageDriver,
+		cfg:        cfg,
+	}
+}
+
+// RequestExport schedules a new export (one pending or running export per tenant), requested by a
+// member (userID) or by an API key (apiKeyID)
+func (s *ExportService) RequestExport(ctx context.Context, tenantID uuid.UUID, userID, apiKeyID *uuid.UUID) (*tenantmodel.DataExport, error) {
+	tx, err := s.masterPool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize concurrent requests of the same tenant
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM tenants WHERE id = $1 FOR UPDATE`, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to lock tenant: %w", err)
+	}
+
+	var busy bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM tenant_exports WHERE tenant_id = $1 AND status IN ($2, $3))`,
+		tenantID, shared.JobStatusPending, shared.JobStatusRunning,
+	).Scan(&busy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check exports: %w", err)
+	}
+	if busy {
+		return nil, ErrExportInProgress
+	}
+
+	export, err := scanExport(tx.QueryRow(ctx, `
+		INSERT INTO tenant_exports (tenant_id, requested_by, requested_by_api_key, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+exportColumns,
+		tenantID, userID, apiKeyID, shared.JobStatusPending,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+	return export, nil
+}
+
+// ListExports lists the tenant exports, newest first
+func (s *ExportService) ListExports(ctx context.Context, tenantID uuid.UUID) ([]tenantmodel.DataExport, error) {
+	rows, err := s.masterPool.Query(ctx, `
+		SELECT `+exportColumns+` FROM tenant_exports
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []tenantmodel.DataExport{}
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export: %w", err)
+		}
+		s.signDownload(export)
+		exports = append(exports, *export)
+	}
+	return exports, rows.Err()
+}
+
+// GetExport returns an export of the tenant with a fresh download link when it is available
+func (s *ExportService) GetExport(ctx context.Context, tenantID, exportID uuid.UUID) (*tenantmodel.DataExport, error) {
+	export, err := scanExport(s.masterPool.QueryRow(ctx,
+		`SELECT `+exportColumns+` FROM tenant_exports WHERE id = $1 AND tenant_id = $2`,
+		exportID, tenantID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+
+	s.signDownload(export)
+	return export, nil
+}
+
+// OpenDownload validates a signed link and opens the export file.
+// Returns the file reader and the export (for size and file name).
+func (s *ExportService) OpenDownload(ctx context.Context, exportID uuid.UUID, expires, signature string) (io.ReadCloser, *tenantmodel.DataExport, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+	if !utils.VerifyMessage(s.cfg.Tenants.ExportLinkSecret, downloadMessage(exportID, expiresAt), signature) {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	export, err := scanExport(s.masterPool.QueryRow(ctx,
+		`SELECT `+exportColumns+` FROM tenant_exports WHERE id = $1`, exportID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	if export.Status != shared.JobStatusCompleted || export.StoragePath == nil ||
+		(export.ExpiresAt != nil && !export.ExpiresAt.After(time.Now())) {
+		return nil, nil, ErrExportUnavailable
+	}
+
+	reader, err := s.storage.GetReader(ctx, *export.StoragePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+	return reader, export, nil
+}
+
+// signDownload fills the download link of a completed export. The link expires after
+// ExportLinkTTLMinutes, or earlier if the file itself expires first.
+func (s *ExportService) signDownload(export *tenantmodel.DataExport) {
+	if export.Status != shared.JobStatusCompleted || export.StoragePath == nil {
+		return
+	}
+	if export.ExpiresAt != nil && !export.ExpiresAt.After(time.Now()) {
+		return // File is waiting to be removed by the worker
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.cfg.Tenants.ExportLinkTTLMinutes) * time.Minute)
+	if export.ExpiresAt != nil && export.ExpiresAt.Before(expiresAt) {
+		expiresAt = *export.ExpiresAt
+	}
+
+	signature := utils.SignMessage(s.cfg.Tenants.ExportLinkSecret, downloadMessage(export.ID, expiresAt.Unix()))
+	url := fmt.Sprintf("/api/v1/exports/%s/download?expires=%d&signature=%s", export.ID, expiresAt.Unix(), signature)
+	export.DownloadURL = &url
+	export.DownloadExpiresAt = &expiresAt
+}
+
+// downloadMessage is the signed content of a download link
+func downloadMessage(exportID uuid.UUID, expiresAt int64) string {
+	return fmt.Sprintf("%s:%d", exportID, expiresAt)
+}
+
+func scanExport(row pgx.Row) (*tenantmodel.DataExport, error) {
+	var e tenantmodel.DataExport
+	err := row.Scan(
+		&e.ID, &e.TenantID, &e.RequestedBy, &e.RequestedByAPIKey, &e.Status, &e.StoragePath, &e.SizeBytes, &e.Tables, &e.MediaCount,
+		&e.Error, &e.ExpiresAt, &e.StartedAt, &e.CompletedAt, &e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"io"
+	"path"
+	"strings"
 )
 
 // StorageDriver defines the interface for different storage backends
@@ -11,9 +13,17 @@ type StorageDriver interface {
 	// Returns the storage path and public URL (if applicable)
 	Upload(ctx context.Context, file io.Reader, path string) (storagePath string, publicURL string, err error)
 
+	// UploadPrivate uploads a file that must not be publicly readable (e.g. tenant backups)
+	// Returns the storage path; the file is only reachable through GetReader
+	UploadPrivate(ctx context.Context, file io.Reader, path string) (storagePath string, err error)
+
 	// Delete removes a file from storage
 	Delete(ctx context.Context, path string) error
 
+	// DeletePrefix removes every file under a prefix (e.g. "{tenant_uuid}/")
+	// Used when a tenant is purged
+	DeletePrefix(ctx context.Context, prefix string) error
+
 	// GetPublicURL returns the public URL for a file
 	// For local storage, this returns the relative path
 	// For cloud storage (S3/R2), this returns the full CDN URL
@@ -27,6 +37,22 @@ type StorageDriver interface {
 	GetReader(ctx context.Context, path string) (io.ReadCloser, error)
 }
 
+// PrivatePrefixes are the storage prefixes written with UploadPrivate
+// Local storage is served by the tenant API, which must refuse these paths
+var PrivatePrefixes = []string{"backups/", "exports/"}
+
+// IsPrivatePath reports whether a storage path belongs to a private prefix
+func IsPrivatePath(p string) bool {
+	// Clean first so "a/../backups/x" and "//backups/x" are caught too
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	for _, prefix := range PrivatePrefixes {
+		if strings.HasPrefix(p+"/", prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // Config holds the storage configuration
 type Config struct {
 	Driver string // local, s3, r2
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // LocalStorage implements StorageDriver for local filesystem
@@ -48,6 +49,12 @@ func (s *LocalStorage) Upload(ctx context.Context, file io.Reader, path string)
 	return path, publicURL, nil
 }
 
+// UploadPrivate writes a file under the uploads path; private prefixes are not served by the tenant API
+func (s *LocalStorage) UploadPrivate(ctx context.Context, file io.Reader, path string) (string, error) {
+	storagePath, _, err := s.Upload(ctx, file, path)
+	return storagePath, err
+}
+
 // Delete removes a file from local filesystem
 func (s *LocalStorage) Delete(ctx context.Context, path string) error {
 	fullPath := filepath.Join(s.basePath, path)
@@ -63,6 +70,23 @@ func (s *LocalStorage) Delete(ctx context.Context, path string) error {
 	return nil
 }
 
+// DeletePrefix removes a directory tree from local filesystem
+func (s *LocalStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" || strings.Contains(prefix, "..") {
+		return fmt.Errorf("invalid prefix: %q", prefix)
+	}
+
+	fullPath := filepath.Join(s.basePath, prefix)
+	if err := os.RemoveAll(fullPath); err != nil {
+		return fmt.Errorf("failed to delete prefix: %w", err)
+	}
+
+	s.removeEmptyDirs(filepath.Dir(fullPath))
+
+	return nil
+}
+
 // GetPublicURL returns the public URL for local storage
 func (s *LocalStorage) GetPublicURL(path string) string {
 	return fmt.Sprintf("/uploads/%s", path)
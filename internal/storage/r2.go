@@ -109,6 +109,35 @@ func (r *R2Storage) Upload(ctx context.Context, file io.Reader, path string) (st
 	return path, publicURL, nil
 }
 
+// UploadPrivate uploads a file to R2 without public-read ACL
+// R2 has no object ACLs: keep the bucket without public access (or use a custom domain limited to media)
+func (r *R2Storage) UploadPrivate(ctx context.Context, file io.Reader, path string) (string, error) {
+	// Files (io.ReadSeeker) are streamed; other readers are buffered like Upload
+	body, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	// Clean path (remove leading slash if present)
+	path = strings.TrimPrefix(path, "/")
+
+	_, err := r.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(r.bucket),
+		Key:         aws.String(path),
+		Body:        body,
+		ContentType: aws.String(getContentType(path)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to R2: %w", err)
+	}
+
+	return path, nil
+}
+
 // Delete removes a file from R2
 func (r *R2Storage) Delete(ctx context.Context, path string) error {
 	// Clean path (remove leading slash if present)
@@ -125,6 +154,14 @@ func (r *R2Storage) Delete(ctx context.Context, path string) error {
 	return nil
 }
 
+// DeletePrefix removes every object under a prefix from R2
+func (r *R2Storage) DeletePrefix(ctx context.Context, prefix string) error {
+	if err := deleteObjectsWithPrefix(ctx, r.client, r.bucket, prefix); err != nil {
+		return fmt.Errorf("failed to delete prefix from R2: %w", err)
+	}
+	return nil
+}
+
 // GetPublicURL returns the public URL for R2 storage
 func (r *R2Storage) GetPublicURL(path string) string {
 	// Clean path (remove leading slash if present)
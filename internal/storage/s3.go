@@ -88,6 +88,34 @@ func (s *S3Storage) Upload(ctx context.Context, file io.Reader, path string) (st
 	return path, publicURL, nil
 }
 
+// UploadPrivate uploads a file to S3 without public-read ACL
+func (s *S3Storage) UploadPrivate(ctx context.Context, file io.Reader, path string) (string, error) {
+	// Files (io.ReadSeeker) are streamed; other readers are buffered like Upload
+	body, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	// Clean path (remove leading slash if present)
+	path = strings.TrimPrefix(path, "/")
+
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(path),
+		Body:        body,
+		ContentType: aws.String(getContentType(path)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to S3: %w", err)
+	}
+
+	return path, nil
+}
+
 // Delete removes a file from S3
 func (s *S3Storage) Delete(ctx context.Context, path string) error {
 	// Clean path (remove leading slash if present)
@@ -104,6 +132,14 @@ func (s *S3Storage) Delete(ctx context.Context, path string) error {
 	return nil
 }
 
+// DeletePrefix removes every object under a prefix from S3
+func (s *S3Storage) DeletePrefix(ctx context.Context, prefix string) error {
+	if err := deleteObjectsWithPrefix(ctx, s.client, s.bucket, prefix); err != nil {
+		return fmt.Errorf("failed to delete prefix from S3: %w", err)
+	}
+	return nil
+}
+
 // GetPublicURL returns the public URL for S3 storage
 func (s *S3Storage) GetPublicURL(path string) string {
 	// Clean path (remove leading slash if present)
@@ -147,6 +183,53 @@ func (s *S3Storage) GetReader(ctx context.Context, path string) (io.ReadCloser,
 	return result.Body, nil
 }
 
+// deleteObjectsWithPrefix lists and deletes objects under a prefix in batches (S3 and R2)
+func deleteObjectsWithPrefix(ctx context.Context, client *s3.Client, bucket, prefix string) error {
+	// Clean prefix and never allow deleting the whole bucket
+	prefix = strings.TrimPrefix(prefix, "/")
+	if prefix == "" {
+		return fmt.Errorf("empty prefix")
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(page.Contents) == 0 {
+			continue
+		}
+
+		// Each page has at most 1000 keys, the DeleteObjects limit
+		objects := make([]types.ObjectIdentifier, 0, len(page.Contents))
+		for _, obj := range page.Contents {
+			objects = append(objects, types.ObjectIdentifier{Key: obj.Key})
+		}
+
+		output, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(output.Errors) > 0 {
+			return fmt.Errorf("failed to delete %d objects (first: %s)", len(output.Errors), aws.ToString(output.Errors[0].Key))
+		}
+	}
+
+	return nil
+}
+
 // getContentType returns the MIME type based on file extension
 func getContentType(path string) string {
 	if strings.HasSuffix(path, ".jpg") || strings.HasSuffix(path, ".jpeg") {
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// APIKeyPrefix marca as chaves de API do tenant; o middleware as distingue de um JWT por ele
+const APIKeyPrefix = "tk_"
+
+// GenerateAPIKey gera uma chave de API (tk_<id>_<segredo>) e o prefixo exibido nas listagens
+func GenerateAPIKey() (key, prefix string, err error) {
+	id, err := GenerateSecret(4)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := GenerateSecret(24)
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix = APIKeyPrefix + id
+	return prefix + "_" + secret, prefix, nil
+}
+
+// IsAPIKey informa se a credencial do header Authorization é uma chave de API
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// NormalizeCIDR aceita um IP ou um CIDR e retorna o CIDR canônico (IP único vira /32 ou /128)
+func NormalizeCIDR(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if addr, err := netip.ParseAddr(value); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+	}
+
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return "", fmt.Errorf("IP ou CIDR inválido: %s", value)
+	}
+	return prefix.Masked().String(), nil
+}
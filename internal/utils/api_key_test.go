@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	format := regexp.MustCompile(`^tk_[0-9a-f]{8}_[0-9a-f]{48}$`)
+	seen := make(map[string]bool)
+
+	for i := 0; i < 20; i++ {
+		key, prefix, err := GenerateAPIKey()
+		if err != nil {
+			t.Fatalf("GenerateAPIKey: %v", err)
+		}
+		if !format.MatchString(key) {
+			t.Fatalf("key %q does not match tk_<id>_<segredo>", key)
+		}
+		if !strings.HasPrefix(key, prefix+"_") || len(prefix) != len(APIKeyPrefix)+8 {
+			t.Errorf("prefix %q does not identify key %q", prefix, key)
+		}
+		if !IsAPIKey(key) {
+			t.Errorf("IsAPIKey(%q) = false", key)
+		}
+		if seen[key] {
+			t.Fatalf("duplicate key %q", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestIsAPIKey(t *testing.T) {
+	tests := []struct {
+		credential string
+		want       bool
+	}{
+		{credential: "tk_0a1b2c3d_" + strings.Repeat("f", 48), want: true},
+		{credential: "eyJhbGciOiJFZERTQSIsImtpZCI6IjEifQ.e30.sig", want: false},
+		{credential: "TK_0a1b2c3d_abc", want: false},
+		{credential: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsAPIKey(tt.credential); got != tt.want {
+			t.Errorf("IsAPIKey(%q) = %v, want %v", tt.credential, got, tt.want)
+		}
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{token: "abc", want: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{token: "", want: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}
+
+	for _, tt := range tests {
+		if got := HashToken(tt.token); got != tt.want {
+			t.Errorf("HashToken(%q) = %s, want %s", tt.token, got, tt.want)
+		}
+	}
+
+	key, _, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	hash := HashToken(key)
+	if hash != HashToken(key) {
+		t.Error("HashToken is not deterministic")
+	}
+	if strings.Contains(hash, key) || hash == HashToken(key+"x") {
+		t.Error("hash leaks or does not depend on the whole key")
+	}
+}
+
+func TestNormalizeCIDR(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "203.0.113.10", want: "203.0.113.10/32"},
+		{in: " 203.0.113.10 ", want: "203.0.113.10/32"},
+		{in: "203.0.113.0/24", want: "203.0.113.0/24"},
+		{in: "203.0.113.77/24", want: "203.0.113.0/24"},
+		{in: "10.0.0.0/8", want: "10.0.0.0/8"},
+		{in: "::ffff:203.0.113.10", want: "203.0.113.10/32"},
+		{in: "2001:db8::1", want: "2001:db8::1/128"},
+		{in: "2001:db8::1/32", want: "2001:db8::/32"},
+		{in: "0.0.0.0/0", want: "0.0.0.0/0"},
+		{in: "203.0.113.10/33", wantErr: true},
+		{in: "203.0.113", wantErr: true},
+		{in: "example.com", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeCIDR(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeCIDR(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeCIDR(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
+	"net"
+	"net/mail"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,9 +19,40 @@ import (
 // Claims representa os claims do JWT
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"`
+	// Login concluído com o segundo fator (TOTP ou código de recuperação)
+	MFA bool `json:"mfa,omitempty"`
+	// Tenant cujo SSO (OIDC) abriu a sessão
+	SSO string `json:"sso,omitempty"`
+	// Segundo fator informado pelo IdP do SSO (claim amr): vale só no tenant do SSO
+	SSOMFA bool `json:"sso_mfa,omitempty"`
+	// Administrador agindo em nome do usuário (impersonação)
+	Act *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Actor identifica o administrador (sys_user) de um token de impersonação, o único tenant que o
+// token acessa e se ele é somente leitura
+type Actor struct {
+	SysUserID string `json:"sub"`
+	TenantID  string `json:"tenant_id"`
+	ReadOnly  bool   `json:"read_only"`
+}
+
+// TokenOptions são os claims opcionais de um access token
+type TokenOptions struct {
+	// MFA marca a sessão como autenticada com o segundo fator
+	MFA bool
+	// SSOTenantID marca a sessão como aberta pelo SSO (OIDC) desse tenant
+	SSOTenantID string
+	// SSOMFA marca o segundo fator feito no IdP do SSO, aceito só pelo tenant SSOTenantID
+	SSOMFA bool
+	// Actor marca o token como impersonação por um administrador
+	Actor *Actor
+	// TokenID define o jti (vazio = novo) e TTL a validade (zero = JWT_ACCESS_TOKEN_MINUTES)
+	TokenID string
+	TTL     time.Duration
+}
+
 // HashPassword cria um hash bcrypt da senha
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -30,65 +65,75 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateJWT gera um token JWT para um usuário
-// Deprecated: Use GenerateAdminJWT or GenerateTenantJWT instead
-func GenerateJWT(userID uuid.UUID, cfg *config.Config) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(cfg.JWT.ExpirationHours) * time.Hour)
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
 
-	claims := &Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// CheckPasswordHashConstantTime é o CheckPasswordHash do login: com hash vazio (email não
+// cadastrado) compara com um hash fictício e retorna false, para que a resposta leve o mesmo
+// tempo e não revele quais contas existem
+func CheckPasswordHashConstantTime(password, hash string) bool {
+	if hash != "" {
+		return CheckPasswordHash(password, hash)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(cfg.JWT.Secret))
-	if err != nil {
-		return "", err
-	}
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+	return false
+}
 
-	return tokenString, nil
+// AccessTokenTTL retorna a validade dos access tokens das APIs Admin e Tenant (JWT_ACCESS_TOKEN_MINUTES)
+func AccessTokenTTL(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.JWT.AccessTokenMinutes) * time.Minute
 }
 
-// GenerateAdminJWT gera um token JWT para Admin API (Control Plane)
-func GenerateAdminJWT(userID uuid.UUID, cfg *config.Config) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(cfg.JWT.ExpirationHours) * time.Hour)
+// GenerateAdminJWT gera um access token JWT (EdDSA, com kid e jti) para Admin API (Control Plane)
+func GenerateAdminJWT(userID uuid.UUID, opts TokenOptions, keys *JWTKeySet, cfg *config.Config) (string, error) {
+	return generateJWT(userID, "admin-api", opts, keys, cfg)
+}
 
-	claims := &Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "admin-api",
-		},
-	}
+// GenerateTenantJWT gera um access token JWT (EdDSA, com kid e jti) para Tenant API (Data Plane)
+func GenerateTenantJWT(userID uuid.UUID, opts TokenOptions, keys *JWTKeySet, cfg *config.Config) (string, error) {
+	return generateJWT(userID, "tenant-api", opts, keys, cfg)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(cfg.AdminAPI.JWTSecret))
+func generateJWT(userID uuid.UUID, issuer string, opts TokenOptions, keys *JWTKeySet, cfg *config.Config) (string, error) {
+	key, err := keys.SigningKey()
 	if err != nil {
 		return "", err
 	}
 
-	return tokenString, nil
-}
+	ttl := AccessTokenTTL(cfg)
+	if opts.TTL > 0 {
+		ttl = opts.TTL
+	}
+	expirationTime := time.Now().Add(ttl)
 
-// GenerateTenantJWT gera um token JWT para Tenant API (Data Plane)
-func GenerateTenantJWT(userID uuid.UUID, cfg *config.Config) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(cfg.JWT.ExpirationHours) * time.Hour)
+	tokenID := opts.TokenID
+	if tokenID == "" {
+		tokenID = uuid.NewString()
+	}
 
 	claims := &Claims{
 		UserID: userID,
+		MFA:    opts.MFA,
+		SSO:    opts.SSOTenantID,
+		SSOMFA: opts.SSOMFA,
+		Act:    opts.Actor,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "tenant-api",
+			Issuer:    issuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(cfg.TenantAPI.JWTSecret))
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(key.PrivateKey)
 	if err != nil {
 		return "", err
 	}
@@ -96,65 +141,29 @@ func GenerateTenantJWT(userID uuid.UUID, cfg *config.Config) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateJWT valida um token JWT e retorna os claims
-// Deprecated: Use ValidateAdminJWT or ValidateTenantJWT instead
-func ValidateJWT(tokenString string, cfg *config.Config) (*Claims, error) {
-	claims := &Claims{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(cfg.JWT.Secret), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
-	}
-
-	return claims, nil
+// ValidateAdminJWT valida um token JWT do Admin API com a chave do kid
+func ValidateAdminJWT(ctx context.Context, tokenString string, keys *JWTKeySet) (*Claims, error) {
+	return validateJWT(ctx, tokenString, "admin-api", keys)
 }
 
-// ValidateAdminJWT valida um token JWT do Admin API
-func ValidateAdminJWT(tokenString string, cfg *config.Config) (*Claims, error) {
-	claims := &Claims{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(cfg.AdminAPI.JWTSecret), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
-	}
-
-	// Validar issuer
-	if claims.Issuer != "admin-api" {
-		return nil, fmt.Errorf("invalid token issuer")
-	}
-
-	return claims, nil
+// ValidateTenantJWT valida um token JWT do Tenant API com a chave do kid
+func ValidateTenantJWT(ctx context.Context, tokenString string, keys *JWTKeySet) (*Claims, error) {
+	return validateJWT(ctx, tokenString, "tenant-api", keys)
 }
 
-// ValidateTenantJWT valida um token JWT do Tenant API
-func ValidateTenantJWT(tokenString string, cfg *config.Config) (*Claims, error) {
+func validateJWT(ctx context.Context, tokenString, issuer string, keys *JWTKeySet) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(cfg.TenantAPI.JWTSecret), nil
+		kid, _ := token.Header["kid"].(string)
+		publicKey, ok := keys.PublicKey(ctx, kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %q", kid)
+		}
+		return publicKey, nil
 	})
 
 	if err != nil {
@@ -165,8 +174,8 @@ func ValidateTenantJWT(tokenString string, cfg *config.Config) (*Claims, error)
 		return nil, fmt.Errorf("invalid token")
 	}
 
-	// Validar issuer
-	if claims.Issuer != "tenant-api" {
+	// Validar issuer (as chaves de cada API são distintas; o issuer garante a separação)
+	if claims.Issuer != issuer {
 		return nil, fmt.Errorf("invalid token issuer")
 	}
 
@@ -224,7 +233,22 @@ func NormalizeEmail(email string) string {
 	return strings.ToLower(strings.TrimSpace(email))
 }
 
+// ValidEmail informa se o email (já normalizado) é um endereço simples válido, sem nome nem <>
+func ValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email && strings.Contains(email, "@")
+}
+
 // NormalizeDomainPrefix normaliza um prefixo de domínio
 func NormalizeDomainPrefix(prefix string) string {
 	return strings.ToLower(strings.TrimSpace(prefix))
 }
+
+// NormalizeHost normaliza um host (header Host ou domínio customizado): lowercase, sem porta e sem ponto final
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestValidEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{email: "ana@example.com", want: true},
+		{email: "ana.silva+sso@sub.example.com.br", want: true},
+		{email: "", want: false},
+		{email: "ana", want: false},
+		{email: "@example.com", want: false},
+		{email: "ana@", want: false},
+		{email: "Ana <ana@example.com>", want: false},
+		{email: "<ana@example.com>", want: false},
+		{email: "ana@example.com, bruno@example.com", want: false},
+		{email: " ana@example.com", want: false},
+		{email: "ana@exa mple.com", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidEmail(tt.email); got != tt.want {
+			t.Errorf("ValidEmail(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoSigningKey é retornado quando a API não tem chave ativa para assinar tokens
+var ErrNoSigningKey = errors.New("nenhuma chave de assinatura JWT ativa")
+
+// Intervalo mínimo entre recargas disparadas por um kid desconhecido
+const jwtKeyReloadThrottle = 10 * time.Second
+
+// JWTKey é uma chave Ed25519 de uma API, identificada pelo kid
+type JWTKey struct {
+	ID        string
+	PublicKey ed25519.PublicKey
+	// nil para chaves em retirada (só validam tokens já emitidos)
+	PrivateKey ed25519.PrivateKey
+	CreatedAt  time.Time
+}
+
+// JWTKeyLoader retorna as chaves aceitas por uma API
+type JWTKeyLoader func(ctx context.Context) ([]JWTKey, error)
+
+// JWK é a chave pública publicada no JWKS (RFC 8037, OKP/Ed25519)
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+// JWKS é o documento servido em /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWTKeySet guarda as chaves de assinatura de uma API (admin-api ou tenant-api).
+// A chave mais recente com chave privada assina; todas as carregadas validam, o que permite
+// rotacionar sem invalidar os tokens emitidos com a chave anterior.
+type JWTKeySet struct {
+	loader JWTKeyLoader
+
+	mu       sync.RWMutex
+	signing  *JWTKey
+	keys     map[string]JWTKey
+	loadedAt time.Time
+}
+
+func NewJWTKeySet(loader JWTKeyLoader) *JWTKeySet {
+	return &JWTKeySet{
+		loader: loader,
+		keys:   make(map[string]JWTKey),
+	}
+}
+
+// Reload recarrega as chaves (após uma rotação)
+func (s *JWTKeySet) Reload(ctx context.Context) error {
+	keys, err := s.loader(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Mais recente primeiro: é a que assina
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+
+	byID := make(map[string]JWTKey, len(keys))
+	var signing *JWTKey
+	for i := range keys {
+		byID[keys[i].ID] = keys[i]
+		if signing == nil && keys[i].PrivateKey != nil {
+			signing = &keys[i]
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = byID
+	s.signing = signing
+	s.loadedAt = time.Now()
+	return nil
+}
+
+// RunRefresh recarrega as chaves periodicamente até ctx ser cancelado (fallback do pub/sub de rotação)
+func (s *JWTKeySet) RunRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				log.Printf("Erro ao recarregar chaves JWT: %v", err)
+			}
+		}
+	}
+}
+
+// SigningKey retorna a chave usada para assinar novos tokens
+func (s *JWTKeySet) SigningKey() (*JWTKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.signing == nil {
+		return nil, ErrNoSigningKey
+	}
+	return s.signing, nil
+}
+
+// PublicKey retorna a chave pública de um kid. Um kid desconhecido pode ter sido criado por outra
+// réplica que já rotacionou: recarrega (no máximo a cada jwtKeyReloadThrottle) antes de recusar.
+func (s *JWTKeySet) PublicKey(ctx context.Context, kid string) (ed25519.PublicKey, bool) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	stale := time.Since(s.loadedAt) > jwtKeyReloadThrottle
+	s.mu.RUnlock()
+
+	if ok {
+		return key.PublicKey, true
+	}
+	if !stale {
+		return nil, false
+	}
+	if err := s.Reload(ctx); err != nil {
+		log.Printf("Erro ao recarregar chaves JWT: %v", err)
+		return nil, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok = s.keys[kid]
+	return key.PublicKey, ok
+}
+
+// JWKS retorna as chaves públicas aceitas, para verificação por outros serviços
+func (s *JWTKeySet) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwks.Keys = append(jwks.Keys, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key.PublicKey),
+			Kid: key.ID,
+			Use: "sig",
+			Alg: "EdDSA",
+		})
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+// GenerateJWTKey gera uma nova chave Ed25519 com kid aleatório
+func GenerateJWTKey() (*JWTKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar chave Ed25519: %w", err)
+	}
+	kid, err := GenerateSecret(8)
+	if err != nil {
+		return nil, err
+	}
+	return &JWTKey{
+		ID:         kid,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+		CreatedAt:  time.Now(),
+	}, nil
+}
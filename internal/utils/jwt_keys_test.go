@@ -0,0 +1,338 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/saas-multi-database-api/internal/config"
+)
+
+// memoryKeys é um JWTKeyLoader sobre uma lista de chaves em memória (jwt_signing_keys em produção)
+type memoryKeys struct {
+	mu    sync.Mutex
+	keys  []JWTKey
+	loads int
+}
+
+func (m *memoryKeys) load(ctx context.Context) ([]JWTKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loads++
+	return append([]JWTKey(nil), m.keys...), nil
+}
+
+func (m *memoryKeys) set(keys ...JWTKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys = keys
+}
+
+func testJWTKey(t *testing.T, createdAt time.Time) JWTKey {
+	t.Helper()
+	key, err := GenerateJWTKey()
+	if err != nil {
+		t.Fatalf("GenerateJWTKey: %v", err)
+	}
+	key.CreatedAt = createdAt
+	return *key
+}
+
+func retired(key JWTKey) JWTKey {
+	key.PrivateKey = nil
+	return key
+}
+
+func testJWTConfig() *config.Config {
+	return &config.Config{JWT: config.JWTConfig{AccessTokenMinutes: 15}}
+}
+
+func newTestKeySet(t *testing.T, keys ...JWTKey) (*JWTKeySet, *memoryKeys) {
+	t.Helper()
+	loader := &memoryKeys{keys: keys}
+	set := NewJWTKeySet(loader.load)
+	if err := set.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	return set, loader
+}
+
+func tokenKid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestJWTKeySetSigningKey(t *testing.T) {
+	now := time.Now()
+	oldKey := testJWTKey(t, now.Add(-48*time.Hour))
+	newKey := testJWTKey(t, now.Add(-time.Hour))
+	newestRetired := retired(testJWTKey(t, now))
+
+	tests := []struct {
+		name    string
+		keys    []JWTKey
+		wantKid string
+		wantErr error
+	}{
+		{name: "single key", keys: []JWTKey{oldKey}, wantKid: oldKey.ID},
+		{name: "most recent key signs", keys: []JWTKey{oldKey, newKey}, wantKid: newKey.ID},
+		{name: "order of the loader does not matter", keys: []JWTKey{newKey, oldKey}, wantKid: newKey.ID},
+		{name: "retired keys never sign", keys: []JWTKey{oldKey, newestRetired}, wantKid: oldKey.ID},
+		{name: "only retired keys", keys: []JWTKey{newestRetired}, wantErr: ErrNoSigningKey},
+		{name: "no keys", wantErr: ErrNoSigningKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, _ := newTestKeySet(t, tt.keys...)
+			key, err := set.SigningKey()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SigningKey err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && key.ID != tt.wantKid {
+				t.Errorf("SigningKey kid = %s, want %s", key.ID, tt.wantKid)
+			}
+		})
+	}
+}
+
+func TestJWTRotationKeepsIssuedTokensValid(t *testing.T) {
+	ctx := context.Background()
+	cfg := testJWTConfig()
+	userID := uuid.New()
+	now := time.Now()
+	oldKey := testJWTKey(t, now.Add(-time.Hour))
+	newKey := testJWTKey(t, now)
+
+	set, loader := newTestKeySet(t, oldKey)
+	oldToken, err := GenerateTenantJWT(userID, TokenOptions{}, set, cfg)
+	if err != nil {
+		t.Fatalf("GenerateTenantJWT: %v", err)
+	}
+
+	// Rotação: a chave nova assina, a antiga só valida até ser removida
+	loader.set(retired(oldKey), newKey)
+	if err := set.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	newToken, err := GenerateTenantJWT(userID, TokenOptions{}, set, cfg)
+	if err != nil {
+		t.Fatalf("GenerateTenantJWT after rotation: %v", err)
+	}
+	if kid := tokenKid(t, newToken); kid != newKey.ID {
+		t.Errorf("new token kid = %s, want %s", kid, newKey.ID)
+	}
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		claims, err := ValidateTenantJWT(ctx, token, set)
+		if err != nil {
+			t.Fatalf("%s token: ValidateTenantJWT: %v", name, err)
+		}
+		if claims.UserID != userID {
+			t.Errorf("%s token: user_id = %s, want %s", name, claims.UserID, userID)
+		}
+	}
+
+	// Depois que a chave antiga é removida os tokens dela são recusados
+	loader.set(newKey)
+	if err := set.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, err := ValidateTenantJWT(ctx, oldToken, set); err == nil {
+		t.Error("token of a removed key was accepted")
+	}
+	if _, err := ValidateTenantJWT(ctx, newToken, set); err != nil {
+		t.Errorf("new token after removing the old key: %v", err)
+	}
+}
+
+func TestJWTUnknownKidReloadsKeys(t *testing.T) {
+	ctx := context.Background()
+	cfg := testJWTConfig()
+	firstKey := testJWTKey(t, time.Now().Add(-time.Hour))
+	otherReplicaKey := testJWTKey(t, time.Now())
+
+	// Outra réplica rotacionou e assinou com uma chave que esta ainda não carregou
+	signer, _ := newTestKeySet(t, otherReplicaKey)
+	token, err := GenerateAdminJWT(uuid.New(), TokenOptions{}, signer, cfg)
+	if err != nil {
+		t.Fatalf("GenerateAdminJWT: %v", err)
+	}
+
+	set, loader := newTestKeySet(t, firstKey)
+	loader.set(firstKey, otherReplicaKey)
+
+	// Dentro do intervalo mínimo o kid desconhecido é recusado sem recarregar
+	loads := loader.loads
+	if _, err := ValidateAdminJWT(ctx, token, set); err == nil {
+		t.Fatal("unknown kid accepted before the reload throttle expired")
+	}
+	if loader.loads != loads {
+		t.Fatalf("reloaded %d times within the throttle window", loader.loads-loads)
+	}
+
+	set.mu.Lock()
+	set.loadedAt = time.Now().Add(-2 * jwtKeyReloadThrottle)
+	set.mu.Unlock()
+
+	if _, err := ValidateAdminJWT(ctx, token, set); err != nil {
+		t.Fatalf("ValidateAdminJWT after reload: %v", err)
+	}
+	if loader.loads != loads+1 {
+		t.Errorf("loads = %d, want %d", loader.loads, loads+1)
+	}
+}
+
+func TestValidateJWTRejectsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	cfg := testJWTConfig()
+	key := testJWTKey(t, time.Now())
+	set, _ := newTestKeySet(t, key)
+	otherSet, _ := newTestKeySet(t, testJWTKey(t, time.Now()))
+	userID := uuid.New()
+
+	valid, err := GenerateTenantJWT(userID, TokenOptions{}, set, cfg)
+	if err != nil {
+		t.Fatalf("GenerateTenantJWT: %v", err)
+	}
+	adminToken, err := GenerateAdminJWT(userID, TokenOptions{}, set, cfg)
+	if err != nil {
+		t.Fatalf("GenerateAdminJWT: %v", err)
+	}
+	expired, err := GenerateTenantJWT(userID, TokenOptions{TTL: time.Nanosecond}, set, cfg)
+	if err != nil {
+		t.Fatalf("GenerateTenantJWT: %v", err)
+	}
+	foreign, err := GenerateTenantJWT(userID, TokenOptions{}, otherSet, cfg)
+	if err != nil {
+		t.Fatalf("GenerateTenantJWT: %v", err)
+	}
+
+	// Token HS256 assinado com a chave pública como segredo (confusão de algoritmo)
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		UserID:           userID,
+		RegisteredClaims: jwt.RegisteredClaims{Issuer: "tenant-api", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	})
+	hmacToken.Header["kid"] = key.ID
+	confused, err := hmacToken.SignedString([]byte(key.PublicKey))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	parts := strings.Split(valid, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"user_id":"`+uuid.NewString()+`","iss":"tenant-api"}`)) + "." + parts[2]
+
+	time.Sleep(time.Millisecond)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "admin token on the tenant API", token: adminToken},
+		{name: "expired", token: expired},
+		{name: "key of another API", token: foreign},
+		{name: "HS256 with the public key", token: confused},
+		{name: "tampered payload", token: tampered},
+		{name: "garbage", token: "not-a-jwt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ValidateTenantJWT(ctx, tt.token, set); err == nil {
+				t.Error("token accepted")
+			}
+		})
+	}
+
+	claims, err := ValidateTenantJWT(ctx, valid, set)
+	if err != nil || claims.UserID != userID {
+		t.Fatalf("valid token: claims = %+v, err = %v", claims, err)
+	}
+}
+
+func TestJWTOptionalClaimsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	set, _ := newTestKeySet(t, testJWTKey(t, time.Now()))
+	tenantID := uuid.NewString()
+
+	token, err := GenerateTenantJWT(uuid.New(), TokenOptions{
+		MFA:         true,
+		SSOTenantID: tenantID,
+		SSOMFA:      true,
+		Actor:       &Actor{SysUserID: uuid.NewString(), TenantID: tenantID, ReadOnly: true},
+		TokenID:     "fixed-jti",
+	}, set, testJWTConfig())
+	if err != nil {
+		t.Fatalf("GenerateTenantJWT: %v", err)
+	}
+
+	claims, err := ValidateTenantJWT(ctx, token, set)
+	if err != nil {
+		t.Fatalf("ValidateTenantJWT: %v", err)
+	}
+	if !claims.MFA || claims.SSO != tenantID || !claims.SSOMFA || claims.ID != "fixed-jti" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if claims.Act == nil || claims.Act.TenantID != tenantID || !claims.Act.ReadOnly {
+		t.Errorf("act = %+v", claims.Act)
+	}
+}
+
+func TestJWKSVerifiesIssuedTokens(t *testing.T) {
+	now := time.Now()
+	signing := testJWTKey(t, now)
+	retiring := retired(testJWTKey(t, now.Add(-time.Hour)))
+	set, _ := newTestKeySet(t, signing, retiring)
+
+	jwks := set.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2", len(jwks.Keys))
+	}
+	if jwks.Keys[0].Kid > jwks.Keys[1].Kid {
+		t.Errorf("JWKS keys not sorted by kid: %s, %s", jwks.Keys[0].Kid, jwks.Keys[1].Kid)
+	}
+
+	byKid := make(map[string]ed25519.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.Alg != "EdDSA" || jwk.Use != "sig" {
+			t.Errorf("jwk %s = %+v", jwk.Kid, jwk)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			t.Fatalf("jwk %s: invalid x (%v)", jwk.Kid, err)
+		}
+		byKid[jwk.Kid] = ed25519.PublicKey(x)
+	}
+	if _, ok := byKid[retiring.ID]; !ok {
+		t.Error("retiring key missing from the JWKS (its tokens could not be verified)")
+	}
+
+	// Um terceiro verifica o token só com a chave publicada
+	token, err := GenerateTenantJWT(uuid.New(), TokenOptions{}, set, testJWTConfig())
+	if err != nil {
+		t.Fatalf("GenerateTenantJWT: %v", err)
+	}
+	parsed, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := byKid[kid]
+		if !ok {
+			return nil, errors.New("kid not in JWKS")
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	if err != nil || !parsed.Valid {
+		t.Fatalf("verify with JWKS: %v", err)
+	}
+}
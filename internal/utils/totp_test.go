@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Segredo ASCII "12345678901234567890" dos vetores de teste da RFC 6238 (SHA1), em base32
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfcTOTPSecret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+
+	// A RFC usa 8 dígitos; com 6 os códigos são os últimos 6 dígitos
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, TOTPStep(time.Unix(tt.unix, 0))); got != tt.want {
+			t.Errorf("totpCode(t=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	key, _ := totpEncoding.DecodeString(rfcTOTPSecret)
+	now := time.Unix(1234567890, 0)
+	current := TOTPStep(now)
+	codeAt := func(offset int64) string { return totpCode(key, current+offset) }
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", secret: rfcTOTPSecret, code: codeAt(0), wantStep: current, wantOK: true},
+		{name: "previous step (clock behind)", secret: rfcTOTPSecret, code: codeAt(-1), wantStep: current - 1, wantOK: true},
+		{name: "next step (clock ahead)", secret: rfcTOTPSecret, code: codeAt(1), wantStep: current + 1, wantOK: true},
+		{name: "two steps behind", secret: rfcTOTPSecret, code: codeAt(-2)},
+		{name: "two steps ahead", secret: rfcTOTPSecret, code: codeAt(2)},
+		{name: "surrounding spaces", secret: rfcTOTPSecret, code: " " + codeAt(0) + " ", wantStep: current, wantOK: true},
+		{name: "lowercase secret", secret: strings.ToLower(rfcTOTPSecret), code: codeAt(0), wantStep: current, wantOK: true},
+		{name: "replay of the last accepted step", secret: rfcTOTPSecret, code: codeAt(0), lastStep: current},
+		{name: "older step after a newer one was used", secret: rfcTOTPSecret, code: codeAt(-1), lastStep: current},
+		{name: "newer step after an older one was used", secret: rfcTOTPSecret, code: codeAt(1), lastStep: current, wantStep: current + 1, wantOK: true},
+		{name: "wrong code", secret: rfcTOTPSecret, code: "000000"},
+		{name: "too short", secret: rfcTOTPSecret, code: codeAt(0)[:5]},
+		{name: "too long", secret: rfcTOTPSecret, code: codeAt(0) + "0"},
+		{name: "invalid secret", secret: "not base32!", code: codeAt(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret is not base32 without padding: %v", err)
+	}
+	if len(key) != totpSecretBytes {
+		t.Errorf("secret has %d bytes, want %d", len(key), totpSecretBytes)
+	}
+
+	code := totpCode(key, TOTPStep(time.Now()))
+	if _, ok := ValidateTOTP(secret, code, time.Now(), 0); !ok {
+		t.Error("code of a generated secret was refused")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(TOTPProvisioningURI(rfcTOTPSecret, "SaaS", "ana@example.com"))
+	if err != nil {
+		t.Fatalf("parse uri: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/SaaS:ana@example.com" {
+		t.Errorf("uri = %s", uri)
+	}
+	query := uri.Query()
+	for param, want := range map[string]string{"secret": rfcTOTPSecret, "issuer": "SaaS", "algorithm": "SHA1", "digits": "6", "period": "30"} {
+		if got := query.Get(param); got != want {
+			t.Errorf("%s = %q, want %q", param, got, want)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+
+	format := regexp.MustCompile(`^[a-hjkmnp-z2-9]{5}-[a-hjkmnp-z2-9]{5}$`)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q does not match xxxxx-xxxxx without ambiguous characters", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "abcde-fghjk", want: "abcdefghjk"},
+		{in: "ABCDE-FGHJK", want: "abcdefghjk"},
+		{in: "  abcde fghjk ", want: "abcdefghjk"},
+		{in: "abcdefghjk", want: "abcdefghjk"},
+		{in: "ab-cd-e fg hjk", want: "abcdefghjk"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.in); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
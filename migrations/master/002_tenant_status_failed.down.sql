@@ -0,0 +1,9 @@
+-- PostgreSQL cannot drop a value from an ENUM: recreate the type without 'failed'
+UPDATE tenants SET status = 'suspended' WHERE status = 'failed';
+
+ALTER TABLE tenants ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE tenant_status RENAME TO tenant_status_old;
+CREATE TYPE tenant_status AS ENUM ('provisioning', 'active', 'suspended');
+ALTER TABLE tenants ALTER COLUMN status TYPE tenant_status USING status::text::tenant_status;
+ALTER TABLE tenants ALTER COLUMN status SET DEFAULT 'provisioning';
+DROP TYPE tenant_status_old;
@@ -0,0 +1,2 @@
+-- Provisioning that exhausted all retry attempts (event moved to the dead-letter queue)
+ALTER TYPE tenant_status ADD VALUE IF NOT EXISTS 'failed';
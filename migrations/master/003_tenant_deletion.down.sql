@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tenants_deletion_scheduled_at;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS deletion_scheduled_at;
+ALTER TABLE tenants DROP COLUMN IF EXISTS deletion_requested_by;
+ALTER TABLE tenants DROP COLUMN IF EXISTS deletion_requested_at;
@@ -0,0 +1,7 @@
+-- Hard deletion with grace period: tenant is suspended and purged by the worker after deletion_scheduled_at
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS deletion_requested_by UUID REFERENCES sys_users(id) ON DELETE SET NULL;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_tenants_deletion_scheduled_at ON tenants(deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL;
@@ -0,0 +1,13 @@
+-- Step-by-step provisioning progress (persisted so reconnecting clients can catch up)
+CREATE TABLE IF NOT EXISTS tenant_provisioning_events (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    step VARCHAR(50) NOT NULL,   -- queued, database_created, schema_applied, seed_data, active, failed
+    status VARCHAR(20) NOT NULL, -- completed, failed
+    message TEXT,
+    attempt INTEGER NOT NULL DEFAULT 0,
+    final BOOLEAN NOT NULL DEFAULT false, -- true quando não haverá novas tentativas
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_provisioning_events_tenant ON tenant_provisioning_events(tenant_id, id);
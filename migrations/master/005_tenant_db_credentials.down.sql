@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tenant_db_credentials_retire_after;
+DROP TABLE IF EXISTS tenant_db_credentials;
@@ -0,0 +1,16 @@
+-- Per-tenant Postgres login roles (password encrypted with TENANT_DB_CREDENTIALS_KEY)
+-- Each tenant has a NOLOGIN group role with the grants and two login roles (_a/_b) used alternately on rotation
+CREATE TABLE IF NOT EXISTS tenant_db_credentials (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    group_role VARCHAR(63) NOT NULL,
+    username VARCHAR(63) NOT NULL,         -- Login role currently used by the APIs
+    password_encrypted TEXT NOT NULL,
+    retiring_username VARCHAR(63),         -- Previous login role, still valid until retire_after
+    retire_after TIMESTAMP,
+    rotated_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_db_credentials_retire_after ON tenant_db_credentials(retire_after)
+    WHERE retire_after IS NOT NULL;
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_tenants_cluster_id;
+ALTER TABLE tenants DROP COLUMN IF EXISTS region;
+ALTER TABLE tenants DROP COLUMN IF EXISTS cluster_id;
+
+DROP INDEX IF EXISTS idx_db_clusters_default;
+DROP TABLE IF EXISTS db_clusters;
+
+-- PostgreSQL cannot drop a value from an ENUM: recreate the type without 'migrating'
+UPDATE tenants SET status = 'suspended' WHERE status = 'migrating';
+
+ALTER TABLE tenants ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE tenant_status RENAME TO tenant_status_old;
+CREATE TYPE tenant_status AS ENUM ('provisioning', 'active', 'suspended', 'failed');
+ALTER TABLE tenants ALTER COLUMN status TYPE tenant_status USING status::text::tenant_status;
+ALTER TABLE tenants ALTER COLUMN status SET DEFAULT 'provisioning';
+DROP TYPE tenant_status_old;
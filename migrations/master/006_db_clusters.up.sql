@@ -0,0 +1,29 @@
+-- Registry of Postgres clusters that host tenant databases
+-- Tenants with cluster_id NULL live on the default cluster (POSTGRES_HOST / MASTER_DB_HOST)
+ALTER TYPE tenant_status ADD VALUE IF NOT EXISTS 'migrating';
+
+CREATE TABLE IF NOT EXISTS db_clusters (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) UNIQUE NOT NULL,
+    host VARCHAR(255) NOT NULL,                 -- Direct Postgres (CREATE DATABASE, migrations)
+    port INTEGER NOT NULL DEFAULT 5432,
+    pool_host VARCHAR(255),                     -- PgBouncer used by the APIs (NULL = host)
+    pool_port INTEGER,
+    admin_user VARCHAR(100),                    -- NULL = POSTGRES_USER
+    admin_password_encrypted TEXT,              -- NULL = POSTGRES_PASSWORD (encrypted with TENANT_DB_CREDENTIALS_KEY)
+    region VARCHAR(50),
+    plan_id UUID REFERENCES plans(id) ON DELETE SET NULL, -- Reserved for tenants of this plan
+    dedicated BOOLEAN NOT NULL DEFAULT false,   -- Only receives tenants assigned explicitly
+    max_tenants INTEGER,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, draining, disabled
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_db_clusters_default ON db_clusters(is_default) WHERE is_default;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS cluster_id UUID REFERENCES db_clusters(id);
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS region VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_tenants_cluster_id ON tenants(cluster_id);
@@ -0,0 +1,4 @@
+-- Pooled databases are not dropped here: remove db_tenant_* databases without a tenant manually
+DROP INDEX IF EXISTS idx_tenant_db_pool_tenant_id;
+DROP INDEX IF EXISTS idx_tenant_db_pool_status;
+DROP TABLE IF EXISTS tenant_db_pool;
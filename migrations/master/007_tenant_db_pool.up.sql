@@ -0,0 +1,16 @@
+-- Warm pool of pre-created, already migrated tenant databases (db_tenant_{db_code})
+-- The worker keeps TENANT_WARM_POOL_SIZE rows 'ready'; CreateTenant claims one and uses its db_code/cluster
+CREATE TABLE IF NOT EXISTS tenant_db_pool (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    cluster_id UUID NOT NULL REFERENCES db_clusters(id) ON DELETE CASCADE,
+    db_code UUID UNIQUE NOT NULL,
+    schema_version INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'ready', -- ready, migrating, claimed
+    tenant_id UUID,                               -- Set on claim (no FK: the tenant row is inserted right after)
+    claimed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_db_pool_status ON tenant_db_pool(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_tenant_db_pool_tenant_id ON tenant_db_pool(tenant_id);
@@ -0,0 +1,3 @@
+-- Archives already uploaded under backups/ are kept in storage
+DROP TABLE IF EXISTS tenant_restores;
+DROP TABLE IF EXISTS tenant_backups;
@@ -0,0 +1,37 @@
+-- Per-tenant backups (archives stored through the storage driver under backups/{tenant_id}/)
+-- Rows are also the job queue: the worker runs 'pending' backups and restores
+CREATE TABLE IF NOT EXISTS tenant_backups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed
+    storage_path TEXT,
+    size_bytes BIGINT,
+    schema_version INTEGER,
+    tables JSONB,                                  -- [{"table": "...", "rows": n}]
+    media_count INTEGER,
+    error TEXT,
+    requested_by UUID,                             -- sys_user (NULL = CLI)
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_backups_tenant ON tenant_backups(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_tenant_backups_status ON tenant_backups(status, created_at);
+
+CREATE TABLE IF NOT EXISTS tenant_restores (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    backup_id UUID NOT NULL REFERENCES tenant_backups(id) ON DELETE CASCADE,
+    mode VARCHAR(20) NOT NULL DEFAULT 'existing',  -- existing (truncate and load), fresh (recreate database)
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed
+    previous_status VARCHAR(20),                   -- Tenant status restored at the end
+    error TEXT,
+    requested_by UUID,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_restores_tenant ON tenant_restores(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_tenant_restores_status ON tenant_restores(status, created_at);
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE slug = 'data_x';
+
+DROP TABLE IF EXISTS tenant_exports;
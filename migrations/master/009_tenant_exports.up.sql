@@ -0,0 +1,33 @@
+-- Tenant data exports requested by tenant owners (zip with JSON, CSV and media files)
+-- Rows are also the job queue: the worker runs 'pending' exports and expires old files
+CREATE TABLE IF NOT EXISTS tenant_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed, expired
+    storage_path TEXT,
+    size_bytes BIGINT,
+    tables JSONB,                                  -- [{"table": "...", "rows": n}]
+    media_count INTEGER,
+    error TEXT,
+    expires_at TIMESTAMP,                          -- File is removed after this (status 'expired')
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_exports_tenant ON tenant_exports(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_tenant_exports_status ON tenant_exports(status, created_at);
+
+-- Permission to request and download exports (owners bypass permission checks)
+INSERT INTO permissions (name, slug, description) VALUES
+    ('Export Data', 'data_x', 'Can export all tenant data')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.slug = 'global_admin' AND p.slug = 'data_x'
+  AND NOT EXISTS (
+    SELECT 1 FROM role_permissions rp
+    WHERE rp.role_id = r.id AND rp.permission_id = p.id
+  );
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS tenant_sandbox_clones;
+
+DROP INDEX IF EXISTS idx_tenants_sandbox_expires_at;
+ALTER TABLE tenants DROP COLUMN IF EXISTS sandbox_expires_at;
+ALTER TABLE tenants DROP COLUMN IF EXISTS sandbox_source_id;
+ALTER TABLE tenants DROP COLUMN IF EXISTS is_sandbox;
@@ -0,0 +1,28 @@
+-- Sandbox tenants: staging copies of a tenant (database and media) created by admins.
+-- Sandboxes are excluded from billing and scheduled for deletion once sandbox_expires_at passes.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS is_sandbox BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS sandbox_source_id UUID REFERENCES tenants(id) ON DELETE SET NULL;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS sandbox_expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_tenants_sandbox_expires_at ON tenants(sandbox_expires_at)
+    WHERE is_sandbox;
+
+-- Clone jobs: the worker copies the source database and media into the sandbox tenant
+CREATE TABLE IF NOT EXISTS tenant_sandbox_clones (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    source_tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    sandbox_tenant_id UUID REFERENCES tenants(id) ON DELETE SET NULL, -- NULL after the sandbox is purged
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed
+    schema_version INTEGER,
+    tables JSONB,                                  -- [{"table": "...", "rows": n}]
+    media_count INTEGER,
+    missing_media INTEGER,
+    error TEXT,
+    requested_by UUID,                             -- sys_user
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_sandbox_clones_source ON tenant_sandbox_clones(source_tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_tenant_sandbox_clones_status ON tenant_sandbox_clones(status, created_at);
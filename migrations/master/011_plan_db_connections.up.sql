@@ -0,0 +1,4 @@
+-- Connection budget of the tenant database pools of a plan (MaxConns of each pool opened by the APIs).
+-- NULL = TENANT_POOL_MAX_CONNS
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS max_db_connections INTEGER
+    CHECK (max_db_connections IS NULL OR max_db_connections > 0);
@@ -0,0 +1,2 @@
+ALTER TABLE db_clusters DROP COLUMN IF EXISTS replica_port;
+ALTER TABLE db_clusters DROP COLUMN IF EXISTS replica_host;
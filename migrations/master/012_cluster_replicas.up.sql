@@ -0,0 +1,4 @@
+-- Optional read replica of each cluster (or the PgBouncer in front of it).
+-- The APIs send list reads there while the replication lag is below TENANT_REPLICA_MAX_LAG_SECONDS.
+ALTER TABLE db_clusters ADD COLUMN IF NOT EXISTS replica_host VARCHAR(255);
+ALTER TABLE db_clusters ADD COLUMN IF NOT EXISTS replica_port INTEGER;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tenant_profiles_verified_domain;
+ALTER TABLE tenant_profiles DROP COLUMN IF EXISTS custom_domain_verified_at;
+ALTER TABLE tenant_profiles DROP COLUMN IF EXISTS custom_domain_verification_token;
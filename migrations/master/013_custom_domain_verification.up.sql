@@ -0,0 +1,7 @@
+-- Custom domains only route public storefront requests after the tenant proves ownership with a DNS TXT record
+ALTER TABLE tenant_profiles ADD COLUMN IF NOT EXISTS custom_domain_verification_token VARCHAR(64);
+ALTER TABLE tenant_profiles ADD COLUMN IF NOT EXISTS custom_domain_verified_at TIMESTAMP;
+
+-- A domain resolves to a single tenant
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_profiles_verified_domain ON tenant_profiles(LOWER(custom_domain))
+    WHERE custom_domain_verified_at IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS tenant_rate_limits;
+ALTER TABLE plans DROP COLUMN IF EXISTS rate_limit_upload;
+ALTER TABLE plans DROP COLUMN IF EXISTS rate_limit_write;
+ALTER TABLE plans DROP COLUMN IF EXISTS rate_limit_read;
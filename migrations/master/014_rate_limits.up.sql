@@ -0,0 +1,15 @@
+-- Requests per minute of each rate limit bucket of the Tenant API, per plan.
+-- NULL = TENANT_RATE_LIMIT_{READ,WRITE,UPLOAD}_PER_MINUTE
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS rate_limit_read INTEGER CHECK (rate_limit_read IS NULL OR rate_limit_read > 0);
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS rate_limit_write INTEGER CHECK (rate_limit_write IS NULL OR rate_limit_write > 0);
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS rate_limit_upload INTEGER CHECK (rate_limit_upload IS NULL OR rate_limit_upload > 0);
+
+-- Admin override of the plan limits for a single tenant (NULL column = plan limit)
+CREATE TABLE IF NOT EXISTS tenant_rate_limits (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    read_per_minute INTEGER CHECK (read_per_minute IS NULL OR read_per_minute > 0),
+    write_per_minute INTEGER CHECK (write_per_minute IS NULL OR write_per_minute > 0),
+    upload_per_minute INTEGER CHECK (upload_per_minute IS NULL OR upload_per_minute > 0),
+    updated_by UUID,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,21 @@
+-- Opaque refresh tokens of the Admin API (subject_type = 'admin', sys_users) and the Tenant API
+-- (subject_type = 'tenant', users). Only the SHA-256 of the token is stored.
+-- Each refresh rotates the token inside its family; presenting an already used token revokes the family.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    family_id UUID NOT NULL,
+    subject_type VARCHAR(10) NOT NULL CHECK (subject_type IN ('admin', 'tenant')),
+    subject_id UUID NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    replaced_by UUID,
+    revoked_at TIMESTAMP,
+    user_agent TEXT,
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_subject ON refresh_tokens(subject_type, subject_id) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires ON refresh_tokens(expires_at);
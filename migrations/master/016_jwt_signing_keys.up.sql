@@ -0,0 +1,16 @@
+-- Ed25519 signing keys of the Admin API (api = 'admin') and the Tenant API (api = 'tenant').
+-- The newest active key signs; retiring keys only verify tokens until verify_until.
+-- private_key is encrypted with JWT_KEYS_ENCRYPTION_KEY (AES-256-GCM).
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    kid VARCHAR(32) PRIMARY KEY,
+    api VARCHAR(10) NOT NULL CHECK (api IN ('admin', 'tenant')),
+    algorithm VARCHAR(10) NOT NULL DEFAULT 'EdDSA',
+    public_key TEXT NOT NULL,
+    private_key TEXT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'retiring')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    rotated_at TIMESTAMP,
+    verify_until TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_api ON jwt_signing_keys(api, status);
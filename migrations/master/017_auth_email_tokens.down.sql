@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS auth_email_tokens;
+
+ALTER TABLE sys_users DROP COLUMN IF EXISTS email_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
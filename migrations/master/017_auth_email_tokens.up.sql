@@ -0,0 +1,21 @@
+-- Email verification of users (Tenant API) and sys_users (Admin API)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+ALTER TABLE sys_users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+-- Single-use tokens sent by email: email verification and password reset.
+-- Only the SHA-256 of the token is stored; tenant_id selects the branding of the email (NULL = platform).
+CREATE TABLE IF NOT EXISTS auth_email_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subject_type VARCHAR(10) NOT NULL CHECK (subject_type IN ('admin', 'tenant')),
+    subject_id UUID NOT NULL,
+    purpose VARCHAR(20) NOT NULL CHECK (purpose IN ('verify_email', 'password_reset')),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    email VARCHAR(255) NOT NULL,
+    tenant_id UUID REFERENCES tenants(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_email_tokens_subject ON auth_email_tokens(subject_type, subject_id, purpose) WHERE used_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_auth_email_tokens_expires ON auth_email_tokens(expires_at);
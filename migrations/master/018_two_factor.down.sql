@@ -0,0 +1,6 @@
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS mfa;
+ALTER TABLE tenants DROP COLUMN IF EXISTS require_two_factor;
+ALTER TABLE sys_roles DROP COLUMN IF EXISTS require_two_factor;
+
+DROP TABLE IF EXISTS two_factor_recovery_codes;
+DROP TABLE IF EXISTS two_factor_credentials;
@@ -0,0 +1,32 @@
+-- TOTP second factor of sys_users (subject_type = 'admin') and users (subject_type = 'tenant').
+-- The secret is encrypted with AUTH_2FA_ENCRYPTION_KEY; enabled_at stays NULL until the first code
+-- is confirmed. last_used_step prevents the same code from being accepted twice.
+CREATE TABLE IF NOT EXISTS two_factor_credentials (
+    subject_type VARCHAR(10) NOT NULL CHECK (subject_type IN ('admin', 'tenant')),
+    subject_id UUID NOT NULL,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (subject_type, subject_id)
+);
+
+-- Single-use recovery codes (SHA-256 of the normalized code)
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subject_type VARCHAR(10) NOT NULL CHECK (subject_type IN ('admin', 'tenant')),
+    subject_id UUID NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_two_factor_recovery_codes_subject ON two_factor_recovery_codes(subject_type, subject_id) WHERE used_at IS NULL;
+
+-- Mandatory 2FA: for every sys_user holding the role, and for the members of the tenant
+ALTER TABLE sys_roles ADD COLUMN IF NOT EXISTS require_two_factor BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS require_two_factor BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Sessions opened with the second factor keep it on every refresh
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS mfa BOOLEAN NOT NULL DEFAULT FALSE;
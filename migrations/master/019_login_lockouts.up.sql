@@ -0,0 +1,18 @@
+-- Audit trail of the login brute-force protection: accounts (email) and client IPs locked after
+-- too many failed attempts, and unlocks (expired locks are not recorded; actor_id = admin who unlocked).
+-- The counters and the active locks themselves live in Redis.
+CREATE TABLE IF NOT EXISTS login_lockout_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subject_type VARCHAR(10) NOT NULL CHECK (subject_type IN ('admin', 'tenant')),
+    event VARCHAR(10) NOT NULL CHECK (event IN ('locked', 'unlocked')),
+    scope VARCHAR(10) NOT NULL CHECK (scope IN ('account', 'ip')),
+    email VARCHAR(255),
+    ip_address VARCHAR(45),
+    failed_attempts INTEGER,
+    locked_until TIMESTAMP,
+    actor_id UUID REFERENCES sys_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_lockout_events_created_at ON login_lockout_events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_lockout_events_email ON login_lockout_events(subject_type, email);
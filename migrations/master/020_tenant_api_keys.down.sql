@@ -0,0 +1,3 @@
+ALTER TABLE tenant_exports DROP COLUMN IF EXISTS requested_by_api_key;
+
+DROP TABLE IF EXISTS tenant_api_keys;
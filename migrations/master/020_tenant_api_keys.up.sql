@@ -0,0 +1,24 @@
+-- Tenant API keys for machine-to-machine access (integrations) to the tenant routes.
+-- Only the SHA-256 of the key is stored; key_prefix identifies it in listings. permissions is the
+-- subset of permission slugs (prod_r, prod_u...) the key may use; allowed_ips (CIDRs) restricts the
+-- client IPs when not empty. Revoked keys are kept for the attribution of what they did.
+CREATE TABLE IF NOT EXISTS tenant_api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    allowed_ips TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(45),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_api_keys_tenant ON tenant_api_keys(tenant_id, created_at DESC);
+
+-- Exports requested with an API key are attributed to the key
+ALTER TABLE tenant_exports ADD COLUMN IF NOT EXISTS requested_by_api_key UUID REFERENCES tenant_api_keys(id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS sso_tenant_id;
+
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS tenant_oidc_providers;
@@ -0,0 +1,38 @@
+-- OpenID Connect (SSO) configuration of a tenant. client_secret is encrypted with
+-- AUTH_OIDC_ENCRYPTION_KEY. group_roles maps IdP groups to tenant role slugs, in priority order:
+-- [{"group": "erp-admins", "role": "admin"}]. sso_only: members (except the owner) can only use the
+-- tenant with a session opened by this provider.
+CREATE TABLE IF NOT EXISTS tenant_oidc_providers (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    client_secret TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{openid,email,profile}',
+    allowed_domains TEXT[] NOT NULL DEFAULT '{}',
+    groups_claim VARCHAR(100) NOT NULL DEFAULT 'groups',
+    group_roles JSONB NOT NULL DEFAULT '[]',
+    default_role VARCHAR(100) NOT NULL DEFAULT 'member',
+    auto_create_users BOOLEAN NOT NULL DEFAULT TRUE,
+    sso_only BOOLEAN NOT NULL DEFAULT FALSE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Accounts of an IdP (issuer + sub) linked to users
+CREATE TABLE IF NOT EXISTS user_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id UUID REFERENCES tenants(id) ON DELETE SET NULL,
+    issuer TEXT NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    last_login_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
+
+-- Sessions opened by the SSO of a tenant keep it on every refresh
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS sso_tenant_id UUID REFERENCES tenants(id) ON DELETE SET NULL;
@@ -0,0 +1,33 @@
+-- Impersonation of tenant users by SaaS administrators (support). Each session is a single short-lived
+-- tenant access token (id = jti of the token) bound to one user and one tenant, read-only unless
+-- read_only is false. Sessions and the requests made with them are kept as an audit trail.
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id UUID PRIMARY KEY,
+    sys_user_id UUID REFERENCES sys_users(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    read_only BOOLEAN NOT NULL DEFAULT TRUE,
+    ip_address VARCHAR(45),
+    expires_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    ended_by UUID REFERENCES sys_users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_created_at ON impersonation_sessions(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_tenant ON impersonation_sessions(tenant_id, created_at DESC);
+
+-- Every Tenant API request made with an impersonation token (including the rejected ones)
+CREATE TABLE IF NOT EXISTS impersonation_requests (
+    id BIGSERIAL PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES impersonation_sessions(id) ON DELETE CASCADE,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_requests_session ON impersonation_requests(session_id, created_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS tenant_invitations;
+
+ALTER TABLE plans DROP COLUMN IF EXISTS max_members;
@@ -0,0 +1,24 @@
+-- Member limit of a plan: members of the tenant, owner and pending invitations included.
+-- NULL = unlimited
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS max_members INTEGER
+    CHECK (max_members IS NULL OR max_members > 0);
+
+-- Invitations to join a tenant, sent by email with a role. Only the SHA-256 of the token is stored;
+-- a pending invitation is neither accepted, revoked nor expired, and there is at most one per email.
+CREATE TABLE IF NOT EXISTS tenant_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_invitations_open
+    ON tenant_invitations(tenant_id, email) WHERE accepted_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tenant_invitations_expires ON tenant_invitations(expires_at);
@@ -0,0 +1,3 @@
+-- The second factor reported by a tenant IdP (amr claim) only counts on that tenant: kept apart from
+-- mfa (platform TOTP) so refreshed sessions keep the same scope
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS sso_mfa BOOLEAN NOT NULL DEFAULT FALSE;
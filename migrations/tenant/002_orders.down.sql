@@ -0,0 +1,6 @@
+-- Drop order tables (reverse order due to foreign keys)
+DROP TABLE IF EXISTS order_items CASCADE;
+DROP TABLE IF EXISTS orders CASCADE;
+DROP TABLE IF EXISTS customers CASCADE;
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_sku_key;
@@ -1,10 +1,45 @@
 # Database Commands
 
-.PHONY: migrate seed
+.PHONY: migrate migrate-tenants migrate-status backup-tenant backup-list backup-restore backup-prune jwt-keys-list jwt-keys-rotate seed
 
-# Apply Master DB migrations
+# Apply Master DB migrations (in order; 002+ are safe to re-run)
 migrate:
-	@docker exec -i saas-postgres psql -U postgres -d master_db < migrations/master/001_initial_schema.up.sql
+	@for f in migrations/master/*.up.sql; do \
+		echo "Applying $$f"; \
+		docker exec -i saas-postgres psql -U postgres -d master_db < $$f; \
+	done
+
+# Apply tenant migrations to every tenant DB (optional: TARGET=<version> TENANT=<url_code>)
+migrate-tenants:
+	@docker exec saas-worker ./migrate $(if $(TARGET),-target $(TARGET)) $(if $(TENANT),-tenant $(TENANT))
+
+# Show the schema version of every tenant DB
+migrate-status:
+	@docker exec saas-worker ./migrate -status
+
+# Back up one tenant DB now (TENANT=<url_code>)
+backup-tenant:
+	@docker exec saas-worker ./backup -tenant $(TENANT)
+
+# List the backups of a tenant (TENANT=<url_code>)
+backup-list:
+	@docker exec saas-worker ./backup -tenant $(TENANT) -list
+
+# Restore a backup (TENANT=<url_code> BACKUP=<id>, optional MODE=fresh)
+backup-restore:
+	@docker exec saas-worker ./backup -tenant $(TENANT) -restore $(BACKUP) $(if $(MODE),-mode $(MODE))
+
+# Remove backups outside the retention policy
+backup-prune:
+	@docker exec saas-worker ./backup -prune
+
+# List the JWT signing keys of an API (API=admin|tenant)
+jwt-keys-list:
+	@docker exec saas-worker ./jwt-keys -api $(API) -list
+
+# Rotate the JWT signing key of an API (API=admin|tenant); the previous key verifies until its tokens expire
+jwt-keys-rotate:
+	@docker exec saas-worker ./jwt-keys -api $(API) -rotate
 
 # Seed is no longer needed - all data is inserted via migration
 seed: